@@ -0,0 +1,192 @@
+// Package archive implements kure's native export format, a single portable file containing every record
+// of a vault encrypted with a passphrase that is independent of the master password.
+//
+// File layout:
+//
+//	magic "KURE" | version (1 byte) | argon2 iterations (4 bytes) | argon2 memory (4 bytes) |
+//	argon2 threads (1 byte) | salt (32 bytes) | nonce (12 bytes) | ciphertext
+//
+// The key is derived from the passphrase using argon2id and the records, a gzip compressed pb.Archive,
+// are sealed with AES-256-GCM. The header is authenticated as additional data.
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"math"
+	"runtime"
+
+	"github.com/GGP1/kure/config"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/argon2"
+	"google.golang.org/protobuf/proto"
+)
+
+// Version is the current version of the format.
+const Version = 1
+
+const (
+	magic      = "KURE"
+	saltSize   = 32
+	nonceSize  = 12
+	headerSize = len(magic) + 1 + 4 + 4 + 1 + saltSize + nonceSize
+	// Upper bound of the key derivation memory to avoid crafted files from exhausting it (4 GiB)
+	maxMemory = 1 << 22
+)
+
+var (
+	// ErrInvalidPassword is returned when the file can't be authenticated with the passphrase provided.
+	ErrInvalidPassword = errors.New("invalid passphrase or corrupted file")
+	errInvalidFormat   = errors.New("invalid file format, is it a kure export?")
+)
+
+// Params are the argon2id parameters used to derive the key.
+type Params struct {
+	Iterations uint32
+	Memory     uint32
+	Threads    uint8
+}
+
+// Encode encrypts the archive with the password and writes it to w.
+func Encode(w io.Writer, archive *pb.Archive, password []byte, params Params) error {
+	if params.Iterations == 0 || params.Memory == 0 || params.Threads == 0 {
+		return errors.New("invalid key derivation parameters")
+	}
+
+	data, err := proto.Marshal(archive)
+	if err != nil {
+		return errors.Wrap(err, "marshal archive")
+	}
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	if _, err := gw.Write(data); err != nil {
+		return errors.Wrap(err, "compress archive")
+	}
+	if err := gw.Close(); err != nil {
+		return errors.Wrap(err, "close gzip writer")
+	}
+
+	header := make([]byte, 0, headerSize)
+	header = append(header, magic...)
+	header = append(header, Version)
+	header = binary.BigEndian.AppendUint32(header, params.Iterations)
+	header = binary.BigEndian.AppendUint32(header, params.Memory)
+	header = append(header, params.Threads)
+
+	salt := make([]byte, saltSize)
+	nonce := make([]byte, nonceSize)
+	// rand.Read never returns an error
+	rand.Read(salt)
+	rand.Read(nonce)
+	header = append(header, salt...)
+	header = append(header, nonce...)
+
+	gcm, err := newGCM(password, salt, params)
+	if err != nil {
+		return err
+	}
+
+	ciphertext := gcm.Seal(header, nonce, buf.Bytes(), header)
+	if _, err := w.Write(ciphertext); err != nil {
+		return errors.Wrap(err, "write archive")
+	}
+
+	return nil
+}
+
+// Decode reads an archive from r and decrypts it with the password.
+func Decode(r io.Reader, password []byte) (*pb.Archive, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "read archive")
+	}
+
+	if len(data) < headerSize || string(data[:len(magic)]) != magic {
+		return nil, errInvalidFormat
+	}
+	header, ciphertext := data[:headerSize], data[headerSize:]
+
+	offset := len(magic)
+	if version := header[offset]; version != Version {
+		return nil, errors.Errorf("unsupported format version %d", version)
+	}
+	offset++
+
+	params := Params{
+		Iterations: binary.BigEndian.Uint32(header[offset:]),
+		Memory:     binary.BigEndian.Uint32(header[offset+4:]),
+		Threads:    header[offset+8],
+	}
+	offset += 9
+	if params.Iterations == 0 || params.Memory == 0 || params.Memory > maxMemory || params.Threads == 0 {
+		return nil, errInvalidFormat
+	}
+
+	salt := header[offset : offset+saltSize]
+	nonce := header[offset+saltSize:]
+
+	gcm, err := newGCM(password, salt, params)
+	if err != nil {
+		return nil, err
+	}
+
+	compressed, err := gcm.Open(nil, nonce, ciphertext, header)
+	if err != nil {
+		return nil, ErrInvalidPassword
+	}
+
+	gr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, errors.Wrap(err, "decompress archive")
+	}
+	defer gr.Close()
+
+	plaintext, err := io.ReadAll(gr)
+	if err != nil {
+		return nil, errors.Wrap(err, "decompress archive")
+	}
+
+	archive := &pb.Archive{}
+	if err := proto.Unmarshal(plaintext, archive); err != nil {
+		return nil, errors.Wrap(err, "unmarshal archive")
+	}
+
+	return archive, nil
+}
+
+func newGCM(password, salt []byte, params Params) (cipher.AEAD, error) {
+	key := argon2.IDKey(password, salt, params.Iterations, params.Memory, params.Threads, 32)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "create cipher")
+	}
+	return cipher.NewGCM(block)
+}
+
+// VaultParams returns the key derivation parameters used by the vault in use, the archive is as expensive
+// to brute force as the database.
+func VaultParams() Params {
+	params := Params{
+		Iterations: config.GetUint32("auth.iterations"),
+		Memory:     config.GetUint32("auth.memory"),
+		Threads:    uint8(min(config.GetUint32("auth.threads"), math.MaxUint8)),
+	}
+	if params.Iterations == 0 {
+		params.Iterations = 1
+	}
+	if params.Memory == 0 {
+		params.Memory = 1 << 20
+	}
+	if params.Threads == 0 {
+		params.Threads = uint8(min(runtime.NumCPU(), math.MaxUint8))
+	}
+	return params
+}
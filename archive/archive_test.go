@@ -0,0 +1,70 @@
+package archive
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/GGP1/kure/pb"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+var testParams = Params{Iterations: 1, Memory: 64, Threads: 1}
+
+func TestArchive(t *testing.T) {
+	expected := &pb.Archive{
+		CreatedAt: 1700000000,
+		Cards:     []*pb.Card{{Name: "visa", Number: "4111111111111111"}},
+		Entries:   []*pb.Entry{{Name: "email", Username: "kure", Password: "secret", Expires: "Never"}},
+		Files:     []*pb.File{{Name: "notes.txt", Content: []byte("notes"), Size: 5}},
+		Totps:     []*pb.TOTP{{Name: "email", Raw: "JBSWY3DPEHPK3PXP", Digits: 6}},
+	}
+
+	var buf bytes.Buffer
+	err := Encode(&buf, expected, []byte("passphrase"), testParams)
+	assert.NoError(t, err)
+	assert.NotContains(t, buf.String(), "secret")
+
+	got, err := Decode(bytes.NewReader(buf.Bytes()), []byte("passphrase"))
+	assert.NoError(t, err)
+	assert.True(t, proto.Equal(expected, got))
+}
+
+func TestDecodeErrors(t *testing.T) {
+	var buf bytes.Buffer
+	err := Encode(&buf, &pb.Archive{}, []byte("passphrase"), testParams)
+	assert.NoError(t, err)
+	valid := buf.Bytes()
+
+	tampered := bytes.Clone(valid)
+	// Modify the argon2 iterations, which are authenticated
+	tampered[len(magic)+4] = 2
+
+	unsupported := bytes.Clone(valid)
+	unsupported[len(magic)] = Version + 1
+
+	cases := []struct {
+		desc     string
+		data     []byte
+		password string
+	}{
+		{desc: "Invalid password", data: valid, password: "invalid"},
+		{desc: "Tampered header", data: tampered, password: "passphrase"},
+		{desc: "Unsupported version", data: unsupported, password: "passphrase"},
+		{desc: "Invalid format", data: []byte("kure"), password: "passphrase"},
+		{desc: "Truncated", data: valid[:headerSize-1], password: "passphrase"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			_, err := Decode(bytes.NewReader(tc.data), []byte(tc.password))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestEncodeInvalidParams(t *testing.T) {
+	err := Encode(&bytes.Buffer{}, &pb.Archive{}, []byte("passphrase"), Params{})
+	assert.Error(t, err)
+}
@@ -7,15 +7,19 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/GGP1/kure/config"
 	"github.com/GGP1/kure/crypt"
 	"github.com/GGP1/kure/db/auth"
 	authDB "github.com/GGP1/kure/db/auth"
+	"github.com/GGP1/kure/db/team"
 	"github.com/GGP1/kure/terminal"
+	"github.com/GGP1/kure/vault"
 
 	"github.com/awnumar/memguard"
 	"github.com/pkg/errors"
@@ -28,6 +32,13 @@ const (
 	keyfilePath string = "keyfile.path"
 )
 
+var (
+	// ErrInvalidPassword is returned when the authentication key cannot be decrypted.
+	ErrInvalidPassword = errors.New("invalid master password")
+	// ErrTeamVault is returned when switching to a team vault using a master password.
+	ErrTeamVault = errors.New("team vaults are unlocked with the member key, not with a master password")
+)
+
 // Login verifies that the human/machine that is trying to execute
 // a command is effectively the owner of the information.
 //
@@ -47,6 +58,22 @@ func Login(db *bolt.DB) error {
 		return Register(db, os.Stdin)
 	}
 
+	if params.Team {
+		if err := loginTeam(db); err != nil {
+			return err
+		}
+		return authDB.CreateBuckets(db)
+	}
+
+	if err := unlock(params); err != nil {
+		return err
+	}
+
+	return authDB.CreateBuckets(db)
+}
+
+// unlock asks for the master password and sets the credentials to the configuration.
+func unlock(params authDB.Params) error {
 	password, err := terminal.ScanPassword("Enter master password", false)
 	if err != nil {
 		return err
@@ -64,14 +91,149 @@ func Login(db *bolt.DB) error {
 	// Try to decrypt the authentication key
 	key, err := crypt.Decrypt(params.AuthKey)
 	if err != nil {
-		return errors.New("invalid master password")
+		return ErrInvalidPassword
 	}
 
 	setKeyToConfig(key)
-
 	return nil
 }
 
+// loginTeam unlocks the team vault with the member key stored in the default vault, whose master
+// password is requested.
+func loginTeam(db *bolt.DB) error {
+	def, err := vault.Get(vault.DefaultName)
+	if err != nil {
+		return err
+	}
+	if filepath.Clean(def.Path) == filepath.Clean(db.Path()) {
+		return errors.New("the default vault cannot be a team vault")
+	}
+
+	personal, err := bolt.Open(def.Path, 0o600, &bolt.Options{ReadOnly: true, Timeout: 200 * time.Millisecond})
+	if err != nil {
+		return errors.Wrap(err, "opening the default vault")
+	}
+	defer personal.Close()
+
+	params, err := authDB.GetParams(personal)
+	if err != nil {
+		return err
+	}
+	if params.AuthKey == nil {
+		return errors.New("the default vault has no registered credentials")
+	}
+
+	// Use the key file of the default vault
+	keyfile := config.Get(keyfilePath)
+	config.Set(keyfilePath, def.Keyfile)
+	err = unlock(params)
+	config.Set(keyfilePath, keyfile)
+	if err != nil {
+		return err
+	}
+
+	memberKey, err := authDB.GetMemberKey(personal)
+	if err != nil {
+		return err
+	}
+	if memberKey == nil {
+		return errors.New("the default vault has no member key, generate one with \"kure team key\"")
+	}
+
+	_, err = SwitchTeam(db, memberKey)
+	return err
+}
+
+// Switch sets the credentials of another database to the configuration so its records can be read.
+// The current password is used unless askPassword is true, the argon2 parameters are always taken
+// from the database.
+//
+// It returns a function that restores the previous credentials.
+func Switch(db *bolt.DB, r io.Reader, askPassword bool) (func(), error) {
+	params, err := authDB.GetParams(db)
+	if err != nil {
+		return nil, err
+	}
+	if params.AuthKey == nil {
+		return nil, errors.New("the database has no registered credentials")
+	}
+	if params.Team {
+		return nil, ErrTeamVault
+	}
+
+	password := config.GetEnclave(authKey + ".password")
+	if askPassword {
+		password, err = terminal.ScanPassword("Enter master password of "+filepath.Base(db.Path()), false)
+		if err != nil {
+			return nil, err
+		}
+
+		if params.UseKeyfile {
+			password, err = combineKeys(r, password)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	previous := config.Get(authKey)
+	restore := func() { config.Set(authKey, previous) }
+	setAuthToConfig(password, params)
+
+	key, err := crypt.Decrypt(params.AuthKey)
+	if err != nil {
+		restore()
+		return nil, ErrInvalidPassword
+	}
+	setKeyToConfig(key)
+
+	return restore, nil
+}
+
+// SwitchTeam sets the credentials of a team vault to the configuration, the vault key is unsealed
+// with the member private key. The records the member can access are restricted by the access list.
+//
+// It returns a function that restores the previous credentials.
+func SwitchTeam(db *bolt.DB, memberKey []byte) (func(), error) {
+	params, err := authDB.GetParams(db)
+	if err != nil {
+		return nil, err
+	}
+	if !params.Team {
+		return nil, errors.New("the database is not a team vault")
+	}
+
+	name, vaultKey, err := team.Unlock(db, memberKey)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := config.Get(authKey)
+	restore := func() { config.Set(authKey, previous) }
+	setAuthToConfig(memguard.NewEnclave(vaultKey), params)
+
+	key, err := crypt.Decrypt(params.AuthKey)
+	if err != nil {
+		restore()
+		return nil, errors.New("invalid vault key")
+	}
+	setKeyToConfig(key)
+
+	member, err := team.Get(db, name)
+	if err != nil {
+		restore()
+		return nil, err
+	}
+
+	config.Set(authKey+".member", name)
+	if !member.Admin {
+		// A nil slice would grant access to every record
+		config.Set(authKey+".prefixes", append([]string{}, member.Prefixes...))
+	}
+
+	return restore, nil
+}
+
 // Register registers the user when there aren't any records yet.
 func Register(db *bolt.DB, r io.Reader) error {
 	password, err := terminal.ScanPassword("New master password", true)
@@ -9,7 +9,9 @@ import (
 
 	cmdutil "github.com/GGP1/kure/commands"
 	"github.com/GGP1/kure/config"
+	"github.com/GGP1/kure/crypt"
 	"github.com/GGP1/kure/db/auth"
+	"github.com/GGP1/kure/db/team"
 
 	"github.com/awnumar/memguard"
 	"github.com/stretchr/testify/assert"
@@ -255,3 +257,82 @@ func TestSetKeyToConfig(t *testing.T) {
 	assert.True(t, ok)
 	assert.Equal(t, key, gotKey)
 }
+
+func TestSwitch(t *testing.T) {
+	db := cmdutil.SetContext(t)
+	current := config.Get("auth.key")
+
+	otherKey := []byte("98765432109876543210987654321098")
+	params := auth.Params{Argon2: auth.Argon2{Iterations: 1, Memory: 1, Threads: 1}}
+	err := auth.Register(db, otherKey, params)
+	assert.NoError(t, err)
+
+	restore, err := Switch(db, nil, false)
+	assert.NoError(t, err)
+	assert.Equal(t, otherKey, config.Get("auth.key"))
+
+	restore()
+	assert.Equal(t, current, config.Get("auth.key"))
+}
+
+func TestSwitchErrors(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	// Not registered
+	_, err := Switch(db, nil, false)
+	assert.Error(t, err)
+
+	// Registered with another password
+	params := auth.Params{Argon2: auth.Argon2{Iterations: 1, Memory: 1, Threads: 1}}
+	err = auth.Register(db, []byte("key"), params)
+	assert.NoError(t, err)
+	config.Set("auth.password", memguard.NewEnclave([]byte("other")))
+	expected := config.Get("auth")
+
+	_, err = Switch(db, nil, false)
+	assert.Error(t, err)
+	assert.Equal(t, expected, config.Get("auth"), "Credentials must be restored on failure")
+}
+
+func TestSwitchTeam(t *testing.T) {
+	db := cmdutil.SetContext(t)
+	personal := config.Get("auth")
+
+	vaultKey := bytes.Repeat([]byte("v"), 32)
+	nameKey := bytes.Repeat([]byte("n"), 32)
+	config.Set("auth", map[string]interface{}{
+		"password":   memguard.NewEnclave(bytes.Clone(vaultKey)),
+		"iterations": team.Argon2.Iterations,
+		"memory":     team.Argon2.Memory,
+		"threads":    team.Argon2.Threads,
+		"key":        nameKey,
+	})
+	err := auth.Register(db, nameKey, auth.Params{Argon2: team.Argon2, Team: true})
+	assert.NoError(t, err)
+
+	_, alicePub, err := crypt.GenerateKeyPair()
+	assert.NoError(t, err)
+	bobKey, bobPub, err := crypt.GenerateKeyPair()
+	assert.NoError(t, err)
+	assert.NoError(t, team.Init(db, vaultKey, team.Member{Name: "alice", PublicKey: alicePub}))
+	assert.NoError(t, team.Add(db, team.Member{Name: "bob", PublicKey: bobPub, Prefixes: []string{"shared/"}}))
+	config.Set("auth", personal)
+
+	_, err = Switch(db, nil, false)
+	assert.ErrorIs(t, err, ErrTeamVault)
+
+	restore, err := SwitchTeam(db, bobKey)
+	assert.NoError(t, err)
+	assert.Equal(t, nameKey, config.Get("auth.key"))
+	assert.Equal(t, "bob", config.Get("auth.member"))
+	assert.Equal(t, []string{"shared/"}, config.Get("auth.prefixes"))
+
+	restore()
+	assert.Equal(t, personal, config.Get("auth"))
+
+	otherKey, _, err := crypt.GenerateKeyPair()
+	assert.NoError(t, err)
+	_, err = SwitchTeam(db, otherKey)
+	assert.Error(t, err)
+	assert.Equal(t, personal, config.Get("auth"))
+}
@@ -0,0 +1,292 @@
+// Package bitwarden implements the Bitwarden JSON export format, including password protected exports.
+package bitwarden
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/hkdf"
+)
+
+// Item types.
+const (
+	LoginType = iota + 1
+	SecureNoteType
+	CardType
+	IdentityType
+	SSHKeyType
+)
+
+// Custom field types.
+const (
+	TextField = iota
+	HiddenField
+	BooleanField
+	LinkedField
+)
+
+// Key derivation functions.
+const (
+	PBKDF2 = iota
+	Argon2id
+)
+
+// ErrInvalidPassword is returned when a password protected export can't be decrypted with the password provided.
+var ErrInvalidPassword = errors.New("invalid password")
+
+// Export represents a Bitwarden JSON export.
+type Export struct {
+	Encrypted         bool   `json:"encrypted"`
+	PasswordProtected bool   `json:"passwordProtected,omitempty"`
+	Salt              string `json:"salt,omitempty"`
+	KdfType           int    `json:"kdfType,omitempty"`
+	KdfIterations     int    `json:"kdfIterations,omitempty"`
+	KdfMemory         int    `json:"kdfMemory,omitempty"`
+	KdfParallelism    int    `json:"kdfParallelism,omitempty"`
+	EncKeyValidation  string `json:"encKeyValidation_DO_NOT_EDIT,omitempty"`
+	// Encrypted export content
+	Data    string   `json:"data,omitempty"`
+	Folders []Folder `json:"folders"`
+	Items   []Item   `json:"items"`
+}
+
+// Folder is used to group items.
+type Folder struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Item represents any of the vault elements.
+type Item struct {
+	ID         string      `json:"id"`
+	FolderID   string      `json:"folderId,omitempty"`
+	Type       int         `json:"type"`
+	Name       string      `json:"name"`
+	Notes      string      `json:"notes,omitempty"`
+	Favorite   bool        `json:"favorite"`
+	Fields     []Field     `json:"fields,omitempty"`
+	Login      *Login      `json:"login,omitempty"`
+	SecureNote *SecureNote `json:"secureNote,omitempty"`
+	Card       *Card       `json:"card,omitempty"`
+	Identity   *Identity   `json:"identity,omitempty"`
+	SSHKey     *SSHKey     `json:"sshKey,omitempty"`
+	// RFC 3339 formatted time of the last modification
+	RevisionDate string `json:"revisionDate,omitempty"`
+}
+
+// Field is an item custom field.
+type Field struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	Type  int    `json:"type"`
+}
+
+// Login contains a login item credentials.
+type Login struct {
+	URIs     []URI  `json:"uris,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	TOTP     string `json:"totp,omitempty"`
+}
+
+// URI is a login item website.
+type URI struct {
+	URI string `json:"uri"`
+}
+
+// SecureNote contains a secure note item type, its content is stored in the item notes.
+type SecureNote struct {
+	Type int `json:"type"`
+}
+
+// Card contains a card item details.
+type Card struct {
+	CardholderName string `json:"cardholderName,omitempty"`
+	Brand          string `json:"brand,omitempty"`
+	Number         string `json:"number,omitempty"`
+	ExpMonth       string `json:"expMonth,omitempty"`
+	ExpYear        string `json:"expYear,omitempty"`
+	Code           string `json:"code,omitempty"`
+}
+
+// SSHKey contains an SSH key item details, the private key is in the OpenSSH format.
+type SSHKey struct {
+	PrivateKey     string `json:"privateKey"`
+	PublicKey      string `json:"publicKey"`
+	KeyFingerprint string `json:"keyFingerprint"`
+}
+
+// Identity contains an identity item details.
+type Identity struct {
+	Title          string `json:"title,omitempty"`
+	FirstName      string `json:"firstName,omitempty"`
+	MiddleName     string `json:"middleName,omitempty"`
+	LastName       string `json:"lastName,omitempty"`
+	Address1       string `json:"address1,omitempty"`
+	Address2       string `json:"address2,omitempty"`
+	Address3       string `json:"address3,omitempty"`
+	City           string `json:"city,omitempty"`
+	State          string `json:"state,omitempty"`
+	PostalCode     string `json:"postalCode,omitempty"`
+	Country        string `json:"country,omitempty"`
+	Company        string `json:"company,omitempty"`
+	Email          string `json:"email,omitempty"`
+	Phone          string `json:"phone,omitempty"`
+	SSN            string `json:"ssn,omitempty"`
+	Username       string `json:"username,omitempty"`
+	PassportNumber string `json:"passportNumber,omitempty"`
+	LicenseNumber  string `json:"licenseNumber,omitempty"`
+}
+
+// Decode reads a Bitwarden JSON export.
+//
+// If the export is password protected, password is called to obtain the password used to decrypt it.
+func Decode(r io.Reader, password func() ([]byte, error)) (*Export, error) {
+	export := &Export{}
+	if err := json.NewDecoder(r).Decode(export); err != nil {
+		return nil, errors.Wrap(err, "decoding JSON")
+	}
+
+	if !export.Encrypted {
+		return export, nil
+	}
+
+	if !export.PasswordProtected {
+		return nil, errors.New("exports encrypted with the account key are not supported, use a password protected or unencrypted one")
+	}
+
+	pwd, err := password()
+	if err != nil {
+		return nil, err
+	}
+
+	return Decrypt(export, pwd)
+}
+
+// Decrypt returns the content of a password protected export.
+func Decrypt(export *Export, password []byte) (*Export, error) {
+	encKey, macKey, err := deriveKeys(export, password)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := decryptString(export.EncKeyValidation, encKey, macKey); err != nil {
+		return nil, ErrInvalidPassword
+	}
+
+	data, err := decryptString(export.Data, encKey, macKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "decrypting data")
+	}
+
+	decrypted := &Export{}
+	if err := json.Unmarshal(data, decrypted); err != nil {
+		return nil, errors.Wrap(err, "decoding decrypted data")
+	}
+
+	return decrypted, nil
+}
+
+// deriveKeys returns the encryption and MAC keys derived from the password.
+func deriveKeys(export *Export, password []byte) ([]byte, []byte, error) {
+	var key []byte
+	switch export.KdfType {
+	case PBKDF2:
+		if export.KdfIterations < 1 {
+			return nil, nil, errors.New("invalid PBKDF2 iterations")
+		}
+		k, err := pbkdf2.Key(sha256.New, string(password), []byte(export.Salt), export.KdfIterations, 32)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "deriving key")
+		}
+		key = k
+
+	case Argon2id:
+		if export.KdfIterations < 1 || export.KdfMemory < 1 || export.KdfParallelism < 1 || export.KdfParallelism > 255 {
+			return nil, nil, errors.New("invalid argon2id parameters")
+		}
+		salt := sha256.Sum256([]byte(export.Salt))
+		// Memory is measured in MiB
+		key = argon2.IDKey(password, salt[:], uint32(export.KdfIterations), uint32(export.KdfMemory)*1024, uint8(export.KdfParallelism), 32)
+
+	default:
+		return nil, nil, errors.Errorf("unsupported key derivation function [%d]", export.KdfType)
+	}
+
+	encKey, err := expand(key, "enc")
+	if err != nil {
+		return nil, nil, err
+	}
+	macKey, err := expand(key, "mac")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return encKey, macKey, nil
+}
+
+func expand(key []byte, info string) ([]byte, error) {
+	out := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.Expand(sha256.New, key, []byte(info)), out); err != nil {
+		return nil, errors.Wrap(err, "stretching key")
+	}
+	return out, nil
+}
+
+// decryptString decrypts an AES-256-CBC and HMAC-SHA256 encrypted string, whose format is "2.iv|ciphertext|mac".
+func decryptString(s string, encKey, macKey []byte) ([]byte, error) {
+	encType, data, found := strings.Cut(s, ".")
+	if !found || encType != "2" {
+		return nil, errors.New("unsupported encryption type")
+	}
+
+	parts := strings.Split(data, "|")
+	if len(parts) != 3 {
+		return nil, errors.New("invalid encrypted string")
+	}
+
+	decoded := make([][]byte, len(parts))
+	for i, p := range parts {
+		b, err := base64.StdEncoding.DecodeString(p)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid encrypted string")
+		}
+		decoded[i] = b
+	}
+	iv, ciphertext, mac := decoded[0], decoded[1], decoded[2]
+
+	h := hmac.New(sha256.New, macKey)
+	h.Write(iv)
+	h.Write(ciphertext)
+	if !hmac.Equal(mac, h.Sum(nil)) {
+		return nil, errors.New("invalid MAC")
+	}
+
+	block, err := aes.NewCipher(encKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating cipher")
+	}
+	if len(iv) != aes.BlockSize || len(ciphertext) == 0 || len(ciphertext)%aes.BlockSize != 0 {
+		return nil, errors.New("invalid ciphertext")
+	}
+
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, ciphertext)
+
+	padding := int(plaintext[len(plaintext)-1])
+	if padding == 0 || padding > aes.BlockSize || !bytes.Equal(plaintext[len(plaintext)-padding:], bytes.Repeat([]byte{byte(padding)}, padding)) {
+		return nil, errors.New("invalid padding")
+	}
+
+	return plaintext[:len(plaintext)-padding], nil
+}
@@ -0,0 +1,141 @@
+package bitwarden
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecode(t *testing.T) {
+	input := `{
+		"encrypted": false,
+		"folders": [{"id": "f1", "name": "Work"}],
+		"items": [
+			{"id": "i1", "folderId": "f1", "type": 1, "name": "GitHub", "login": {"username": "user", "password": "pass", "totp": "JBSWY3DPEHPK3PXP", "uris": [{"match": null, "uri": "https://github.com"}]}},
+			{"id": "i2", "folderId": null, "type": 3, "name": "Visa", "notes": null, "card": {"brand": "Visa", "number": "4111111111111111", "expMonth": "1", "expYear": "2030", "code": "123"}}
+		]
+	}`
+
+	export, err := Decode(strings.NewReader(input), nil)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []Folder{{ID: "f1", Name: "Work"}}, export.Folders)
+	assert.Len(t, export.Items, 2)
+	assert.Equal(t, "f1", export.Items[0].FolderID)
+	assert.Equal(t, &Login{
+		URIs:     []URI{{URI: "https://github.com"}},
+		Username: "user",
+		Password: "pass",
+		TOTP:     "JBSWY3DPEHPK3PXP",
+	}, export.Items[0].Login)
+	assert.Equal(t, "", export.Items[1].FolderID)
+	assert.Equal(t, "4111111111111111", export.Items[1].Card.Number)
+}
+
+func TestDecrypt(t *testing.T) {
+	plain := &Export{
+		Folders: []Folder{},
+		Items:   []Item{{ID: "1", Type: SecureNoteType, Name: "Note", Notes: "secret", SecureNote: &SecureNote{}}},
+	}
+
+	cases := []struct {
+		desc   string
+		export *Export
+	}{
+		{
+			desc:   "PBKDF2",
+			export: &Export{Salt: "salt", KdfType: PBKDF2, KdfIterations: 1000},
+		},
+		{
+			desc:   "Argon2id",
+			export: &Export{Salt: "salt", KdfType: Argon2id, KdfIterations: 1, KdfMemory: 1, KdfParallelism: 1},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			encrypt(t, tc.export, plain, []byte("password"))
+
+			data, err := json.Marshal(tc.export)
+			assert.NoError(t, err)
+
+			got, err := Decode(bytes.NewReader(data), func() ([]byte, error) {
+				return []byte("password"), nil
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, plain, got)
+
+			_, err = Decrypt(tc.export, []byte("invalid"))
+			assert.Equal(t, ErrInvalidPassword, err)
+		})
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	cases := []struct {
+		desc  string
+		input string
+	}{
+		{desc: "Invalid JSON", input: "{"},
+		{desc: "Account restricted", input: `{"encrypted": true, "encKeyValidation_DO_NOT_EDIT": "2.a|b|c", "data": "2.a|b|c"}`},
+		{desc: "Unsupported KDF", input: `{"encrypted": true, "passwordProtected": true, "kdfType": 5, "kdfIterations": 1}`},
+		{desc: "Invalid iterations", input: `{"encrypted": true, "passwordProtected": true, "kdfType": 0}`},
+		{desc: "Invalid argon2 parameters", input: `{"encrypted": true, "passwordProtected": true, "kdfType": 1, "kdfIterations": 1}`},
+		{desc: "Invalid encrypted string", input: `{"encrypted": true, "passwordProtected": true, "kdfIterations": 1, "encKeyValidation_DO_NOT_EDIT": "0.abc"}`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			_, err := Decode(strings.NewReader(tc.input), func() ([]byte, error) {
+				return []byte("password"), nil
+			})
+			assert.Error(t, err)
+		})
+	}
+}
+
+// encrypt populates the password protected fields of export with the encrypted content of plain.
+func encrypt(t *testing.T, export, plain *Export, password []byte) {
+	t.Helper()
+	encKey, macKey, err := deriveKeys(export, password)
+	assert.NoError(t, err)
+
+	data, err := json.Marshal(plain)
+	assert.NoError(t, err)
+
+	export.Encrypted = true
+	export.PasswordProtected = true
+	export.EncKeyValidation = encryptString(t, []byte("validation"), encKey, macKey)
+	export.Data = encryptString(t, data, encKey, macKey)
+}
+
+func encryptString(t *testing.T, plaintext, encKey, macKey []byte) string {
+	t.Helper()
+	iv := make([]byte, aes.BlockSize)
+	_, err := rand.Read(iv)
+	assert.NoError(t, err)
+
+	padding := aes.BlockSize - len(plaintext)%aes.BlockSize
+	plaintext = append(plaintext, bytes.Repeat([]byte{byte(padding)}, padding)...)
+
+	block, err := aes.NewCipher(encKey)
+	assert.NoError(t, err)
+	ciphertext := make([]byte, len(plaintext))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, plaintext)
+
+	h := hmac.New(sha256.New, macKey)
+	h.Write(iv)
+	h.Write(ciphertext)
+
+	enc := base64.StdEncoding
+	return "2." + enc.EncodeToString(iv) + "|" + enc.EncodeToString(ciphertext) + "|" + enc.EncodeToString(h.Sum(nil))
+}
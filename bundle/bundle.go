@@ -0,0 +1,275 @@
+// Package bundle implements the format used to share a few records with another user, encrypted with a
+// passphrase or sealed with the recipient's X25519 public key.
+//
+// Layout:
+//
+//	magic "KURB" | version (1 byte) | mode (1 byte) | mode header | ciphertext
+//
+// Passphrase bundles use the same key derivation as kure exports, their mode header is:
+//
+//	argon2 iterations (4 bytes) | argon2 memory (4 bytes) | argon2 threads (1 byte) | salt (32 bytes) | nonce (12 bytes)
+//
+// and the header is authenticated as additional data. Sealed bundles have no mode header, the ciphertext
+// is created with crypt.Seal.
+//
+// The plaintext is the expiration time (unix seconds, 8 bytes, 0 if it never expires) followed by a pb.Archive
+// with the records shared, the expiration can't be modified without invalidating the bundle.
+package bundle
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"time"
+
+	"github.com/GGP1/kure/archive"
+	"github.com/GGP1/kure/crypt"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/argon2"
+	"google.golang.org/protobuf/proto"
+)
+
+// Version is the current version of the format.
+const Version = 1
+
+// PEMType is the type of the armored bundles block.
+const PEMType = "KURE BUNDLE"
+
+const (
+	magic = "KURB"
+	// Modes
+	passphrase byte = 1
+	sealed     byte = 2
+
+	prefixSize     = len(magic) + 2
+	saltSize       = 32
+	nonceSize      = 12
+	paramsSize     = 4 + 4 + 1
+	passHeaderSize = prefixSize + paramsSize + saltSize + nonceSize
+	expiresSize    = 8
+	// Upper bound of the key derivation memory to avoid crafted bundles from exhausting it (4 GiB)
+	maxMemory = 1 << 22
+)
+
+var (
+	// ErrExpired is returned when decoding a bundle whose expiration time has passed.
+	ErrExpired = errors.New("the bundle has expired")
+	// ErrInvalidPassword is returned when the bundle can't be authenticated with the passphrase provided.
+	ErrInvalidPassword = errors.New("invalid passphrase or corrupted bundle")
+	errInvalidFormat   = errors.New("invalid bundle format")
+)
+
+// Bundle contains the records shared.
+type Bundle struct {
+	Archive *pb.Archive
+	// Zero if the bundle never expires
+	Expires time.Time
+}
+
+// Encode encrypts the bundle with the passphrase.
+func Encode(b *Bundle, password []byte, params archive.Params) ([]byte, error) {
+	if params.Iterations == 0 || params.Memory == 0 || params.Threads == 0 {
+		return nil, errors.New("invalid key derivation parameters")
+	}
+
+	plaintext, err := marshal(b)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 0, passHeaderSize)
+	header = append(header, magic...)
+	header = append(header, Version, passphrase)
+	header = binary.BigEndian.AppendUint32(header, params.Iterations)
+	header = binary.BigEndian.AppendUint32(header, params.Memory)
+	header = append(header, params.Threads)
+
+	salt := make([]byte, saltSize)
+	nonce := make([]byte, nonceSize)
+	// rand.Read never returns an error
+	rand.Read(salt)
+	rand.Read(nonce)
+	header = append(header, salt...)
+	header = append(header, nonce...)
+
+	gcm, err := newGCM(password, salt, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(header, nonce, plaintext, header), nil
+}
+
+// Seal encrypts the bundle so only the owner of the private key matching the X25519 public key can
+// decrypt it.
+func Seal(b *Bundle, publicKey []byte) ([]byte, error) {
+	plaintext, err := marshal(b)
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := crypt.Seal(publicKey, plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, 0, prefixSize+len(ciphertext))
+	data = append(data, magic...)
+	data = append(data, Version, sealed)
+	return append(data, ciphertext...), nil
+}
+
+// Sealed reports whether the bundle was sealed with a public key instead of being encrypted with a passphrase.
+func Sealed(data []byte) (bool, error) {
+	if len(data) < prefixSize || string(data[:len(magic)]) != magic {
+		return false, errInvalidFormat
+	}
+	if version := data[len(magic)]; version != Version {
+		return false, errors.Errorf("unsupported bundle version %d", version)
+	}
+
+	switch data[len(magic)+1] {
+	case passphrase:
+		return false, nil
+	case sealed:
+		return true, nil
+	default:
+		return false, errInvalidFormat
+	}
+}
+
+// Decode decrypts a bundle encrypted with a passphrase.
+func Decode(data, password []byte) (*Bundle, error) {
+	isSealed, err := Sealed(data)
+	if err != nil {
+		return nil, err
+	}
+	if isSealed {
+		return nil, errors.New("the bundle is sealed with a public key")
+	}
+	if len(data) < passHeaderSize {
+		return nil, errInvalidFormat
+	}
+
+	header, ciphertext := data[:passHeaderSize], data[passHeaderSize:]
+	offset := prefixSize
+	params := archive.Params{
+		Iterations: binary.BigEndian.Uint32(header[offset:]),
+		Memory:     binary.BigEndian.Uint32(header[offset+4:]),
+		Threads:    header[offset+8],
+	}
+	offset += paramsSize
+	if params.Iterations == 0 || params.Memory == 0 || params.Memory > maxMemory || params.Threads == 0 {
+		return nil, errInvalidFormat
+	}
+
+	salt := header[offset : offset+saltSize]
+	nonce := header[offset+saltSize:]
+
+	gcm, err := newGCM(password, salt, params)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, header)
+	if err != nil {
+		return nil, ErrInvalidPassword
+	}
+
+	return unmarshal(plaintext)
+}
+
+// Open decrypts a bundle sealed with the public key of the X25519 private key passed.
+func Open(data, privateKey []byte) (*Bundle, error) {
+	isSealed, err := Sealed(data)
+	if err != nil {
+		return nil, err
+	}
+	if !isSealed {
+		return nil, errors.New("the bundle is encrypted with a passphrase")
+	}
+
+	plaintext, err := crypt.Open(privateKey, data[prefixSize:])
+	if err != nil {
+		return nil, errors.New("the bundle wasn't sealed with your public key")
+	}
+
+	return unmarshal(plaintext)
+}
+
+// Armor returns the bundle encoded as a PEM block.
+func Armor(data []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: PEMType, Bytes: data})
+}
+
+// Unarmor returns the bundle contained in text, either a PEM block, base64 or the bundle itself.
+func Unarmor(text []byte) ([]byte, error) {
+	if bytes.HasPrefix(text, []byte(magic)) {
+		return text, nil
+	}
+
+	if block, _ := pem.Decode(text); block != nil {
+		if block.Type != PEMType {
+			return nil, errors.Errorf("invalid block type %q", block.Type)
+		}
+		return block.Bytes, nil
+	}
+
+	data, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(text)))
+	if err != nil {
+		return nil, errInvalidFormat
+	}
+	return data, nil
+}
+
+func marshal(b *Bundle) ([]byte, error) {
+	data, err := proto.Marshal(b.Archive)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal records")
+	}
+
+	var expires int64
+	if !b.Expires.IsZero() {
+		expires = b.Expires.Unix()
+	}
+
+	plaintext := make([]byte, 0, expiresSize+len(data))
+	plaintext = binary.BigEndian.AppendUint64(plaintext, uint64(expires))
+	return append(plaintext, data...), nil
+}
+
+func unmarshal(plaintext []byte) (*Bundle, error) {
+	if len(plaintext) < expiresSize {
+		return nil, errInvalidFormat
+	}
+
+	b := &Bundle{Archive: &pb.Archive{}}
+	if expires := int64(binary.BigEndian.Uint64(plaintext)); expires != 0 {
+		b.Expires = time.Unix(expires, 0)
+		if time.Now().After(b.Expires) {
+			return nil, ErrExpired
+		}
+	}
+
+	if err := proto.Unmarshal(plaintext[expiresSize:], b.Archive); err != nil {
+		return nil, errors.Wrap(err, "unmarshal records")
+	}
+
+	return b, nil
+}
+
+func newGCM(password, salt []byte, params archive.Params) (cipher.AEAD, error) {
+	key := argon2.IDKey(password, salt, params.Iterations, params.Memory, params.Threads, 32)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "create cipher")
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
 	cmdutil "github.com/GGP1/kure/commands"
 	"github.com/GGP1/kure/commands/add/phrase"
@@ -24,13 +25,16 @@ const example = `
 kure add Sample -c
 
 * Add an entry generating a random password
-kure add Sample -l 27 -L 1,2,3,4,5 -i & -e / -r`
+kure add Sample -l 27 -L 1,2,3,4,5 -i & -e / -r
+
+* Add an entry generating a random password using a profile from the configuration
+kure add Sample -p bank`
 
 type addOptions struct {
-	include, exclude string
-	levels           []int
-	length           uint64
-	custom, repeat   bool
+	include, exclude, profile string
+	levels                    []int
+	length                    uint64
+	custom, repeat            bool
 }
 
 // NewCmd returns a new command.
@@ -58,6 +62,11 @@ func NewCmd(db *bolt.DB, r io.Reader) *cobra.Command {
 	f.StringVarP(&opts.include, "include", "i", "", "characters to include in the password")
 	f.StringVarP(&opts.exclude, "exclude", "e", "", "characters to exclude from the password")
 	f.BoolVarP(&opts.repeat, "repeat", "r", true, "allow character repetition")
+	f.StringVarP(&opts.profile, "profile", "p", "", "password generation profile")
+
+	for _, flag := range cmdutil.ProfileFlags {
+		cmd.MarkFlagsMutuallyExclusive("profile", flag)
+	}
 
 	return cmd
 }
@@ -67,7 +76,14 @@ func runAdd(db *bolt.DB, r io.Reader, opts *addOptions) cmdutil.RunEFunc {
 		name := strings.Join(args, " ")
 		name = cmdutil.NormalizeName(name)
 
-		if !opts.custom {
+		var profile *cmdutil.Profile
+		if opts.profile != "" {
+			p, err := cmdutil.GetProfile(opts.profile)
+			if err != nil {
+				return err
+			}
+			profile = p
+		} else if !opts.custom {
 			if opts.length < 1 {
 				return cmdutil.ErrInvalidLength
 			}
@@ -81,13 +97,27 @@ func runAdd(db *bolt.DB, r io.Reader, opts *addOptions) cmdutil.RunEFunc {
 			return err
 		}
 
-		if !opts.custom {
+		switch {
+		case opts.custom && profile != nil:
+			if err := profile.Validate(e.Password); err != nil {
+				return err
+			}
+
+		case profile != nil:
+			e.Password, err = profile.Generate()
+			if err != nil {
+				return err
+			}
+
+		case !opts.custom:
 			// Generate random password
 			e.Password, err = genPassword(opts)
 			if err != nil {
 				return err
 			}
 		}
+		e.Profile = opts.profile
+		e.PasswordUpdatedAt = time.Now().Unix()
 
 		if err := entry.Create(db, e); err != nil {
 			return err
@@ -100,23 +130,9 @@ func runAdd(db *bolt.DB, r io.Reader, opts *addOptions) cmdutil.RunEFunc {
 
 // genPassword returns a customized random password or an error.
 func genPassword(opts *addOptions) (string, error) {
-	levels := make([]atoll.Level, len(opts.levels))
-	for i, lvl := range opts.levels {
-		switch lvl {
-		case 1:
-			levels[i] = atoll.Lower
-		case 2:
-			levels[i] = atoll.Upper
-		case 3:
-			levels[i] = atoll.Digit
-		case 4:
-			levels[i] = atoll.Space
-		case 5:
-			levels[i] = atoll.Special
-
-		default:
-			return "", errors.Errorf("invalid level [%d]", lvl)
-		}
+	levels, err := cmdutil.ParseLevels(opts.levels)
+	if err != nil {
+		return "", err
 	}
 
 	p := &atoll.Password{
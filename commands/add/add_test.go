@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/config"
 	"github.com/GGP1/kure/db/entry"
 	"github.com/GGP1/kure/pb"
 
@@ -44,6 +45,27 @@ func TestAdd(t *testing.T) {
 	}
 }
 
+func TestAddProfile(t *testing.T) {
+	db := cmdutil.SetContext(t)
+	config.Set("gen.profiles.pin", map[string]interface{}{
+		"length": 6,
+		"levels": []interface{}{3},
+	})
+
+	buf := bytes.NewBufferString("username\nurl\n03/05/2024\nnotes<")
+	cmd := NewCmd(db, buf)
+	cmd.SetArgs([]string{"test"})
+	cmd.Flags().Set("profile", "pin")
+
+	err := cmd.Execute()
+	assert.NoError(t, err)
+
+	e, err := entry.Get(db, "test")
+	assert.NoError(t, err)
+	assert.Equal(t, "pin", e.Profile)
+	assert.Regexp(t, "^[0-9]{6}$", e.Password)
+}
+
 func TestAddErrors(t *testing.T) {
 	db := cmdutil.SetContext(t)
 
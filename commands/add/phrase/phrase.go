@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
 	cmdutil "github.com/GGP1/kure/commands"
 	"github.com/GGP1/kure/db/entry"
@@ -74,6 +75,7 @@ func runPhrase(db *bolt.DB, r io.Reader, opts *phraseOptions) cmdutil.RunEFunc {
 		if err != nil {
 			return err
 		}
+		e.PasswordUpdatedAt = time.Now().Unix()
 
 		if err := entry.Create(db, e); err != nil {
 			return err
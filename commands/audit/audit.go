@@ -0,0 +1,30 @@
+package audit
+
+import (
+	"os"
+
+	"github.com/GGP1/kure/commands/audit/breaches"
+	"github.com/GGP1/kure/commands/audit/passwords"
+
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+const example = `
+kure audit (breaches|passwords)`
+
+// NewCmd returns a new command.
+func NewCmd(db *bolt.DB) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "audit",
+		Short:   "Audit the records stored",
+		Example: example,
+	}
+
+	cmd.AddCommand(
+		breaches.NewCmd(db, os.Stdin),
+		passwords.NewCmd(db),
+	)
+
+	return cmd
+}
@@ -0,0 +1,336 @@
+package breaches
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"unicode/utf16"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/commands/rotate"
+	"github.com/GGP1/kure/db/entry"
+	"github.com/GGP1/kure/pb"
+	"github.com/GGP1/kure/terminal"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+	"golang.org/x/crypto/md4"
+)
+
+const example = `
+* Check passwords against the SHA-1 file ordered by hash
+kure audit breaches -p path/to/pwned-passwords-sha1-ordered-by-hash.txt
+
+* Check passwords against a directory of NTLM range files
+kure audit breaches -p path/to/ranges --ntlm
+
+* Check passwords and rotate the compromised ones
+kure audit breaches -p path/to/file --rotate`
+
+// prefixLength is the number of characters of the hash used to name range files.
+const prefixLength = 5
+
+type breachesOptions struct {
+	path   string
+	json   bool
+	ntlm   bool
+	rotate bool
+}
+
+type breach struct {
+	Name        string `json:"name"`
+	Occurrences int    `json:"occurrences"`
+}
+
+// NewCmd returns a new command.
+func NewCmd(db *bolt.DB, r io.Reader) *cobra.Command {
+	opts := breachesOptions{}
+	cmd := &cobra.Command{
+		Use:   "breaches",
+		Short: "Check passwords against a local Have I Been Pwned file",
+		Long: `Check passwords against a local Have I Been Pwned file.
+
+The passwords are hashed and looked up in a file downloaded from Have I Been Pwned, nothing is sent over the network.
+
+The path may point to:
+	• A single file ordered by hash, where each line has the format HASH:COUNT.
+	• A directory with range files named after the first 5 characters of the hash (e.g. 5BAA6.txt), where each line has the format SUFFIX:COUNT.
+
+Files are searched using binary search, so they are never loaded into memory.
+
+Use the rotate flag to be asked, for each compromised entry, whether to rotate its password.
+
+The command fails if any compromised password is left unrotated.`,
+		Aliases: []string{"pwned"},
+		Example: example,
+		RunE:    runBreaches(db, r, &opts),
+		PostRun: func(cmd *cobra.Command, args []string) {
+			// Reset variables (session)
+			opts = breachesOptions{}
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVarP(&opts.path, "path", "p", "", "path to the hashes file or range files directory")
+	f.BoolVar(&opts.ntlm, "ntlm", false, "use NTLM hashes instead of SHA-1")
+	f.BoolVar(&opts.rotate, "rotate", false, "rotate compromised passwords interactively")
+	f.BoolVar(&opts.json, "json", false, "output the report in JSON format")
+
+	return cmd
+}
+
+func runBreaches(db *bolt.DB, r io.Reader, opts *breachesOptions) cmdutil.RunEFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		if opts.path == "" {
+			return cmdutil.ErrInvalidPath
+		}
+
+		entries, err := entry.List(db)
+		if err != nil {
+			return err
+		}
+
+		hash := sha1Hash
+		if opts.ntlm {
+			hash = ntlmHash
+		}
+
+		breaches, compromised, err := check(opts.path, entries, hash)
+		if err != nil {
+			return err
+		}
+
+		if opts.json {
+			if err := json.NewEncoder(os.Stdout).Encode(breaches); err != nil {
+				return errors.Wrap(err, "encoding report to JSON")
+			}
+		} else {
+			printReport(breaches)
+		}
+
+		if opts.rotate {
+			compromised, err = rotateEntries(db, r, compromised)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(compromised) > 0 {
+			return errors.Errorf("found %d compromised passwords", len(compromised))
+		}
+		return nil
+	}
+}
+
+// check looks for each entry password hash in the file or directory located at path and returns
+// the breaches found along with the compromised entries.
+func check(path string, entries []*pb.Entry, hash func(string) string) ([]breach, []*pb.Entry, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "obtaining file information")
+	}
+
+	var lookup func(hash string) (int, error)
+	if info.IsDir() {
+		lookup = func(hash string) (int, error) {
+			return searchRange(path, hash)
+		}
+	} else {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "opening file")
+		}
+		defer f.Close()
+
+		lookup = func(hash string) (int, error) {
+			return search(f, info.Size(), hash)
+		}
+	}
+
+	breaches := make([]breach, 0)
+	compromised := make([]*pb.Entry, 0)
+	for _, e := range entries {
+		if e.Password == "" {
+			continue
+		}
+
+		occurrences, err := lookup(hash(e.Password))
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "looking up %q", e.Name)
+		}
+
+		if occurrences > 0 {
+			breaches = append(breaches, breach{Name: e.Name, Occurrences: occurrences})
+			compromised = append(compromised, e)
+		}
+	}
+
+	return breaches, compromised, nil
+}
+
+// rotateEntries asks the user whether to rotate each entry's password and returns the ones left unrotated.
+func rotateEntries(db *bolt.DB, r io.Reader, entries []*pb.Entry) ([]*pb.Entry, error) {
+	unrotated := make([]*pb.Entry, 0, len(entries))
+	for _, e := range entries {
+		if !terminal.Confirm(r, fmt.Sprintf("Rotate %q password?", e.Name)) {
+			unrotated = append(unrotated, e)
+			continue
+		}
+
+		password, err := rotate.NewPassword(e)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := rotate.SetPassword(db, e, password); err != nil {
+			return nil, err
+		}
+		fmt.Printf("%q password rotated\n", e.Name)
+	}
+
+	return unrotated, nil
+}
+
+// search performs a binary search over a file sorted by hash and returns the number of occurrences
+// of the hash, or zero if it wasn't found.
+func search(r io.ReaderAt, size int64, hash string) (int, error) {
+	lo, hi := int64(0), size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+
+		start, line, err := lineAt(r, size, mid)
+		if err != nil {
+			return 0, err
+		}
+
+		// No line starts after mid, look for it in the first half
+		if start < 0 {
+			hi = mid
+			continue
+		}
+
+		lineHash, occurrences, err := parseLine(line)
+		if err != nil {
+			return 0, err
+		}
+
+		switch {
+		case lineHash == hash:
+			return occurrences, nil
+		case lineHash < hash:
+			lo = start + int64(len(line)) + 1
+		default:
+			hi = mid
+		}
+	}
+
+	return 0, nil
+}
+
+// searchRange looks for the hash in the range file named after its prefix.
+func searchRange(dir, hash string) (int, error) {
+	filename := filepath.Join(dir, hash[:prefixLength]+".txt")
+	f, err := os.Open(filename)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, errors.Wrap(err, "opening range file")
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, errors.Wrap(err, "obtaining file information")
+	}
+
+	return search(f, info.Size(), hash[prefixLength:])
+}
+
+// lineAt returns the first line that starts at or after offset and its starting position.
+//
+// If there is no line after offset, the position returned is -1.
+func lineAt(r io.ReaderAt, size, offset int64) (int64, string, error) {
+	start := offset
+	if offset > 0 {
+		// Skip the line that contains offset unless offset is the beginning of a line
+		br := bufio.NewReader(io.NewSectionReader(r, offset-1, size-offset+1))
+		skipped, err := br.ReadString('\n')
+		if err != nil {
+			if err == io.EOF {
+				return -1, "", nil
+			}
+			return 0, "", errors.Wrap(err, "reading file")
+		}
+		start = offset - 1 + int64(len(skipped))
+	}
+
+	if start >= size {
+		return -1, "", nil
+	}
+
+	br := bufio.NewReader(io.NewSectionReader(r, start, size-start))
+	line, err := br.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return 0, "", errors.Wrap(err, "reading file")
+	}
+
+	return start, strings.TrimSuffix(line, "\n"), nil
+}
+
+// parseLine returns the hash and the number of occurrences of a "HASH:COUNT" line.
+func parseLine(line string) (string, int, error) {
+	hash, count, found := strings.Cut(strings.TrimSpace(line), ":")
+	if !found {
+		return strings.ToUpper(hash), 1, nil
+	}
+
+	occurrences, err := strconv.Atoi(count)
+	if err != nil {
+		return "", 0, errors.Errorf("invalid line format: %q", line)
+	}
+
+	return strings.ToUpper(hash), occurrences, nil
+}
+
+func printReport(breaches []breach) {
+	if len(breaches) == 0 {
+		fmt.Println("No compromised passwords were found")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tOCCURRENCES")
+	for _, b := range breaches {
+		fmt.Fprintf(w, "%s\t%d\n", b.Name, b.Occurrences)
+	}
+	w.Flush()
+}
+
+func sha1Hash(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// ntlmHash returns the MD4 hash of the UTF-16 little-endian encoded password.
+func ntlmHash(password string) string {
+	encoded := utf16.Encode([]rune(password))
+	buf := make([]byte, len(encoded)*2)
+	for i, r := range encoded {
+		binary.LittleEndian.PutUint16(buf[i*2:], r)
+	}
+
+	h := md4.New()
+	h.Write(buf)
+	return strings.ToUpper(hex.EncodeToString(h.Sum(nil)))
+}
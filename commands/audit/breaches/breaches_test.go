@@ -0,0 +1,166 @@
+package breaches
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/db/entry"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaches(t *testing.T) {
+	db := cmdutil.SetContext(t)
+	path := writeHashes(t, "password", "123456", "qwerty")
+
+	err := entry.Create(db, &pb.Entry{Name: "safe", Password: "D7#pQ2!vL9@mX4&z"})
+	assert.NoError(t, err)
+
+	cmd := NewCmd(db, nil)
+	cmd.Flags().Set("path", path)
+	err = cmd.Execute()
+	assert.NoError(t, err)
+
+	err = entry.Create(db, &pb.Entry{Name: "pwned", Password: "password"})
+	assert.NoError(t, err)
+
+	t.Run("Compromised", func(t *testing.T) {
+		cmd := NewCmd(db, nil)
+		f := cmd.Flags()
+		f.Set("path", path)
+		f.Set("json", "true")
+
+		err := cmd.Execute()
+		assert.Error(t, err)
+	})
+
+	t.Run("Rotate", func(t *testing.T) {
+		cmd := NewCmd(db, bytes.NewBufferString("y\n"))
+		f := cmd.Flags()
+		f.Set("path", path)
+		f.Set("rotate", "true")
+
+		err := cmd.Execute()
+		assert.NoError(t, err)
+
+		e, err := entry.Get(db, "pwned")
+		assert.NoError(t, err)
+		assert.NotEqual(t, "password", e.Password)
+		assert.NotZero(t, e.PasswordUpdatedAt)
+	})
+}
+
+func TestBreachesErrors(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	cases := []struct {
+		desc string
+		path string
+	}{
+		{
+			desc: "Invalid path",
+			path: "",
+		},
+		{
+			desc: "Non-existent file",
+			path: "non-existent.txt",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			cmd := NewCmd(db, nil)
+			cmd.Flags().Set("path", tc.path)
+
+			err := cmd.Execute()
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestSearch(t *testing.T) {
+	passwords := make([]string, 500)
+	for i := range passwords {
+		passwords[i] = fmt.Sprintf("password%d", i)
+	}
+	path := writeHashes(t, passwords...)
+
+	f, err := os.Open(path)
+	assert.NoError(t, err)
+	defer f.Close()
+
+	info, err := f.Stat()
+	assert.NoError(t, err)
+
+	for i, password := range passwords {
+		got, err := search(f, info.Size(), sha1Hash(password))
+		assert.NoError(t, err)
+		assert.Equal(t, i+1, got, password)
+	}
+
+	for _, password := range []string{"", "kure", "non-existent"} {
+		got, err := search(f, info.Size(), sha1Hash(password))
+		assert.NoError(t, err)
+		assert.Zero(t, got)
+	}
+
+	t.Run("Invalid format", func(t *testing.T) {
+		content := "5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:count\n"
+		got, err := search(strings.NewReader(content), int64(len(content)), sha1Hash("password"))
+		assert.Error(t, err)
+		assert.Zero(t, got)
+	})
+}
+
+func TestSearchRange(t *testing.T) {
+	dir := t.TempDir()
+	hash := sha1Hash("password")
+
+	content := fmt.Sprintf("0018A45C4D1DEF81644B54AB7F969B88D65:1\r\n%s:42\r\nFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF:2\r\n", hash[prefixLength:])
+	err := os.WriteFile(filepath.Join(dir, hash[:prefixLength]+".txt"), []byte(content), 0o600)
+	assert.NoError(t, err)
+
+	got, err := searchRange(dir, hash)
+	assert.NoError(t, err)
+	assert.Equal(t, 42, got)
+
+	got, err = searchRange(dir, sha1Hash("kure"))
+	assert.NoError(t, err)
+	assert.Zero(t, got)
+}
+
+func TestNTLMHash(t *testing.T) {
+	assert.Equal(t, "8846F7EAEE8FB117AD06BDD830B7586C", ntlmHash("password"))
+}
+
+func TestPostRun(t *testing.T) {
+	NewCmd(nil, nil).PostRun(nil, nil)
+}
+
+// writeHashes creates a file with the passwords' SHA-1 hashes ordered, the number of
+// occurrences of each one is its position in the list plus one.
+func writeHashes(t *testing.T, passwords ...string) string {
+	t.Helper()
+
+	lines := make([]string, len(passwords))
+	for i, p := range passwords {
+		sum := sha1.Sum([]byte(p))
+		lines[i] = fmt.Sprintf("%s:%d", strings.ToUpper(hex.EncodeToString(sum[:])), i+1)
+	}
+	sort.Strings(lines)
+
+	path := filepath.Join(t.TempDir(), "hashes.txt")
+	err := os.WriteFile(path, []byte(strings.Join(lines, "\r\n")+"\r\n"), 0o600)
+	assert.NoError(t, err)
+
+	return path
+}
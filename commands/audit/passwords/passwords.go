@@ -0,0 +1,220 @@
+package passwords
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/db/entry"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/GGP1/atoll"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+const example = `
+* Audit all the passwords
+kure audit passwords
+
+* Flag passwords weaker than 80 bits or unchanged for more than 90 days
+kure audit passwords --min-entropy 80 --max-age 2160h
+
+* Output the report in JSON format
+kure audit passwords --json`
+
+// Issues found in an entry.
+const (
+	weak        = "weak"
+	reused      = "reused"
+	old         = "old"
+	expired     = "expired"
+	expiring    = "expiring"
+	insecureURL = "insecure url"
+)
+
+const day = 24 * time.Hour
+
+type passwordsOptions struct {
+	minEntropy   float64
+	maxAge       time.Duration
+	expireWithin time.Duration
+	json         bool
+}
+
+type issue struct {
+	Name    string `json:"name"`
+	Issue   string `json:"issue"`
+	Details string `json:"details"`
+}
+
+// NewCmd returns a new command.
+func NewCmd(db *bolt.DB) *cobra.Command {
+	opts := passwordsOptions{}
+	cmd := &cobra.Command{
+		Use:   "passwords",
+		Short: "Report weak, reused, old and expired passwords",
+		Long: `Report weak, reused, old and expired passwords.
+
+Every entry is decrypted and checked for:
+	• Weak passwords: the estimated entropy is lower than the minimum.
+	• Reused passwords: the same password is used in multiple entries.
+	• Old passwords: the password has not been changed for longer than the maximum age.
+	• Expired passwords: the entry is expired or expires within the time specified.
+	• Insecure URLs: the entry URL uses HTTP instead of HTTPS.
+
+The entropy is estimated using the character levels and the length of each password.
+Entries created before kure started tracking password changes are not checked for their age.
+
+The command fails if any issue is found, making it suitable for automated checks.`,
+		Aliases: []string{"pwds"},
+		Example: example,
+		RunE:    runPasswords(db, &opts),
+		PostRun: func(cmd *cobra.Command, args []string) {
+			// Reset variables (session)
+			opts = passwordsOptions{
+				minEntropy:   60,
+				maxAge:       365 * day,
+				expireWithin: 30 * day,
+			}
+		},
+	}
+
+	f := cmd.Flags()
+	f.Float64Var(&opts.minEntropy, "min-entropy", 60, "minimum password entropy in bits")
+	f.DurationVar(&opts.maxAge, "max-age", 365*day, "maximum time without changing a password (0 to disable)")
+	f.DurationVar(&opts.expireWithin, "expire-within", 30*day, "report entries that expire within this time")
+	f.BoolVar(&opts.json, "json", false, "output the report in JSON format")
+
+	return cmd
+}
+
+func runPasswords(db *bolt.DB, opts *passwordsOptions) cmdutil.RunEFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		entries, err := entry.List(db)
+		if err != nil {
+			return err
+		}
+
+		issues := audit(entries, opts, time.Now())
+
+		if opts.json {
+			if err := json.NewEncoder(os.Stdout).Encode(issues); err != nil {
+				return errors.Wrap(err, "encoding report to JSON")
+			}
+		} else {
+			printReport(issues)
+		}
+
+		if len(issues) > 0 {
+			return errors.Errorf("found %d issues", len(issues))
+		}
+		return nil
+	}
+}
+
+// audit checks every entry and returns the issues found sorted by name.
+func audit(entries []*pb.Entry, opts *passwordsOptions, now time.Time) []issue {
+	issues := make([]issue, 0)
+	passwords := make(map[string][]string, len(entries))
+
+	for _, e := range entries {
+		if e.Password != "" {
+			passwords[e.Password] = append(passwords[e.Password], e.Name)
+
+			entropy := atoll.SecretFromString(e.Password).Entropy()
+			if entropy < opts.minEntropy {
+				issues = append(issues, issue{
+					Name:    e.Name,
+					Issue:   weak,
+					Details: fmt.Sprintf("%.2f bits of entropy", entropy),
+				})
+			}
+
+			if opts.maxAge > 0 && e.PasswordUpdatedAt != 0 {
+				age := now.Sub(time.Unix(e.PasswordUpdatedAt, 0))
+				if age > opts.maxAge {
+					issues = append(issues, issue{
+						Name:    e.Name,
+						Issue:   old,
+						Details: fmt.Sprintf("unchanged for %d days", int(age/day)),
+					})
+				}
+			}
+		}
+
+		if e.Expires != "" && e.Expires != "Never" {
+			// Error is always nil as "expires" field was already formatted before being saved
+			expiration, _ := time.Parse(time.RFC1123Z, e.Expires)
+			if now.After(expiration) {
+				issues = append(issues, issue{
+					Name:    e.Name,
+					Issue:   expired,
+					Details: "expired on " + expiration.Format("02/01/2006"),
+				})
+			} else if expiration.Sub(now) <= opts.expireWithin {
+				issues = append(issues, issue{
+					Name:    e.Name,
+					Issue:   expiring,
+					Details: "expires on " + expiration.Format("02/01/2006"),
+				})
+			}
+		}
+
+		if strings.HasPrefix(strings.ToLower(e.URL), "http://") {
+			issues = append(issues, issue{
+				Name:    e.Name,
+				Issue:   insecureURL,
+				Details: e.URL,
+			})
+		}
+	}
+
+	for _, names := range passwords {
+		if len(names) < 2 {
+			continue
+		}
+
+		sort.Strings(names)
+		for i, name := range names {
+			others := make([]string, 0, len(names)-1)
+			others = append(others, names[:i]...)
+			others = append(others, names[i+1:]...)
+			issues = append(issues, issue{
+				Name:    name,
+				Issue:   reused,
+				Details: "also used by " + strings.Join(others, ", "),
+			})
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Name == issues[j].Name {
+			return issues[i].Issue < issues[j].Issue
+		}
+		return issues[i].Name < issues[j].Name
+	})
+
+	return issues
+}
+
+func printReport(issues []issue) {
+	if len(issues) == 0 {
+		fmt.Println("No issues were found")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tISSUE\tDETAILS")
+	for _, i := range issues {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", i.Name, i.Issue, i.Details)
+	}
+	w.Flush()
+}
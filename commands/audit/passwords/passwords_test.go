@@ -0,0 +1,116 @@
+package passwords
+
+import (
+	"testing"
+	"time"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/db/entry"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswords(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	e := &pb.Entry{
+		Name:              "test",
+		Password:          "K$9vT!q2@Lm#8xZp&Wr4",
+		URL:               "https://github.com",
+		Expires:           "Never",
+		PasswordUpdatedAt: time.Now().Unix(),
+	}
+	err := entry.Create(db, e)
+	assert.NoError(t, err)
+
+	cmd := NewCmd(db)
+	err = cmd.Execute()
+	assert.NoError(t, err)
+
+	t.Run("Issues found", func(t *testing.T) {
+		err := entry.Create(db, &pb.Entry{Name: "weak", Password: "1234", Expires: "Never"})
+		assert.NoError(t, err)
+
+		cmd := NewCmd(db)
+		cmd.Flags().Set("json", "true")
+		err = cmd.Execute()
+		assert.Error(t, err)
+	})
+}
+
+func TestAudit(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	strong := "K$9vT!q2@Lm#8xZp&Wr4"
+	entries := []*pb.Entry{
+		{
+			Name:              "strong",
+			Password:          "yD7#pQ2!vL9@mX4&zR8s",
+			URL:               "https://kure.com",
+			Expires:           "Never",
+			PasswordUpdatedAt: now.Add(-24 * time.Hour).Unix(),
+		},
+		{
+			Name:     "weak",
+			Password: "abc",
+			Expires:  "Never",
+		},
+		{
+			Name:     "reused/one",
+			Password: strong,
+			Expires:  "Never",
+		},
+		{
+			Name:     "reused/two",
+			Password: strong,
+			Expires:  "Never",
+		},
+		{
+			Name:              "old",
+			Password:          "Jq8$Wm2!xT7@Lp4&Zr9v",
+			Expires:           "Never",
+			PasswordUpdatedAt: now.Add(-400 * day).Unix(),
+		},
+		{
+			Name:    "expired",
+			Expires: "Mon, 01 Jan 2024 15:04:05 -0700",
+		},
+		{
+			Name:    "expiring",
+			Expires: "Mon, 10 Jun 2024 15:04:05 -0700",
+		},
+		{
+			Name:    "insecure",
+			URL:     "HTTP://example.com",
+			Expires: "Never",
+		},
+	}
+	opts := &passwordsOptions{
+		minEntropy:   60,
+		maxAge:       365 * day,
+		expireWithin: 30 * day,
+	}
+
+	expected := []issue{
+		{Name: "expired", Issue: expired, Details: "expired on 01/01/2024"},
+		{Name: "expiring", Issue: expiring, Details: "expires on 10/06/2024"},
+		{Name: "insecure", Issue: insecureURL, Details: "HTTP://example.com"},
+		{Name: "old", Issue: old, Details: "unchanged for 400 days"},
+		{Name: "reused/one", Issue: reused, Details: "also used by reused/two"},
+		{Name: "reused/two", Issue: reused, Details: "also used by reused/one"},
+		{Name: "weak", Issue: weak, Details: "14.10 bits of entropy"},
+	}
+
+	got := audit(entries, opts, now)
+	assert.Equal(t, expected, got)
+
+	t.Run("Disable maximum age", func(t *testing.T) {
+		opts := &passwordsOptions{minEntropy: 0, maxAge: 0}
+		got := audit(entries[4:5], opts, now)
+		assert.Empty(t, got)
+	})
+}
+
+func TestPostRun(t *testing.T) {
+	NewCmd(nil).PostRun(nil, nil)
+}
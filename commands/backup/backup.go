@@ -1,19 +1,15 @@
 package backup
 
 import (
-	"context"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
 	"path/filepath"
-	"strconv"
-	"sync"
 	"time"
 
 	cmdutil "github.com/GGP1/kure/commands"
-	"github.com/GGP1/kure/config"
-	"github.com/GGP1/kure/sig"
+	bls "github.com/GGP1/kure/commands/backup/ls"
+	brestore "github.com/GGP1/kure/commands/backup/restore"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
@@ -27,37 +23,69 @@ kure backup --path path/to/file
 * Serve the database on a local server, port 7777
 kure backup --http --port 7777
 
-* Download database
-curl localhost:7777 > database_name`
+* Download the database using the URL printed
+curl -o kure.db http://localhost:7777/<token>
+
+* Serve the database over TLS on all interfaces for 2 minutes
+kure backup --http --tls --host 0.0.0.0 --timeout 2m
+
+* Upload an encrypted backup to the remotes "s3" and "nas"
+kure backup --remote s3,nas
+
+* List and restore local snapshots
+kure backup ls
+kure backup restore 20261019T150405Z`
 
 type backupOptions struct {
-	path  string
-	port  uint16
-	httpB bool
+	host    string
+	path    string
+	remotes []string
+	timeout time.Duration
+	port    uint16
+	httpB   bool
+	tls     bool
 }
 
 // NewCmd returns a new command.
-func NewCmd(db *bolt.DB) *cobra.Command {
+func NewCmd(db *bolt.DB, r io.Reader) *cobra.Command {
 	opts := backupOptions{}
 	cmd := &cobra.Command{
-		Use:     "backup",
-		Short:   "Create database backup",
+		Use:   "backup",
+		Short: "Create database backup",
+		Long: `Create database backup.
+
+The http server listens on localhost by default and serves the database only once, on a URL containing a random token. It shuts down after the first successful download or when the timeout is reached. Use --tls to serve it over HTTPS with a self-signed certificate, whose fingerprint is printed.
+
+Remote backups are uploaded to the destinations defined under the "backup.remotes" configuration key: S3-compatible object storage, WebDAV or SFTP servers. The database is compacted and encrypted for the remote's age recipient before being uploaded, and every upload is verified.
+
+Local snapshots are taken automatically after commands that modify the database or on a schedule, depending on the "backup.snapshots" configuration. Use the ls and restore subcommands to manage them.`,
 		Example: example,
 		RunE:    opts.runBackup(db),
 		PostRun: func(cmd *cobra.Command, args []string) {
 			// Reset variables (session)
 			opts = backupOptions{
-				port: 8080,
+				host:    "localhost",
+				port:    8080,
+				timeout: 5 * time.Minute,
 			}
 		},
 	}
 
 	f := cmd.Flags()
 	f.BoolVar(&opts.httpB, "http", false, "serve database file on a local server")
+	f.StringVar(&opts.host, "host", "localhost", "address the server listens on")
 	f.StringVar(&opts.path, "path", "", "destination file path")
 	f.Uint16Var(&opts.port, "port", 8080, "server port")
+	f.DurationVar(&opts.timeout, "timeout", 5*time.Minute, "time to wait for the download before shutting down the server")
+	f.BoolVar(&opts.tls, "tls", false, "serve over HTTPS using a self-signed certificate")
+	f.StringSliceVar(&opts.remotes, "remote", nil, "upload an encrypted backup to the remotes specified")
 
-	cmd.MarkFlagsMutuallyExclusive("http", "path")
+	cmd.MarkFlagsMutuallyExclusive("http", "path", "remote")
+
+	cmd.AddCommand(
+		bls.NewCmd(),
+		brestore.NewCmd(db, r),
+	)
 
 	return cmd
 }
@@ -65,53 +93,15 @@ func NewCmd(db *bolt.DB) *cobra.Command {
 func (opts *backupOptions) runBackup(db *bolt.DB) cmdutil.RunEFunc {
 	return func(cmd *cobra.Command, args []string) error {
 		if opts.httpB {
-			return serveFile(db, opts.port)
+			return serveFile(db, cmd.OutOrStdout(), opts)
 		}
 
-		return fileBackup(db, opts.path)
-	}
-}
-
-// serveFile serves the file on localhost.
-func serveFile(db *bolt.DB, port uint16) error {
-	if port == 0 {
-		return errors.New("invalid port")
-	}
-
-	server := &http.Server{
-		Addr: fmt.Sprintf(":%d", port),
-	}
-	sig.Signal.AddCleanup(func() error {
-		// Do not exit after a signal as we are handling the shutdown
-		sig.Signal.KeepAlive()
-		fmt.Println("Shutting down server...")
-
-		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
-		defer cancel()
-
-		if err := server.Shutdown(ctx); err != nil {
-			return errors.Wrap(err, "graceful shutdown")
+		if len(opts.remotes) > 0 {
+			return remoteBackup(db, cmd.OutOrStdout(), opts.remotes)
 		}
 
-		if err := server.Close(); err != nil {
-			return errors.Wrap(err, "closing server")
-		}
-		return nil
-	})
-
-	// Register route only once, otherwise it will panic if
-	// called multiple times inside a session
-	var once sync.Once
-	once.Do(func() {
-		http.HandleFunc("/", httpBackup(db))
-	})
-	fmt.Printf("Serving database on http://localhost:%d (Press Ctrl+C to quit)\n", port)
-
-	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		return errors.Wrap(err, "starting server")
+		return fileBackup(db, opts.path)
 	}
-
-	return nil
 }
 
 // fileBackup writes the database to a new file.
@@ -148,28 +138,6 @@ func fileBackup(db *bolt.DB, path string) error {
 	return nil
 }
 
-// httpBackup writes a consistent view of the database to a http endpoint.
-func httpBackup(db *bolt.DB) http.HandlerFunc {
-	name := filepath.Base(config.GetString("database.path"))
-	disposition := fmt.Sprintf(`attachment; filename=%q`, name)
-
-	return func(w http.ResponseWriter, r *http.Request) {
-		err := db.View(func(tx *bolt.Tx) error {
-			w.Header().Set("Content-Type", "application/octet-stream")
-			w.Header().Set("Content-Disposition", disposition)
-			w.Header().Set("Content-Length", strconv.Itoa(int(tx.Size())))
-			if _, err := tx.WriteTo(w); err != nil {
-				return errors.Wrap(err, "writing the database")
-			}
-
-			return nil
-		})
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-		}
-	}
-}
-
 // writeTo writes the entire database to a writer.
 func writeTo(db *bolt.DB, w io.Writer) error {
 	return db.View(func(tx *bolt.Tx) error {
@@ -2,17 +2,30 @@ package backup
 
 import (
 	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/tls"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/config"
 	"github.com/GGP1/kure/db/entry"
 	"github.com/GGP1/kure/pb"
 	bolt "go.etcd.io/bbolt"
 
+	"filippo.io/age"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
 )
 
 func TestBackupFile(t *testing.T) {
@@ -28,7 +41,7 @@ func TestBackupFile(t *testing.T) {
 	err := entry.Create(db, &pb.Entry{Name: name})
 	assert.NoError(t, err)
 
-	cmd := NewCmd(db)
+	cmd := NewCmd(db, nil)
 	f := cmd.Flags()
 	f.Set("path", filename)
 
@@ -50,35 +63,127 @@ func TestBackupFile(t *testing.T) {
 func TestBackupServer(t *testing.T) {
 	db := cmdutil.SetContext(t)
 
+	cases := []struct {
+		desc string
+		tls  bool
+	}{
+		{desc: "HTTP"},
+		{desc: "HTTPS", tls: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			s, err := newBackupServer(db, &backupOptions{host: "127.0.0.1", timeout: time.Minute, tls: tc.tls})
+			assert.NoError(t, err)
+
+			var out bytes.Buffer
+			errCh := make(chan error, 1)
+			go func() { errCh <- s.serve(&out) }()
+
+			client := &http.Client{
+				Transport: &http.Transport{
+					TLSClientConfig: &tls.Config{
+						InsecureSkipVerify: true,
+						VerifyConnection: func(cs tls.ConnectionState) error {
+							assert.Equal(t, s.fingerprint, fingerprint(cs.PeerCertificates[0].Raw))
+							return nil
+						},
+					},
+				},
+			}
+
+			// Requests without the token are rejected
+			res, err := client.Get(s.url[:strings.LastIndex(s.url, "/")] + "/invalid")
+			assert.NoError(t, err)
+			res.Body.Close()
+			assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+			res, err = client.Get(s.url)
+			assert.NoError(t, err)
+			body, err := io.ReadAll(res.Body)
+			assert.NoError(t, err)
+			res.Body.Close()
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			assert.Equal(t, "application/octet-stream", res.Header.Get("Content-Type"))
+			assert.NotEmpty(t, body)
+
+			// The server shuts down after the first download
+			assert.NoError(t, <-errCh)
+			assert.Contains(t, out.String(), "Database downloaded")
+		})
+	}
+}
+
+func TestBackupServerTimeout(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	s, err := newBackupServer(db, &backupOptions{host: "127.0.0.1", timeout: 10 * time.Millisecond})
+	assert.NoError(t, err)
+
+	err = s.serve(io.Discard)
+	assert.Error(t, err)
+}
+
+func TestHTTPBackupOnce(t *testing.T) {
+	db := cmdutil.SetContext(t)
+	done := make(chan struct{})
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /{token}", httpBackup(db, "token", done))
+
 	rec := httptest.NewRecorder()
-	req, err := http.NewRequest("GET", "localhost:4000/", nil)
-	assert.NoError(t, err, "Failed sending the request")
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodHead, "/token", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Zero(t, rec.Body.Len())
 
-	hf := httpBackup(db)
-	hf.ServeHTTP(rec, req)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/token", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, rec.Header().Get("Content-Length"), strconv.Itoa(rec.Body.Len()))
+	<-done
 
-	res := rec.Result()
-	assert.Equal(t, http.StatusOK, res.StatusCode)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/token", nil))
+	assert.Equal(t, http.StatusGone, rec.Code)
 
-	gotCt := res.Header.Get("Content-Type")
-	expectedCt := "application/octet-stream"
-	assert.Equal(t, expectedCt, gotCt)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/token", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestSelfSignedCert(t *testing.T) {
+	cert, err := selfSignedCert("192.168.1.10")
+	assert.NoError(t, err)
+	assert.NoError(t, cert.Leaf.VerifyHostname("192.168.1.10"))
+	assert.NoError(t, cert.Leaf.VerifyHostname("localhost"))
+	assert.Error(t, cert.Leaf.VerifyHostname("example.com"))
+
+	cert, err = selfSignedCert("kure.lan")
+	assert.NoError(t, err)
+	assert.NoError(t, cert.Leaf.VerifyHostname("kure.lan"))
 }
 
 func TestBackupErrors(t *testing.T) {
 	db := cmdutil.SetContext(t)
 
 	cases := []struct {
-		desc string
-		port string
-		http string
-		path string
+		desc    string
+		port    string
+		http    string
+		path    string
+		timeout string
 	}{
 		{
-			desc: "HTTP",
+			desc: "Invalid port",
 			http: "true",
 			port: "0",
 		},
+		{
+			desc:    "Invalid timeout",
+			http:    "true",
+			port:    "8080",
+			timeout: "0s",
+		},
 		{
 			desc: "Invalid path",
 			path: "",
@@ -94,7 +199,7 @@ func TestBackupErrors(t *testing.T) {
 		},
 	}
 
-	cmd := NewCmd(db)
+	cmd := NewCmd(db, nil)
 
 	for _, tc := range cases {
 		t.Run(tc.desc, func(t *testing.T) {
@@ -102,6 +207,9 @@ func TestBackupErrors(t *testing.T) {
 			f.Set("path", tc.path)
 			f.Set("http", tc.http)
 			f.Set("port", tc.port)
+			if tc.timeout != "" {
+				f.Set("timeout", tc.timeout)
+			}
 
 			err := cmd.Execute()
 			assert.Error(t, err)
@@ -120,5 +228,127 @@ func TestWriteTo(t *testing.T) {
 }
 
 func TestPostRun(t *testing.T) {
-	NewCmd(nil).PostRun(nil, nil)
+	NewCmd(nil, nil).PostRun(nil, nil)
+}
+
+func TestBackupRemote(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	err := entry.Create(db,
+		&pb.Entry{Name: "test", Password: "secret", Expires: "Never"},
+		&pb.Entry{Name: "backups/nas", Username: "user", Password: "pass", Expires: "Never"},
+	)
+	assert.NoError(t, err)
+
+	identity, err := age.GenerateX25519Identity()
+	assert.NoError(t, err)
+
+	uploads := make(map[string][]byte)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); user != "user" || pass != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.Method {
+		case http.MethodPut:
+			uploads[r.URL.Path], _ = io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodGet:
+			w.Write(uploads[r.URL.Path])
+		}
+	}))
+	defer ts.Close()
+
+	config.Set("database.path", "/home/user/vault.db")
+	config.Set("backup.remotes.nas", map[string]interface{}{
+		"type":        "webdav",
+		"url":         ts.URL + "/backups",
+		"credentials": "backups/nas",
+		"recipient":   identity.Recipient().String(),
+	})
+
+	var out bytes.Buffer
+	cmd := NewCmd(db, nil)
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"--remote", "nas"})
+
+	err = cmd.Execute()
+	assert.NoError(t, err)
+	assert.Len(t, uploads, 1)
+
+	for path, content := range uploads {
+		assert.Regexp(t, `^/backups/vault-\d{8}T\d{6}Z\.db\.age$`, path)
+		assert.Contains(t, out.String(), "Backup uploaded to nas as "+strings.TrimPrefix(path, "/backups/"))
+
+		r, err := age.Decrypt(bytes.NewReader(content), identity)
+		assert.NoError(t, err)
+		snapshot, err := io.ReadAll(r)
+		assert.NoError(t, err)
+
+		filename := filepath.Join(t.TempDir(), "snapshot.db")
+		err = os.WriteFile(filename, snapshot, 0o600)
+		assert.NoError(t, err)
+
+		snapshotDB, err := bolt.Open(filename, 0o600, nil)
+		assert.NoError(t, err)
+		e, err := entry.Get(snapshotDB, "test")
+		assert.NoError(t, err)
+		assert.Equal(t, "secret", e.Password)
+		assert.NoError(t, snapshotDB.Close())
+	}
+}
+
+func TestBackupRemoteErrors(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	identity, err := age.GenerateX25519Identity()
+	assert.NoError(t, err)
+	recipient := identity.Recipient().String()
+
+	config.Set("backup.remotes", map[string]interface{}{
+		"no_recipient":   map[string]interface{}{"type": "webdav", "url": "http://localhost"},
+		"invalid_type":   map[string]interface{}{"type": "ftp", "recipient": recipient},
+		"no_credentials": map[string]interface{}{"type": "s3", "url": "http://localhost/bucket", "recipient": recipient},
+		"missing_entry":  map[string]interface{}{"type": "webdav", "credentials": "non-existent", "recipient": recipient},
+		"no_auth":        map[string]interface{}{"type": "sftp", "url": "sftp://user@localhost", "host_key": "SHA256:x", "recipient": recipient},
+		"timeout":        map[string]interface{}{"type": "webdav", "url": "http://localhost", "timeout": "0s", "recipient": recipient},
+	})
+
+	cases := []string{"non_existent", "invalid.name", "no_recipient", "invalid_type", "no_credentials", "missing_entry", "no_auth", "timeout"}
+
+	for _, name := range cases {
+		t.Run(name, func(t *testing.T) {
+			cmd := NewCmd(db, nil)
+			cmd.SetArgs([]string{"--remote", name})
+
+			err := cmd.Execute()
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestHostKeyCallback(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(priv)
+	assert.NoError(t, err)
+	key := signer.PublicKey()
+
+	callback, err := hostKeyCallback(ssh.FingerprintSHA256(key), "")
+	assert.NoError(t, err)
+	assert.NoError(t, callback("localhost:22", nil, key))
+
+	callback, err = hostKeyCallback("SHA256:invalid", "")
+	assert.NoError(t, err)
+	assert.Error(t, callback("localhost:22", nil, key))
+
+	knownHosts := filepath.Join(t.TempDir(), "known_hosts")
+	err = os.WriteFile(knownHosts, []byte(knownhosts.Line([]string{"localhost:2222"}, key)+"\n"), 0o600)
+	assert.NoError(t, err)
+
+	callback, err = hostKeyCallback("", knownHosts)
+	assert.NoError(t, err)
+	addr := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 2222}
+	assert.NoError(t, callback("localhost:2222", addr, key))
+	assert.Error(t, callback("example.com:22", addr, key))
 }
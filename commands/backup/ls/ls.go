@@ -0,0 +1,64 @@
+package ls
+
+import (
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/commands/backup/snapshot"
+
+	"github.com/spf13/cobra"
+)
+
+const example = `
+kure backup ls`
+
+// NewCmd returns a new command.
+func NewCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "ls",
+		Short:   "List database snapshots",
+		Example: example,
+		Args:    cobra.NoArgs,
+		RunE:    runLs(),
+	}
+}
+
+func runLs() cmdutil.RunEFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		dir := snapshot.GetConfig().Dir
+		snapshots, err := snapshot.List(dir)
+		if err != nil {
+			return err
+		}
+
+		out := cmd.OutOrStdout()
+		if len(snapshots) == 0 {
+			fmt.Fprintln(out, "No snapshots were found in", dir)
+			return nil
+		}
+
+		w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(w, "Name\tDate\tSize")
+		for _, s := range snapshots {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", s, s.Time.Local().Format(time.DateTime), formatSize(s.Size))
+		}
+		return w.Flush()
+	}
+}
+
+// formatSize returns the size in a human-readable format.
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
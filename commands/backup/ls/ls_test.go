@@ -0,0 +1,54 @@
+package ls
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/commands/backup/snapshot"
+	"github.com/GGP1/kure/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLs(t *testing.T) {
+	db := cmdutil.SetContext(t)
+	dir := t.TempDir()
+	config.Set("backup.snapshots.dir", dir)
+
+	var out bytes.Buffer
+	cmd := NewCmd()
+	cmd.SetOut(&out)
+
+	err := cmd.Execute()
+	assert.NoError(t, err)
+	assert.Equal(t, "No snapshots were found in "+dir+"\n", out.String())
+
+	_, err = snapshot.Take(db, dir, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	_, err = snapshot.Take(db, dir, time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+
+	out.Reset()
+	err = cmd.Execute()
+	assert.NoError(t, err)
+
+	lines := bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+	assert.Len(t, lines, 3)
+	assert.True(t, bytes.HasPrefix(lines[1], []byte("20260102T000000Z")))
+	assert.True(t, bytes.HasPrefix(lines[2], []byte("20260101T000000Z")))
+}
+
+func TestFormatSize(t *testing.T) {
+	cases := map[int64]string{
+		512:         "512 B",
+		1024:        "1.0 KiB",
+		1536:        "1.5 KiB",
+		1048576 * 3: "3.0 MiB",
+	}
+
+	for size, expected := range cases {
+		assert.Equal(t, expected, formatSize(size))
+	}
+}
@@ -0,0 +1,209 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/commands/backup/snapshot"
+	"github.com/GGP1/kure/config"
+	"github.com/GGP1/kure/db/entry"
+	"github.com/GGP1/kure/db/sshkey"
+	"github.com/GGP1/kure/remote"
+	"github.com/GGP1/kure/sshagent"
+
+	"filippo.io/age"
+	"github.com/pkg/errors"
+	"github.com/spf13/cast"
+	bolt "go.etcd.io/bbolt"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// defaultTimeout is the maximum time an upload can take if the remote doesn't specify one.
+const defaultTimeout = 5 * time.Minute
+
+// remoteConfig is a backup destination defined under the "backup.remotes" configuration key.
+type remoteConfig struct {
+	target    remote.Target
+	recipient age.Recipient
+	timeout   time.Duration
+}
+
+// remoteBackup uploads an encrypted and compacted snapshot of the database to the remotes specified.
+func remoteBackup(db *bolt.DB, w io.Writer, names []string) error {
+	remotes := make([]*remoteConfig, len(names))
+	for i, name := range names {
+		r, err := getRemote(db, name)
+		if err != nil {
+			return errors.Wrapf(err, "remote %q", name)
+		}
+		remotes[i] = r
+	}
+
+	snapshot, err := snapshot.Compact(db)
+	if err != nil {
+		return err
+	}
+
+	filename := snapshotName(time.Now())
+	for i, r := range remotes {
+		content, err := encrypt(snapshot, r.recipient)
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+		err = r.target.Upload(ctx, filename, content)
+		cancel()
+		if err != nil {
+			return errors.Wrapf(err, "remote %q", names[i])
+		}
+
+		fmt.Fprintf(w, "Backup uploaded to %s as %s\n", names[i], filename)
+	}
+
+	return nil
+}
+
+// getRemote returns the remote configuration with the name specified.
+func getRemote(db *bolt.DB, name string) (*remoteConfig, error) {
+	if name == "" || strings.Contains(name, ".") {
+		return nil, errors.New("invalid remote name")
+	}
+
+	mp, ok := config.Get("backup.remotes." + name).(map[string]interface{})
+	if !ok {
+		return nil, errors.New("remote does not exist")
+	}
+
+	recipient, err := age.ParseX25519Recipient(cast.ToString(mp["recipient"]))
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing age recipient")
+	}
+
+	timeout := defaultTimeout
+	if t, ok := mp["timeout"]; ok {
+		timeout = cast.ToDuration(t)
+		if timeout <= 0 {
+			return nil, errors.New("invalid timeout")
+		}
+	}
+
+	var username, password string
+	if name := cast.ToString(mp["credentials"]); name != "" {
+		e, err := entry.Get(db, cmdutil.NormalizeName(name))
+		if err != nil {
+			return nil, errors.Wrap(err, "credentials")
+		}
+		username, password = e.Username, e.Password
+	}
+
+	url := cast.ToString(mp["url"])
+	targetType := cast.ToString(mp["type"])
+	var target remote.Target
+	switch targetType {
+	case "s3":
+		target, err = remote.NewS3(http.DefaultClient, url, cast.ToString(mp["region"]), username, password)
+	case "webdav":
+		target, err = remote.NewWebDAV(http.DefaultClient, url, username, password)
+	case "sftp":
+		target, err = newSFTP(db, mp, url, username, password)
+	default:
+		return nil, errors.Errorf("invalid type %q, use s3, webdav or sftp", targetType)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &remoteConfig{target: target, recipient: recipient, timeout: timeout}, nil
+}
+
+// newSFTP returns an SFTP target that authenticates with an SSH key stored in kure and/or a password.
+func newSFTP(db *bolt.DB, mp map[string]interface{}, url, username, password string) (remote.Target, error) {
+	var auth []ssh.AuthMethod
+	if name := cast.ToString(mp["ssh_key"]); name != "" {
+		key, err := sshkey.Get(db, cmdutil.NormalizeName(name))
+		if err != nil {
+			return nil, errors.Wrap(err, "ssh key")
+		}
+		signer, err := sshagent.Signer(key)
+		if err != nil {
+			return nil, err
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if password != "" {
+		auth = append(auth, ssh.Password(password))
+	}
+
+	hostKeyCallback, err := hostKeyCallback(cast.ToString(mp["host_key"]), cast.ToString(mp["known_hosts"]))
+	if err != nil {
+		return nil, err
+	}
+
+	return remote.NewSFTP(url, username, auth, hostKeyCallback)
+}
+
+// hostKeyCallback verifies the server's host key against the fingerprint specified or, if it's empty,
+// against the known hosts file (~/.ssh/known_hosts by default).
+func hostKeyCallback(fingerprint, knownHostsPath string) (ssh.HostKeyCallback, error) {
+	if fingerprint != "" {
+		return func(_ string, _ net.Addr, key ssh.PublicKey) error {
+			if got := ssh.FingerprintSHA256(key); got != fingerprint {
+				return errors.Errorf("host key mismatch: expected %s, got %s", fingerprint, got)
+			}
+			return nil
+		}, nil
+	}
+
+	if knownHostsPath == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, errors.Wrap(err, "finding home directory")
+		}
+		knownHostsPath = filepath.Join(home, ".ssh", "known_hosts")
+	}
+
+	callback, err := knownhosts.New(knownHostsPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading known hosts")
+	}
+	return callback, nil
+}
+
+// encrypt encrypts the snapshot for the age recipient.
+func encrypt(snapshot []byte, recipient age.Recipient) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, recipient)
+	if err != nil {
+		return nil, errors.Wrap(err, "encrypting snapshot")
+	}
+
+	if _, err := w.Write(snapshot); err != nil {
+		return nil, errors.Wrap(err, "encrypting snapshot")
+	}
+	if err := w.Close(); err != nil {
+		return nil, errors.Wrap(err, "encrypting snapshot")
+	}
+
+	return buf.Bytes(), nil
+}
+
+// snapshotName returns the name of the file uploaded, based on the database name and the time.
+func snapshotName(t time.Time) string {
+	name := filepath.Base(config.GetString("database.path"))
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	if name == "" || name == "." {
+		name = "kure"
+	}
+	return fmt.Sprintf("%s-%s.db.age", name, t.UTC().Format("20060102T150405Z"))
+}
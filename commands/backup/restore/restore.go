@@ -0,0 +1,67 @@
+package restore
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/commands/backup/snapshot"
+	"github.com/GGP1/kure/terminal"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+const example = `
+* Restore a snapshot
+kure backup restore 20261019T150405Z`
+
+// NewCmd returns a new command.
+func NewCmd(db *bolt.DB, r io.Reader) *cobra.Command {
+	return &cobra.Command{
+		Use:   "restore <snapshot>",
+		Short: "Restore a database snapshot",
+		Long: `Restore a database snapshot.
+
+The snapshot is decrypted and verified before replacing the content of the database, which is done in a single transaction. A snapshot of the current state is taken beforehand so the restoration can be undone.
+
+Snapshots can only be restored using the same credentials that were used to create them.`,
+		Example: example,
+		Args:    cobra.ExactArgs(1),
+		RunE:    runRestore(db, r),
+	}
+}
+
+func runRestore(db *bolt.DB, r io.Reader) cmdutil.RunEFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		c := snapshot.GetConfig()
+		s, err := snapshot.Get(c.Dir, args[0])
+		if err != nil {
+			return err
+		}
+
+		// Verify the snapshot before modifying anything
+		data, err := snapshot.Load(s)
+		if err != nil {
+			return err
+		}
+
+		if !terminal.Confirm(r, fmt.Sprintf("The database content will be replaced by the snapshot %s. Proceed?", s)) {
+			return nil
+		}
+
+		current, err := snapshot.Take(db, c.Dir, time.Now())
+		if err != nil {
+			return errors.Wrap(err, "taking a snapshot of the current state")
+		}
+
+		if err := snapshot.Restore(db, data); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Snapshot %s restored, the previous state was saved as %s\n", s, current)
+		return nil
+	}
+}
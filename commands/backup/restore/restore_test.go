@@ -0,0 +1,108 @@
+package restore
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/commands/backup/snapshot"
+	"github.com/GGP1/kure/config"
+	"github.com/GGP1/kure/db/bucket"
+	"github.com/GGP1/kure/db/entry"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestRestore(t *testing.T) {
+	db := cmdutil.SetContext(t)
+	dir := t.TempDir()
+	config.Set("backup.snapshots.dir", dir)
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucket.Auth.GetName())
+		return err
+	})
+	assert.NoError(t, err)
+
+	err = entry.Create(db, &pb.Entry{Name: "test", Expires: "Never"})
+	assert.NoError(t, err)
+
+	s, err := snapshot.Take(db, dir, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+
+	err = entry.Remove(db, "test")
+	assert.NoError(t, err)
+
+	var out bytes.Buffer
+	cmd := NewCmd(db, strings.NewReader("y\n"))
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{s.String()})
+
+	err = cmd.Execute()
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), "Snapshot 20260101T000000Z restored")
+
+	_, err = entry.Get(db, "test")
+	assert.NoError(t, err)
+
+	// The state previous to the restoration was saved
+	snapshots, err := snapshot.List(dir)
+	assert.NoError(t, err)
+	assert.Len(t, snapshots, 2)
+}
+
+func TestRestoreAutoSnapshot(t *testing.T) {
+	db := cmdutil.SetContext(t)
+	dir := t.TempDir()
+	config.Set("backup.snapshots.dir", dir)
+	config.Set("backup.snapshots.auto", true)
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucket.Auth.GetName())
+		return err
+	})
+	assert.NoError(t, err)
+
+	s, err := snapshot.Take(db, dir, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	err = entry.Create(db, &pb.Entry{Name: "after", Expires: "Never"})
+	assert.NoError(t, err)
+
+	cmd := NewCmd(db, strings.NewReader("y\n"))
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetArgs([]string{s.String()})
+	assert.NoError(t, cmd.Execute())
+
+	// Automatic snapshot taken by the root command right after the restoration
+	auto, err := snapshot.Auto(db, true)
+	assert.NoError(t, err)
+	assert.NotNil(t, auto)
+
+	snapshots, err := snapshot.List(dir)
+	assert.NoError(t, err)
+	assert.Len(t, snapshots, 3)
+
+	// The state previous to the restoration can still be restored
+	previous := snapshots[1]
+	assert.NotEqual(t, auto.Name, previous.Name)
+	data, err := snapshot.Load(previous)
+	assert.NoError(t, err)
+	assert.NoError(t, snapshot.Restore(db, data))
+	_, err = entry.Get(db, "after")
+	assert.NoError(t, err)
+}
+
+func TestRestoreErrors(t *testing.T) {
+	db := cmdutil.SetContext(t)
+	config.Set("backup.snapshots.dir", t.TempDir())
+
+	cmd := NewCmd(db, strings.NewReader("y\n"))
+	cmd.SetArgs([]string{"20260101T000000Z"})
+
+	err := cmd.Execute()
+	assert.Error(t, err)
+}
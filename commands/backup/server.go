@@ -0,0 +1,288 @@
+package backup
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GGP1/kure/config"
+	"github.com/GGP1/kure/sig"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// backupServer serves the database once on a URL containing a random token.
+type backupServer struct {
+	server   *http.Server
+	listener net.Listener
+	// done is closed after the first successful download
+	done    chan struct{}
+	url     string
+	timeout time.Duration
+	// Set only when TLS is enabled
+	fingerprint  string
+	publicKeyPin string
+}
+
+// serveFile serves the database until it's downloaded, the timeout is reached or a signal is received.
+func serveFile(db *bolt.DB, w io.Writer, opts *backupOptions) error {
+	if opts.port == 0 {
+		return errors.New("invalid port")
+	}
+	if opts.timeout <= 0 {
+		return errors.New("invalid timeout")
+	}
+
+	s, err := newBackupServer(db, opts)
+	if err != nil {
+		return err
+	}
+
+	sig.Signal.AddCleanup(func() error {
+		// Do not exit after a signal as we are handling the shutdown
+		sig.Signal.KeepAlive()
+		return s.shutdown()
+	})
+
+	fmt.Fprintf(w, "Serving database on %s (Press Ctrl+C to quit)\n", s.url)
+	if s.fingerprint != "" {
+		fmt.Fprintln(w, "Certificate fingerprint (SHA-256):", s.fingerprint)
+		fmt.Fprintf(w, "Download it with: curl --insecure --pinnedpubkey sha256//%s -o %s %s\n",
+			s.publicKeyPin, databaseName(), s.url)
+	}
+	fmt.Fprintf(w, "The server will shut down after the first download or in %v\n", opts.timeout)
+
+	return s.serve(w)
+}
+
+// newBackupServer creates a server listening on the host and port specified.
+func newBackupServer(db *bolt.DB, opts *backupOptions) (*backupServer, error) {
+	token, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	addr := net.JoinHostPort(opts.host, strconv.Itoa(int(opts.port)))
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, errors.Wrap(err, "starting server")
+	}
+
+	s := &backupServer{
+		listener: listener,
+		done:     make(chan struct{}),
+		timeout:  opts.timeout,
+	}
+
+	scheme := "http"
+	if opts.tls {
+		cert, err := selfSignedCert(opts.host)
+		if err != nil {
+			listener.Close()
+			return nil, err
+		}
+
+		s.fingerprint = fingerprint(cert.Leaf.Raw)
+		pin := sha256.Sum256(cert.Leaf.RawSubjectPublicKeyInfo)
+		s.publicKeyPin = base64.StdEncoding.EncodeToString(pin[:])
+		s.listener = tls.NewListener(listener, &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+		})
+		scheme = "https"
+	}
+
+	// Use the port assigned by the system in case it was zero
+	port := listener.Addr().(*net.TCPAddr).Port
+	s.url = fmt.Sprintf("%s://%s/%s", scheme, net.JoinHostPort(opts.host, strconv.Itoa(port)), token)
+
+	// Use a new multiplexer, registering the route on the default one
+	// would panic if the command is executed multiple times inside a session
+	mux := http.NewServeMux()
+	mux.Handle("GET /{token}", httpBackup(db, token, s.done))
+	s.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	return s, nil
+}
+
+// serve blocks until the database is downloaded, the timeout is reached or the server is shut down.
+func (s *backupServer) serve(w io.Writer) error {
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.server.Serve(s.listener)
+	}()
+
+	timer := time.NewTimer(s.timeout)
+	defer timer.Stop()
+
+	select {
+	case <-s.done:
+		fmt.Fprintln(w, "Database downloaded, shutting down server...")
+		return s.shutdown()
+	case <-timer.C:
+		if err := s.shutdown(); err != nil {
+			return err
+		}
+		return errors.New("timeout reached before the database was downloaded")
+	case err := <-errCh:
+		if err != nil && err != http.ErrServerClosed {
+			return errors.Wrap(err, "serving database")
+		}
+		return nil
+	}
+}
+
+func (s *backupServer) shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := s.server.Shutdown(ctx); err != nil {
+		s.server.Close()
+		return errors.Wrap(err, "graceful shutdown")
+	}
+	return nil
+}
+
+// httpBackup writes a consistent view of the database to a http endpoint. It responds only to
+// requests containing the token and closes done after the first successful download, further
+// requests are rejected.
+func httpBackup(db *bolt.DB, token string, done chan<- struct{}) http.HandlerFunc {
+	disposition := fmt.Sprintf(`attachment; filename=%q`, databaseName())
+	var (
+		mu         sync.Mutex
+		downloaded bool
+	)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.PathValue("token")), []byte(token)) != 1 {
+			http.NotFound(w, r)
+			return
+		}
+
+		// Serve one request at a time so the database is downloaded only once
+		mu.Lock()
+		defer mu.Unlock()
+
+		if downloaded {
+			http.Error(w, "the database was already downloaded", http.StatusGone)
+			return
+		}
+
+		err := db.View(func(tx *bolt.Tx) error {
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Header().Set("Content-Disposition", disposition)
+			w.Header().Set("Content-Length", strconv.Itoa(int(tx.Size())))
+			w.Header().Set("Cache-Control", "no-store")
+			if r.Method == http.MethodHead {
+				return nil
+			}
+
+			if _, err := tx.WriteTo(w); err != nil {
+				return errors.Wrap(err, "writing the database")
+			}
+			return nil
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if r.Method != http.MethodHead {
+			downloaded = true
+			close(done)
+		}
+	}
+}
+
+// selfSignedCert generates a short-lived self-signed certificate valid for the host and the loopback addresses.
+func selfSignedCert(host string) (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, errors.Wrap(err, "generating private key")
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, errors.Wrap(err, "generating serial number")
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "kure"},
+		NotBefore:             now.Add(-time.Minute),
+		NotAfter:              now.Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		if !ip.IsUnspecified() {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		}
+	} else if host != "" && host != "localhost" {
+		template.DNSNames = append(template.DNSNames, host)
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, errors.Wrap(err, "creating certificate")
+	}
+
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return tls.Certificate{}, errors.Wrap(err, "parsing certificate")
+	}
+
+	return tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  key,
+		Leaf:        leaf,
+	}, nil
+}
+
+// fingerprint returns the SHA-256 fingerprint of a certificate in the format used by openssl.
+func fingerprint(der []byte) string {
+	sum := sha256.Sum256(der)
+	hex := make([]string, len(sum))
+	for i, b := range sum {
+		hex[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(hex, ":")
+}
+
+// randomToken returns a URL-safe random string with 256 bits of entropy.
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "generating token")
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func databaseName() string {
+	return filepath.Base(config.GetString("database.path"))
+}
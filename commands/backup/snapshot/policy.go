@@ -0,0 +1,62 @@
+package snapshot
+
+import (
+	"fmt"
+	"time"
+)
+
+// Policy determines which snapshots are retained. The latest snapshot is always kept.
+//
+// If both values are zero, every snapshot is kept.
+type Policy struct {
+	// Number of days for which the newest snapshot of the day is kept
+	Daily int
+	// Number of weeks for which the newest snapshot of the week is kept
+	Weekly int
+}
+
+// Apply splits the snapshots (sorted from newest to oldest) into the ones to keep and the ones to remove.
+func (p Policy) Apply(snapshots []*Snapshot) (keep, remove []*Snapshot) {
+	if p.Daily <= 0 && p.Weekly <= 0 {
+		return snapshots, nil
+	}
+
+	kept := make(map[*Snapshot]struct{}, len(snapshots))
+	if len(snapshots) > 0 {
+		kept[snapshots[0]] = struct{}{}
+	}
+
+	keepPeriods(kept, snapshots, p.Daily, func(t time.Time) string {
+		return t.Format("2006-01-02")
+	})
+	keepPeriods(kept, snapshots, p.Weekly, func(t time.Time) string {
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-%d", year, week)
+	})
+
+	for _, s := range snapshots {
+		if _, ok := kept[s]; ok {
+			keep = append(keep, s)
+		} else {
+			remove = append(remove, s)
+		}
+	}
+
+	return keep, remove
+}
+
+// keepPeriods marks the newest snapshot of the n most recent periods as kept.
+func keepPeriods(kept map[*Snapshot]struct{}, snapshots []*Snapshot, n int, period func(time.Time) string) {
+	last := ""
+	for _, s := range snapshots {
+		if n <= 0 {
+			return
+		}
+
+		if p := period(s.Time.Local()); p != last {
+			kept[s] = struct{}{}
+			last = p
+			n--
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package snapshot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyApply(t *testing.T) {
+	// Snapshots taken every 12 hours for 4 weeks, newest first
+	start := time.Date(2026, 10, 19, 18, 0, 0, 0, time.Local)
+	snapshots := make([]*Snapshot, 56)
+	for i := range snapshots {
+		tm := start.Add(-time.Duration(i) * 12 * time.Hour)
+		snapshots[i] = &Snapshot{Name: tm.Format(time.RFC3339), Time: tm}
+	}
+
+	cases := []struct {
+		desc     string
+		policy   Policy
+		expected []string
+	}{
+		{
+			desc:   "Daily",
+			policy: Policy{Daily: 3},
+			expected: []string{
+				start.Format(time.RFC3339),
+				start.AddDate(0, 0, -1).Format(time.RFC3339),
+				start.AddDate(0, 0, -2).Format(time.RFC3339),
+			},
+		},
+		{
+			desc:   "Weekly",
+			policy: Policy{Weekly: 2},
+			expected: []string{
+				// Monday
+				start.Format(time.RFC3339),
+				// Sunday, last snapshot of the previous week
+				start.AddDate(0, 0, -1).Format(time.RFC3339),
+			},
+		},
+		{
+			desc:   "Daily and weekly",
+			policy: Policy{Daily: 2, Weekly: 3},
+			expected: []string{
+				start.Format(time.RFC3339),
+				start.AddDate(0, 0, -1).Format(time.RFC3339),
+				start.AddDate(0, 0, -8).Format(time.RFC3339),
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			keep, remove := tc.policy.Apply(snapshots)
+			assert.Len(t, remove, len(snapshots)-len(keep))
+
+			got := make([]string, len(keep))
+			for i, s := range keep {
+				got[i] = s.Name
+			}
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
+
+func TestPolicyApplyKeepAll(t *testing.T) {
+	snapshots := []*Snapshot{{Time: time.Now()}, {Time: time.Now().Add(-time.Hour)}}
+
+	keep, remove := Policy{}.Apply(snapshots)
+	assert.Equal(t, snapshots, keep)
+	assert.Empty(t, remove)
+}
+
+func TestPolicyApplyKeepLatest(t *testing.T) {
+	now := time.Now()
+	snapshots := []*Snapshot{{Time: now}, {Time: now.Add(-time.Minute)}}
+
+	keep, remove := Policy{Weekly: 1}.Apply(snapshots)
+	assert.Equal(t, snapshots[:1], keep)
+	assert.Equal(t, snapshots[1:], remove)
+}
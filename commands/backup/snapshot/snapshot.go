@@ -0,0 +1,440 @@
+// Package snapshot manages the encrypted copies of the database stored in a local directory.
+//
+// Snapshots are compacted copies of the database encrypted with the master key, they can only be
+// restored using the same credentials that were used to create them.
+package snapshot
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/config"
+	"github.com/GGP1/kure/crypt"
+	"github.com/GGP1/kure/db/bucket"
+	"github.com/GGP1/kure/sig"
+	"github.com/GGP1/kure/vault"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cast"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	ext        = ".snapshot"
+	timeLayout = "20060102T150405Z"
+)
+
+// Snapshot is an encrypted copy of the database.
+type Snapshot struct {
+	Time time.Time
+	Name string
+	Path string
+	Size int64
+	// Number of the snapshots taken in the same second, the name has a "-<seq>" suffix if it's higher than one
+	seq int
+}
+
+// Config contains the snapshots settings defined under the "backup.snapshots" configuration key.
+type Config struct {
+	Dir string
+	// Take a snapshot after every command that modifies the database
+	Auto bool
+	// Take a snapshot if the latest one is older than Interval
+	Interval time.Duration
+	Policy   Policy
+}
+
+// GetConfig returns the snapshots configuration. The directory defaults to "snapshots" next to the
+// database file, the snapshots of vaults other than the default one are stored in a subdirectory.
+func GetConfig() Config {
+	dir := config.GetString("backup.snapshots.dir")
+	if dir == "" {
+		dir = filepath.Join(filepath.Dir(config.GetString("database.path")), "snapshots")
+	}
+	if name := vault.Active(); name != vault.DefaultName {
+		dir = filepath.Join(dir, name)
+	}
+
+	return Config{
+		Dir:      dir,
+		Auto:     cast.ToBool(config.Get("backup.snapshots.auto")),
+		Interval: config.GetDuration("backup.snapshots.interval"),
+		Policy: Policy{
+			Daily:  cast.ToInt(config.Get("backup.snapshots.keep_daily")),
+			Weekly: cast.ToInt(config.Get("backup.snapshots.keep_weekly")),
+		},
+	}
+}
+
+// Auto takes a snapshot if wrote is true and automatic snapshots are enabled, or if the latest
+// snapshot is older than the interval configured, and prunes the old ones afterwards.
+//
+// It returns a nil snapshot if none was taken.
+func Auto(db *bolt.DB, wrote bool) (*Snapshot, error) {
+	c := GetConfig()
+	if !c.Auto && c.Interval <= 0 {
+		return nil, nil
+	}
+
+	take := wrote && c.Auto
+	if !take && c.Interval > 0 {
+		snapshots, err := List(c.Dir)
+		if err != nil {
+			return nil, err
+		}
+		take = len(snapshots) == 0 || time.Since(snapshots[0].Time) >= c.Interval
+	}
+	if !take {
+		return nil, nil
+	}
+
+	s, err := Take(db, c.Dir, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := Prune(c.Dir, c.Policy); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Take stores an encrypted snapshot of the database in dir.
+func Take(db *bolt.DB, dir string, t time.Time) (*Snapshot, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, errors.Wrap(err, "creating snapshots directory")
+	}
+
+	data, err := Compact(db)
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted, err := crypt.Encrypt(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "encrypting snapshot")
+	}
+
+	t = t.UTC()
+	name, path, seq, err := reserve(dir, t)
+	if err != nil {
+		return nil, err
+	}
+
+	// Write to a temporary file first so a snapshot is never left half written
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, encrypted, 0o600); err != nil {
+		os.Remove(tmp)
+		os.Remove(path)
+		return nil, errors.Wrap(err, "writing snapshot")
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		os.Remove(path)
+		return nil, errors.Wrap(err, "writing snapshot")
+	}
+
+	return &Snapshot{
+		Time: t.Truncate(time.Second),
+		Name: name,
+		Path: path,
+		Size: int64(len(encrypted)),
+		seq:  seq,
+	}, nil
+}
+
+// reserve creates an empty file with a name that isn't used by any other snapshot, so existing
+// snapshots taken in the same second are never overwritten.
+func reserve(dir string, t time.Time) (name, path string, seq int, err error) {
+	for seq = 1; ; seq++ {
+		name = t.Format(timeLayout)
+		if seq > 1 {
+			name += "-" + strconv.Itoa(seq)
+		}
+		name += ext
+		path = filepath.Join(dir, name)
+
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err != nil {
+			if errors.Is(err, os.ErrExist) {
+				continue
+			}
+			return "", "", 0, errors.Wrap(err, "creating snapshot")
+		}
+		if err := f.Close(); err != nil {
+			return "", "", 0, errors.Wrap(err, "creating snapshot")
+		}
+		return name, path, seq, nil
+	}
+}
+
+// List returns the snapshots stored in dir sorted from newest to oldest.
+func List(dir string) ([]*Snapshot, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "reading snapshots directory")
+	}
+
+	snapshots := make([]*Snapshot, 0, len(files))
+	for _, f := range files {
+		name := f.Name()
+		if f.IsDir() || !strings.HasSuffix(name, ext) {
+			continue
+		}
+
+		t, seq, err := parseName(strings.TrimSuffix(name, ext))
+		if err != nil {
+			continue
+		}
+
+		info, err := f.Info()
+		if err != nil {
+			return nil, errors.Wrap(err, "reading snapshot information")
+		}
+
+		snapshots = append(snapshots, &Snapshot{
+			Time: t,
+			Name: name,
+			Path: filepath.Join(dir, name),
+			Size: info.Size(),
+			seq:  seq,
+		})
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		a, b := snapshots[i], snapshots[j]
+		if a.Time.Equal(b.Time) {
+			return a.seq > b.seq
+		}
+		return a.Time.After(b.Time)
+	})
+
+	return snapshots, nil
+}
+
+// parseName returns the time and the sequence number of a snapshot name without the extension.
+func parseName(name string) (time.Time, int, error) {
+	seq := 1
+	if timestamp, suffix, ok := strings.Cut(name, "-"); ok {
+		n, err := strconv.Atoi(suffix)
+		if err != nil || n < 2 {
+			return time.Time{}, 0, errors.Errorf("invalid snapshot name %q", name)
+		}
+		name, seq = timestamp, n
+	}
+
+	t, err := time.Parse(timeLayout, name)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	return t, seq, nil
+}
+
+// Get returns the snapshot with the name specified, the extension may be omitted.
+func Get(dir, name string) (*Snapshot, error) {
+	name = strings.TrimSuffix(filepath.Base(name), ext)
+
+	snapshots, err := List(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range snapshots {
+		if strings.TrimSuffix(s.Name, ext) == name {
+			return s, nil
+		}
+	}
+
+	return nil, errors.Errorf("snapshot %q does not exist", name)
+}
+
+// Prune removes the snapshots that are not retained by the policy.
+func Prune(dir string, policy Policy) ([]*Snapshot, error) {
+	snapshots, err := List(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	_, remove := policy.Apply(snapshots)
+	for _, s := range remove {
+		if err := os.Remove(s.Path); err != nil {
+			return nil, errors.Wrap(err, "removing snapshot")
+		}
+	}
+
+	return remove, nil
+}
+
+// Load reads and decrypts the snapshot and verifies that it's a valid database.
+func Load(s *Snapshot) ([]byte, error) {
+	encrypted, err := os.ReadFile(s.Path)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading snapshot")
+	}
+
+	data, err := Decrypt(encrypted)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshotDB, cleanup, err := Open(data)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	err = snapshotDB.View(func(tx *bolt.Tx) error {
+		if tx.Bucket(bucket.Auth.GetName()) == nil {
+			return errors.New("invalid snapshot: missing authentication bucket")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// Restore replaces the content of db with the snapshot loaded in a single transaction.
+func Restore(db *bolt.DB, data []byte) error {
+	snapshotDB, cleanup, err := Open(data)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	return snapshotDB.View(func(src *bolt.Tx) error {
+		return db.Update(func(dst *bolt.Tx) error {
+			var names [][]byte
+			err := dst.ForEach(func(name []byte, _ *bolt.Bucket) error {
+				names = append(names, bytes.Clone(name))
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			for _, name := range names {
+				if err := dst.DeleteBucket(name); err != nil {
+					return errors.Wrapf(err, "deleting bucket %q", name)
+				}
+			}
+
+			return src.ForEach(func(name []byte, b *bolt.Bucket) error {
+				newBucket, err := dst.CreateBucket(bytes.Clone(name))
+				if err != nil {
+					return errors.Wrapf(err, "creating bucket %q", name)
+				}
+				return copyBucket(newBucket, b)
+			})
+		})
+	})
+}
+
+// Decrypt deciphers a snapshot.
+func Decrypt(encrypted []byte) ([]byte, error) {
+	// Salt (32 bytes) + nonce (12 bytes)
+	if len(encrypted) < 44 {
+		return nil, errors.New("invalid snapshot: too short")
+	}
+
+	data, err := crypt.Decrypt(encrypted)
+	if err != nil {
+		return nil, errors.Wrap(err, "the snapshot couldn't be decrypted with the current credentials")
+	}
+	return data, nil
+}
+
+// Compact returns a compacted copy of the database.
+func Compact(db *bolt.DB) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "kure-snapshot-")
+	if err != nil {
+		return nil, errors.Wrap(err, "creating temporary directory")
+	}
+	defer os.RemoveAll(dir)
+	sig.Signal.AddCleanup(func() error { return os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "snapshot.db")
+	newDB, err := bolt.Open(path, 0o600, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening database copy")
+	}
+
+	if err := bolt.Compact(newDB, db, 0); err != nil {
+		newDB.Close()
+		return nil, errors.Wrap(err, "compacting database")
+	}
+
+	if err := newDB.Close(); err != nil {
+		return nil, errors.Wrap(err, "closing database copy")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading database copy")
+	}
+
+	if err := cmdutil.Erase(path); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Open writes the decrypted snapshot to a temporary file and opens it in read-only mode. The
+// function returned closes the database and erases the file.
+func Open(data []byte) (*bolt.DB, func(), error) {
+	dir, err := os.MkdirTemp("", "kure-snapshot-")
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "creating temporary directory")
+	}
+	sig.Signal.AddCleanup(func() error { return os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "snapshot.db")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		os.RemoveAll(dir)
+		return nil, nil, errors.Wrap(err, "writing snapshot")
+	}
+
+	db, err := bolt.Open(path, 0o600, &bolt.Options{ReadOnly: true, Timeout: time.Second})
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, nil, errors.Wrap(err, "invalid snapshot")
+	}
+
+	cleanup := func() {
+		db.Close()
+		cmdutil.Erase(path)
+		os.RemoveAll(dir)
+	}
+	return db, cleanup, nil
+}
+
+// copyBucket copies every key and nested bucket from src into dst.
+func copyBucket(dst, src *bolt.Bucket) error {
+	return src.ForEach(func(k, v []byte) error {
+		if v != nil {
+			return dst.Put(bytes.Clone(k), bytes.Clone(v))
+		}
+
+		nested, err := dst.CreateBucket(bytes.Clone(k))
+		if err != nil {
+			return err
+		}
+		return copyBucket(nested, src.Bucket(k))
+	})
+}
+
+// String returns the snapshot name without the extension.
+func (s *Snapshot) String() string {
+	return strings.TrimSuffix(s.Name, ext)
+}
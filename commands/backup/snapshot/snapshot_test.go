@@ -0,0 +1,217 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/config"
+	"github.com/GGP1/kure/db/bucket"
+	"github.com/GGP1/kure/db/entry"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/awnumar/memguard"
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestTakeAndRestore(t *testing.T) {
+	db := setContext(t)
+	dir := t.TempDir()
+
+	err := entry.Create(db, &pb.Entry{Name: "test", Password: "old", Expires: "Never"})
+	assert.NoError(t, err)
+
+	s, err := Take(db, dir, time.Date(2026, 10, 19, 15, 4, 5, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Equal(t, "20261019T150405Z", s.String())
+
+	info, err := os.Stat(s.Path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	// Modify the database after the snapshot
+	err = entry.Update(db, "test", &pb.Entry{Name: "test", Password: "new", Expires: "Never"})
+	assert.NoError(t, err)
+	err = entry.Create(db, &pb.Entry{Name: "after", Expires: "Never"})
+	assert.NoError(t, err)
+
+	got, err := Get(dir, "20261019T150405Z.snapshot")
+	assert.NoError(t, err)
+	assert.Equal(t, s, got)
+
+	data, err := Load(got)
+	assert.NoError(t, err)
+	err = Restore(db, data)
+	assert.NoError(t, err)
+
+	e, err := entry.Get(db, "test")
+	assert.NoError(t, err)
+	assert.Equal(t, "old", e.Password)
+
+	_, err = entry.Get(db, "after")
+	assert.Error(t, err, "Records created after the snapshot must not exist")
+}
+
+func TestList(t *testing.T) {
+	db := setContext(t)
+	dir := t.TempDir()
+
+	times := []time.Time{
+		time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 1, 3, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	for _, tm := range times {
+		_, err := Take(db, dir, tm)
+		assert.NoError(t, err)
+	}
+	// Files that are not snapshots are ignored
+	err := os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0o600)
+	assert.NoError(t, err)
+	err = os.WriteFile(filepath.Join(dir, "invalid.snapshot"), nil, 0o600)
+	assert.NoError(t, err)
+
+	snapshots, err := List(dir)
+	assert.NoError(t, err)
+	assert.Len(t, snapshots, 3)
+	assert.Equal(t, "20260103T000000Z", snapshots[0].String())
+	assert.Equal(t, "20260102T000000Z", snapshots[1].String())
+	assert.Equal(t, "20260101T000000Z", snapshots[2].String())
+
+	snapshots, err = List(filepath.Join(dir, "non-existent"))
+	assert.NoError(t, err)
+	assert.Empty(t, snapshots)
+
+	_, err = Get(dir, "20250101T000000Z")
+	assert.Error(t, err)
+}
+
+func TestTakeSameSecond(t *testing.T) {
+	db := setContext(t)
+	dir := t.TempDir()
+	tm := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	err := entry.Create(db, &pb.Entry{Name: "first", Expires: "Never"})
+	assert.NoError(t, err)
+	first, err := Take(db, dir, tm)
+	assert.NoError(t, err)
+
+	err = entry.Create(db, &pb.Entry{Name: "second", Expires: "Never"})
+	assert.NoError(t, err)
+	second, err := Take(db, dir, tm.Add(500*time.Millisecond))
+	assert.NoError(t, err)
+	assert.Equal(t, "20260101T000000Z-2", second.String())
+
+	snapshots, err := List(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, []*Snapshot{second, first}, snapshots)
+
+	// The first snapshot wasn't overwritten
+	data, err := Load(first)
+	assert.NoError(t, err)
+	assert.NoError(t, Restore(db, data))
+	_, err = entry.Get(db, "second")
+	assert.Error(t, err)
+
+	got, err := Get(dir, "20260101T000000Z-2")
+	assert.NoError(t, err)
+	assert.Equal(t, second, got)
+}
+
+func TestLoadErrors(t *testing.T) {
+	db := setContext(t)
+	dir := t.TempDir()
+
+	s, err := Take(db, dir, time.Now())
+	assert.NoError(t, err)
+
+	t.Run("Different credentials", func(t *testing.T) {
+		password := config.GetEnclave("auth.password")
+		t.Cleanup(func() { config.Set("auth.password", password) })
+		config.Set("auth.password", memguard.NewEnclave([]byte("2")))
+
+		_, err := Load(s)
+		assert.Error(t, err)
+	})
+
+	t.Run("Corrupted", func(t *testing.T) {
+		path := filepath.Join(dir, "20250101T000000Z.snapshot")
+		err := os.WriteFile(path, []byte("corrupted"), 0o600)
+		assert.NoError(t, err)
+
+		_, err = Load(&Snapshot{Path: path})
+		assert.Error(t, err)
+	})
+
+	t.Run("Invalid database", func(t *testing.T) {
+		err := Restore(db, []byte("not a database"))
+		assert.Error(t, err)
+	})
+}
+
+func TestAuto(t *testing.T) {
+	db := setContext(t)
+	dir := t.TempDir()
+	config.Set("backup.snapshots.dir", dir)
+
+	s, err := Auto(db, true)
+	assert.NoError(t, err)
+	assert.Nil(t, s, "Snapshots are disabled by default")
+
+	config.Set("backup.snapshots.auto", true)
+	s, err = Auto(db, false)
+	assert.NoError(t, err)
+	assert.Nil(t, s, "The database wasn't modified")
+
+	s, err = Auto(db, true)
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+
+	config.Set("backup.snapshots.auto", false)
+	config.Set("backup.snapshots.interval", "1h")
+	s, err = Auto(db, true)
+	assert.NoError(t, err)
+	assert.Nil(t, s, "The latest snapshot is recent")
+
+	// Make the latest snapshot older than the interval
+	snapshots, err := List(dir)
+	assert.NoError(t, err)
+	old := filepath.Join(dir, time.Now().Add(-2*time.Hour).UTC().Format(timeLayout)+ext)
+	err = os.Rename(snapshots[0].Path, old)
+	assert.NoError(t, err)
+
+	s, err = Auto(db, false)
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+
+	snapshots, err = List(dir)
+	assert.NoError(t, err)
+	assert.Len(t, snapshots, 2)
+}
+
+func TestGetConfig(t *testing.T) {
+	config.Reset()
+	config.Set("database.path", "/home/user/.kure/kure.db")
+	config.Set("backup.snapshots.keep_daily", 7)
+	config.Set("backup.snapshots.keep_weekly", 4)
+
+	c := GetConfig()
+	assert.Equal(t, "/home/user/.kure/snapshots", c.Dir)
+	assert.Equal(t, Policy{Daily: 7, Weekly: 4}, c.Policy)
+	assert.False(t, c.Auto)
+	assert.Zero(t, c.Interval)
+}
+
+// setContext returns a test database with the authentication bucket created.
+func setContext(t *testing.T) *bolt.DB {
+	db := cmdutil.SetContext(t)
+	err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucket.Auth.GetName())
+		return err
+	})
+	assert.NoError(t, err)
+	return db
+}
@@ -0,0 +1,88 @@
+package cmdutil
+
+import (
+	"strings"
+
+	"github.com/GGP1/kure/pb"
+
+	"github.com/pkg/errors"
+)
+
+// FmtIBAN returns the IBAN split in groups of four characters, as it's usually printed.
+func FmtIBAN(iban string) string {
+	iban = NormalizeIBAN(iban)
+
+	var sb strings.Builder
+	for i, r := range iban {
+		if i > 0 && i%4 == 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// NormalizeIBAN removes the spaces from the IBAN and converts it to uppercase.
+func NormalizeIBAN(iban string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(iban), " ", ""))
+}
+
+// ValidateBankAccount normalizes and validates the IBAN and SWIFT/BIC codes. Empty fields are not validated.
+func ValidateBankAccount(b *pb.BankAccount) error {
+	b.Iban = NormalizeIBAN(b.Iban)
+	if b.Iban != "" {
+		if err := validIBAN(b.Iban); err != nil {
+			return err
+		}
+	}
+
+	b.Swift = strings.ToUpper(strings.TrimSpace(b.Swift))
+	if b.Swift != "" {
+		if (len(b.Swift) != 8 && len(b.Swift) != 11) || !isAlphanumeric(b.Swift) {
+			return errors.New("invalid SWIFT/BIC code: it must be 8 or 11 alphanumeric characters long")
+		}
+	}
+
+	return nil
+}
+
+// validIBAN checks the IBAN format and its ISO 7064 mod 97-10 checksum.
+func validIBAN(iban string) error {
+	if len(iban) < 15 || len(iban) > 34 || !isAlphanumeric(iban) {
+		return errors.New("invalid IBAN: it must contain between 15 and 34 alphanumeric characters")
+	}
+	if !isLetter(iban[0]) || !isLetter(iban[1]) || !isDigits(iban[2:4]) {
+		return errors.New("invalid IBAN: it must start with the country code followed by two check digits")
+	}
+
+	// Move the first four characters to the end and replace letters with numbers (A=10, ..., Z=35)
+	rearranged := iban[4:] + iban[:4]
+	remainder := 0
+	for i := 0; i < len(rearranged); i++ {
+		c := rearranged[i]
+		if isLetter(c) {
+			n := int(c-'A') + 10
+			remainder = (remainder*100 + n) % 97
+			continue
+		}
+		remainder = (remainder*10 + int(c-'0')) % 97
+	}
+
+	if remainder != 1 {
+		return errors.New("invalid IBAN: checksum mismatch, check for typos")
+	}
+	return nil
+}
+
+func isAlphanumeric(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isLetter(s[i]) && (s[i] < '0' || s[i] > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+func isLetter(c byte) bool {
+	return c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,75 @@
+package add
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/db/bank"
+	"github.com/GGP1/kure/pb"
+	"github.com/GGP1/kure/terminal"
+
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+const example = `
+* Add a new bank account
+kure bank add Sample`
+
+// NewCmd returns a new command.
+func NewCmd(db *bolt.DB, r io.Reader) *cobra.Command {
+	return &cobra.Command{
+		Use:   "add <name>",
+		Short: "Add a bank account",
+		Long: `Add a bank account.
+
+The IBAN is validated using its check digits and stored without spaces. The SWIFT/BIC code must be 8 or 11 characters long.`,
+		Aliases: []string{"create", "new"},
+		Example: example,
+		Args:    cmdutil.MustNotExist(db, cmdutil.Bank),
+		RunE:    runAdd(db, r),
+	}
+}
+
+func runAdd(db *bolt.DB, r io.Reader) cmdutil.RunEFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		name := strings.Join(args, " ")
+		name = cmdutil.NormalizeName(name)
+
+		b, err := input(name, r)
+		if err != nil {
+			return err
+		}
+
+		if err := bank.Create(db, b); err != nil {
+			return err
+		}
+
+		fmt.Printf("\n%q added\n", name)
+		return nil
+	}
+}
+
+func input(name string, r io.Reader) (*pb.BankAccount, error) {
+	reader := bufio.NewReader(r)
+	b := &pb.BankAccount{
+		Name:          name,
+		Bank:          terminal.Scanln(reader, "Bank"),
+		Holder:        terminal.Scanln(reader, "Holder"),
+		AccountNumber: terminal.Scanln(reader, "Account number"),
+		RoutingNumber: terminal.Scanln(reader, "Routing number"),
+		Iban:          terminal.Scanln(reader, "IBAN"),
+		Swift:         terminal.Scanln(reader, "SWIFT/BIC"),
+		Pin:           terminal.Scanln(reader, "PIN"),
+		Notes:         terminal.Scanlns(reader, "Notes"),
+	}
+
+	if err := cmdutil.ValidateBankAccount(b); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
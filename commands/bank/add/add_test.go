@@ -0,0 +1,101 @@
+package add
+
+import (
+	"bytes"
+	"testing"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/db/bank"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdd(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	cases := []struct {
+		desc string
+		name string
+	}{
+		{
+			desc: "Add",
+			name: "test",
+		},
+		{
+			desc: "Add2",
+			name: "test2",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			buf := bytes.NewBufferString("Kure Bank\nJohn Doe\n31926819\n601613\nGB82 WEST 1234 5698 7654 32\nWESTGB22\n1234\nnotes<\n")
+			cmd := NewCmd(db, buf)
+			cmd.SetArgs([]string{tc.name})
+
+			err := cmd.Execute()
+			assert.NoError(t, err)
+
+			_, err = bank.Get(db, tc.name)
+			assert.NoError(t, err, "Bank account wasn't created correctly")
+		})
+	}
+}
+
+func TestAddErrors(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	err := bank.Create(db, &pb.BankAccount{Name: "test"})
+	assert.NoError(t, err)
+
+	cases := []struct {
+		desc string
+		name string
+	}{
+		{
+			desc: "Already exists",
+			name: "test",
+		},
+		{
+			desc: "Invalid name",
+			name: "",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			buf := bytes.NewBufferString("Kure Bank\n\n\n\n\n\n\n<\n")
+			cmd := NewCmd(db, buf)
+			cmd.SetArgs([]string{tc.name})
+
+			err := cmd.Execute()
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestInput(t *testing.T) {
+	buf := bytes.NewBufferString("Kure Bank\nJohn Doe\n31926819\n601613\ngb82 west 1234 5698 7654 32\nwestgb22\n1234\nnotes<")
+	expected := &pb.BankAccount{
+		Name:          "test",
+		Bank:          "Kure Bank",
+		Holder:        "John Doe",
+		AccountNumber: "31926819",
+		RoutingNumber: "601613",
+		Iban:          "GB82WEST12345698765432",
+		Swift:         "WESTGB22",
+		Pin:           "1234",
+		Notes:         "notes",
+	}
+
+	got, err := input("test", buf)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, got)
+
+	t.Run("Invalid IBAN", func(t *testing.T) {
+		buf := bytes.NewBufferString("\n\n\n\nGB82WEST12345698765431\n\n\n<")
+		_, err := input("test", buf)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,36 @@
+package bank
+
+import (
+	"os"
+
+	badd "github.com/GGP1/kure/commands/bank/add"
+	bcopy "github.com/GGP1/kure/commands/bank/copy"
+	bedit "github.com/GGP1/kure/commands/bank/edit"
+	bls "github.com/GGP1/kure/commands/bank/ls"
+	brm "github.com/GGP1/kure/commands/bank/rm"
+
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+const example = `
+kure bank (add|copy|edit|ls|rm)`
+
+// NewCmd returns a new command.
+func NewCmd(db *bolt.DB) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "bank",
+		Short:   "Bank account operations",
+		Example: example,
+	}
+
+	cmd.AddCommand(
+		badd.NewCmd(db, os.Stdin),
+		bcopy.NewCmd(db),
+		bedit.NewCmd(db),
+		bls.NewCmd(db),
+		brm.NewCmd(db, os.Stdin),
+	)
+
+	return cmd
+}
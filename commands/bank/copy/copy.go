@@ -0,0 +1,81 @@
+package copy
+
+import (
+	"strings"
+	"time"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/db/bank"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+const example = `
+* Copy the account number
+kure bank copy Sample
+
+* Copy the IBAN
+kure bank copy Sample -i
+
+* Copy the PIN and clean after 30s
+kure bank copy Sample -p -t 30s`
+
+type copyOptions struct {
+	iban, pin bool
+	timeout   time.Duration
+}
+
+// NewCmd returns a new command.
+func NewCmd(db *bolt.DB) *cobra.Command {
+	opts := copyOptions{}
+	cmd := &cobra.Command{
+		Use:     "copy <name>",
+		Short:   "Copy bank account number, IBAN or PIN",
+		Aliases: []string{"cp"},
+		Example: example,
+		Args:    cmdutil.MustExist(db, cmdutil.Bank),
+		RunE:    runCopy(db, &opts),
+		PostRun: func(cmd *cobra.Command, args []string) {
+			// Reset variables (session)
+			opts = copyOptions{}
+		},
+	}
+
+	f := cmd.Flags()
+	f.BoolVarP(&opts.iban, "iban", "i", false, "copy the IBAN")
+	f.BoolVarP(&opts.pin, "pin", "p", false, "copy the PIN")
+	f.DurationVarP(&opts.timeout, "timeout", "t", 0, "clipboard clearing timeout")
+
+	return cmd
+}
+
+func runCopy(db *bolt.DB, opts *copyOptions) cmdutil.RunEFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		if opts.iban && opts.pin {
+			return errors.New("only one of the iban and pin flags can be used")
+		}
+
+		name := strings.Join(args, " ")
+		name = cmdutil.NormalizeName(name)
+
+		b, err := bank.Get(db, name)
+		if err != nil {
+			return err
+		}
+
+		field := "Account number"
+		copy := b.AccountNumber
+		switch {
+		case opts.iban:
+			field = "IBAN"
+			copy = b.Iban
+		case opts.pin:
+			field = "PIN"
+			copy = b.Pin
+		}
+
+		return cmdutil.WriteClipboard(cmd, opts.timeout, field, copy)
+	}
+}
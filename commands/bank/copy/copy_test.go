@@ -0,0 +1,123 @@
+package copy
+
+import (
+	"testing"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/db/bank"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/atotto/clipboard"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCopy(t *testing.T) {
+	if clipboard.Unsupported {
+		t.Skip("No clipboard utilities available")
+	}
+	db := cmdutil.SetContext(t)
+
+	b := &pb.BankAccount{
+		Name:          "test",
+		AccountNumber: "31926819",
+		Iban:          "GB82WEST12345698765432",
+		Pin:           "1234",
+	}
+	err := bank.Create(db, b)
+	assert.NoError(t, err, "Failed creating the bank account")
+
+	cases := []struct {
+		desc    string
+		value   string
+		timeout string
+		iban    string
+		pin     string
+	}{
+		{
+			desc:  "Copy account number",
+			value: b.AccountNumber,
+		},
+		{
+			desc:  "Copy IBAN",
+			value: b.Iban,
+			iban:  "true",
+		},
+		{
+			desc:  "Copy PIN",
+			value: b.Pin,
+			pin:   "true",
+		},
+		{
+			desc:    "Copy w/Timeout",
+			value:   "",
+			timeout: "1ns",
+		},
+	}
+
+	cmd := NewCmd(db)
+	f := cmd.Flags()
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			cmd.SetArgs([]string{b.Name})
+			f.Set("timeout", tc.timeout)
+			f.Set("iban", tc.iban)
+			f.Set("pin", tc.pin)
+
+			err := cmd.Execute()
+			assert.NoError(t, err)
+
+			got, err := clipboard.ReadAll()
+			assert.NoError(t, err, "Failed reading from clipboard")
+
+			assert.Equal(t, tc.value, got)
+		})
+	}
+}
+
+func TestCopyErrors(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	err := bank.Create(db, &pb.BankAccount{Name: "test"})
+	assert.NoError(t, err, "Failed creating the bank account")
+
+	cases := []struct {
+		desc string
+		name string
+		iban string
+		pin  string
+	}{
+		{
+			desc: "Invalid name",
+			name: "",
+		},
+		{
+			desc: "Non existent bank account",
+			name: "non-existent",
+		},
+		{
+			desc: "IBAN and PIN",
+			name: "test",
+			iban: "true",
+			pin:  "true",
+		},
+	}
+
+	cmd := NewCmd(db)
+	f := cmd.Flags()
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			cmd.SetArgs([]string{tc.name})
+			f.Set("iban", tc.iban)
+			f.Set("pin", tc.pin)
+
+			err := cmd.Execute()
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestPostRun(t *testing.T) {
+	NewCmd(nil).PostRun(nil, nil)
+}
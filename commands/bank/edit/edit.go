@@ -0,0 +1,231 @@
+package edit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/db/bank"
+	"github.com/GGP1/kure/pb"
+	"github.com/GGP1/kure/sig"
+	"github.com/GGP1/kure/terminal"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+const example = `
+* Edit using the standard input
+kure bank edit Sample
+
+* Edit using the text editor
+kure bank edit Sample -i`
+
+type editOptions struct {
+	interactive bool
+}
+
+// NewCmd returns a new command.
+func NewCmd(db *bolt.DB) *cobra.Command {
+	opts := editOptions{}
+	cmd := &cobra.Command{
+		Use:   "edit <name>",
+		Short: "Edit a bank account",
+		Long: `Edit a bank account.
+
+If the name is edited, kure will remove the old bank account and create one with the new name.
+
+The IBAN and SWIFT/BIC code are validated as when adding a bank account.`,
+		Example: example,
+		Args:    cmdutil.MustExist(db, cmdutil.Bank),
+		RunE:    runEdit(db, &opts),
+		PostRun: func(cmd *cobra.Command, args []string) {
+			// Reset variables (session)
+			opts = editOptions{}
+		},
+	}
+
+	cmd.Flags().BoolVarP(&opts.interactive, "it", "i", false, "use the text editor")
+
+	return cmd
+}
+
+func runEdit(db *bolt.DB, opts *editOptions) cmdutil.RunEFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		name := strings.Join(args, " ")
+		name = cmdutil.NormalizeName(name)
+
+		oldAccount, err := bank.Get(db, name)
+		if err != nil {
+			return err
+		}
+
+		if opts.interactive {
+			return useTextEditor(db, oldAccount)
+		}
+
+		return useStdin(db, os.Stdin, oldAccount)
+	}
+}
+
+func createTempFile(b *pb.BankAccount) (string, error) {
+	f, err := os.CreateTemp("", "*.json")
+	if err != nil {
+		return "", errors.Wrap(err, "creating temporary file")
+	}
+
+	content, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return "", errors.Wrap(err, "encoding bank account")
+	}
+
+	if _, err := f.Write(content); err != nil {
+		return "", errors.Wrap(err, "writing temporary file")
+	}
+
+	if err := f.Close(); err != nil {
+		return "", errors.Wrap(err, "closing temporary file")
+	}
+
+	return f.Name(), nil
+}
+
+// readTmpFile reads the modified file and formats the bank account.
+func readTmpFile(filename string) (*pb.BankAccount, error) {
+	var b pb.BankAccount
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading file")
+	}
+	defer f.Close()
+
+	if err := json.NewDecoder(f).Decode(&b); err != nil {
+		return nil, errors.Wrap(err, "decoding file")
+	}
+
+	return &b, nil
+}
+
+// updateAccount takes the name of the bank account that's being edited to check if the name was
+// changed. If it was, it will remove the old one.
+func updateAccount(db *bolt.DB, name string, b *pb.BankAccount) error {
+	if b.Name == "" {
+		return cmdutil.ErrInvalidName
+	}
+	if err := cmdutil.ValidateBankAccount(b); err != nil {
+		return err
+	}
+
+	name = cmdutil.NormalizeName(name)
+	b.Name = cmdutil.NormalizeName(b.Name)
+
+	if err := bank.Update(db, name, b); err != nil {
+		return err
+	}
+
+	fmt.Println(b.Name, "updated")
+	return nil
+}
+
+func useStdin(db *bolt.DB, r io.Reader, oldAccount *pb.BankAccount) error {
+	fmt.Println("Type '-' to clear the field or leave blank to use the current value")
+	reader := bufio.NewReader(r)
+
+	scanln := func(field, value string) string {
+		input := terminal.Scanln(reader, fmt.Sprintf("%s [%s]", field, value))
+		if input == "-" {
+			return ""
+		} else if input != "" {
+			return input
+		}
+		return value
+	}
+
+	newAccount := &pb.BankAccount{
+		Name:          scanln("Name", oldAccount.Name),
+		Bank:          scanln("Bank", oldAccount.Bank),
+		Holder:        scanln("Holder", oldAccount.Holder),
+		AccountNumber: scanln("Account number", oldAccount.AccountNumber),
+		RoutingNumber: scanln("Routing number", oldAccount.RoutingNumber),
+		Iban:          scanln("IBAN", oldAccount.Iban),
+		Swift:         scanln("SWIFT/BIC", oldAccount.Swift),
+		Pin:           scanln("PIN", oldAccount.Pin),
+	}
+
+	notes := terminal.Scanlns(reader, fmt.Sprintf("Notes [%s]", oldAccount.Notes))
+	if notes == "" {
+		notes = oldAccount.Notes
+	} else if notes == "-" {
+		notes = ""
+	}
+	newAccount.Notes = notes
+
+	return updateAccount(db, oldAccount.Name, newAccount)
+}
+
+func useTextEditor(db *bolt.DB, oldAccount *pb.BankAccount) error {
+	editor := cmdutil.SelectEditor()
+	bin, err := exec.LookPath(editor)
+	if err != nil {
+		return errors.Errorf("executable %q not found", editor)
+	}
+
+	filename, err := createTempFile(oldAccount)
+	if err != nil {
+		return err
+	}
+
+	sig.Signal.AddCleanup(func() error { return cmdutil.Erase(filename) })
+	defer cmdutil.Erase(filename)
+
+	// Open the temporary file with the selected text editor
+	edit := exec.Command(bin, filename)
+	edit.Stdin = os.Stdin
+	edit.Stdout = os.Stdout
+
+	if err := edit.Start(); err != nil {
+		return errors.Wrapf(err, "running %s", editor)
+	}
+
+	done := make(chan struct{}, 1)
+	errCh := make(chan error, 1)
+	go cmdutil.WatchFile(filename, done, errCh)
+
+	// Block until an event is received or an error occurs
+	select {
+	case <-done:
+	case err := <-errCh:
+		return err
+	}
+
+	if err := edit.Wait(); err != nil {
+		return err
+	}
+
+	newAccount, err := readTmpFile(filename)
+	if err != nil {
+		return err
+	}
+
+	rmTabs := func(old string) string {
+		return strings.ReplaceAll(old, "\t", "")
+	}
+	newAccount.Name = rmTabs(newAccount.Name)
+	newAccount.Bank = rmTabs(newAccount.Bank)
+	newAccount.Holder = rmTabs(newAccount.Holder)
+	newAccount.AccountNumber = rmTabs(newAccount.AccountNumber)
+	newAccount.RoutingNumber = rmTabs(newAccount.RoutingNumber)
+	newAccount.Iban = rmTabs(newAccount.Iban)
+	newAccount.Swift = rmTabs(newAccount.Swift)
+	newAccount.Pin = rmTabs(newAccount.Pin)
+	newAccount.Notes = rmTabs(newAccount.Notes)
+
+	return updateAccount(db, oldAccount.Name, newAccount)
+}
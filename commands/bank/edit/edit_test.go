@@ -0,0 +1,179 @@
+package edit
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/config"
+	"github.com/GGP1/kure/db/bank"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestEditErrors(t *testing.T) {
+	db := cmdutil.SetContext(t)
+	createAccount(t, db, "test")
+
+	cases := []struct {
+		set  func()
+		desc string
+		name string
+		it   string
+	}{
+		{
+			desc: "Invalid name",
+			name: "",
+			set:  func() {},
+		},
+		{
+			desc: "Non-existent entry",
+			name: "non-existent",
+			it:   "true",
+			set: func() {
+				config.Set("editor", "non-existent")
+			},
+		},
+	}
+
+	cmd := NewCmd(db)
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			tc.set()
+			cmd.SetArgs([]string{tc.name})
+			cmd.Flags().Set("it", tc.it)
+
+			err := cmd.Execute()
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestCreateTempFile(t *testing.T) {
+	record := &pb.BankAccount{Name: "test-create-file"}
+	filename, err := createTempFile(record)
+	assert.NoError(t, err, "Failed creating the file")
+	defer os.Remove(filename)
+
+	content, err := os.ReadFile(filename)
+	assert.NoError(t, err, "Failed reading the file")
+
+	var got pb.BankAccount
+	err = json.Unmarshal(content, &got)
+	assert.NoError(t, err, "Failed reading the file")
+
+	if !reflect.DeepEqual(record, &got) {
+		t.Error("Expected accounts to be deep equal")
+	}
+}
+
+func TestReadTmpFile(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		_, err := readTmpFile("testdata/test_read.json")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		dir, _ := os.Getwd()
+		os.Chdir("testdata")
+		// Go back to the initial directory
+		defer os.Chdir(dir)
+
+		cases := []struct {
+			desc     string
+			filename string
+		}{
+			{
+				desc:     "Does not exists",
+				filename: "does_not_exists.json",
+			},
+			{
+				desc:     "EOF",
+				filename: "test_read_EOF.json",
+			},
+		}
+
+		for _, tc := range cases {
+			t.Run(tc.desc, func(t *testing.T) {
+				_, err := readTmpFile(tc.filename)
+				assert.Error(t, err)
+			})
+		}
+	})
+}
+
+func TestUpdateAccount(t *testing.T) {
+	db := cmdutil.SetContext(t)
+	name := "test_update"
+	createAccount(t, db, name)
+
+	newName := "new_name"
+	newAccount := &pb.BankAccount{
+		Name:  newName,
+		Bank:  "Kure Bank",
+		Iban:  "gb82 west 1234 5698 7654 32",
+		Swift: "westgb22",
+	}
+
+	err := updateAccount(db, name, newAccount)
+	assert.NoError(t, err)
+
+	b, err := bank.Get(db, newName)
+	assert.NoError(t, err)
+	assert.Equal(t, "GB82WEST12345698765432", b.Iban)
+	assert.Equal(t, "WESTGB22", b.Swift)
+
+	t.Run("Invalid IBAN", func(t *testing.T) {
+		invalid := &pb.BankAccount{Name: newName, Iban: "GB82WEST12345698765431"}
+		err := updateAccount(db, newName, invalid)
+		assert.Error(t, err)
+	})
+
+	t.Run("Invalid name", func(t *testing.T) {
+		newAccount.Name = ""
+		err := updateAccount(db, "fail", newAccount)
+		assert.Error(t, err)
+	})
+}
+
+func TestUseStdin(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	oldAccount := &pb.BankAccount{
+		Name:          "test",
+		Bank:          "Kure Bank",
+		AccountNumber: "31926819",
+		Pin:           "1234",
+		Notes:         "test\nnotes",
+	}
+
+	buf := bytes.NewBufferString("\nAtoll Bank\nJohn Doe\n\n\n\n\n-\n-<\n")
+
+	err := useStdin(db, buf, oldAccount)
+	assert.NoError(t, err)
+
+	got, err := bank.Get(db, "test")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Atoll Bank", got.Bank)
+	assert.Equal(t, "John Doe", got.Holder)
+	assert.Equal(t, oldAccount.AccountNumber, got.AccountNumber)
+	assert.Empty(t, got.Pin)
+	assert.Empty(t, got.Notes)
+}
+
+func TestPostRun(t *testing.T) {
+	NewCmd(nil).PostRun(nil, nil)
+}
+
+func createAccount(t *testing.T, db *bolt.DB, name string) {
+	t.Helper()
+	err := bank.Create(db, &pb.BankAccount{Name: name})
+	assert.NoError(t, err, "Failed creating the bank account")
+}
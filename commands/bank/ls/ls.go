@@ -0,0 +1,137 @@
+package ls
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/db/bank"
+	"github.com/GGP1/kure/orderedmap"
+	"github.com/GGP1/kure/pb"
+	"github.com/GGP1/kure/terminal"
+	"github.com/GGP1/kure/tree"
+
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+const example = `
+* List one, show sensitive information and QR code
+kure bank ls Sample -s -q
+
+* Filter by name
+kure bank ls Sample -f
+
+* List all
+kure bank ls`
+
+type lsOptions struct {
+	filter, qr, show bool
+}
+
+// NewCmd returns a new command.
+func NewCmd(db *bolt.DB) *cobra.Command {
+	opts := lsOptions{}
+	cmd := &cobra.Command{
+		Use:   "ls <name>",
+		Short: "List bank accounts",
+		Long: `List bank accounts.
+
+The account number and IBAN are masked, except for the last four characters, and the PIN is hidden unless the show flag is used.`,
+		Example: example,
+		Args:    cmdutil.MustExistLs(db, cmdutil.Bank),
+		RunE:    runLs(db, &opts),
+		PostRun: func(cmd *cobra.Command, args []string) {
+			// Reset variables (session)
+			opts = lsOptions{}
+		},
+	}
+
+	f := cmd.Flags()
+	f.BoolVarP(&opts.filter, "filter", "f", false, "filter by name")
+	f.BoolVarP(&opts.qr, "qr", "q", false, "display the QR code of the IBAN on the terminal")
+	f.BoolVarP(&opts.show, "show", "s", false, "show the full account number, IBAN and PIN")
+
+	return cmd
+}
+
+func runLs(db *bolt.DB, opts *lsOptions) cmdutil.RunEFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		name := strings.Join(args, " ")
+		name = cmdutil.NormalizeName(name)
+
+		// List all
+		if name == "" {
+			accounts, err := bank.ListNames(db)
+			if err != nil {
+				return err
+			}
+
+			tree.Print(accounts)
+			return nil
+		}
+
+		// Filter by name
+		if opts.filter {
+			accounts, err := bank.ListNames(db)
+			if err != nil {
+				return err
+			}
+
+			var matches []string
+			for _, account := range accounts {
+				matched, err := regexp.MatchString(name, account)
+				if err != nil {
+					return err
+				}
+
+				if matched {
+					matches = append(matches, account)
+				}
+			}
+
+			if len(matches) == 0 {
+				return errors.New("no bank accounts were found")
+			}
+
+			tree.Print(matches)
+			return nil
+		}
+
+		// List one
+		b, err := bank.Get(db, name)
+		if err != nil {
+			return err
+		}
+
+		if opts.qr {
+			return terminal.DisplayQRCode(b.Iban)
+		}
+
+		printAccount(name, b, opts.show)
+		return nil
+	}
+}
+
+func printAccount(name string, b *pb.BankAccount, show bool) {
+	iban := cmdutil.FmtIBAN(b.Iban)
+	if !show {
+		b.AccountNumber = cmdutil.Mask(b.AccountNumber, 4)
+		iban = cmdutil.FmtIBAN(cmdutil.Mask(b.Iban, 4))
+		b.Pin = strings.Repeat("•", len(b.Pin))
+	}
+
+	mp := orderedmap.New()
+	mp.Set("Bank", b.Bank)
+	mp.Set("Holder", b.Holder)
+	mp.Set("Account number", b.AccountNumber)
+	mp.Set("Routing number", b.RoutingNumber)
+	mp.Set("IBAN", iban)
+	mp.Set("SWIFT/BIC", b.Swift)
+	mp.Set("PIN", b.Pin)
+	mp.Set("Notes", b.Notes)
+
+	fmt.Println(cmdutil.BuildBox(name, mp))
+}
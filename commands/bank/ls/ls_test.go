@@ -0,0 +1,123 @@
+package ls
+
+import (
+	"testing"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/db/bank"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLs(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	err := bank.Create(db, &pb.BankAccount{
+		Name:          "test",
+		AccountNumber: "31926819",
+		Iban:          "GB82WEST12345698765432",
+		Pin:           "1234",
+	})
+	assert.NoError(t, err, "Failed creating the bank account")
+
+	cases := []struct {
+		desc   string
+		name   string
+		filter string
+		show   string
+		qr     string
+	}{
+		{
+			desc: "List one",
+			name: "test",
+		},
+		{
+			desc: "List one and show qr",
+			name: "test",
+			qr:   "true",
+		},
+		{
+			desc:   "Filter by name",
+			name:   "te*",
+			filter: "true",
+		},
+		{
+			desc: "List all",
+			name: "",
+		},
+		{
+			desc: "List one and show",
+			name: "test",
+			show: "true",
+		},
+	}
+
+	cmd := NewCmd(db)
+	f := cmd.Flags()
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			cmd.SetArgs([]string{tc.name})
+			f.Set("filter", tc.filter)
+			f.Set("show", tc.show)
+			f.Set("qr", tc.qr)
+
+			err := cmd.Execute()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestLsErrors(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	err := bank.Create(db, &pb.BankAccount{Name: "test"})
+	assert.NoError(t, err, "Failed creating the bank account")
+
+	cases := []struct {
+		desc   string
+		name   string
+		filter string
+		qr     string
+	}{
+		{
+			desc:   "Bank account does not exist",
+			name:   "non-existent",
+			filter: "false",
+		},
+		{
+			desc:   "No bank accounts found",
+			name:   "non-existent",
+			filter: "true",
+		},
+		{
+			desc:   "Filter syntax error",
+			name:   "[error",
+			filter: "true",
+		},
+		{
+			desc: "No data to encode",
+			name: "test",
+			qr:   "true",
+		},
+	}
+
+	cmd := NewCmd(db)
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			cmd.SetArgs([]string{tc.name})
+			f := cmd.Flags()
+			f.Set("filter", tc.filter)
+			f.Set("qr", tc.qr)
+
+			err := cmd.Execute()
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestPostRun(t *testing.T) {
+	NewCmd(nil).PostRun(nil, nil)
+}
@@ -0,0 +1,68 @@
+package rm
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/db/bank"
+	"github.com/GGP1/kure/terminal"
+
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+const example = `
+* Remove a bank account
+kure bank rm Sample
+
+* Remove a directory
+kure bank rm SampleDir/
+
+* Remove multiple bank accounts
+kure bank rm Sample Sample2 Sample3`
+
+// NewCmd returns the a new command.
+func NewCmd(db *bolt.DB, r io.Reader) *cobra.Command {
+	return &cobra.Command{
+		Use:     "rm <names>",
+		Short:   "Remove bank accounts or directories",
+		Example: example,
+		Args:    cmdutil.MustExist(db, cmdutil.Bank, true),
+		RunE:    runRm(db, r),
+	}
+}
+
+func runRm(db *bolt.DB, r io.Reader) cmdutil.RunEFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		if !terminal.Confirm(r, "Are you sure you want to proceed?") {
+			return nil
+		}
+
+		names := make([]string, 0, len(args))
+		for _, name := range args {
+			name = cmdutil.NormalizeName(name, true)
+
+			if !strings.HasSuffix(name, "/") {
+				names = append(names, name)
+				fmt.Println("Remove:", name)
+				continue
+			}
+
+			accounts, err := bank.ListNames(db)
+			if err != nil {
+				return err
+			}
+
+			for _, a := range accounts {
+				if strings.HasPrefix(a, name) {
+					names = append(names, a)
+					fmt.Println("Remove:", a)
+				}
+			}
+		}
+
+		return bank.Remove(db, names...)
+	}
+}
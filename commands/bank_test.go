@@ -0,0 +1,50 @@
+package cmdutil
+
+import (
+	"testing"
+
+	"github.com/GGP1/kure/pb"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateBankAccount(t *testing.T) {
+	b := &pb.BankAccount{
+		Iban:  " gb82 west 1234 5698 7654 32 ",
+		Swift: "deutdeff500",
+	}
+	err := ValidateBankAccount(b)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "GB82WEST12345698765432", b.Iban)
+	assert.Equal(t, "DEUTDEFF500", b.Swift)
+
+	t.Run("Empty", func(t *testing.T) {
+		assert.NoError(t, ValidateBankAccount(&pb.BankAccount{}))
+	})
+}
+
+func TestValidateBankAccountErrors(t *testing.T) {
+	cases := []struct {
+		desc    string
+		account *pb.BankAccount
+	}{
+		{desc: "IBAN checksum", account: &pb.BankAccount{Iban: "GB82WEST12345698765431"}},
+		{desc: "IBAN too short", account: &pb.BankAccount{Iban: "GB82WEST"}},
+		{desc: "IBAN country", account: &pb.BankAccount{Iban: "1282WEST12345698765432"}},
+		{desc: "IBAN symbols", account: &pb.BankAccount{Iban: "GB82-WEST-1234-5698-7654-32"}},
+		{desc: "SWIFT length", account: &pb.BankAccount{Swift: "DEUTDE"}},
+		{desc: "SWIFT symbols", account: &pb.BankAccount{Swift: "DEUT-DEFF"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			assert.Error(t, ValidateBankAccount(tc.account))
+		})
+	}
+}
+
+func TestFmtIBAN(t *testing.T) {
+	assert.Equal(t, "GB82 WEST 1234 5698 7654 32", FmtIBAN("gb82west12345698765432"))
+	assert.Equal(t, "", FmtIBAN(""))
+}
@@ -0,0 +1,363 @@
+package cmdutil
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GGP1/kure/pb"
+
+	"github.com/pkg/errors"
+)
+
+// CardExpiryWarning is how long before its expiration a card is reported as expiring soon.
+const CardExpiryWarning = 30 * day
+
+var errInvalidExpiry = errors.New("\"expire date\" field has an invalid format. Valid formats: MM/YY, MM/YYYY or YYYY/MM")
+
+// cardBrand contains the information used to identify and format a card number.
+type cardBrand struct {
+	name string
+	// Ranges of the issuer identification number, both ends included. Ranges are compared against
+	// the number prefix with the same amount of digits
+	ranges [][2]int
+	// Valid number lengths
+	lengths []int
+	// Digits in each group when displaying the number
+	groups []int
+	// Security code length
+	codeLength int
+}
+
+// cardBrands is sorted so that more specific ranges are checked first.
+var cardBrands = []cardBrand{
+	{
+		name:       "American Express",
+		ranges:     [][2]int{{34, 34}, {37, 37}},
+		lengths:    []int{15},
+		groups:     []int{4, 6, 5},
+		codeLength: 4,
+	},
+	{
+		name:       "Diners Club",
+		ranges:     [][2]int{{300, 305}, {36, 36}, {38, 39}},
+		lengths:    []int{14, 16},
+		groups:     []int{4, 6, 4},
+		codeLength: 3,
+	},
+	{
+		name:       "Discover",
+		ranges:     [][2]int{{6011, 6011}, {644, 649}, {65, 65}},
+		lengths:    []int{16, 19},
+		groups:     []int{4, 4, 4, 4},
+		codeLength: 3,
+	},
+	{
+		name:       "JCB",
+		ranges:     [][2]int{{3528, 3589}},
+		lengths:    []int{16, 17, 18, 19},
+		groups:     []int{4, 4, 4, 4},
+		codeLength: 3,
+	},
+	{
+		name:       "Maestro",
+		ranges:     [][2]int{{5018, 5018}, {5020, 5020}, {5038, 5038}, {5893, 5893}, {6304, 6304}, {6759, 6759}, {6761, 6763}},
+		lengths:    []int{12, 13, 14, 15, 16, 17, 18, 19},
+		groups:     []int{4, 4, 4, 4},
+		codeLength: 3,
+	},
+	{
+		name:       "Mastercard",
+		ranges:     [][2]int{{51, 55}, {2221, 2720}},
+		lengths:    []int{16},
+		groups:     []int{4, 4, 4, 4},
+		codeLength: 3,
+	},
+	{
+		name:       "UnionPay",
+		ranges:     [][2]int{{62, 62}},
+		lengths:    []int{16, 17, 18, 19},
+		groups:     []int{4, 4, 4, 4},
+		codeLength: 3,
+	},
+	{
+		name:       "Visa",
+		ranges:     [][2]int{{4, 4}},
+		lengths:    []int{13, 16, 19},
+		groups:     []int{4, 4, 4, 4},
+		codeLength: 3,
+	},
+}
+
+// CardBrand returns the brand of the card number, or an empty string if it's unknown.
+func CardBrand(number string) string {
+	if b, ok := findCardBrand(NormalizeCardNumber(number)); ok {
+		return b.name
+	}
+	return ""
+}
+
+// CardExpiry parses the card expiration date and returns the moment the card stops being valid,
+// the first day of the month following the one printed on the card.
+//
+// Valid formats: MM/YY, MM/YYYY and YYYY/MM. Dashes may be used instead of slashes.
+func CardExpiry(date string) (time.Time, error) {
+	date = strings.ReplaceAll(strings.TrimSpace(date), "-", "/")
+	first, second, found := strings.Cut(date, "/")
+	if !found {
+		return time.Time{}, errInvalidExpiry
+	}
+
+	monthStr, yearStr := first, second
+	if len(first) == 4 {
+		monthStr, yearStr = second, first
+	}
+
+	month, err := strconv.Atoi(monthStr)
+	if err != nil || len(monthStr) > 2 || month < 1 || month > 12 {
+		return time.Time{}, errInvalidExpiry
+	}
+	year, err := strconv.Atoi(yearStr)
+	if err != nil || (len(yearStr) != 2 && len(yearStr) != 4) {
+		return time.Time{}, errInvalidExpiry
+	}
+	if len(yearStr) == 2 {
+		year += 2000
+	}
+
+	return time.Date(year, time.Month(month)+1, 1, 0, 0, 0, 0, time.UTC), nil
+}
+
+// CardExpiryStatus returns a text describing whether the card is expired or expires soon,
+// and an empty string if the expiration date is unknown or far enough.
+func CardExpiryStatus(date string, now time.Time) string {
+	expiry, err := CardExpiry(date)
+	if err != nil {
+		return ""
+	}
+
+	left := expiry.Sub(now)
+	switch {
+	case left <= 0:
+		return "expired"
+	case left <= CardExpiryWarning:
+		days := int(left.Hours() / 24)
+		if days == 0 {
+			return "expires today"
+		}
+		if days == 1 {
+			return "expires in 1 day"
+		}
+		return "expires in " + strconv.Itoa(days) + " days"
+	default:
+		return ""
+	}
+}
+
+// FmtCardExpiry returns the expiration date in the MM/YYYY format.
+func FmtCardExpiry(date string) (string, error) {
+	expiry, err := CardExpiry(date)
+	if err != nil {
+		return "", err
+	}
+	// CardExpiry returns the month following the expiration one
+	expiry = expiry.AddDate(0, -1, 0)
+	return expiry.Format("01/2006"), nil
+}
+
+// FmtCardNumber returns the number split in groups as printed on the card. If show is false,
+// all the digits but the last four are masked.
+func FmtCardNumber(number string, show bool) string {
+	number = NormalizeCardNumber(number)
+	if number == "" {
+		return ""
+	}
+
+	digits := []rune(number)
+	if !show {
+		for i := 0; i < len(digits)-4; i++ {
+			digits[i] = '•'
+		}
+	}
+
+	groups := []int{4, 4, 4, 4}
+	if b, ok := findCardBrand(number); ok {
+		groups = b.groups
+	}
+
+	var sb strings.Builder
+	start := 0
+	for i := 0; start < len(digits); i++ {
+		size := 4
+		if i < len(groups) {
+			size = groups[i]
+		}
+		end := min(start+size, len(digits))
+		if start > 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(string(digits[start:end]))
+		start = end
+	}
+
+	return sb.String()
+}
+
+// NormalizeCardNumber removes the spaces and dashes from the card number.
+func NormalizeCardNumber(number string) string {
+	return strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(number))
+}
+
+// ValidateCard normalizes the card number and expiration date, validates them and the security code
+// and sets the card type to its brand if it's empty. Empty fields are not validated.
+func ValidateCard(c *pb.Card) error {
+	c.Number = NormalizeCardNumber(c.Number)
+	if c.Number != "" {
+		if err := validCardNumber(c.Number); err != nil {
+			return err
+		}
+		if c.Type == "" {
+			c.Type = CardBrand(c.Number)
+		}
+	}
+
+	c.SecurityCode = strings.TrimSpace(c.SecurityCode)
+	if err := validSecurityCode(c.SecurityCode, c.Number); err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(c.ExpireDate) != "" {
+		expireDate, err := FmtCardExpiry(c.ExpireDate)
+		if err != nil {
+			return err
+		}
+		c.ExpireDate = expireDate
+	}
+
+	return nil
+}
+
+// ValidateCardUpdate is like ValidateCard but it only validates the fields that differ from the old card, so
+// cards stored before the validation was introduced can be edited without fixing their values first. The
+// security code is validated if the number was modified as well, as its length depends on the brand.
+func ValidateCardUpdate(old, c *pb.Card) error {
+	numberChanged := NormalizeCardNumber(c.Number) != NormalizeCardNumber(old.Number)
+	codeChanged := numberChanged || strings.TrimSpace(c.SecurityCode) != strings.TrimSpace(old.SecurityCode)
+	expireDateChanged := strings.TrimSpace(c.ExpireDate) != strings.TrimSpace(old.ExpireDate)
+
+	check := &pb.Card{Type: c.Type}
+	if numberChanged {
+		check.Number = c.Number
+	}
+	if expireDateChanged {
+		check.ExpireDate = c.ExpireDate
+	}
+	if err := ValidateCard(check); err != nil {
+		return err
+	}
+
+	if codeChanged {
+		// The brand is taken from the number even if it's an unchanged one that isn't valid
+		c.SecurityCode = strings.TrimSpace(c.SecurityCode)
+		if err := validSecurityCode(c.SecurityCode, NormalizeCardNumber(c.Number)); err != nil {
+			return err
+		}
+	}
+
+	if numberChanged {
+		c.Number = check.Number
+	}
+	if expireDateChanged {
+		c.ExpireDate = check.ExpireDate
+	}
+	c.Type = check.Type
+	if c.Type == "" {
+		c.Type = CardBrand(c.Number)
+	}
+	return nil
+}
+
+// validSecurityCode checks the security code length, which depends on the brand of the card number if
+// it's known. Empty codes are valid.
+func validSecurityCode(code, number string) error {
+	if code == "" {
+		return nil
+	}
+
+	codeLength := 0
+	if b, ok := findCardBrand(number); ok {
+		codeLength = b.codeLength
+	}
+	if !isDigits(code) || len(code) < 3 || len(code) > 4 || (codeLength != 0 && len(code) != codeLength) {
+		return errors.New("invalid security code")
+	}
+	return nil
+}
+
+// validCardNumber checks the card number length, its brand length (if known) and Luhn checksum.
+func validCardNumber(number string) error {
+	if !isDigits(number) {
+		return errors.New("invalid card number: it must contain only digits")
+	}
+	if len(number) < 12 || len(number) > 19 {
+		return errors.New("invalid card number: it must be between 12 and 19 digits long")
+	}
+	if b, ok := findCardBrand(number); ok && !slices.Contains(b.lengths, len(number)) {
+		return errors.Errorf("invalid card number: %s numbers can't be %d digits long", b.name, len(number))
+	}
+	if !luhn(number) {
+		return errors.New("invalid card number: checksum mismatch, check for typos")
+	}
+	return nil
+}
+
+// luhn reports whether the number passes the Luhn (mod 10) checksum.
+func luhn(number string) bool {
+	sum := 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		d := int(number[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+func findCardBrand(number string) (cardBrand, bool) {
+	if !isDigits(number) {
+		return cardBrand{}, false
+	}
+
+	for _, b := range cardBrands {
+		for _, r := range b.ranges {
+			size := len(strconv.Itoa(r[0]))
+			if len(number) < size {
+				continue
+			}
+			prefix, _ := strconv.Atoi(number[:size])
+			if prefix >= r[0] && prefix <= r[1] {
+				return b, true
+			}
+		}
+	}
+	return cardBrand{}, false
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -22,8 +22,11 @@ kure card add Sample`
 // NewCmd returns a new command.
 func NewCmd(db *bolt.DB, r io.Reader) *cobra.Command {
 	return &cobra.Command{
-		Use:     "add <name>",
-		Short:   "Add a card",
+		Use:   "add <name>",
+		Short: "Add a card",
+		Long: `Add a card.
+
+The number is validated using the Luhn algorithm and the type is set to the card brand (Visa, Mastercard, American Express, etc.), it's requested only if the brand is unknown. The expire date is stored as MM/YYYY, valid formats: MM/YY, MM/YYYY or YYYY/MM.`,
 		Aliases: []string{"create", "new"},
 		Example: example,
 		Args:    cmdutil.MustNotExist(db, cmdutil.Card),
@@ -53,12 +56,22 @@ func runAdd(db *bolt.DB, r io.Reader) cmdutil.RunEFunc {
 func input(db *bolt.DB, name string, r io.Reader) (*pb.Card, error) {
 	reader := bufio.NewReader(r)
 	c := &pb.Card{
-		Name:         name,
-		Type:         terminal.Scanln(reader, "Type"),
-		Number:       terminal.Scanln(reader, "Number"),
-		SecurityCode: terminal.Scanln(reader, "Security code"),
-		ExpireDate:   terminal.Scanln(reader, "Expire date"),
-		Notes:        terminal.Scanlns(reader, "Notes"),
+		Name:   name,
+		Number: terminal.Scanln(reader, "Number"),
+	}
+
+	brand := cmdutil.CardBrand(c.Number)
+	if brand == "" {
+		c.Type = terminal.Scanln(reader, "Type")
+	} else {
+		fmt.Println("Type:", brand)
+	}
+	c.SecurityCode = terminal.Scanln(reader, "Security code")
+	c.ExpireDate = terminal.Scanln(reader, "Expire date")
+	c.Notes = terminal.Scanlns(reader, "Notes")
+
+	if err := cmdutil.ValidateCard(c); err != nil {
+		return nil, err
 	}
 
 	return c, nil
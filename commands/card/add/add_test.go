@@ -30,7 +30,7 @@ func TestAdd(t *testing.T) {
 
 	for _, tc := range cases {
 		t.Run(tc.desc, func(t *testing.T) {
-			buf := bytes.NewBufferString("type\n123456789\n1234\n2021/06\nnotes<\n")
+			buf := bytes.NewBufferString("4111 1111 1111 1111\n123\n06/2031\nnotes<\n")
 			cmd := NewCmd(db, buf)
 			cmd.SetArgs([]string{tc.name})
 
@@ -65,7 +65,7 @@ func TestAddErrors(t *testing.T) {
 
 	for _, tc := range cases {
 		t.Run(tc.desc, func(t *testing.T) {
-			buf := bytes.NewBufferString("type\n123456789\n1234\n2021/06\nnotes<\n")
+			buf := bytes.NewBufferString("4111 1111 1111 1111\n123\n06/2031\nnotes<\n")
 			cmd := NewCmd(db, buf)
 			cmd.SetArgs([]string{tc.name})
 
@@ -78,19 +78,63 @@ func TestAddErrors(t *testing.T) {
 func TestInput(t *testing.T) {
 	db := cmdutil.SetContext(t)
 
-	expected := &pb.Card{
-		Name:         "test",
-		Type:         "type",
-		Number:       "123456789",
-		SecurityCode: "1234",
-		ExpireDate:   "2021/06",
-		Notes:        "notes",
+	cases := []struct {
+		desc     string
+		input    string
+		expected *pb.Card
+	}{
+		{
+			desc:  "Brand detected",
+			input: "3782-822463-10005\n1234\n2031/06\nnotes<",
+			expected: &pb.Card{
+				Name:         "test",
+				Type:         "American Express",
+				Number:       "378282246310005",
+				SecurityCode: "1234",
+				ExpireDate:   "06/2031",
+				Notes:        "notes",
+			},
+		},
+		{
+			desc:  "Unknown brand",
+			input: "7000000000000005\nStore\n123\n06/31\nnotes<",
+			expected: &pb.Card{
+				Name:         "test",
+				Type:         "Store",
+				Number:       "7000000000000005",
+				SecurityCode: "123",
+				ExpireDate:   "06/2031",
+				Notes:        "notes",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := input(db, "test", bytes.NewBufferString(tc.input))
+			assert.NoError(t, err, "Failed creating the card")
+
+			assert.Equal(t, tc.expected, got)
+		})
 	}
+}
 
-	buf := bytes.NewBufferString("type\n123456789\n1234\n2021/06\nnotes<")
+func TestInputErrors(t *testing.T) {
+	db := cmdutil.SetContext(t)
 
-	got, err := input(db, "test", buf)
-	assert.NoError(t, err, "Failed creating the card")
+	cases := []struct {
+		desc  string
+		input string
+	}{
+		{desc: "Invalid checksum", input: "4111111111111112\n123\n06/2031\nnotes<"},
+		{desc: "Invalid security code", input: "4111111111111111\n12a\n06/2031\nnotes<"},
+		{desc: "Invalid expire date", input: "4111111111111111\n123\n2031/13\nnotes<"},
+	}
 
-	assert.Equal(t, expected, got)
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			_, err := input(db, "test", bytes.NewBufferString(tc.input))
+			assert.Error(t, err)
+		})
+	}
 }
@@ -39,7 +39,9 @@ func NewCmd(db *bolt.DB) *cobra.Command {
 		Short: "Edit a card",
 		Long: `Edit a card.
 
-If the name is edited, kure will remove the old card and create one with the new name.`,
+If the name is edited, kure will remove the old card and create one with the new name.
+
+The number, security code and expire date are validated as when adding a card if they are modified. If the type is cleared, it's set to the card brand.`,
 		Example: example,
 		Args:    cmdutil.MustExist(db, cmdutil.Card),
 		RunE:    runEdit(db, &opts),
@@ -111,14 +113,17 @@ func readTmpFile(filename string) (*pb.Card, error) {
 	return &c, nil
 }
 
-// updateCard takes the name of the card that's being edited to check if the name was
-// changed. If it was, it will remove the old one.
-func updateCard(db *bolt.DB, name string, c *pb.Card) error {
+// updateCard takes the card that's being edited to check if the name was changed. If it was,
+// it will remove the old one. Only the fields modified are validated.
+func updateCard(db *bolt.DB, oldCard, c *pb.Card) error {
 	if c.Name == "" {
 		return cmdutil.ErrInvalidName
 	}
+	if err := cmdutil.ValidateCardUpdate(oldCard, c); err != nil {
+		return err
+	}
 
-	name = cmdutil.NormalizeName(name)
+	name := cmdutil.NormalizeName(oldCard.Name)
 	c.Name = cmdutil.NormalizeName(c.Name)
 
 	if err := card.Update(db, name, c); err != nil {
@@ -159,7 +164,7 @@ func useStdin(db *bolt.DB, r io.Reader, oldCard *pb.Card) error {
 	}
 	newCard.Notes = notes
 
-	return updateCard(db, oldCard.Name, newCard)
+	return updateCard(db, oldCard, newCard)
 }
 
 func useTextEditor(db *bolt.DB, oldCard *pb.Card) error {
@@ -216,5 +221,5 @@ func useTextEditor(db *bolt.DB, oldCard *pb.Card) error {
 	newCard.ExpireDate = rmTabs(newCard.ExpireDate)
 	newCard.Notes = rmTabs(newCard.Notes)
 
-	return updateCard(db, oldCard.Name, newCard)
+	return updateCard(db, oldCard, newCard)
 }
@@ -117,13 +117,13 @@ func TestUpdateCard(t *testing.T) {
 	newCard := &pb.Card{
 		Name:         newName,
 		Type:         "",
-		Number:       "12345678",
-		SecurityCode: "1234",
+		Number:       "4111111111111111",
+		SecurityCode: "123",
 		ExpireDate:   "12/2023",
 		Notes:        "",
 	}
 
-	err := updateCard(db, name, newCard)
+	err := updateCard(db, &pb.Card{Name: name}, newCard)
 	assert.NoError(t, err)
 
 	c, err := card.Get(db, newName)
@@ -131,9 +131,17 @@ func TestUpdateCard(t *testing.T) {
 
 	assert.NotEqual(t, newCard, c)
 
+	assert.Equal(t, "Visa", c.Type)
+
+	t.Run("Invalid number", func(t *testing.T) {
+		invalid := &pb.Card{Name: newName, Number: "4111111111111112"}
+		err := updateCard(db, &pb.Card{Name: newName}, invalid)
+		assert.Error(t, err)
+	})
+
 	t.Run("Invalid name", func(t *testing.T) {
 		newCard.Name = ""
-		err := updateCard(db, "fail", newCard)
+		err := updateCard(db, &pb.Card{Name: "fail"}, newCard)
 		assert.Error(t, err)
 	})
 }
@@ -144,8 +152,8 @@ func TestUseStdin(t *testing.T) {
 	oldCard := &pb.Card{
 		Name:         "test",
 		Type:         "",
-		Number:       "12345678",
-		SecurityCode: "1234",
+		Number:       "4111111111111111",
+		SecurityCode: "123",
 		ExpireDate:   "06/2023",
 		Notes:        "test\nnotes",
 	}
@@ -165,6 +173,59 @@ func TestUseStdin(t *testing.T) {
 	assert.Empty(t, got.Notes)
 }
 
+func TestUseStdinLegacyCard(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	// Stored before the validation was introduced
+	oldCard := &pb.Card{
+		Name:         "legacy",
+		Number:       "4111111111111112",
+		SecurityCode: "12",
+		ExpireDate:   "June 2030",
+	}
+	assert.NoError(t, card.Create(db, oldCard))
+
+	buf := bytes.NewBufferString("\nDebit\n\n\n\nnew notes<\n")
+	err := useStdin(db, buf, oldCard)
+	assert.NoError(t, err)
+
+	got, err := card.Get(db, "legacy")
+	assert.NoError(t, err)
+	assert.Equal(t, "Debit", got.Type)
+	assert.Equal(t, oldCard.Number, got.Number)
+	assert.Equal(t, oldCard.SecurityCode, got.SecurityCode)
+	assert.Equal(t, oldCard.ExpireDate, got.ExpireDate)
+	assert.Equal(t, "new notes", got.Notes)
+
+	t.Run("Invalid new number", func(t *testing.T) {
+		buf := bytes.NewBufferString("\n\n4111111111111113\n\n\n<\n")
+		assert.Error(t, useStdin(db, buf, got))
+	})
+}
+
+func TestUseStdinSecurityCode(t *testing.T) {
+	cases := []struct {
+		desc   string
+		number string
+		code   string
+	}{
+		{desc: "Visa", number: "4111111111111111", code: "1234"},
+		{desc: "American Express", number: "378282246310005", code: "123"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			db := cmdutil.SetContext(t)
+			oldCard := &pb.Card{Name: "test", Number: tc.number}
+			assert.NoError(t, card.Create(db, oldCard))
+
+			// Only the security code is modified
+			buf := bytes.NewBufferString("\n\n\n" + tc.code + "\n\n<\n")
+			assert.Error(t, useStdin(db, buf, oldCard))
+		})
+	}
+}
+
 func TestPostRun(t *testing.T) {
 	NewCmd(nil).PostRun(nil, nil)
 }
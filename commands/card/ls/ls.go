@@ -3,8 +3,11 @@ package ls
 import (
 	"errors"
 	"fmt"
+	"os"
 	"regexp"
 	"strings"
+	"text/tabwriter"
+	"time"
 
 	cmdutil "github.com/GGP1/kure/commands"
 	"github.com/GGP1/kure/db/card"
@@ -35,8 +38,11 @@ type lsOptions struct {
 func NewCmd(db *bolt.DB) *cobra.Command {
 	opts := lsOptions{}
 	cmd := &cobra.Command{
-		Use:     "ls <name>",
-		Short:   "List cards",
+		Use:   "ls <name>",
+		Short: "List cards",
+		Long: `List cards.
+
+When listing all the cards, those that are expired or expire in the next 30 days are reported. Card numbers are masked, except for the last four digits, unless the show flag is used.`,
 		Example: example,
 		Args:    cmdutil.MustExistLs(db, cmdutil.Card),
 		RunE:    runLs(db, &opts),
@@ -49,7 +55,7 @@ func NewCmd(db *bolt.DB) *cobra.Command {
 	f := cmd.Flags()
 	f.BoolVarP(&opts.filter, "filter", "f", false, "filter by name")
 	f.BoolVarP(&opts.qr, "qr", "q", false, "display the QR code of the number on the terminal")
-	f.BoolVarP(&opts.show, "show", "s", false, "show card number and security code")
+	f.BoolVarP(&opts.show, "show", "s", false, "show the full card number and security code")
 
 	return cmd
 }
@@ -67,7 +73,7 @@ func runLs(db *bolt.DB, opts *lsOptions) cmdutil.RunEFunc {
 			}
 
 			tree.Print(cards)
-			return nil
+			return printExpiring(db, time.Now())
 		}
 
 		// Filter by name
@@ -113,17 +119,47 @@ func runLs(db *bolt.DB, opts *lsOptions) cmdutil.RunEFunc {
 }
 
 func printCard(name string, c *pb.Card, show bool) {
-	if !show {
-		c.Number = "••••••••••••••••"
-		c.SecurityCode = "•••"
+	if !show && c.SecurityCode != "" {
+		c.SecurityCode = strings.Repeat("•", len(c.SecurityCode))
+	}
+
+	expireDate := c.ExpireDate
+	if status := cmdutil.CardExpiryStatus(c.ExpireDate, time.Now()); status != "" {
+		expireDate += " (" + status + ")"
 	}
 
 	mp := orderedmap.New()
 	mp.Set("Type", c.Type)
-	mp.Set("Number", c.Number)
+	mp.Set("Number", cmdutil.FmtCardNumber(c.Number, show))
 	mp.Set("Security code", c.SecurityCode)
-	mp.Set("Expire date", c.ExpireDate)
+	mp.Set("Expire date", expireDate)
 	mp.Set("Notes", c.Notes)
 
 	fmt.Println(cmdutil.BuildBox(name, mp))
 }
+
+// printExpiring prints the cards that are expired or expire soon.
+func printExpiring(db *bolt.DB, now time.Time) error {
+	cards, err := card.List(db)
+	if err != nil {
+		return err
+	}
+
+	var expiring []*pb.Card
+	for _, c := range cards {
+		if cmdutil.CardExpiryStatus(c.ExpireDate, now) != "" {
+			expiring = append(expiring, c)
+		}
+	}
+	if len(expiring) == 0 {
+		return nil
+	}
+
+	fmt.Println("\nExpiring cards:")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	for _, c := range expiring {
+		fmt.Fprintf(w, "  %s\t%s\t%s\n", c.Name, c.ExpireDate, cmdutil.CardExpiryStatus(c.ExpireDate, now))
+	}
+	w.Flush()
+	return nil
+}
@@ -14,8 +14,9 @@ func TestLs(t *testing.T) {
 	db := cmdutil.SetContext(t)
 
 	err := card.Create(db, &pb.Card{
-		Name:   "test",
-		Number: "1500135",
+		Name:       "test",
+		Number:     "1500135",
+		ExpireDate: "01/2020",
 	})
 	assert.NoError(t, err, "Failed creating the card")
 
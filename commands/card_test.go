@@ -0,0 +1,181 @@
+package cmdutil
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GGP1/kure/pb"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCardBrand(t *testing.T) {
+	cases := []struct {
+		number   string
+		expected string
+	}{
+		{number: "4111 1111 1111 1111", expected: "Visa"},
+		{number: "5555555555554444", expected: "Mastercard"},
+		{number: "2223003122003222", expected: "Mastercard"},
+		{number: "378282246310005", expected: "American Express"},
+		{number: "6011111111111117", expected: "Discover"},
+		{number: "30569309025904", expected: "Diners Club"},
+		{number: "3530111333300000", expected: "JCB"},
+		{number: "6759649826438453", expected: "Maestro"},
+		{number: "6200000000000005", expected: "UnionPay"},
+		{number: "7000000000000005", expected: ""},
+		{number: "", expected: ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.number, func(t *testing.T) {
+			assert.Equal(t, tc.expected, CardBrand(tc.number))
+		})
+	}
+}
+
+func TestValidateCard(t *testing.T) {
+	c := &pb.Card{
+		Number:       " 4111-1111-1111-1111 ",
+		SecurityCode: "123",
+		ExpireDate:   "2031-6",
+	}
+	err := ValidateCard(c)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Visa", c.Type)
+	assert.Equal(t, "4111111111111111", c.Number)
+	assert.Equal(t, "06/2031", c.ExpireDate)
+
+	t.Run("Keep type", func(t *testing.T) {
+		c := &pb.Card{Type: "Debit", Number: "4111111111111111"}
+		assert.NoError(t, ValidateCard(c))
+		assert.Equal(t, "Debit", c.Type)
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		assert.NoError(t, ValidateCard(&pb.Card{}))
+	})
+}
+
+func TestValidateCardUpdate(t *testing.T) {
+	old := &pb.Card{Number: "4111111111111112", SecurityCode: "12", ExpireDate: "June 2030"}
+
+	t.Run("Unchanged invalid fields", func(t *testing.T) {
+		c := &pb.Card{Type: "Debit", Number: old.Number, SecurityCode: old.SecurityCode, ExpireDate: old.ExpireDate}
+		assert.NoError(t, ValidateCardUpdate(old, c))
+		assert.Equal(t, old.Number, c.Number)
+		assert.Equal(t, "Debit", c.Type)
+	})
+
+	t.Run("Modified fields", func(t *testing.T) {
+		c := &pb.Card{Number: "4111 1111 1111 1111", SecurityCode: "123", ExpireDate: "2031-6"}
+		assert.NoError(t, ValidateCardUpdate(old, c))
+		assert.Equal(t, "4111111111111111", c.Number)
+		assert.Equal(t, "Visa", c.Type)
+		assert.Equal(t, "06/2031", c.ExpireDate)
+	})
+
+	t.Run("Invalid modified field", func(t *testing.T) {
+		c := &pb.Card{Number: old.Number, SecurityCode: old.SecurityCode, ExpireDate: "13/30"}
+		assert.Error(t, ValidateCardUpdate(old, c))
+	})
+
+	t.Run("Security code of the stored number", func(t *testing.T) {
+		visa := &pb.Card{Number: "4111111111111111"}
+		assert.Error(t, ValidateCardUpdate(visa, &pb.Card{Number: visa.Number, SecurityCode: "1234"}))
+		assert.NoError(t, ValidateCardUpdate(visa, &pb.Card{Number: visa.Number, SecurityCode: "123"}))
+
+		amex := &pb.Card{Number: "378282246310005"}
+		assert.Error(t, ValidateCardUpdate(amex, &pb.Card{Number: amex.Number, SecurityCode: "123"}))
+		assert.NoError(t, ValidateCardUpdate(amex, &pb.Card{Number: amex.Number, SecurityCode: "1234"}))
+	})
+
+	t.Run("Security code of a new number", func(t *testing.T) {
+		c := &pb.Card{Number: "378282246310005", SecurityCode: "123"}
+		assert.Error(t, ValidateCardUpdate(&pb.Card{Number: "4111111111111111", SecurityCode: "123"}, c))
+	})
+}
+
+func TestValidateCardErrors(t *testing.T) {
+	cases := []struct {
+		desc string
+		card *pb.Card
+	}{
+		{desc: "Letters", card: &pb.Card{Number: "4111a11111111111"}},
+		{desc: "Too short", card: &pb.Card{Number: "42"}},
+		{desc: "Brand length", card: &pb.Card{Number: "37828224631000"}},
+		{desc: "Checksum", card: &pb.Card{Number: "4111111111111112"}},
+		{desc: "Security code length", card: &pb.Card{Number: "378282246310005", SecurityCode: "123"}},
+		{desc: "Security code letters", card: &pb.Card{SecurityCode: "12a"}},
+		{desc: "Expire date month", card: &pb.Card{ExpireDate: "13/30"}},
+		{desc: "Expire date format", card: &pb.Card{ExpireDate: "June 2030"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			assert.Error(t, ValidateCard(tc.card))
+		})
+	}
+}
+
+func TestCardExpiry(t *testing.T) {
+	expected := time.Date(2031, time.July, 1, 0, 0, 0, 0, time.UTC)
+	for _, date := range []string{"06/31", "6/2031", "2031/06", "2031-06", "06-2031"} {
+		t.Run(date, func(t *testing.T) {
+			got, err := CardExpiry(date)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, got)
+		})
+	}
+
+	t.Run("December", func(t *testing.T) {
+		got, err := CardExpiry("12/30")
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2031, time.January, 1, 0, 0, 0, 0, time.UTC), got)
+	})
+}
+
+func TestCardExpiryStatus(t *testing.T) {
+	now := time.Date(2030, time.June, 10, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		date     string
+		expected string
+	}{
+		{date: "05/2030", expected: "expired"},
+		{date: "06/2030", expected: "expires in 21 days"},
+		{date: "07/2030", expected: ""},
+		{date: "", expected: ""},
+		{date: "invalid", expected: ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.date, func(t *testing.T) {
+			assert.Equal(t, tc.expected, CardExpiryStatus(tc.date, now))
+		})
+	}
+}
+
+func TestFmtCardNumber(t *testing.T) {
+	cases := []struct {
+		desc     string
+		number   string
+		show     bool
+		expected string
+	}{
+		{desc: "Visa", number: "4111111111111111", show: true, expected: "4111 1111 1111 1111"},
+		{desc: "Visa masked", number: "4111111111111111", expected: "•••• •••• •••• 1111"},
+		{desc: "Amex", number: "378282246310005", show: true, expected: "3782 822463 10005"},
+		{desc: "Amex masked", number: "378282246310005", expected: "•••• •••••• •0005"},
+		{desc: "Diners", number: "30569309025904", show: true, expected: "3056 930902 5904"},
+		{desc: "Unknown", number: "700000000000000512", show: true, expected: "7000 0000 0000 0005 12"},
+		{desc: "Empty", number: "", expected: ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			assert.Equal(t, tc.expected, FmtCardNumber(tc.number, tc.show))
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package credential
+
+import (
+	"os"
+
+	"github.com/GGP1/kure/commands/credential/git"
+	"github.com/GGP1/kure/commands/credential/process"
+
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+const example = `
+kure credential (git|process)`
+
+// NewCmd returns a new command.
+func NewCmd(db *bolt.DB) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "credential",
+		Short:   "Provide entries credentials to other programs",
+		Example: example,
+	}
+
+	cmd.AddCommand(
+		git.NewCmd(db, os.Stdin),
+		process.NewCmd(db),
+	)
+
+	return cmd
+}
@@ -0,0 +1,287 @@
+package git
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/db/entry"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+// storeDir is the directory where the credentials stored by git are saved, only those can be erased.
+const storeDir = "git/"
+
+const example = `
+* Use kure as the credential helper of every repository
+git config --global credential.helper "!kure credential git"
+
+* Consider the repository path when looking up entries
+git config --global credential.useHttpPath true
+
+* Look up a credential manually
+printf "protocol=https\nhost=github.com\n\n" | kure credential git get`
+
+// NewCmd returns a new command.
+func NewCmd(db *bolt.DB, r io.Reader) *cobra.Command {
+	return &cobra.Command{
+		Use:   "git <get|store|erase>",
+		Short: "Git credential helper",
+		Long: `Git credential helper, it implements the protocol described in gitcredentials(7).
+
+get: prints the username and password of the entry whose URL matches the protocol, host and path requested (the latter only if credential.useHttpPath is enabled). The username must match as well if git provides it. When several entries match, the one with the longest URL path is used.
+
+store: updates the password of the matching entry, or creates a new one inside the "git/" directory.
+
+erase: removes the matching entry only if it's inside the "git/" directory, entries created by hand are never removed.
+
+Unknown actions are ignored as the protocol requires.`,
+		Example: example,
+		Args:    cobra.ExactArgs(1),
+		RunE:    runGit(db, r),
+	}
+}
+
+func runGit(db *bolt.DB, r io.Reader) cmdutil.RunEFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		req, err := parseRequest(r)
+		if err != nil {
+			return err
+		}
+
+		switch args[0] {
+		case "get":
+			return get(db, cmd.OutOrStdout(), req)
+		case "store":
+			return store(db, req)
+		case "erase":
+			return erase(db, req)
+		}
+
+		return nil
+	}
+}
+
+// get writes the credentials of the entry matching the request.
+func get(db *bolt.DB, w io.Writer, req request) error {
+	e, err := lookup(db, req)
+	if err != nil || e == nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "username=%s\n", e.Username)
+	fmt.Fprintf(w, "password=%s\n", e.Password)
+	if expires, err := time.Parse(time.RFC1123Z, e.Expires); err == nil {
+		fmt.Fprintf(w, "password_expiry_utc=%d\n", expires.Unix())
+	}
+	return nil
+}
+
+// store saves the credentials approved by git.
+func store(db *bolt.DB, req request) error {
+	if req.host == "" || req.username == "" || req.password == "" {
+		return nil
+	}
+
+	expires := "Never"
+	if req.passwordExpiry != 0 {
+		expires = time.Unix(req.passwordExpiry, 0).Format(time.RFC1123Z)
+	}
+
+	e, err := lookup(db, req)
+	if err != nil {
+		return err
+	}
+	// Update the entry only if it's as specific as the request, credentials for a single
+	// repository must not overwrite the ones of the whole host
+	if e != nil && entryURL(e).path == req.path {
+		if e.Password == req.password && e.Expires == expires {
+			return nil
+		}
+		e.Password = req.password
+		e.Expires = expires
+		e.PasswordUpdatedAt = time.Now().Unix()
+		return entry.Update(db, e.Name, e)
+	}
+
+	name := storeDir + req.host
+	if req.path != "" {
+		name += "/" + req.path
+	}
+	name = cmdutil.NormalizeName(name + "/" + req.username)
+	return entry.Create(db, &pb.Entry{
+		Name:              name,
+		Username:          req.username,
+		Password:          req.password,
+		URL:               req.url(),
+		Expires:           expires,
+		PasswordUpdatedAt: time.Now().Unix(),
+	})
+}
+
+// erase removes the credentials rejected by git, if they were stored by it.
+func erase(db *bolt.DB, req request) error {
+	e, err := lookup(db, req)
+	if err != nil || e == nil {
+		return err
+	}
+
+	if !strings.HasPrefix(e.Name, storeDir) {
+		return nil
+	}
+	if req.password != "" && req.password != e.Password {
+		return nil
+	}
+
+	return entry.Remove(db, e.Name)
+}
+
+// lookup returns the entry that best matches the request or nil if there is none.
+func lookup(db *bolt.DB, req request) (*pb.Entry, error) {
+	if req.host == "" {
+		return nil, nil
+	}
+
+	entries, err := entry.List(db)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(entries, func(a, b *pb.Entry) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	var (
+		best      *pb.Entry
+		bestScore = -1
+	)
+	for _, e := range entries {
+		if score := req.match(e); score > bestScore {
+			best, bestScore = e, score
+		}
+	}
+
+	return best, nil
+}
+
+// request contains the attributes sent by git.
+type request struct {
+	protocol       string
+	host           string
+	path           string
+	username       string
+	password       string
+	passwordExpiry int64
+}
+
+// parseRequest reads "key=value" lines until a blank line or the end of the input.
+func parseRequest(r io.Reader) (request, error) {
+	var req request
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if line == "" {
+			break
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+
+		switch key {
+		case "protocol":
+			req.protocol = value
+		case "host":
+			req.host = strings.ToLower(value)
+		case "path":
+			req.path = strings.Trim(value, "/")
+		case "username":
+			req.username = value
+		case "password":
+			req.password = value
+		case "password_expiry_utc":
+			req.passwordExpiry, _ = strconv.ParseInt(value, 10, 64)
+		case "url":
+			u, err := url.Parse(value)
+			if err != nil {
+				return request{}, errors.Wrap(err, "parsing url")
+			}
+			req.protocol, req.host, req.path = u.Scheme, strings.ToLower(u.Host), strings.Trim(u.Path, "/")
+			if u.User != nil {
+				req.username = u.User.Username()
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return request{}, errors.Wrap(err, "reading request")
+	}
+
+	return req, nil
+}
+
+// match returns the length of the entry URL path if it matches the request, or -1 otherwise.
+//
+// Entries without a scheme match any protocol and those without a path match any path.
+func (req request) match(e *pb.Entry) int {
+	if e.URL == "" || (req.username != "" && e.Username != req.username) {
+		return -1
+	}
+
+	u := entryURL(e)
+	if u.scheme != "" && req.protocol != "" && u.scheme != req.protocol {
+		return -1
+	}
+	if u.host == "" || u.host != req.host {
+		return -1
+	}
+
+	if u.path == "" || req.path == "" {
+		return 0
+	}
+	if req.path != u.path && !strings.HasPrefix(req.path, u.path+"/") &&
+		strings.TrimSuffix(req.path, ".git") != u.path {
+		return -1
+	}
+	return len(u.path)
+}
+
+// url returns the URL of the request.
+func (req request) url() string {
+	u := url.URL{Scheme: req.protocol, Host: req.host}
+	if req.path != "" {
+		u.Path = "/" + req.path
+	}
+	return u.String()
+}
+
+type entryLocation struct {
+	scheme, host, path string
+}
+
+// entryURL parses the entry URL, which may not include the scheme.
+func entryURL(e *pb.Entry) entryLocation {
+	rawURL := strings.TrimSpace(e.URL)
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "//" + rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return entryLocation{}
+	}
+
+	return entryLocation{
+		scheme: u.Scheme,
+		host:   strings.ToLower(u.Host),
+		path:   strings.Trim(u.Path, "/"),
+	}
+}
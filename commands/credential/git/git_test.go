@@ -0,0 +1,159 @@
+package git
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/db/entry"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestGet(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	expires := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	err := entry.Create(db,
+		&pb.Entry{Name: "github", Username: "user", Password: "host", URL: "https://github.com", Expires: "Never"},
+		&pb.Entry{Name: "work/kure", Username: "user", Password: "repo", URL: "github.com/GGP1/kure", Expires: expires.Format(time.RFC1123Z)},
+		&pb.Entry{Name: "gitlab", Username: "other", Password: "gitlab", URL: "http://gitlab.com:8080/", Expires: "Never"},
+	)
+	assert.NoError(t, err)
+
+	cases := []struct {
+		desc     string
+		input    string
+		expected string
+	}{
+		{
+			desc:     "Host",
+			input:    "protocol=https\nhost=github.com\n\n",
+			expected: "username=user\npassword=host\n",
+		},
+		{
+			desc:     "Longest path",
+			input:    "protocol=https\nhost=github.com\npath=GGP1/kure.git\n",
+			expected: "username=user\npassword=repo\npassword_expiry_utc=1893553445\n",
+		},
+		{
+			desc:     "Other path",
+			input:    "protocol=https\nhost=github.com\npath=GGP1/atoll.git\n",
+			expected: "username=user\npassword=host\n",
+		},
+		{
+			desc:     "URL attribute",
+			input:    "url=http://gitlab.com:8080/repo.git\n",
+			expected: "username=other\npassword=gitlab\n",
+		},
+		{
+			desc:     "Protocol mismatch",
+			input:    "protocol=https\nhost=gitlab.com:8080\n",
+			expected: "",
+		},
+		{
+			desc:     "Username mismatch",
+			input:    "protocol=https\nhost=github.com\nusername=other\n",
+			expected: "",
+		},
+		{
+			desc:     "No match",
+			input:    "protocol=https\nhost=bitbucket.org\n",
+			expected: "",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := NewCmd(db, bytes.NewBufferString(tc.input))
+			cmd.SetOut(&out)
+			cmd.SetArgs([]string{"get"})
+
+			err := cmd.Execute()
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, out.String())
+		})
+	}
+}
+
+func TestStore(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	err := entry.Create(db, &pb.Entry{Name: "github", Username: "user", Password: "old", URL: "https://github.com", Expires: "Never"})
+	assert.NoError(t, err)
+
+	t.Run("Update", func(t *testing.T) {
+		execute(t, db, "store", "protocol=https\nhost=github.com\nusername=user\npassword=new\npassword_expiry_utc=1893553445\n")
+
+		got, err := entry.Get(db, "github")
+		assert.NoError(t, err)
+		assert.Equal(t, "new", got.Password)
+		expires, err := time.Parse(time.RFC1123Z, got.Expires)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1893553445), expires.Unix())
+		assert.NotZero(t, got.PasswordUpdatedAt)
+	})
+
+	t.Run("Create", func(t *testing.T) {
+		execute(t, db, "store", "protocol=https\nhost=gitlab.com\nusername=user\npassword=token\n")
+
+		got, err := entry.Get(db, "git/gitlab.com/user")
+		assert.NoError(t, err)
+		assert.Equal(t, "token", got.Password)
+		assert.Equal(t, "https://gitlab.com", got.URL)
+		assert.Equal(t, "Never", got.Expires)
+	})
+
+	t.Run("Repository", func(t *testing.T) {
+		execute(t, db, "store", "protocol=https\nhost=github.com\npath=GGP1/kure.git\nusername=user\npassword=repo\n")
+
+		got, err := entry.Get(db, "git/github.com/ggp1/kure.git/user")
+		assert.NoError(t, err)
+		assert.Equal(t, "https://github.com/GGP1/kure.git", got.URL)
+
+		// The host entry is not modified
+		host, err := entry.Get(db, "github")
+		assert.NoError(t, err)
+		assert.Equal(t, "new", host.Password)
+	})
+}
+
+func TestErase(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	err := entry.Create(db,
+		&pb.Entry{Name: "github", Username: "user", Password: "manual", URL: "https://github.com", Expires: "Never"},
+		&pb.Entry{Name: "git/gitlab.com/user", Username: "user", Password: "stored", URL: "https://gitlab.com", Expires: "Never"},
+	)
+	assert.NoError(t, err)
+
+	execute(t, db, "erase", "protocol=https\nhost=github.com\nusername=user\npassword=manual\n")
+	_, err = entry.Get(db, "github")
+	assert.NoError(t, err, "Entries outside the git directory must not be erased")
+
+	execute(t, db, "erase", "protocol=https\nhost=gitlab.com\nusername=user\npassword=other\n")
+	_, err = entry.Get(db, "git/gitlab.com/user")
+	assert.NoError(t, err, "Entries with a different password must not be erased")
+
+	execute(t, db, "erase", "protocol=https\nhost=gitlab.com\nusername=user\npassword=stored\n")
+	_, err = entry.Get(db, "git/gitlab.com/user")
+	assert.Error(t, err)
+}
+
+func TestUnknownAction(t *testing.T) {
+	db := cmdutil.SetContext(t)
+	execute(t, db, "unknown", "protocol=https\nhost=github.com\n")
+}
+
+func execute(t *testing.T, db *bolt.DB, action, input string) {
+	t.Helper()
+
+	cmd := NewCmd(db, bytes.NewBufferString(input))
+	cmd.SetArgs([]string{action})
+	err := cmd.Execute()
+	assert.NoError(t, err)
+}
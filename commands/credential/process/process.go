@@ -0,0 +1,123 @@
+package process
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/db/entry"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+const example = `
+* Print an entry credentials
+kure credential process Sample
+
+* Use an entry as the AWS CLI credentials, add this to ~/.aws/config
+[profile kure]
+credential_process = kure credential process aws/access-key --format aws`
+
+type processOptions struct {
+	format string
+}
+
+// NewCmd returns a new command.
+func NewCmd(db *bolt.DB) *cobra.Command {
+	opts := processOptions{}
+	cmd := &cobra.Command{
+		Use:   "process <name>",
+		Short: "Print an entry as credential process output",
+		Long: `Print an entry username, password and expiration as JSON, for tools that read credentials from an external process.
+
+Formats:
+• generic: {"key": "<username>", "secret": "<password>", "expiry": "<RFC 3339 date>"}
+• aws: AWS CLI credential_process output, the username is the access key ID and the password the secret access key
+
+The expiry is omitted if the entry never expires.`,
+		Example: example,
+		Args:    cmdutil.MustExist(db, cmdutil.Entry),
+		RunE:    runProcess(db, &opts),
+		PostRun: func(cmd *cobra.Command, args []string) {
+			// Reset variables (session)
+			opts = processOptions{
+				format: "generic",
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.format, "format", "f", "generic", "output format (generic, aws)")
+
+	return cmd
+}
+
+func runProcess(db *bolt.DB, opts *processOptions) cmdutil.RunEFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		name := strings.Join(args, " ")
+		name = cmdutil.NormalizeName(name)
+
+		e, err := entry.Get(db, name)
+		if err != nil {
+			return err
+		}
+
+		var output any
+		switch opts.format {
+		case "generic":
+			output = genericCredential(e)
+		case "aws":
+			output = awsCredential(e)
+		default:
+			return errors.Errorf("invalid format %q, use generic or aws", opts.format)
+		}
+
+		if err := json.NewEncoder(cmd.OutOrStdout()).Encode(output); err != nil {
+			return errors.Wrap(err, "encoding credentials")
+		}
+		return nil
+	}
+}
+
+type generic struct {
+	Key    string `json:"key"`
+	Secret string `json:"secret"`
+	Expiry string `json:"expiry,omitempty"`
+}
+
+func genericCredential(e *pb.Entry) generic {
+	return generic{
+		Key:    e.Username,
+		Secret: e.Password,
+		Expiry: expiry(e),
+	}
+}
+
+// aws is the output expected by the AWS CLI credential_process setting.
+type aws struct {
+	Version         int    `json:"Version"`
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string `json:"SecretAccessKey"`
+	Expiration      string `json:"Expiration,omitempty"`
+}
+
+func awsCredential(e *pb.Entry) aws {
+	return aws{
+		Version:         1,
+		AccessKeyID:     e.Username,
+		SecretAccessKey: e.Password,
+		Expiration:      expiry(e),
+	}
+}
+
+// expiry returns the entry expiration in the RFC 3339 format, or an empty string if it never expires.
+func expiry(e *pb.Entry) string {
+	expires, err := time.Parse(time.RFC1123Z, e.Expires)
+	if err != nil {
+		return ""
+	}
+	return expires.UTC().Format(time.RFC3339)
+}
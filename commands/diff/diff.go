@@ -0,0 +1,346 @@
+package diff
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/GGP1/kure/auth"
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/commands/backup/snapshot"
+	dbutil "github.com/GGP1/kure/db"
+	"github.com/GGP1/kure/db/bank"
+	"github.com/GGP1/kure/db/bucket"
+	"github.com/GGP1/kure/db/card"
+	"github.com/GGP1/kure/db/entry"
+	"github.com/GGP1/kure/db/file"
+	"github.com/GGP1/kure/db/identity"
+	"github.com/GGP1/kure/db/note"
+	"github.com/GGP1/kure/db/sshkey"
+	"github.com/GGP1/kure/db/totp"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const example = `
+* Compare the database with a backup
+kure diff path/to/backup.db
+
+* Compare the database with a snapshot
+kure diff 20261019T150405Z
+
+* Compare with a database using other credentials and show secrets
+kure diff path/to/other.db --password --show`
+
+const mask = "••••••••"
+
+// recordType contains the information needed to list and compare the records of a bucket.
+type recordType struct {
+	name   string
+	bucket []byte
+	list   func(db *bolt.DB) ([]dbutil.Record, error)
+	// Fields that are masked unless --show is used
+	secrets []string
+}
+
+var recordTypes = []recordType{
+	{
+		name:    "bank",
+		bucket:  bucket.Bank.GetName(),
+		list:    listFunc(bank.List),
+		secrets: []string{"account_number", "routing_number", "iban", "pin"},
+	},
+	{
+		name:    "card",
+		bucket:  bucket.Card.GetName(),
+		list:    listFunc(card.List),
+		secrets: []string{"number", "security_code"},
+	},
+	{
+		name:    "entry",
+		bucket:  bucket.Entry.GetName(),
+		list:    listFunc(entry.List),
+		secrets: []string{"password", "previous_password"},
+	},
+	{
+		name:   "file",
+		bucket: bucket.File.GetName(),
+		list:   listFunc(file.List),
+	},
+	{
+		name:    "identity",
+		bucket:  bucket.Identity.GetName(),
+		list:    listFunc(identity.List),
+		secrets: []string{"number"},
+	},
+	{
+		name:    "note",
+		bucket:  bucket.Note.GetName(),
+		list:    listFunc(note.List),
+		secrets: []string{"text"},
+	},
+	{
+		name:    "ssh",
+		bucket:  bucket.SSH.GetName(),
+		list:    listFunc(sshkey.List),
+		secrets: []string{"private_key", "passphrase"},
+	},
+	{
+		name:    "totp",
+		bucket:  bucket.TOTP.GetName(),
+		list:    listFunc(totp.List),
+		secrets: []string{"raw"},
+	},
+}
+
+type diffOptions struct {
+	password bool
+	show     bool
+}
+
+// recordDiff is a record that differs between both databases.
+type recordDiff struct {
+	name string
+	// '+' added, '-' removed, '~' changed
+	op      byte
+	changes []fieldChange
+}
+
+type fieldChange struct {
+	field, old, new string
+}
+
+// NewCmd returns a new command.
+func NewCmd(db *bolt.DB, r io.Reader) *cobra.Command {
+	opts := diffOptions{}
+	cmd := &cobra.Command{
+		Use:   "diff <database|snapshot>",
+		Short: "Compare the database with another one or a snapshot",
+		Long: `Compare the database with another one or a snapshot.
+
+Records are reported as added (+) if they exist only in the other database, removed (-) if they exist only in the current one and changed (~) otherwise, with the fields that differ. Files are compared by size and SHA-256 checksum.
+
+The other database is decrypted with the current credentials, use --password to enter its own master password. Secret fields are masked unless --show is used.`,
+		Example: example,
+		Args:    cobra.ExactArgs(1),
+		RunE:    runDiff(db, r, &opts),
+		PostRun: func(cmd *cobra.Command, args []string) {
+			// Reset variables (session)
+			opts = diffOptions{}
+		},
+	}
+
+	f := cmd.Flags()
+	f.BoolVarP(&opts.password, "password", "p", false, "ask for the master password of the other database")
+	f.BoolVarP(&opts.show, "show", "s", false, "show secret fields")
+
+	return cmd
+}
+
+func runDiff(db *bolt.DB, r io.Reader, opts *diffOptions) cmdutil.RunEFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		other, closeOther, err := open(args[0])
+		if err != nil {
+			return err
+		}
+		defer closeOther()
+
+		current, err := load(db)
+		if err != nil {
+			return err
+		}
+
+		restore, err := auth.Switch(other, r, opts.password)
+		if err != nil {
+			return errors.Wrap(err, "other database")
+		}
+		otherRecords, err := load(other)
+		restore()
+		if err != nil {
+			return errors.Wrap(err, "other database")
+		}
+
+		printDiff(cmd.OutOrStdout(), current, otherRecords, opts.show)
+		return nil
+	}
+}
+
+// open opens the database file or snapshot in read-only mode.
+func open(name string) (*bolt.DB, func(), error) {
+	if _, err := os.Stat(name); err == nil {
+		db, err := bolt.Open(name, 0o600, &bolt.Options{ReadOnly: true, Timeout: time.Second})
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "opening %q", name)
+		}
+		return db, func() { db.Close() }, nil
+	}
+
+	s, err := snapshot.Get(snapshot.GetConfig().Dir, name)
+	if err != nil {
+		return nil, nil, errors.Errorf("%q is neither a database file nor a snapshot", name)
+	}
+
+	data, err := snapshot.Load(s)
+	if err != nil {
+		return nil, nil, err
+	}
+	return snapshot.Open(data)
+}
+
+// load returns the records of each type mapped by name.
+func load(db *bolt.DB) ([]map[string]dbutil.Record, error) {
+	records := make([]map[string]dbutil.Record, len(recordTypes))
+	for i, rt := range recordTypes {
+		records[i] = make(map[string]dbutil.Record)
+
+		// Databases created with older versions may not have all the buckets
+		exists := false
+		_ = db.View(func(tx *bolt.Tx) error {
+			exists = tx.Bucket(rt.bucket) != nil
+			return nil
+		})
+		if !exists {
+			continue
+		}
+
+		list, err := rt.list(db)
+		if err != nil {
+			return nil, errors.Wrapf(err, "listing %s records", rt.name)
+		}
+		for _, r := range list {
+			records[i][r.GetName()] = r
+		}
+	}
+	return records, nil
+}
+
+// printDiff writes the differences between both databases.
+func printDiff(w io.Writer, current, other []map[string]dbutil.Record, show bool) {
+	var added, removed, changed int
+	for i, rt := range recordTypes {
+		diffs := compareRecords(rt, current[i], other[i], show)
+		if len(diffs) == 0 {
+			continue
+		}
+
+		fmt.Fprintln(w, rt.name)
+		for _, d := range diffs {
+			fmt.Fprintf(w, "  %c %s\n", d.op, d.name)
+			for _, c := range d.changes {
+				fmt.Fprintf(w, "      %s: %s → %s\n", c.field, c.old, c.new)
+			}
+
+			switch d.op {
+			case '+':
+				added++
+			case '-':
+				removed++
+			default:
+				changed++
+			}
+		}
+	}
+
+	if added+removed+changed == 0 {
+		fmt.Fprintln(w, "No differences found")
+		return
+	}
+	fmt.Fprintf(w, "\n%d added, %d removed, %d changed\n", added, removed, changed)
+}
+
+// compareRecords returns the records that differ sorted by name.
+func compareRecords(rt recordType, current, other map[string]dbutil.Record, show bool) []recordDiff {
+	var diffs []recordDiff
+	for name, c := range current {
+		o, ok := other[name]
+		if !ok {
+			diffs = append(diffs, recordDiff{name: name, op: '-'})
+			continue
+		}
+		if proto.Equal(c, o) {
+			continue
+		}
+		diffs = append(diffs, recordDiff{name: name, op: '~', changes: compareFields(rt, c, o, show)})
+	}
+	for name := range other {
+		if _, ok := current[name]; !ok {
+			diffs = append(diffs, recordDiff{name: name, op: '+'})
+		}
+	}
+
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].name < diffs[j].name
+	})
+	return diffs
+}
+
+// compareFields returns the fields that differ between two records of the same type.
+func compareFields(rt recordType, current, other dbutil.Record, show bool) []fieldChange {
+	a, b := current.ProtoReflect(), other.ProtoReflect()
+	fields := a.Descriptor().Fields()
+
+	var changes []fieldChange
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		va, vb := a.Get(fd), b.Get(fd)
+		if va.Equal(vb) {
+			continue
+		}
+
+		name := string(fd.Name())
+		secret := !show && slices.Contains(rt.secrets, name)
+		changes = append(changes, fieldChange{
+			field: strings.ToLower(name),
+			old:   formatValue(fd, va, secret),
+			new:   formatValue(fd, vb, secret),
+		})
+	}
+	return changes
+}
+
+// formatValue returns the field value as a string. Bytes are represented by their checksum and
+// timestamps in a readable format.
+func formatValue(fd protoreflect.FieldDescriptor, v protoreflect.Value, secret bool) string {
+	switch {
+	case secret:
+		return mask
+	case fd.Kind() == protoreflect.BytesKind:
+		sum := sha256.Sum256(v.Bytes())
+		return "sha256:" + hex.EncodeToString(sum[:8])
+	case fd.Kind() == protoreflect.StringKind:
+		return fmt.Sprintf("%q", v.String())
+	case strings.HasSuffix(string(fd.Name()), "_at"):
+		if v.Int() == 0 {
+			return "never"
+		}
+		return time.Unix(v.Int(), 0).Format(time.DateTime)
+	default:
+		return v.String()
+	}
+}
+
+// listFunc adapts the list functions of each record type.
+func listFunc[R dbutil.Record](list func(*bolt.DB) ([]R, error)) func(*bolt.DB) ([]dbutil.Record, error) {
+	return func(db *bolt.DB) ([]dbutil.Record, error) {
+		records, err := list(db)
+		if err != nil {
+			return nil, err
+		}
+
+		result := make([]dbutil.Record, len(records))
+		for i, r := range records {
+			result[i] = r
+		}
+		return result, nil
+	}
+}
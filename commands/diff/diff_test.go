@@ -0,0 +1,139 @@
+package diff
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/commands/backup/snapshot"
+	"github.com/GGP1/kure/config"
+	"github.com/GGP1/kure/db/auth"
+	"github.com/GGP1/kure/db/entry"
+	"github.com/GGP1/kure/db/file"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestDiff(t *testing.T) {
+	db, otherPath := setContext(t, func(db, other *bolt.DB) {
+		err := entry.Create(db,
+			&pb.Entry{Name: "github", Username: "user", Password: "old", Expires: "Never"},
+			&pb.Entry{Name: "gitlab", Password: "1", Expires: "Never"},
+		)
+		assert.NoError(t, err)
+		err = entry.Create(other,
+			&pb.Entry{Name: "github", Username: "user2", Password: "new", Expires: "Never"},
+			&pb.Entry{Name: "aws", Password: "2", Expires: "Never"},
+		)
+		assert.NoError(t, err)
+
+		assert.NoError(t, file.Create(db, &pb.File{Name: "notes.txt", Content: []byte("a"), Size: 1}))
+		assert.NoError(t, file.Create(other, &pb.File{Name: "notes.txt", Content: []byte("b"), Size: 1}))
+	})
+
+	t.Run("Masked", func(t *testing.T) {
+		out := execute(t, db, otherPath)
+		expected := `entry
+  + aws
+  ~ github
+      username: "user" → "user2"
+      password: •••••••• → ••••••••
+  - gitlab
+file
+  ~ notes.txt
+      content: sha256:ca978112ca1bbdca → sha256:3e23e8160039594a
+
+1 added, 1 removed, 2 changed
+`
+		assert.Equal(t, expected, out)
+	})
+
+	t.Run("Show", func(t *testing.T) {
+		out := execute(t, db, otherPath, "--show")
+		assert.Contains(t, out, `password: "old" → "new"`)
+	})
+}
+
+func TestDiffIdentical(t *testing.T) {
+	db, otherPath := setContext(t, func(db, other *bolt.DB) {
+		e := &pb.Entry{Name: "github", Password: "1", Expires: "Never"}
+		assert.NoError(t, entry.Create(db, e))
+		assert.NoError(t, entry.Create(other, e))
+	})
+
+	out := execute(t, db, otherPath)
+	assert.Equal(t, "No differences found\n", out)
+}
+
+func TestDiffSnapshot(t *testing.T) {
+	db, _ := setContext(t, func(db, other *bolt.DB) {
+		assert.NoError(t, entry.Create(db, &pb.Entry{Name: "github", Password: "1", Expires: "Never"}))
+	})
+	config.Set("backup.snapshots.dir", t.TempDir())
+
+	s, err := snapshot.Take(db, snapshot.GetConfig().Dir, time.Now())
+	assert.NoError(t, err)
+	assert.NoError(t, entry.Remove(db, "github"))
+
+	out := execute(t, db, s.String())
+	assert.Contains(t, out, "entry\n  + github\n")
+}
+
+func TestDiffErrors(t *testing.T) {
+	db := cmdutil.SetContext(t)
+	config.Set("backup.snapshots.dir", t.TempDir())
+
+	// Database without credentials
+	path := filepath.Join(t.TempDir(), "other.db")
+	other, err := bolt.Open(path, 0o600, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, other.Close())
+
+	cases := []string{"non-existent", path}
+	for _, name := range cases {
+		t.Run(name, func(t *testing.T) {
+			cmd := NewCmd(db, nil)
+			cmd.SetArgs([]string{name})
+			assert.Error(t, cmd.Execute())
+		})
+	}
+}
+
+func TestPostRun(t *testing.T) {
+	NewCmd(nil, nil).PostRun(nil, nil)
+}
+
+// setContext returns the current database and the path to another one registered with the same
+// credentials, setup is called to populate them before the other one is closed.
+func setContext(t *testing.T, setup func(db, other *bolt.DB)) (*bolt.DB, string) {
+	db := cmdutil.SetContext(t)
+	registerAuth(t, db)
+
+	path := filepath.Join(t.TempDir(), "other.db")
+	other, err := bolt.Open(path, 0o600, nil)
+	assert.NoError(t, err)
+	registerAuth(t, other)
+
+	setup(db, other)
+	assert.NoError(t, other.Close())
+	return db, path
+}
+
+func registerAuth(t *testing.T, db *bolt.DB) {
+	params := auth.Params{Argon2: auth.Argon2{Iterations: 1, Memory: 1, Threads: 1}}
+	err := auth.Register(db, config.Get("auth.key").([]byte), params)
+	assert.NoError(t, err)
+}
+
+func execute(t *testing.T, db *bolt.DB, args ...string) string {
+	var out bytes.Buffer
+	cmd := NewCmd(db, nil)
+	cmd.SetOut(&out)
+	cmd.SetArgs(args)
+	assert.NoError(t, cmd.Execute())
+	return out.String()
+}
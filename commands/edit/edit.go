@@ -19,6 +19,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	bolt "go.etcd.io/bbolt"
+	"google.golang.org/protobuf/proto"
 )
 
 const example = `
@@ -119,6 +120,20 @@ func readTmpFile(filename string) (*pb.Entry, error) {
 	return &e, nil
 }
 
+// trackPasswordUpdate sets the time the password was last modified and keeps the previous password,
+// the values the user may have typed in the text editor are discarded.
+//
+// Editing the password doesn't finish a pending rotation, the previous password is still the one set
+// on the site until the rotation is confirmed.
+func trackPasswordUpdate(oldEntry, newEntry *pb.Entry) {
+	newEntry.PreviousPassword = oldEntry.PreviousPassword
+	if newEntry.Password != oldEntry.Password {
+		newEntry.PasswordUpdatedAt = time.Now().Unix()
+		return
+	}
+	newEntry.PasswordUpdatedAt = oldEntry.PasswordUpdatedAt
+}
+
 // updateEntry takes the name of the entry that's being edited to check if the name was
 // changed. If it was, it will remove the old one.
 func updateEntry(db *bolt.DB, name string, e *pb.Entry) error {
@@ -146,6 +161,17 @@ func updateEntry(db *bolt.DB, name string, e *pb.Entry) error {
 
 func useStdin(db *bolt.DB, r io.Reader, oldEntry *pb.Entry) error {
 	fmt.Println("Type '-' to clear the field (except Name and Password) or leave blank to use the current value")
+
+	newEntry, err := scanEntry(r, oldEntry, readPassword)
+	if err != nil {
+		return err
+	}
+
+	return updateEntry(db, oldEntry.Name, newEntry)
+}
+
+// scanEntry reads the new values of the entry fields, readPassword is used to read the password.
+func scanEntry(r io.Reader, oldEntry *pb.Entry, readPassword func(current string) (string, error)) (*pb.Entry, error) {
 	reader := bufio.NewReader(r)
 
 	scanln := func(field, value string) string {
@@ -158,26 +184,16 @@ func useStdin(db *bolt.DB, r io.Reader, oldEntry *pb.Entry) error {
 		return value
 	}
 
-	newEntry := &pb.Entry{}
+	// Start from a copy so the fields that aren't prompted are kept
+	newEntry := proto.Clone(oldEntry).(*pb.Entry)
 	newEntry.Name = scanln("Name", oldEntry.Name)
 	newEntry.Username = scanln("Username", oldEntry.Username)
 
-	enclave, err := terminal.ScanPassword("Password", true)
+	password, err := readPassword(oldEntry.Password)
 	if err != nil {
-		if err == terminal.ErrInvalidPassword {
-			// Assume the user typed an empty string to not modify the password
-			newEntry.Password = oldEntry.Password
-		} else {
-			return err
-		}
-	} else {
-		pwd, err := enclave.Open()
-		if err != nil {
-			return errors.Wrap(err, "opening enclave")
-		}
-
-		newEntry.Password = pwd.String()
+		return nil, err
 	}
+	newEntry.Password = password
 
 	newEntry.URL = scanln("URL", oldEntry.URL)
 	newEntry.Expires = scanln("Expires", oldEntry.Expires)
@@ -189,8 +205,27 @@ func useStdin(db *bolt.DB, r io.Reader, oldEntry *pb.Entry) error {
 		notes = ""
 	}
 	newEntry.Notes = notes
+	trackPasswordUpdate(oldEntry, newEntry)
 
-	return updateEntry(db, oldEntry.Name, newEntry)
+	return newEntry, nil
+}
+
+// readPassword asks for the new password, the current one is kept if the user types an empty string.
+func readPassword(current string) (string, error) {
+	enclave, err := terminal.ScanPassword("Password", true)
+	if err != nil {
+		if err == terminal.ErrInvalidPassword {
+			return current, nil
+		}
+		return "", err
+	}
+
+	pwd, err := enclave.Open()
+	if err != nil {
+		return "", errors.Wrap(err, "opening enclave")
+	}
+
+	return pwd.String(), nil
 }
 
 func useTextEditor(db *bolt.DB, oldEntry *pb.Entry) error {
@@ -245,6 +280,7 @@ func useTextEditor(db *bolt.DB, oldEntry *pb.Entry) error {
 	newEntry.Username = rmTabs(newEntry.Username)
 	newEntry.URL = rmTabs(newEntry.URL)
 	newEntry.Notes = rmTabs(newEntry.Notes)
+	trackPasswordUpdate(oldEntry, newEntry)
 
 	return updateEntry(db, oldEntry.Name, newEntry)
 }
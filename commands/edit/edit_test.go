@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"strconv"
+	"strings"
 	"testing"
 
 	cmdutil "github.com/GGP1/kure/commands"
@@ -140,6 +141,73 @@ func TestUpdateEntry(t *testing.T) {
 	})
 }
 
+func TestScanEntry(t *testing.T) {
+	oldEntry := &pb.Entry{
+		Name:              "test",
+		Username:          "kure",
+		Password:          "new",
+		PreviousPassword:  "old",
+		PasswordUpdatedAt: 1700000000,
+		Expires:           "Never",
+	}
+
+	cases := []struct {
+		desc     string
+		password string
+	}{
+		{desc: "Unchanged password", password: "new"},
+		{desc: "Changed password", password: "newer"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			r := strings.NewReader("\nadmin\n\n\n<")
+			newEntry, err := scanEntry(r, oldEntry, func(string) (string, error) { return tc.password, nil })
+			assert.NoError(t, err)
+
+			assert.Equal(t, "admin", newEntry.Username)
+			assert.Equal(t, tc.password, newEntry.Password)
+			// The pending rotation is kept
+			assert.Equal(t, "old", newEntry.PreviousPassword)
+		})
+	}
+}
+
+func TestScanEntryProfile(t *testing.T) {
+	oldEntry := &pb.Entry{
+		Name:     "test",
+		Password: "secret",
+		Expires:  "Never",
+		Profile:  "bank",
+	}
+
+	r := strings.NewReader("\n\nhttps://kure.sh\n\n<")
+	newEntry, err := scanEntry(r, oldEntry, func(current string) (string, error) { return current, nil })
+	assert.NoError(t, err)
+
+	assert.Equal(t, "https://kure.sh", newEntry.URL)
+	assert.Equal(t, "bank", newEntry.Profile)
+	assert.Equal(t, "", oldEntry.URL, "The old entry must not be modified")
+}
+
+func TestTrackPasswordUpdate(t *testing.T) {
+	oldEntry := &pb.Entry{Password: "new", PreviousPassword: "old", PasswordUpdatedAt: 1700000000}
+
+	t.Run("Unchanged password", func(t *testing.T) {
+		newEntry := &pb.Entry{Password: "new", PreviousPassword: "typed", PasswordUpdatedAt: 1}
+		trackPasswordUpdate(oldEntry, newEntry)
+		assert.Equal(t, "old", newEntry.PreviousPassword)
+		assert.Equal(t, oldEntry.PasswordUpdatedAt, newEntry.PasswordUpdatedAt)
+	})
+
+	t.Run("Changed password", func(t *testing.T) {
+		newEntry := &pb.Entry{Password: "newer"}
+		trackPasswordUpdate(oldEntry, newEntry)
+		assert.Equal(t, "old", newEntry.PreviousPassword)
+		assert.Greater(t, newEntry.PasswordUpdatedAt, oldEntry.PasswordUpdatedAt)
+	})
+}
+
 func TestPostRun(t *testing.T) {
 	NewCmd(nil).PostRun(nil, nil)
 }
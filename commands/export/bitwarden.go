@@ -0,0 +1,293 @@
+package export
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/GGP1/kure/bitwarden"
+	"github.com/GGP1/kure/db/bank"
+	"github.com/GGP1/kure/db/card"
+	"github.com/GGP1/kure/db/entry"
+	"github.com/GGP1/kure/db/file"
+	"github.com/GGP1/kure/db/identity"
+	"github.com/GGP1/kure/db/note"
+	"github.com/GGP1/kure/db/sshkey"
+	"github.com/GGP1/kure/db/totp"
+	"github.com/GGP1/kure/pb"
+	"github.com/GGP1/kure/sshagent"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+	"golang.org/x/crypto/ssh"
+)
+
+func exportBitwarden(db *bolt.DB, w io.Writer) error {
+	export, err := bitwardenExport(db)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(export); err != nil {
+		return errors.Wrap(err, "encoding JSON")
+	}
+
+	return nil
+}
+
+// bitwardenExport maps kure records to a Bitwarden unencrypted JSON export.
+//
+// Directories are mapped to folders, entries to logins (including their TOTP), cards to cards, identities
+// to identities and notes, bank accounts and text files to secure notes. Binary files are skipped as Bitwarden
+// does not support attachments in exports.
+func bitwardenExport(db *bolt.DB) (*bitwarden.Export, error) {
+	entries, err := entry.List(db)
+	if err != nil {
+		return nil, err
+	}
+	cards, err := card.List(db)
+	if err != nil {
+		return nil, err
+	}
+	totps, err := totp.List(db)
+	if err != nil {
+		return nil, err
+	}
+	files, err := file.List(db)
+	if err != nil {
+		return nil, err
+	}
+	identities, err := identity.List(db)
+	if err != nil {
+		return nil, err
+	}
+	banks, err := bank.List(db)
+	if err != nil {
+		return nil, err
+	}
+	notes, err := note.List(db)
+	if err != nil {
+		return nil, err
+	}
+	sshKeys, err := sshkey.List(db)
+	if err != nil {
+		return nil, err
+	}
+
+	size := len(entries) + len(cards) + len(files) + len(identities) + len(banks) + len(notes) + len(sshKeys)
+	export := &bitwarden.Export{
+		Folders: make([]bitwarden.Folder, 0),
+		Items:   make([]bitwarden.Item, 0, size),
+	}
+	folders := make(map[string]string)
+	newItem := func(name string, itemType int) bitwarden.Item {
+		dir, title := splitName(name)
+		item := bitwarden.Item{ID: newUUID(), Type: itemType, Name: title}
+		if dir != "" {
+			id, ok := folders[dir]
+			if !ok {
+				id = newUUID()
+				folders[dir] = id
+				export.Folders = append(export.Folders, bitwarden.Folder{ID: id, Name: dir})
+			}
+			item.FolderID = id
+		}
+		return item
+	}
+
+	totpsByName := make(map[string]string, len(totps))
+	for _, t := range totps {
+		_, title := splitName(t.Name)
+		totpsByName[t.Name] = otpURI(title, t)
+	}
+
+	for _, e := range entries {
+		item := newItem(e.Name, bitwarden.LoginType)
+		item.Notes = e.Notes
+		if e.PasswordUpdatedAt > 0 {
+			item.RevisionDate = time.Unix(e.PasswordUpdatedAt, 0).UTC().Format(time.RFC3339)
+		}
+		item.Login = &bitwarden.Login{
+			Username: e.Username,
+			Password: e.Password,
+			TOTP:     totpsByName[e.Name],
+		}
+		if e.URL != "" {
+			item.Login.URIs = []bitwarden.URI{{URI: e.URL}}
+		}
+		delete(totpsByName, e.Name)
+		export.Items = append(export.Items, item)
+	}
+
+	// TOTPs without an entry
+	for _, t := range totps {
+		uri, ok := totpsByName[t.Name]
+		if !ok {
+			continue
+		}
+		item := newItem(t.Name, bitwarden.LoginType)
+		item.Login = &bitwarden.Login{TOTP: uri}
+		export.Items = append(export.Items, item)
+	}
+
+	for _, c := range cards {
+		item := newItem(c.Name, bitwarden.CardType)
+		item.Notes = c.Notes
+		item.Card = &bitwarden.Card{
+			Brand:  c.Type,
+			Number: c.Number,
+			Code:   c.SecurityCode,
+		}
+		if month, year, ok := splitExpireDate(c.ExpireDate); ok {
+			item.Card.ExpMonth = month
+			item.Card.ExpYear = year
+		} else if c.ExpireDate != "" {
+			item.Fields = []bitwarden.Field{{Name: "Expire date", Value: c.ExpireDate, Type: bitwarden.TextField}}
+		}
+		export.Items = append(export.Items, item)
+	}
+
+	for _, f := range files {
+		if !utf8.Valid(f.Content) {
+			fmt.Fprintf(os.Stderr, "Skipping %q: binary files are not supported\n", f.Name)
+			continue
+		}
+		item := newItem(f.Name, bitwarden.SecureNoteType)
+		item.Notes = string(f.Content)
+		item.SecureNote = &bitwarden.SecureNote{}
+		export.Items = append(export.Items, item)
+	}
+
+	for _, i := range identities {
+		item := newItem(i.Name, bitwarden.IdentityType)
+		item.Notes = i.Notes
+		item.Identity, item.Fields = bitwardenIdentity(i)
+		export.Items = append(export.Items, item)
+	}
+
+	for _, b := range banks {
+		item := newItem(b.Name, bitwarden.SecureNoteType)
+		item.Notes = b.Notes
+		item.SecureNote = &bitwarden.SecureNote{}
+		item.Fields = bitwardenFields(
+			bitwarden.Field{Name: "Bank", Value: b.Bank, Type: bitwarden.TextField},
+			bitwarden.Field{Name: "Holder", Value: b.Holder, Type: bitwarden.TextField},
+			bitwarden.Field{Name: "Account number", Value: b.AccountNumber, Type: bitwarden.HiddenField},
+			bitwarden.Field{Name: "Routing number", Value: b.RoutingNumber, Type: bitwarden.TextField},
+			bitwarden.Field{Name: "IBAN", Value: b.Iban, Type: bitwarden.HiddenField},
+			bitwarden.Field{Name: "SWIFT/BIC", Value: b.Swift, Type: bitwarden.TextField},
+			bitwarden.Field{Name: "PIN", Value: b.Pin, Type: bitwarden.HiddenField},
+		)
+		export.Items = append(export.Items, item)
+	}
+
+	for _, n := range notes {
+		item := newItem(n.Name, bitwarden.SecureNoteType)
+		item.Notes = n.Text
+		item.SecureNote = &bitwarden.SecureNote{}
+		export.Items = append(export.Items, item)
+	}
+
+	for _, k := range sshKeys {
+		sshKey, err := bitwardenSSHKey(k)
+		if err != nil {
+			return nil, err
+		}
+		item := newItem(k.Name, bitwarden.SSHKeyType)
+		item.SSHKey = sshKey
+		export.Items = append(export.Items, item)
+	}
+
+	return export, nil
+}
+
+// bitwardenSSHKey converts the private key to the unencrypted OpenSSH format, the only one supported by Bitwarden.
+func bitwardenSSHKey(k *pb.SSHKey) (*bitwarden.SSHKey, error) {
+	key, err := sshagent.ParseKey(k.PrivateKey, k.Passphrase)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%q", k.Name)
+	}
+
+	block, err := ssh.MarshalPrivateKey(key, k.Comment)
+	if err != nil {
+		return nil, errors.Wrapf(err, "encoding %q private key", k.Name)
+	}
+
+	return &bitwarden.SSHKey{
+		PrivateKey:     string(pem.EncodeToMemory(block)),
+		PublicKey:      k.PublicKey,
+		KeyFingerprint: k.Fingerprint,
+	}, nil
+}
+
+// bitwardenIdentity maps the identity full name and number to the Bitwarden identity fields, those
+// without an equivalent are returned as custom fields.
+func bitwardenIdentity(i *pb.Identity) (*bitwarden.Identity, []bitwarden.Field) {
+	id := &bitwarden.Identity{}
+	if first, last, found := strings.Cut(strings.TrimSpace(i.FullName), " "); found {
+		id.FirstName, id.LastName = first, strings.TrimSpace(last)
+	} else {
+		id.FirstName = first
+	}
+
+	number := bitwarden.Field{Name: "Number", Value: i.Number, Type: bitwarden.HiddenField}
+	switch strings.ToLower(i.Type) {
+	case "passport":
+		id.PassportNumber, number.Value = i.Number, ""
+	case "driver license", "driver's license", "license":
+		id.LicenseNumber, number.Value = i.Number, ""
+	case "ssn", "social security number":
+		id.SSN, number.Value = i.Number, ""
+	}
+
+	fields := bitwardenFields(
+		bitwarden.Field{Name: "Type", Value: i.Type, Type: bitwarden.TextField},
+		number,
+		bitwarden.Field{Name: "Issuer", Value: i.Issuer, Type: bitwarden.TextField},
+		bitwarden.Field{Name: "Issue date", Value: i.IssueDate, Type: bitwarden.TextField},
+		bitwarden.Field{Name: "Expire date", Value: i.ExpireDate, Type: bitwarden.TextField},
+	)
+	return id, fields
+}
+
+// bitwardenFields returns the fields that have a value.
+func bitwardenFields(fields ...bitwarden.Field) []bitwarden.Field {
+	var nonEmpty []bitwarden.Field
+	for _, f := range fields {
+		if f.Value != "" {
+			nonEmpty = append(nonEmpty, f)
+		}
+	}
+	return nonEmpty
+}
+
+// splitExpireDate splits a "MM/YY" or "MM/YYYY" date into month and year.
+func splitExpireDate(date string) (month, year string, ok bool) {
+	month, year, found := strings.Cut(strings.TrimSpace(date), "/")
+	if !found || len(month) == 0 || len(month) > 2 || (len(year) != 2 && len(year) != 4) {
+		return "", "", false
+	}
+	month = strings.TrimPrefix(month, "0")
+	if len(year) == 2 {
+		year = "20" + year
+	}
+	return month, year, true
+}
+
+// newUUID returns a random (version 4) UUID.
+func newUUID() string {
+	var b [16]byte
+	// rand.Read never returns an error
+	rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -3,8 +3,9 @@ package export
 import (
 	"encoding/csv"
 	"fmt"
-	"os"
+	"io"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	cmdutil "github.com/GGP1/kure/commands"
@@ -17,10 +18,46 @@ import (
 )
 
 const example = `
-kure export <manager-name> -p path/to/file`
+* Export
+kure export <manager-name> -p path/to/file
+
+* Export to an encrypted KeePass database
+kure export keepassxc -p path/to/file.kdbx
+
+* Export entries, cards, TOTPs and text files to a Bitwarden JSON file
+kure export bitwarden -p path/to/file.json
+
+* Export the whole vault to an encrypted file that can be imported into another one
+kure export kure -p path/to/vault.kure
+
+* Stream every record as NDJSON to another tool
+kure export ndjson -p - | jq -r 'select(.kind == "entry") | .name'
+
+* Export only some columns
+kure export lastpass -p - --fields name,username,password
+
+* Encrypt the stream for an age recipient
+kure export json -p - --encrypt-to age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p > kure.json.age`
+
+// Formats that don't depend on the manager.
+const (
+	formatJSON   = "json"
+	formatNDJSON = "ndjson"
+)
+
+var formatNames = map[string]string{
+	"bitwarden":  "JSON",
+	"csv":        "CSV",
+	"kdbx":       "KDBX",
+	"kure":       "kure",
+	formatJSON:   "JSON",
+	formatNDJSON: "NDJSON",
+}
 
 type exportOptions struct {
-	path string
+	path      string
+	encryptTo string
+	fields    []string
 }
 
 // NewCmd returns a new command.
@@ -33,11 +70,24 @@ func NewCmd(db *bolt.DB) *cobra.Command {
 		
 This command creates a CSV file with all the entries unencrypted, make sure to delete it after it's used.
 
+KeePass databases (KDBX 4) are created directly, and encrypted, when the file extension is ".kdbx". Directories are mapped to groups, TOTPs to the "otp" field and files to attachments of the entry named after their directory.
+
+Bitwarden JSON files are created when the file extension is ".json". Directories are mapped to folders, entries to logins (with their TOTP), cards to cards and text files to secure notes. The file is not encrypted.
+
+The kure format (".kure") contains every record of the vault (entries, bank accounts, cards, files, identities, notes, SSH keys and TOTPs) encrypted with a passphrase chosen at export time, independent of the master password. Use "kure import kure" to merge it into another vault.
+
+The "json" and "ndjson" formats contain every record of the vault (entries, bank accounts, cards, files, identities, notes, SSH keys and TOTPs), the field "kind" identifies the record type. NDJSON writes one record per line.
+
+Use "-" as the path to write the export to the standard output instead of a file, the fields flag to emit only some of the columns (CSV) or fields (JSON) and the encrypt-to flag to encrypt the output for an age X25519 recipient, so the plaintext never touches the disk.
+
 Supported:
 	• 1Password
 	• Bitwarden
+	• JSON
    	• Keepass/X/XC
-   	• Lastpass`,
+	• Kure
+   	• Lastpass
+	• NDJSON`,
 		Example: example,
 		Args:    cmdutil.SupportedManagers(),
 		RunE:    runExport(db, &opts),
@@ -47,7 +97,10 @@ Supported:
 		},
 	}
 
-	cmd.Flags().StringVarP(&opts.path, "path", "p", "", "destination file path")
+	f := cmd.Flags()
+	f.StringVarP(&opts.path, "path", "p", "", "destination file path, use \"-\" for the standard output")
+	f.StringVar(&opts.encryptTo, "encrypt-to", "", "encrypt the output for an age recipient")
+	f.StringSliceVarP(&opts.fields, "fields", "f", nil, "columns or fields to export (CSV, JSON and NDJSON only)")
 
 	return cmd
 }
@@ -60,46 +113,149 @@ func runExport(db *bolt.DB, opts *exportOptions) cmdutil.RunEFunc {
 		if opts.path == "" {
 			return cmdutil.ErrInvalidPath
 		}
-		ext := filepath.Ext(opts.path)
-		if ext == "" || ext == "." {
-			opts.path += ".csv"
+
+		format, err := exportFormat(manager, opts.path)
+		if err != nil {
+			return err
+		}
+		if len(opts.fields) > 0 && (format == "kure" || format == "kdbx" || format == "bitwarden") {
+			return errors.New("the fields flag is only supported with the CSV, JSON and NDJSON formats")
 		}
 
-		headers, records, err := fmtEntries(db, manager)
+		var write func(w io.Writer) error
+		switch format {
+		case "kure":
+			write = func(w io.Writer) error { return exportKure(db, w) }
+		case "kdbx":
+			write = func(w io.Writer) error { return exportKDBX(db, w) }
+		case "bitwarden":
+			write = func(w io.Writer) error { return exportBitwarden(db, w) }
+		case formatJSON, formatNDJSON:
+			if err := validJSONFields(opts.fields); err != nil {
+				return err
+			}
+			records, err := jsonRecords(db)
+			if err != nil {
+				return err
+			}
+			write = func(w io.Writer) error {
+				if format == formatJSON {
+					return writeJSON(w, records, opts.fields)
+				}
+				return writeNDJSON(w, records, opts.fields)
+			}
+		default:
+			headers, records, err := fmtEntries(db, manager)
+			if err != nil {
+				return err
+			}
+			headers, records, err = projectColumns(headers, records, opts.fields)
+			if err != nil {
+				return err
+			}
+			write = func(w io.Writer) error { return writeCSV(w, headers, records) }
+		}
+
+		if ext := filepath.Ext(opts.path); opts.path != "-" && (ext == "" || ext == ".") {
+			opts.path = strings.TrimSuffix(opts.path, ".") + "." + format
+		}
+
+		out, err := newOutput(opts.path, cmd.OutOrStdout(), opts.encryptTo)
 		if err != nil {
 			return err
 		}
 
-		if err := createCSV(headers, records, opts.path); err != nil {
+		if err := write(out); err != nil {
+			out.abort()
+			return err
+		}
+
+		if err := out.Close(); err != nil {
 			return err
 		}
 
-		abs, _ := filepath.Abs(opts.path)
-		fmt.Println("Created CSV file at", abs)
+		if opts.path != "-" {
+			abs, _ := filepath.Abs(opts.path)
+			fmt.Printf("Created %s file at %s\n", formatNames[format], abs)
+		}
 		return nil
 	}
 }
 
-func createCSV(headers []string, records [][]string, path string) error {
-	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_RDWR, 0o600)
-	if err != nil {
-		return errors.Wrap(err, "creating the file")
+// exportFormat returns the format of the export depending on the manager and the file extension.
+func exportFormat(manager, path string) (string, error) {
+	switch manager {
+	case "kure", formatJSON, formatNDJSON:
+		return manager, nil
 	}
 
-	w := csv.NewWriter(f)
-	if err := w.Write(headers); err != nil {
+	if path == "-" {
+		return "csv", nil
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".kdbx":
+		switch manager {
+		case "keepass", "keepassx", "keepassxc":
+			return "kdbx", nil
+		default:
+			return "", errors.Errorf("the KDBX format is only supported by KeePass, not by %s", manager)
+		}
+
+	case ".json":
+		if manager != "bitwarden" {
+			return "", errors.Errorf("the JSON format is only supported by Bitwarden, not by %s", manager)
+		}
+		return "bitwarden", nil
+
+	default:
+		return "csv", nil
+	}
+}
+
+func writeCSV(w io.Writer, headers []string, records [][]string) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(headers); err != nil {
 		return errors.Wrap(err, "writing headers")
 	}
 
-	if err := w.WriteAll(records); err != nil {
+	if err := cw.WriteAll(records); err != nil {
 		return errors.Wrap(err, "writing records")
 	}
 
-	if err := f.Close(); err != nil {
-		return errors.Wrap(err, "closing file")
+	return nil
+}
+
+// projectColumns returns the headers and records with only the columns specified, compared without
+// case sensitivity and in the order they are listed. All the columns are returned if fields is empty.
+func projectColumns(headers []string, records [][]string, fields []string) ([]string, [][]string, error) {
+	if len(fields) == 0 {
+		return headers, records, nil
 	}
 
-	return nil
+	indices := make([]int, len(fields))
+	for i, field := range fields {
+		idx := slices.IndexFunc(headers, func(h string) bool { return strings.EqualFold(h, field) })
+		if idx == -1 {
+			return nil, nil, errors.Errorf("invalid field %q, available: %s", field, strings.Join(headers, ", "))
+		}
+		indices[i] = idx
+	}
+
+	projectedHeaders := make([]string, len(indices))
+	for i, idx := range indices {
+		projectedHeaders[i] = headers[idx]
+	}
+
+	projected := make([][]string, len(records))
+	for i, record := range records {
+		projected[i] = make([]string, len(indices))
+		for j, idx := range indices {
+			projected[i][j] = record[idx]
+		}
+	}
+
+	return projectedHeaders, projected, nil
 }
 
 // fmtEntries takes all the entries in the database and formats them
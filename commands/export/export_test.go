@@ -2,18 +2,34 @@ package export
 
 import (
 	"bytes"
+	"crypto/ed25519"
 	"encoding/csv"
+	"encoding/pem"
+	"io"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/GGP1/kure/archive"
+	"github.com/GGP1/kure/bitwarden"
 	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/db/bank"
+	"github.com/GGP1/kure/db/card"
 	"github.com/GGP1/kure/db/entry"
+	"github.com/GGP1/kure/db/file"
+	"github.com/GGP1/kure/db/identity"
+	"github.com/GGP1/kure/db/note"
+	"github.com/GGP1/kure/db/sshkey"
 	"github.com/GGP1/kure/db/totp"
+	"github.com/GGP1/kure/kdbx"
 	"github.com/GGP1/kure/pb"
+	"github.com/GGP1/kure/sshagent"
 
+	"filippo.io/age"
 	"github.com/stretchr/testify/assert"
 	bolt "go.etcd.io/bbolt"
+	"golang.org/x/crypto/ssh"
 )
 
 func TestExport(t *testing.T) {
@@ -97,20 +113,36 @@ func TestInvalidExport(t *testing.T) {
 	db := cmdutil.SetContext(t)
 
 	cases := []struct {
-		desc    string
-		manager string
-		path    string
+		desc      string
+		manager   string
+		path      string
+		fields    string
+		recipient string
 	}{
 		{desc: "Invalid name", manager: "", path: "test.csv"},
 		{desc: "Invalid path", manager: "keepass", path: ""},
 		{desc: "Unsupported manager", manager: "unsupported", path: "test.csv"},
+		{desc: "KDBX unsupported manager", manager: "lastpass", path: "test.kdbx"},
+		{desc: "JSON unsupported manager", manager: "1password", path: "test.json"},
+		{desc: "Fields unsupported format", manager: "bitwarden", path: "test.json", fields: "name"},
+		{desc: "Invalid JSON field", manager: "json", path: "-", fields: "invalid"},
+		{desc: "Invalid CSV field", manager: "keepass", path: "-", fields: "invalid"},
+		{desc: "Invalid recipient", manager: "json", path: "-", recipient: "age1invalid"},
 	}
 
 	for _, tc := range cases {
 		t.Run(tc.desc, func(t *testing.T) {
 			cmd := NewCmd(db)
+			cmd.SetOut(io.Discard)
 			cmd.SetArgs([]string{tc.manager})
-			cmd.Flags().Set("path", tc.path)
+			f := cmd.Flags()
+			f.Set("path", tc.path)
+			if tc.fields != "" {
+				f.Set("fields", tc.fields)
+			}
+			if tc.recipient != "" {
+				f.Set("encrypt-to", tc.recipient)
+			}
 
 			err := cmd.Execute()
 			assert.Error(t, err)
@@ -123,7 +155,7 @@ func TestArgs(t *testing.T) {
 	cmd := NewCmd(db)
 
 	t.Run("Supported", func(t *testing.T) {
-		list := []string{"1password", "bitwarden", "keepass", "keepassx", "keepassxc", "lastpass"}
+		list := []string{"1password", "bitwarden", "keepass", "keepassx", "keepassxc", "kure", "lastpass", "json", "ndjson"}
 		for _, name := range list {
 			err := cmd.Args(cmd, []string{name})
 			assert.NoError(t, err)
@@ -219,3 +251,382 @@ func createEntry(t *testing.T, db *bolt.DB) {
 	err := entry.Create(db, e)
 	assert.NoError(t, err)
 }
+
+// createSSHKey stores an ed25519 key encrypted with a passphrase.
+func createSSHKey(t *testing.T, db *bolt.DB) *pb.SSHKey {
+	t.Helper()
+	priv := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+	block, err := ssh.MarshalPrivateKeyWithPassphrase(priv, "", []byte("passphrase"))
+	assert.NoError(t, err)
+
+	key := &pb.SSHKey{
+		Name:       "github",
+		PrivateKey: string(pem.EncodeToMemory(block)),
+		Passphrase: "passphrase",
+		Comment:    "test@kure",
+	}
+	err = cmdutil.ValidateSSHKey(key)
+	assert.NoError(t, err)
+	err = sshkey.Create(db, key)
+	assert.NoError(t, err)
+	return key
+}
+
+func TestKDBXDatabase(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	expires := "Wed, 02 Jan 2030 03:04:05 +0000"
+	err := entry.Create(db,
+		&pb.Entry{Name: "email", Username: "user", Password: "email123", Expires: expires},
+		&pb.Entry{Name: "work/github", Password: "github123", Expires: "Never"},
+	)
+	assert.NoError(t, err)
+
+	err = totp.Create(db, &pb.TOTP{Name: "work/github", Raw: "JBSWY3DPEHPK3PXP", Digits: 6})
+	assert.NoError(t, err)
+
+	for _, name := range []string{"email/recovery.txt", "work/notes.txt"} {
+		err = file.Create(db, &pb.File{Name: name, Content: []byte(name)})
+		assert.NoError(t, err)
+	}
+
+	database, err := kdbxDatabase(db)
+	assert.NoError(t, err)
+
+	root := database.Root
+	assert.Len(t, root.Entries, 1)
+	email := root.Entries[0]
+	assert.Equal(t, "email", email.Title)
+	assert.Equal(t, "email123", email.Password)
+	assert.Equal(t, time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC), email.Expires)
+	assert.Equal(t, []kdbx.Attachment{{Name: "recovery.txt", Data: []byte("email/recovery.txt")}}, email.Attachments)
+
+	assert.Len(t, root.Groups, 1)
+	work := root.Groups[0]
+	assert.Equal(t, "work", work.Name)
+	assert.Len(t, work.Entries, 2)
+
+	github := work.Entries[0]
+	assert.Equal(t, "github", github.Title)
+	assert.True(t, github.Expires.IsZero())
+	assert.Equal(t, "otpauth://totp/github?digits=6&period=30&secret=JBSWY3DPEHPK3PXP", github.Field("otp"))
+
+	// Files that don't match any entry are stored in a new one
+	notes := work.Entries[1]
+	assert.Equal(t, "notes.txt", notes.Title)
+	assert.Equal(t, []kdbx.Attachment{{Name: "notes.txt", Data: []byte("work/notes.txt")}}, notes.Attachments)
+}
+
+func TestBitwardenExport(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	err := entry.Create(db, &pb.Entry{Name: "work/github", Username: "user", Password: "github123", URL: "https://github.com", Expires: "Never"})
+	assert.NoError(t, err)
+	err = totp.Create(db, &pb.TOTP{Name: "work/github", Raw: "JBSWY3DPEHPK3PXP", Digits: 6})
+	assert.NoError(t, err)
+	err = card.Create(db, &pb.Card{Name: "visa", Type: "Visa", Number: "4111111111111111", SecurityCode: "123", ExpireDate: "01/30"})
+	assert.NoError(t, err)
+	err = file.Create(db, &pb.File{Name: "work/notes.txt", Content: []byte("notes")})
+	assert.NoError(t, err)
+	err = file.Create(db, &pb.File{Name: "image.png", Content: []byte{0xff, 0xfe, 0xfd}})
+	assert.NoError(t, err)
+	err = identity.Create(db, &pb.Identity{Name: "passport", Type: "Passport", FullName: "John Doe", Number: "X1234567", Issuer: "Atoll"})
+	assert.NoError(t, err)
+	err = bank.Create(db, &pb.BankAccount{Name: "bank", Bank: "Kure Bank", Iban: "GB82WEST12345698765432"})
+	assert.NoError(t, err)
+	err = note.Create(db, &pb.Note{Name: "wifi", Text: "SSID: kure"})
+	assert.NoError(t, err)
+	key := createSSHKey(t, db)
+
+	export, err := bitwardenExport(db)
+	assert.NoError(t, err)
+
+	assert.Len(t, export.Folders, 1)
+	assert.Equal(t, "work", export.Folders[0].Name)
+	folderID := export.Folders[0].ID
+
+	// The binary file is skipped
+	assert.Len(t, export.Items, 7)
+
+	login := export.Items[0]
+	assert.Equal(t, bitwarden.LoginType, login.Type)
+	assert.Equal(t, "github", login.Name)
+	assert.Equal(t, folderID, login.FolderID)
+	assert.Equal(t, &bitwarden.Login{
+		URIs:     []bitwarden.URI{{URI: "https://github.com"}},
+		Username: "user",
+		Password: "github123",
+		TOTP:     "otpauth://totp/github?digits=6&period=30&secret=JBSWY3DPEHPK3PXP",
+	}, login.Login)
+
+	visa := export.Items[1]
+	assert.Equal(t, bitwarden.CardType, visa.Type)
+	assert.Empty(t, visa.FolderID)
+	assert.Equal(t, &bitwarden.Card{Brand: "Visa", Number: "4111111111111111", ExpMonth: "1", ExpYear: "2030", Code: "123"}, visa.Card)
+
+	note := export.Items[2]
+	assert.Equal(t, bitwarden.SecureNoteType, note.Type)
+	assert.Equal(t, "notes.txt", note.Name)
+	assert.Equal(t, folderID, note.FolderID)
+	assert.Equal(t, "notes", note.Notes)
+
+	passport := export.Items[3]
+	assert.Equal(t, bitwarden.IdentityType, passport.Type)
+	assert.Equal(t, &bitwarden.Identity{FirstName: "John", LastName: "Doe", PassportNumber: "X1234567"}, passport.Identity)
+	assert.Equal(t, []bitwarden.Field{
+		{Name: "Type", Value: "Passport", Type: bitwarden.TextField},
+		{Name: "Issuer", Value: "Atoll", Type: bitwarden.TextField},
+	}, passport.Fields)
+
+	account := export.Items[4]
+	assert.Equal(t, bitwarden.SecureNoteType, account.Type)
+	assert.Equal(t, []bitwarden.Field{
+		{Name: "Bank", Value: "Kure Bank", Type: bitwarden.TextField},
+		{Name: "IBAN", Value: "GB82WEST12345698765432", Type: bitwarden.HiddenField},
+	}, account.Fields)
+
+	wifi := export.Items[5]
+	assert.Equal(t, bitwarden.SecureNoteType, wifi.Type)
+	assert.Equal(t, "SSID: kure", wifi.Notes)
+
+	github := export.Items[6]
+	assert.Equal(t, bitwarden.SSHKeyType, github.Type)
+	assert.Equal(t, key.PublicKey, github.SSHKey.PublicKey)
+	assert.Equal(t, key.Fingerprint, github.SSHKey.KeyFingerprint)
+	// The key is exported decrypted
+	_, err = sshagent.ParseKey(github.SSHKey.PrivateKey, "")
+	assert.NoError(t, err)
+}
+
+func TestExportBitwardenFile(t *testing.T) {
+	db := cmdutil.SetContext(t)
+	createEntry(t, db)
+
+	path := filepath.Join(t.TempDir(), "export.json")
+	cmd := NewCmd(db)
+	cmd.SetArgs([]string{"bitwarden"})
+	cmd.Flags().Set("path", path)
+
+	err := cmd.Execute()
+	assert.NoError(t, err)
+
+	f, err := os.Open(path)
+	assert.NoError(t, err)
+	defer f.Close()
+
+	export, err := bitwarden.Decode(f, nil)
+	assert.NoError(t, err)
+	assert.Len(t, export.Items, 1)
+	assert.Equal(t, "May the force be with you", export.Items[0].Name)
+}
+
+func TestSplitExpireDate(t *testing.T) {
+	cases := []struct {
+		date  string
+		month string
+		year  string
+		ok    bool
+	}{
+		{date: "01/30", month: "1", year: "2030", ok: true},
+		{date: "12/2031", month: "12", year: "2031", ok: true},
+		{date: "2031-12", ok: false},
+		{date: "", ok: false},
+	}
+
+	for _, tc := range cases {
+		month, year, ok := splitExpireDate(tc.date)
+		assert.Equal(t, tc.ok, ok, tc.date)
+		assert.Equal(t, tc.month, month, tc.date)
+		assert.Equal(t, tc.year, year, tc.date)
+	}
+}
+
+func TestKureArchive(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	err := entry.Create(db, &pb.Entry{Name: "work/github", Username: "user", Password: "github123", Expires: "Never"})
+	assert.NoError(t, err)
+	err = totp.Create(db, &pb.TOTP{Name: "work/github", Raw: "JBSWY3DPEHPK3PXP", Digits: 6})
+	assert.NoError(t, err)
+	err = card.Create(db, &pb.Card{Name: "visa", Number: "4111111111111111"})
+	assert.NoError(t, err)
+	err = file.Create(db, &pb.File{Name: "image.png", Content: []byte{0xff, 0xfe, 0xfd}, Size: 3})
+	assert.NoError(t, err)
+	err = identity.Create(db, &pb.Identity{Name: "passport", Number: "X1234567"})
+	assert.NoError(t, err)
+	err = bank.Create(db, &pb.BankAccount{Name: "bank", Iban: "GB82WEST12345698765432"})
+	assert.NoError(t, err)
+	err = note.Create(db, &pb.Note{Name: "wifi", Text: "SSID: kure"})
+	assert.NoError(t, err)
+	key := createSSHKey(t, db)
+
+	a, err := kureArchive(db)
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	params := archive.Params{Iterations: 1, Memory: 64, Threads: 1}
+	err = archive.Encode(&buf, a, []byte("passphrase"), params)
+	assert.NoError(t, err)
+
+	got, err := archive.Decode(&buf, []byte("passphrase"))
+	assert.NoError(t, err)
+
+	assert.NotZero(t, got.CreatedAt)
+	assert.Len(t, got.Entries, 1)
+	assert.Equal(t, "github123", got.Entries[0].Password)
+	assert.Len(t, got.Totps, 1)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", got.Totps[0].Raw)
+	assert.Len(t, got.Cards, 1)
+	assert.Equal(t, "4111111111111111", got.Cards[0].Number)
+	assert.Len(t, got.Files, 1)
+	// Files content is stored decompressed
+	assert.Equal(t, []byte{0xff, 0xfe, 0xfd}, got.Files[0].Content)
+	assert.Len(t, got.Identities, 1)
+	assert.Equal(t, "X1234567", got.Identities[0].Number)
+	assert.Len(t, got.BankAccounts, 1)
+	assert.Equal(t, "GB82WEST12345698765432", got.BankAccounts[0].Iban)
+	assert.Len(t, got.Notes, 1)
+	assert.Equal(t, "SSID: kure", got.Notes[0].Text)
+	assert.Len(t, got.SshKeys, 1)
+	assert.Equal(t, key.PrivateKey, got.SshKeys[0].PrivateKey)
+	assert.Equal(t, key.Passphrase, got.SshKeys[0].Passphrase)
+}
+
+func TestExportStdout(t *testing.T) {
+	db := cmdutil.SetContext(t)
+	err := entry.Create(db, &pb.Entry{Name: "work/github", Username: "user", Password: "github123", Expires: "Never"})
+	assert.NoError(t, err)
+	err = card.Create(db, &pb.Card{Name: "visa", Number: "4111111111111111"})
+	assert.NoError(t, err)
+	err = file.Create(db, &pb.File{Name: "image.png", Content: []byte{0xff, 0xfe}, Size: 2})
+	assert.NoError(t, err)
+	err = totp.Create(db, &pb.TOTP{Name: "work/github", Raw: "JBSWY3DPEHPK3PXP", Digits: 6})
+	assert.NoError(t, err)
+	err = note.Create(db, &pb.Note{Name: "wifi", Text: "SSID: kure"})
+	assert.NoError(t, err)
+
+	cases := []struct {
+		desc     string
+		manager  string
+		fields   string
+		expected string
+	}{
+		{
+			desc:     "CSV",
+			manager:  "keepassxc",
+			expected: "Group,Title,Username,Password,URL,Notes\nwork,github,user,github123,,\n",
+		},
+		{
+			desc:     "CSV fields",
+			manager:  "keepassxc",
+			fields:   "title,password",
+			expected: "Title,Password\ngithub,github123\n",
+		},
+		{
+			desc:    "NDJSON",
+			manager: "ndjson",
+			expected: `{"kind":"entry","name":"work/github","username":"user","password":"github123","url":"","notes":"","expires":"Never","password_updated_at":0}
+{"kind":"card","name":"visa","type":"","number":"4111111111111111","security_code":"","expire_date":"","notes":""}
+{"kind":"file","name":"image.png","content":"//4=","encoding":"base64","size":2,"created_at":0,"updated_at":0}
+{"kind":"totp","name":"work/github","secret":"JBSWY3DPEHPK3PXP","digits":6}
+{"kind":"note","name":"wifi","text":"SSID: kure"}
+`,
+		},
+		{
+			desc:    "NDJSON fields",
+			manager: "ndjson",
+			fields:  "kind,name",
+			expected: `{"kind":"entry","name":"work/github"}
+{"kind":"card","name":"visa"}
+{"kind":"file","name":"image.png"}
+{"kind":"totp","name":"work/github"}
+{"kind":"note","name":"wifi"}
+`,
+		},
+		{
+			desc:    "JSON fields",
+			manager: "json",
+			fields:  "name,password",
+			expected: `[
+  {
+    "name": "work/github",
+    "password": "github123"
+  },
+  {
+    "name": "visa"
+  },
+  {
+    "name": "image.png"
+  },
+  {
+    "name": "work/github"
+  },
+  {
+    "name": "wifi"
+  }
+]
+`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			var buf bytes.Buffer
+			cmd := NewCmd(db)
+			cmd.SetOut(&buf)
+			cmd.SetArgs([]string{tc.manager})
+			f := cmd.Flags()
+			f.Set("path", "-")
+			if tc.fields != "" {
+				f.Set("fields", tc.fields)
+			}
+
+			err := cmd.Execute()
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, buf.String())
+		})
+	}
+}
+
+func TestExportEncryptTo(t *testing.T) {
+	db := cmdutil.SetContext(t)
+	createEntry(t, db)
+
+	identity, err := age.GenerateX25519Identity()
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	cmd := NewCmd(db)
+	cmd.SetOut(&buf)
+	cmd.SetArgs([]string{"ndjson"})
+	f := cmd.Flags()
+	f.Set("path", "-")
+	f.Set("encrypt-to", identity.Recipient().String())
+
+	err = cmd.Execute()
+	assert.NoError(t, err)
+	assert.NotContains(t, buf.String(), "May the force be with you")
+
+	r, err := age.Decrypt(&buf, identity)
+	assert.NoError(t, err)
+	got, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Contains(t, string(got), `"name":"May the force be with you"`)
+}
+
+func TestExportFile(t *testing.T) {
+	db := cmdutil.SetContext(t)
+	createEntry(t, db)
+
+	path := filepath.Join(t.TempDir(), "export")
+	cmd := NewCmd(db)
+	cmd.SetArgs([]string{"ndjson"})
+	cmd.Flags().Set("path", path)
+
+	err := cmd.Execute()
+	assert.NoError(t, err)
+
+	content, err := os.ReadFile(path + ".ndjson")
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), `"name":"May the force be with you"`)
+}
@@ -0,0 +1,200 @@
+package export
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"slices"
+	"unicode/utf8"
+
+	"github.com/GGP1/kure/db/bank"
+	"github.com/GGP1/kure/db/card"
+	"github.com/GGP1/kure/db/entry"
+	"github.com/GGP1/kure/db/file"
+	"github.com/GGP1/kure/db/identity"
+	"github.com/GGP1/kure/db/note"
+	"github.com/GGP1/kure/db/sshkey"
+	"github.com/GGP1/kure/db/totp"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Fields emitted by the JSON and NDJSON formats, "kind" identifies the record type.
+var (
+	entryFields    = []string{"kind", "name", "username", "password", "url", "notes", "expires", "password_updated_at"}
+	cardFields     = []string{"kind", "name", "type", "number", "security_code", "expire_date", "notes"}
+	fileFields     = []string{"kind", "name", "content", "encoding", "size", "created_at", "updated_at"}
+	totpFields     = []string{"kind", "name", "secret", "digits"}
+	identityFields = []string{"kind", "name", "type", "full_name", "number", "issuer", "issue_date", "expire_date", "notes"}
+	bankFields     = []string{"kind", "name", "bank", "holder", "account_number", "routing_number", "iban", "swift", "pin", "notes"}
+	noteFields     = []string{"kind", "name", "text"}
+	sshKeyFields   = []string{"kind", "name", "private_key", "passphrase", "public_key", "comment", "fingerprint", "type"}
+)
+
+// record is a kure record as emitted by the JSON and NDJSON formats, fields are encoded in order.
+type record []recordField
+
+type recordField struct {
+	name  string
+	value interface{}
+}
+
+func newRecord(names []string, values ...interface{}) record {
+	r := make(record, len(names))
+	for i, name := range names {
+		r[i] = recordField{name: name, value: values[i]}
+	}
+	return r
+}
+
+// MarshalJSON encodes the record as an object keeping the fields order.
+func (r record) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range r {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(f.name)
+		value, err := json.Marshal(f.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// project returns the record with only the fields specified, all of them if the list is empty.
+func (r record) project(fields []string) record {
+	if len(fields) == 0 {
+		return r
+	}
+	projected := make(record, 0, len(fields))
+	for _, f := range r {
+		if slices.Contains(fields, f.name) {
+			projected = append(projected, f)
+		}
+	}
+	return projected
+}
+
+// jsonRecords returns every record stored in the database.
+func jsonRecords(db *bolt.DB) ([]record, error) {
+	entries, err := entry.List(db)
+	if err != nil {
+		return nil, err
+	}
+	cards, err := card.List(db)
+	if err != nil {
+		return nil, err
+	}
+	files, err := file.List(db)
+	if err != nil {
+		return nil, err
+	}
+	totps, err := totp.List(db)
+	if err != nil {
+		return nil, err
+	}
+	identities, err := identity.List(db)
+	if err != nil {
+		return nil, err
+	}
+	banks, err := bank.List(db)
+	if err != nil {
+		return nil, err
+	}
+	notes, err := note.List(db)
+	if err != nil {
+		return nil, err
+	}
+	sshKeys, err := sshkey.List(db)
+	if err != nil {
+		return nil, err
+	}
+
+	size := len(entries) + len(cards) + len(files) + len(totps) + len(identities) + len(banks) + len(notes) + len(sshKeys)
+	records := make([]record, 0, size)
+	for _, e := range entries {
+		records = append(records, newRecord(entryFields,
+			"entry", e.Name, e.Username, e.Password, e.URL, e.Notes, e.Expires, e.PasswordUpdatedAt))
+	}
+	for _, c := range cards {
+		records = append(records, newRecord(cardFields,
+			"card", c.Name, c.Type, c.Number, c.SecurityCode, c.ExpireDate, c.Notes))
+	}
+	for _, f := range files {
+		content, encoding := string(f.Content), "utf-8"
+		if !utf8.Valid(f.Content) {
+			content, encoding = base64.StdEncoding.EncodeToString(f.Content), "base64"
+		}
+		records = append(records, newRecord(fileFields,
+			"file", f.Name, content, encoding, f.Size, f.CreatedAt, f.UpdatedAt))
+	}
+	for _, t := range totps {
+		records = append(records, newRecord(totpFields, "totp", t.Name, t.Raw, t.Digits))
+	}
+	for _, i := range identities {
+		records = append(records, newRecord(identityFields,
+			"identity", i.Name, i.Type, i.FullName, i.Number, i.Issuer, i.IssueDate, i.ExpireDate, i.Notes))
+	}
+	for _, b := range banks {
+		records = append(records, newRecord(bankFields,
+			"bank", b.Name, b.Bank, b.Holder, b.AccountNumber, b.RoutingNumber, b.Iban, b.Swift, b.Pin, b.Notes))
+	}
+	for _, n := range notes {
+		records = append(records, newRecord(noteFields, "note", n.Name, n.Text))
+	}
+	for _, k := range sshKeys {
+		records = append(records, newRecord(sshKeyFields,
+			"ssh", k.Name, k.PrivateKey, k.Passphrase, k.PublicKey, k.Comment, k.Fingerprint, k.Type))
+	}
+
+	return records, nil
+}
+
+// validJSONFields returns an error if any of the fields is not emitted by the JSON formats.
+func validJSONFields(fields []string) error {
+	all := [][]string{entryFields, cardFields, fileFields, totpFields, identityFields, bankFields, noteFields, sshKeyFields}
+	for _, f := range fields {
+		valid := slices.ContainsFunc(all, func(kindFields []string) bool {
+			return slices.Contains(kindFields, f)
+		})
+		if !valid {
+			return errors.Errorf("invalid field %q", f)
+		}
+	}
+	return nil
+}
+
+// writeJSON writes the records as a JSON array.
+func writeJSON(w io.Writer, records []record, fields []string) error {
+	projected := make([]record, len(records))
+	for i, r := range records {
+		projected[i] = r.project(fields)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(projected); err != nil {
+		return errors.Wrap(err, "encoding JSON")
+	}
+	return nil
+}
+
+// writeNDJSON writes one record per line.
+func writeNDJSON(w io.Writer, records []record, fields []string) error {
+	enc := json.NewEncoder(w)
+	for _, r := range records {
+		if err := enc.Encode(r.project(fields)); err != nil {
+			return errors.Wrap(err, "encoding JSON")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package export
+
+import (
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GGP1/kure/db/entry"
+	"github.com/GGP1/kure/db/file"
+	"github.com/GGP1/kure/db/totp"
+	"github.com/GGP1/kure/kdbx"
+	"github.com/GGP1/kure/pb"
+	"github.com/GGP1/kure/terminal"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+func exportKDBX(db *bolt.DB, w io.Writer) error {
+	database, err := kdbxDatabase(db)
+	if err != nil {
+		return err
+	}
+
+	enclave, err := terminal.ScanPassword("KeePass database password", true)
+	if err != nil {
+		return err
+	}
+	password, err := enclave.Open()
+	if err != nil {
+		return errors.Wrap(err, "opening enclave")
+	}
+	defer password.Destroy()
+
+	return kdbx.Encode(w, database, password.Bytes())
+}
+
+// kdbxDatabase maps kure records to a KeePass database.
+//
+// Directories are mapped to groups, TOTPs to the "otp" field of the entry with the same name
+// and files to attachments of the entry named after their directory. TOTPs and files that
+// don't match any entry are stored in new ones.
+func kdbxDatabase(db *bolt.DB) (*kdbx.Database, error) {
+	entries, err := entry.List(db)
+	if err != nil {
+		return nil, err
+	}
+	totps, err := totp.List(db)
+	if err != nil {
+		return nil, err
+	}
+	files, err := file.List(db)
+	if err != nil {
+		return nil, err
+	}
+
+	root := &kdbx.Group{Name: "Root"}
+	kdbxEntries := make(map[string]*kdbx.Entry, len(entries))
+	getEntry := func(name string) *kdbx.Entry {
+		if e, ok := kdbxEntries[name]; ok {
+			return e
+		}
+
+		dir, title := splitName(name)
+		e := &kdbx.Entry{Title: title}
+		g := group(root, dir)
+		g.Entries = append(g.Entries, e)
+		kdbxEntries[name] = e
+		return e
+	}
+
+	for _, e := range entries {
+		ke := getEntry(e.Name)
+		ke.Username = e.Username
+		ke.Password = e.Password
+		ke.URL = e.URL
+		ke.Notes = e.Notes
+		if e.PasswordUpdatedAt > 0 {
+			ke.Modified = time.Unix(e.PasswordUpdatedAt, 0).UTC()
+		}
+
+		if e.Expires != "Never" {
+			// Error is always nil as "expires" field was already formatted before being saved
+			expires, _ := time.Parse(time.RFC1123Z, e.Expires)
+			ke.Expires = expires.UTC()
+		}
+	}
+
+	for _, t := range totps {
+		e := getEntry(t.Name)
+		e.Fields = append(e.Fields, kdbx.Field{Key: "otp", Value: otpURI(e.Title, t), Protected: true})
+	}
+
+	for _, f := range files {
+		dir, name := splitName(f.Name)
+		owner := dir
+		if _, ok := kdbxEntries[dir]; !ok || dir == "" {
+			// Store the file in an entry with its name
+			owner = f.Name
+		}
+
+		e := getEntry(owner)
+		e.Attachments = append(e.Attachments, kdbx.Attachment{Name: name, Data: f.Content})
+	}
+
+	return &kdbx.Database{Name: "Kure", Root: root}, nil
+}
+
+// group returns the group located at path, creating the missing ones.
+func group(root *kdbx.Group, path string) *kdbx.Group {
+	if path == "" {
+		return root
+	}
+
+	g := root
+	for _, name := range strings.Split(path, "/") {
+		var next *kdbx.Group
+		for _, sg := range g.Groups {
+			if sg.Name == name {
+				next = sg
+				break
+			}
+		}
+
+		if next == nil {
+			next = &kdbx.Group{Name: name}
+			g.Groups = append(g.Groups, next)
+		}
+		g = next
+	}
+
+	return g
+}
+
+// otpURI returns the TOTP key URI used by KeePassXC.
+func otpURI(label string, t *pb.TOTP) string {
+	query := url.Values{}
+	query.Set("secret", t.Raw)
+	query.Set("period", "30")
+	query.Set("digits", strconv.Itoa(int(t.Digits)))
+
+	uri := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + label,
+		RawQuery: query.Encode(),
+	}
+	return uri.String()
+}
@@ -0,0 +1,89 @@
+package export
+
+import (
+	"io"
+	"time"
+
+	"github.com/GGP1/kure/archive"
+	"github.com/GGP1/kure/db/bank"
+	"github.com/GGP1/kure/db/card"
+	"github.com/GGP1/kure/db/entry"
+	"github.com/GGP1/kure/db/file"
+	"github.com/GGP1/kure/db/identity"
+	"github.com/GGP1/kure/db/note"
+	"github.com/GGP1/kure/db/sshkey"
+	"github.com/GGP1/kure/db/totp"
+	"github.com/GGP1/kure/pb"
+	"github.com/GGP1/kure/terminal"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+func exportKure(db *bolt.DB, w io.Writer) error {
+	a, err := kureArchive(db)
+	if err != nil {
+		return err
+	}
+
+	enclave, err := terminal.ScanPassword("Export passphrase", true)
+	if err != nil {
+		return err
+	}
+	password, err := enclave.Open()
+	if err != nil {
+		return errors.Wrap(err, "opening enclave")
+	}
+	defer password.Destroy()
+
+	return archive.Encode(w, a, password.Bytes(), archive.VaultParams())
+}
+
+// kureArchive returns an archive containing every record stored in the database.
+func kureArchive(db *bolt.DB) (*pb.Archive, error) {
+	cards, err := card.List(db)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := entry.List(db)
+	if err != nil {
+		return nil, err
+	}
+	files, err := file.List(db)
+	if err != nil {
+		return nil, err
+	}
+	totps, err := totp.List(db)
+	if err != nil {
+		return nil, err
+	}
+	identities, err := identity.List(db)
+	if err != nil {
+		return nil, err
+	}
+	banks, err := bank.List(db)
+	if err != nil {
+		return nil, err
+	}
+	notes, err := note.List(db)
+	if err != nil {
+		return nil, err
+	}
+	sshKeys, err := sshkey.List(db)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &pb.Archive{
+		CreatedAt:    time.Now().Unix(),
+		Cards:        cards,
+		Entries:      entries,
+		Files:        files,
+		Totps:        totps,
+		Identities:   identities,
+		BankAccounts: banks,
+		Notes:        notes,
+		SshKeys:      sshKeys,
+	}
+	return a, nil
+}
@@ -0,0 +1,80 @@
+package export
+
+import (
+	"io"
+	"os"
+
+	"filippo.io/age"
+	"github.com/pkg/errors"
+)
+
+// output is the destination of an export: a file or the standard output, optionally encrypted
+// for an age recipient.
+type output struct {
+	w    io.Writer
+	file *os.File
+	age  io.WriteCloser
+}
+
+// newOutput returns an output that writes to stdout if the path is "-" and to a new file otherwise.
+// If recipient is not empty, the content is encrypted with age before being written.
+func newOutput(path string, stdout io.Writer, recipient string) (*output, error) {
+	var r age.Recipient
+	if recipient != "" {
+		x25519, err := age.ParseX25519Recipient(recipient)
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing age recipient")
+		}
+		r = x25519
+	}
+
+	out := &output{w: stdout}
+	if path != "-" {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err != nil {
+			return nil, errors.Wrap(err, "creating the file")
+		}
+		out.file = f
+		out.w = f
+	}
+
+	if r != nil {
+		w, err := age.Encrypt(out.w, r)
+		if err != nil {
+			out.abort()
+			return nil, errors.Wrap(err, "encrypting output")
+		}
+		out.age = w
+		out.w = w
+	}
+
+	return out, nil
+}
+
+func (o *output) Write(p []byte) (int, error) {
+	return o.w.Write(p)
+}
+
+// Close flushes the encrypted stream and closes the file.
+func (o *output) Close() error {
+	if o.age != nil {
+		if err := o.age.Close(); err != nil {
+			o.abort()
+			return errors.Wrap(err, "encrypting output")
+		}
+	}
+	if o.file != nil {
+		if err := o.file.Close(); err != nil {
+			return errors.Wrap(err, "closing file")
+		}
+	}
+	return nil
+}
+
+// abort closes and removes the file, if any.
+func (o *output) abort() {
+	if o.file != nil {
+		o.file.Close()
+		os.Remove(o.file.Name())
+	}
+}
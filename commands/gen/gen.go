@@ -22,11 +22,15 @@ kure gen -l 18 -L 1,2,3 -i %&/ -e ? -r
 kure gen -l 20 -q
 
 * Generate, copy and mute standard output
-kure gen -l 25 -cm`
+kure gen -l 25 -cm
+
+* Generate using a profile from the configuration
+kure gen -p bank`
 
 type genOptions struct {
 	include string
 	exclude string
+	profile string
 	levels  []int
 	length  uint64
 	qr      bool
@@ -64,48 +68,18 @@ Average time taken to crack is based on a brute force attack scenario where the
 	f.BoolVarP(&opts.repeat, "repeat", "r", true, "allow character repetition")
 	f.BoolVarP(&opts.qr, "qr", "q", false, "display the password QR code on the terminal")
 	f.BoolVarP(&opts.mute, "mute", "m", false, "mute standard output when the password is copied")
+	f.StringVarP(&opts.profile, "profile", "p", "", "password generation profile")
+
+	for _, flag := range cmdutil.ProfileFlags {
+		cmd.MarkFlagsMutuallyExclusive("profile", flag)
+	}
 
 	return cmd
 }
 
 func runGen(opts *genOptions) cmdutil.RunEFunc {
 	return func(cmd *cobra.Command, args []string) error {
-		if opts.length < 1 {
-			return cmdutil.ErrInvalidLength
-		}
-
-		if len(opts.levels) == 0 {
-			return errors.New("please specify levels")
-		}
-
-		levels := make([]atoll.Level, len(opts.levels))
-		for i, lvl := range opts.levels {
-			switch lvl {
-			case 1:
-				levels[i] = atoll.Lower
-			case 2:
-				levels[i] = atoll.Upper
-			case 3:
-				levels[i] = atoll.Digit
-			case 4:
-				levels[i] = atoll.Space
-			case 5:
-				levels[i] = atoll.Special
-
-			default:
-				return errors.Errorf("invalid level [%d]", lvl)
-			}
-		}
-
-		p := &atoll.Password{
-			Length:  opts.length,
-			Levels:  levels,
-			Include: opts.include,
-			Exclude: opts.exclude,
-			Repeat:  opts.repeat,
-		}
-
-		password, err := atoll.NewSecret(p)
+		p, password, err := generate(opts)
 		if err != nil {
 			return err
 		}
@@ -138,3 +112,54 @@ Average time taken to crack: %s
 		return nil
 	}
 }
+
+// generate returns the password parameters used and the password generated, taking
+// them from the profile if one was specified.
+func generate(opts *genOptions) (*atoll.Password, []byte, error) {
+	if opts.profile != "" {
+		profile, err := cmdutil.GetProfile(opts.profile)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		p, err := profile.Password()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		password, err := profile.Generate()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return p, []byte(password), nil
+	}
+
+	if opts.length < 1 {
+		return nil, nil, cmdutil.ErrInvalidLength
+	}
+
+	if len(opts.levels) == 0 {
+		return nil, nil, errors.New("please specify levels")
+	}
+
+	levels, err := cmdutil.ParseLevels(opts.levels)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p := &atoll.Password{
+		Length:  opts.length,
+		Levels:  levels,
+		Include: opts.include,
+		Exclude: opts.exclude,
+		Repeat:  opts.repeat,
+	}
+
+	password, err := atoll.NewSecret(p)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return p, password, nil
+}
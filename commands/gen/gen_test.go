@@ -5,6 +5,8 @@ import (
 	"strconv"
 	"testing"
 
+	"github.com/GGP1/kure/config"
+
 	"github.com/atotto/clipboard"
 	"github.com/stretchr/testify/assert"
 )
@@ -112,6 +114,29 @@ func TestGenErrors(t *testing.T) {
 	}
 }
 
+func TestGenProfile(t *testing.T) {
+	config.Reset()
+	config.Set("gen.profiles.bank", map[string]interface{}{
+		"length":   12,
+		"levels":   []interface{}{1, 2, 3},
+		"required": []interface{}{"0123456789"},
+	})
+
+	cmd := NewCmd()
+	cmd.Flags().Set("profile", "bank")
+
+	err := cmd.Execute()
+	assert.NoError(t, err)
+
+	t.Run("Non-existent", func(t *testing.T) {
+		cmd := NewCmd()
+		cmd.Flags().Set("profile", "non-existent")
+
+		err := cmd.Execute()
+		assert.Error(t, err)
+	})
+}
+
 func TestPostRun(t *testing.T) {
 	NewCmd().PostRun(nil, nil)
 }
@@ -0,0 +1,64 @@
+package add
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/db/identity"
+	"github.com/GGP1/kure/pb"
+	"github.com/GGP1/kure/terminal"
+
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+const example = `
+* Add a new identity
+kure identity add Sample`
+
+// NewCmd returns a new command.
+func NewCmd(db *bolt.DB, r io.Reader) *cobra.Command {
+	return &cobra.Command{
+		Use:   "add <name>",
+		Short: "Add an identity",
+		Long: `Add an identity.
+
+Identities hold the details of identity documents like passports, driver licenses or national ID cards.`,
+		Aliases: []string{"create", "new"},
+		Example: example,
+		Args:    cmdutil.MustNotExist(db, cmdutil.Identity),
+		RunE:    runAdd(db, r),
+	}
+}
+
+func runAdd(db *bolt.DB, r io.Reader) cmdutil.RunEFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		name := strings.Join(args, " ")
+		name = cmdutil.NormalizeName(name)
+
+		i := input(name, r)
+		if err := identity.Create(db, i); err != nil {
+			return err
+		}
+
+		fmt.Printf("\n%q added\n", name)
+		return nil
+	}
+}
+
+func input(name string, r io.Reader) *pb.Identity {
+	reader := bufio.NewReader(r)
+	return &pb.Identity{
+		Name:       name,
+		Type:       terminal.Scanln(reader, "Type"),
+		FullName:   terminal.Scanln(reader, "Full name"),
+		Number:     terminal.Scanln(reader, "Number"),
+		Issuer:     terminal.Scanln(reader, "Issuer"),
+		IssueDate:  terminal.Scanln(reader, "Issue date"),
+		ExpireDate: terminal.Scanln(reader, "Expire date"),
+		Notes:      terminal.Scanlns(reader, "Notes"),
+	}
+}
@@ -0,0 +1,93 @@
+package add
+
+import (
+	"bytes"
+	"testing"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/db/identity"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdd(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	cases := []struct {
+		desc string
+		name string
+	}{
+		{
+			desc: "Add",
+			name: "test",
+		},
+		{
+			desc: "Add2",
+			name: "test2",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			buf := bytes.NewBufferString("Passport\nJohn Doe\nX1234567\nGovernment\n2020-01-01\n2030-01-01\nnotes<\n")
+			cmd := NewCmd(db, buf)
+			cmd.SetArgs([]string{tc.name})
+
+			err := cmd.Execute()
+			assert.NoError(t, err)
+
+			_, err = identity.Get(db, tc.name)
+			assert.NoError(t, err, "Identity wasn't created correctly")
+		})
+	}
+}
+
+func TestAddErrors(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	err := identity.Create(db, &pb.Identity{Name: "test"})
+	assert.NoError(t, err)
+
+	cases := []struct {
+		desc string
+		name string
+	}{
+		{
+			desc: "Already exists",
+			name: "test",
+		},
+		{
+			desc: "Invalid name",
+			name: "",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			buf := bytes.NewBufferString("Passport\n\n\n\n\n\n<\n")
+			cmd := NewCmd(db, buf)
+			cmd.SetArgs([]string{tc.name})
+
+			err := cmd.Execute()
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestInput(t *testing.T) {
+	buf := bytes.NewBufferString("Passport\nJohn Doe\nX1234567\nGovernment\n2020-01-01\n2030-01-01\nnotes<")
+	expected := &pb.Identity{
+		Name:       "test",
+		Type:       "Passport",
+		FullName:   "John Doe",
+		Number:     "X1234567",
+		Issuer:     "Government",
+		IssueDate:  "2020-01-01",
+		ExpireDate: "2030-01-01",
+		Notes:      "notes",
+	}
+
+	got := input("test", buf)
+	assert.Equal(t, expected, got)
+}
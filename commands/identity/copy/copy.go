@@ -0,0 +1,58 @@
+package copy
+
+import (
+	"strings"
+	"time"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/db/identity"
+
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+const example = `
+* Copy the document number
+kure identity copy Sample
+
+* Copy and clean after 30s
+kure identity copy Sample -t 30s`
+
+type copyOptions struct {
+	timeout time.Duration
+}
+
+// NewCmd returns a new command.
+func NewCmd(db *bolt.DB) *cobra.Command {
+	opts := copyOptions{}
+	cmd := &cobra.Command{
+		Use:     "copy <name>",
+		Short:   "Copy identity document number",
+		Aliases: []string{"cp"},
+		Example: example,
+		Args:    cmdutil.MustExist(db, cmdutil.Identity),
+		RunE:    runCopy(db, &opts),
+		PostRun: func(cmd *cobra.Command, args []string) {
+			// Reset variables (session)
+			opts = copyOptions{}
+		},
+	}
+
+	cmd.Flags().DurationVarP(&opts.timeout, "timeout", "t", 0, "clipboard clearing timeout")
+
+	return cmd
+}
+
+func runCopy(db *bolt.DB, opts *copyOptions) cmdutil.RunEFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		name := strings.Join(args, " ")
+		name = cmdutil.NormalizeName(name)
+
+		i, err := identity.Get(db, name)
+		if err != nil {
+			return err
+		}
+
+		return cmdutil.WriteClipboard(cmd, opts.timeout, "Number", i.Number)
+	}
+}
@@ -0,0 +1,92 @@
+package copy
+
+import (
+	"testing"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/db/identity"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/atotto/clipboard"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCopy(t *testing.T) {
+	if clipboard.Unsupported {
+		t.Skip("No clipboard utilities available")
+	}
+	db := cmdutil.SetContext(t)
+
+	record := &pb.Identity{
+		Name:   "test",
+		Number: "X1234567",
+	}
+	err := identity.Create(db, record)
+	assert.NoError(t, err, "Failed creating the identity")
+
+	cases := []struct {
+		desc    string
+		value   string
+		timeout string
+	}{
+		{
+			desc:  "Copy",
+			value: record.Number,
+		},
+		{
+			desc:    "Copy w/Timeout",
+			value:   "",
+			timeout: "1ns",
+		},
+	}
+
+	cmd := NewCmd(db)
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			cmd.SetArgs([]string{record.Name})
+			cmd.Flags().Set("timeout", tc.timeout)
+
+			err := cmd.Execute()
+			assert.NoError(t, err)
+
+			got, err := clipboard.ReadAll()
+			assert.NoError(t, err, "Failed reading from clipboard")
+
+			assert.Equal(t, tc.value, got)
+		})
+	}
+}
+
+func TestCopyErrors(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	cases := []struct {
+		desc string
+		name string
+	}{
+		{
+			desc: "Invalid name",
+			name: "",
+		},
+		{
+			desc: "Non existent identity",
+			name: "non-existent",
+		},
+	}
+
+	cmd := NewCmd(db)
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			cmd.SetArgs([]string{tc.name})
+
+			err := cmd.Execute()
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestPostRun(t *testing.T) {
+	NewCmd(nil).PostRun(nil, nil)
+}
@@ -0,0 +1,224 @@
+package edit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/db/identity"
+	"github.com/GGP1/kure/pb"
+	"github.com/GGP1/kure/sig"
+	"github.com/GGP1/kure/terminal"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+const example = `
+* Edit using the standard input
+kure identity edit Sample
+
+* Edit using the text editor
+kure identity edit Sample -i`
+
+type editOptions struct {
+	interactive bool
+}
+
+// NewCmd returns a new command.
+func NewCmd(db *bolt.DB) *cobra.Command {
+	opts := editOptions{}
+	cmd := &cobra.Command{
+		Use:   "edit <name>",
+		Short: "Edit an identity",
+		Long: `Edit an identity.
+
+If the name is edited, kure will remove the old identity and create one with the new name.`,
+		Example: example,
+		Args:    cmdutil.MustExist(db, cmdutil.Identity),
+		RunE:    runEdit(db, &opts),
+		PostRun: func(cmd *cobra.Command, args []string) {
+			// Reset variables (session)
+			opts = editOptions{}
+		},
+	}
+
+	cmd.Flags().BoolVarP(&opts.interactive, "it", "i", false, "use the text editor")
+
+	return cmd
+}
+
+func runEdit(db *bolt.DB, opts *editOptions) cmdutil.RunEFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		name := strings.Join(args, " ")
+		name = cmdutil.NormalizeName(name)
+
+		oldIdentity, err := identity.Get(db, name)
+		if err != nil {
+			return err
+		}
+
+		if opts.interactive {
+			return useTextEditor(db, oldIdentity)
+		}
+
+		return useStdin(db, os.Stdin, oldIdentity)
+	}
+}
+
+func createTempFile(i *pb.Identity) (string, error) {
+	f, err := os.CreateTemp("", "*.json")
+	if err != nil {
+		return "", errors.Wrap(err, "creating temporary file")
+	}
+
+	content, err := json.MarshalIndent(i, "", "  ")
+	if err != nil {
+		return "", errors.Wrap(err, "encoding identity")
+	}
+
+	if _, err := f.Write(content); err != nil {
+		return "", errors.Wrap(err, "writing temporary file")
+	}
+
+	if err := f.Close(); err != nil {
+		return "", errors.Wrap(err, "closing temporary file")
+	}
+
+	return f.Name(), nil
+}
+
+// readTmpFile reads the modified file and formats the identity.
+func readTmpFile(filename string) (*pb.Identity, error) {
+	var i pb.Identity
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading file")
+	}
+	defer f.Close()
+
+	if err := json.NewDecoder(f).Decode(&i); err != nil {
+		return nil, errors.Wrap(err, "decoding file")
+	}
+
+	return &i, nil
+}
+
+// updateIdentity takes the name of the identity that's being edited to check if the name was
+// changed. If it was, it will remove the old one.
+func updateIdentity(db *bolt.DB, name string, i *pb.Identity) error {
+	if i.Name == "" {
+		return cmdutil.ErrInvalidName
+	}
+
+	name = cmdutil.NormalizeName(name)
+	i.Name = cmdutil.NormalizeName(i.Name)
+
+	if err := identity.Update(db, name, i); err != nil {
+		return err
+	}
+
+	fmt.Println(i.Name, "updated")
+	return nil
+}
+
+func useStdin(db *bolt.DB, r io.Reader, oldIdentity *pb.Identity) error {
+	fmt.Println("Type '-' to clear the field or leave blank to use the current value")
+	reader := bufio.NewReader(r)
+
+	scanln := func(field, value string) string {
+		input := terminal.Scanln(reader, fmt.Sprintf("%s [%s]", field, value))
+		if input == "-" {
+			return ""
+		} else if input != "" {
+			return input
+		}
+		return value
+	}
+
+	newIdentity := &pb.Identity{
+		Name:       scanln("Name", oldIdentity.Name),
+		Type:       scanln("Type", oldIdentity.Type),
+		FullName:   scanln("Full name", oldIdentity.FullName),
+		Number:     scanln("Number", oldIdentity.Number),
+		Issuer:     scanln("Issuer", oldIdentity.Issuer),
+		IssueDate:  scanln("Issue date", oldIdentity.IssueDate),
+		ExpireDate: scanln("Expire date", oldIdentity.ExpireDate),
+	}
+
+	notes := terminal.Scanlns(reader, fmt.Sprintf("Notes [%s]", oldIdentity.Notes))
+	if notes == "" {
+		notes = oldIdentity.Notes
+	} else if notes == "-" {
+		notes = ""
+	}
+	newIdentity.Notes = notes
+
+	return updateIdentity(db, oldIdentity.Name, newIdentity)
+}
+
+func useTextEditor(db *bolt.DB, oldIdentity *pb.Identity) error {
+	editor := cmdutil.SelectEditor()
+	bin, err := exec.LookPath(editor)
+	if err != nil {
+		return errors.Errorf("executable %q not found", editor)
+	}
+
+	filename, err := createTempFile(oldIdentity)
+	if err != nil {
+		return err
+	}
+
+	sig.Signal.AddCleanup(func() error { return cmdutil.Erase(filename) })
+	defer cmdutil.Erase(filename)
+
+	// Open the temporary file with the selected text editor
+	edit := exec.Command(bin, filename)
+	edit.Stdin = os.Stdin
+	edit.Stdout = os.Stdout
+
+	if err := edit.Start(); err != nil {
+		return errors.Wrapf(err, "running %s", editor)
+	}
+
+	done := make(chan struct{}, 1)
+	errCh := make(chan error, 1)
+	go cmdutil.WatchFile(filename, done, errCh)
+
+	// Block until an event is received or an error occurs
+	select {
+	case <-done:
+	case err := <-errCh:
+		return err
+	}
+
+	if err := edit.Wait(); err != nil {
+		return err
+	}
+
+	newIdentity, err := readTmpFile(filename)
+	if err != nil {
+		return err
+	}
+
+	rmTabs := func(old string) string {
+		return strings.ReplaceAll(old, "\t", "")
+	}
+	newIdentity.Name = rmTabs(newIdentity.Name)
+	newIdentity.Type = rmTabs(newIdentity.Type)
+	newIdentity.FullName = rmTabs(newIdentity.FullName)
+	newIdentity.Number = rmTabs(newIdentity.Number)
+	newIdentity.Issuer = rmTabs(newIdentity.Issuer)
+	newIdentity.IssueDate = rmTabs(newIdentity.IssueDate)
+	newIdentity.ExpireDate = rmTabs(newIdentity.ExpireDate)
+	newIdentity.Notes = rmTabs(newIdentity.Notes)
+
+	return updateIdentity(db, oldIdentity.Name, newIdentity)
+}
@@ -0,0 +1,178 @@
+package edit
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/config"
+	"github.com/GGP1/kure/db/identity"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestEditErrors(t *testing.T) {
+	db := cmdutil.SetContext(t)
+	createIdentity(t, db, "test")
+
+	cases := []struct {
+		set  func()
+		desc string
+		name string
+		it   string
+	}{
+		{
+			desc: "Invalid name",
+			name: "",
+			set:  func() {},
+		},
+		{
+			desc: "Non-existent entry",
+			name: "non-existent",
+			it:   "true",
+			set: func() {
+				config.Set("editor", "non-existent")
+			},
+		},
+	}
+
+	cmd := NewCmd(db)
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			tc.set()
+			cmd.SetArgs([]string{tc.name})
+			cmd.Flags().Set("it", tc.it)
+
+			err := cmd.Execute()
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestCreateTempFile(t *testing.T) {
+	record := &pb.Identity{Name: "test-create-file"}
+	filename, err := createTempFile(record)
+	assert.NoError(t, err, "Failed creating the file")
+	defer os.Remove(filename)
+
+	content, err := os.ReadFile(filename)
+	assert.NoError(t, err, "Failed reading the file")
+
+	var got pb.Identity
+	err = json.Unmarshal(content, &got)
+	assert.NoError(t, err, "Failed reading the file")
+
+	if !reflect.DeepEqual(record, &got) {
+		t.Error("Expected identities to be deep equal")
+	}
+}
+
+func TestReadTmpFile(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		_, err := readTmpFile("testdata/test_read.json")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		dir, _ := os.Getwd()
+		os.Chdir("testdata")
+		// Go back to the initial directory
+		defer os.Chdir(dir)
+
+		cases := []struct {
+			desc     string
+			filename string
+		}{
+			{
+				desc:     "Does not exists",
+				filename: "does_not_exists.json",
+			},
+			{
+				desc:     "EOF",
+				filename: "test_read_EOF.json",
+			},
+		}
+
+		for _, tc := range cases {
+			t.Run(tc.desc, func(t *testing.T) {
+				_, err := readTmpFile(tc.filename)
+				assert.Error(t, err)
+			})
+		}
+	})
+}
+
+func TestUpdateIdentity(t *testing.T) {
+	db := cmdutil.SetContext(t)
+	name := "test_update"
+	createIdentity(t, db, name)
+
+	newName := "new_name"
+	newIdentity := &pb.Identity{
+		Name:       newName,
+		Type:       "Passport",
+		FullName:   "John Doe",
+		Number:     "X1234567",
+		ExpireDate: "2030-01-01",
+	}
+
+	err := updateIdentity(db, name, newIdentity)
+	assert.NoError(t, err)
+
+	i, err := identity.Get(db, newName)
+	assert.NoError(t, err)
+	assert.Equal(t, newIdentity.Number, i.Number)
+
+	_, err = identity.Get(db, name)
+	assert.Error(t, err, "The old identity wasn't removed")
+
+	t.Run("Invalid name", func(t *testing.T) {
+		newIdentity.Name = ""
+		err := updateIdentity(db, "fail", newIdentity)
+		assert.Error(t, err)
+	})
+}
+
+func TestUseStdin(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	oldIdentity := &pb.Identity{
+		Name:     "test",
+		Type:     "Passport",
+		FullName: "John Doe",
+		Number:   "X1234567",
+		Issuer:   "Government",
+		Notes:    "test\nnotes",
+	}
+
+	buf := bytes.NewBufferString("\nDriver license\n\nD7654321\n-\n2021-01-01\n\n-<\n")
+
+	err := useStdin(db, buf, oldIdentity)
+	assert.NoError(t, err)
+
+	got, err := identity.Get(db, "test")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Driver license", got.Type)
+	assert.Equal(t, oldIdentity.FullName, got.FullName)
+	assert.Equal(t, "D7654321", got.Number)
+	assert.Empty(t, got.Issuer)
+	assert.Equal(t, "2021-01-01", got.IssueDate)
+	assert.Empty(t, got.Notes)
+}
+
+func TestPostRun(t *testing.T) {
+	NewCmd(nil).PostRun(nil, nil)
+}
+
+func createIdentity(t *testing.T, db *bolt.DB, name string) {
+	t.Helper()
+	err := identity.Create(db, &pb.Identity{Name: name})
+	assert.NoError(t, err, "Failed creating the identity")
+}
@@ -0,0 +1,36 @@
+package identity
+
+import (
+	"os"
+
+	iadd "github.com/GGP1/kure/commands/identity/add"
+	icopy "github.com/GGP1/kure/commands/identity/copy"
+	iedit "github.com/GGP1/kure/commands/identity/edit"
+	ils "github.com/GGP1/kure/commands/identity/ls"
+	irm "github.com/GGP1/kure/commands/identity/rm"
+
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+const example = `
+kure identity (add|copy|edit|ls|rm)`
+
+// NewCmd returns a new command.
+func NewCmd(db *bolt.DB) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "identity",
+		Short:   "Identity operations",
+		Example: example,
+	}
+
+	cmd.AddCommand(
+		iadd.NewCmd(db, os.Stdin),
+		icopy.NewCmd(db),
+		iedit.NewCmd(db),
+		ils.NewCmd(db),
+		irm.NewCmd(db, os.Stdin),
+	)
+
+	return cmd
+}
@@ -0,0 +1,133 @@
+package ls
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/db/identity"
+	"github.com/GGP1/kure/orderedmap"
+	"github.com/GGP1/kure/pb"
+	"github.com/GGP1/kure/terminal"
+	"github.com/GGP1/kure/tree"
+
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+const example = `
+* List one, show sensitive information and QR code
+kure identity ls Sample -s -q
+
+* Filter by name
+kure identity ls Sample -f
+
+* List all
+kure identity ls`
+
+type lsOptions struct {
+	filter, qr, show bool
+}
+
+// NewCmd returns a new command.
+func NewCmd(db *bolt.DB) *cobra.Command {
+	opts := lsOptions{}
+	cmd := &cobra.Command{
+		Use:   "ls <name>",
+		Short: "List identities",
+		Long: `List identities.
+
+Document numbers are masked, except for the last four characters, unless the show flag is used.`,
+		Example: example,
+		Args:    cmdutil.MustExistLs(db, cmdutil.Identity),
+		RunE:    runLs(db, &opts),
+		PostRun: func(cmd *cobra.Command, args []string) {
+			// Reset variables (session)
+			opts = lsOptions{}
+		},
+	}
+
+	f := cmd.Flags()
+	f.BoolVarP(&opts.filter, "filter", "f", false, "filter by name")
+	f.BoolVarP(&opts.qr, "qr", "q", false, "display the QR code of the number on the terminal")
+	f.BoolVarP(&opts.show, "show", "s", false, "show the full document number")
+
+	return cmd
+}
+
+func runLs(db *bolt.DB, opts *lsOptions) cmdutil.RunEFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		name := strings.Join(args, " ")
+		name = cmdutil.NormalizeName(name)
+
+		// List all
+		if name == "" {
+			identities, err := identity.ListNames(db)
+			if err != nil {
+				return err
+			}
+
+			tree.Print(identities)
+			return nil
+		}
+
+		// Filter by name
+		if opts.filter {
+			identities, err := identity.ListNames(db)
+			if err != nil {
+				return err
+			}
+
+			var matches []string
+			for _, identity := range identities {
+				matched, err := regexp.MatchString(name, identity)
+				if err != nil {
+					return err
+				}
+
+				if matched {
+					matches = append(matches, identity)
+				}
+			}
+
+			if len(matches) == 0 {
+				return errors.New("no identities were found")
+			}
+
+			tree.Print(matches)
+			return nil
+		}
+
+		// List one
+		i, err := identity.Get(db, name)
+		if err != nil {
+			return err
+		}
+
+		if opts.qr {
+			return terminal.DisplayQRCode(i.Number)
+		}
+
+		printIdentity(name, i, opts.show)
+		return nil
+	}
+}
+
+func printIdentity(name string, i *pb.Identity, show bool) {
+	if !show {
+		i.Number = cmdutil.Mask(i.Number, 4)
+	}
+
+	mp := orderedmap.New()
+	mp.Set("Type", i.Type)
+	mp.Set("Full name", i.FullName)
+	mp.Set("Number", i.Number)
+	mp.Set("Issuer", i.Issuer)
+	mp.Set("Issue date", i.IssueDate)
+	mp.Set("Expire date", i.ExpireDate)
+	mp.Set("Notes", i.Notes)
+
+	fmt.Println(cmdutil.BuildBox(name, mp))
+}
@@ -0,0 +1,68 @@
+package rm
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/db/identity"
+	"github.com/GGP1/kure/terminal"
+
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+const example = `
+* Remove a identity
+kure identity rm Sample
+
+* Remove a directory
+kure identity rm SampleDir/
+
+* Remove multiple identities
+kure identity rm Sample Sample2 Sample3`
+
+// NewCmd returns the a new command.
+func NewCmd(db *bolt.DB, r io.Reader) *cobra.Command {
+	return &cobra.Command{
+		Use:     "rm <names>",
+		Short:   "Remove identities or directories",
+		Example: example,
+		Args:    cmdutil.MustExist(db, cmdutil.Identity, true),
+		RunE:    runRm(db, r),
+	}
+}
+
+func runRm(db *bolt.DB, r io.Reader) cmdutil.RunEFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		if !terminal.Confirm(r, "Are you sure you want to proceed?") {
+			return nil
+		}
+
+		names := make([]string, 0, len(args))
+		for _, name := range args {
+			name = cmdutil.NormalizeName(name, true)
+
+			if !strings.HasSuffix(name, "/") {
+				names = append(names, name)
+				fmt.Println("Remove:", name)
+				continue
+			}
+
+			identities, err := identity.ListNames(db)
+			if err != nil {
+				return err
+			}
+
+			for _, i := range identities {
+				if strings.HasPrefix(i, name) {
+					names = append(names, i)
+					fmt.Println("Remove:", i)
+				}
+			}
+		}
+
+		return identity.Remove(db, names...)
+	}
+}
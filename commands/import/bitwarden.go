@@ -0,0 +1,266 @@
+package importt
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/GGP1/kure/bitwarden"
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/pb"
+	"github.com/GGP1/kure/terminal"
+
+	"github.com/awnumar/memguard"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+)
+
+func readBitwarden(filePath string) (*records, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening file")
+	}
+	defer f.Close()
+
+	var password *memguard.LockedBuffer
+	export, err := bitwarden.Decode(f, func() ([]byte, error) {
+		enclave, err := terminal.ScanPassword("Bitwarden export password", false)
+		if err != nil {
+			return nil, err
+		}
+		password, err = enclave.Open()
+		if err != nil {
+			return nil, errors.Wrap(err, "opening enclave")
+		}
+		return password.Bytes(), nil
+	})
+	if password != nil {
+		password.Destroy()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return bitwardenRecords(export), nil
+}
+
+// bitwardenRecords maps Bitwarden folders to directories, logins to entries, cards to cards,
+// secure notes to notes and identities to identities.
+func bitwardenRecords(export *bitwarden.Export) *records {
+	r := newRecords()
+
+	folders := make(map[string]string, len(export.Folders))
+	for _, f := range export.Folders {
+		folders[f.ID] = f.Name
+	}
+
+	names := make(map[int]map[string]struct{})
+	for _, item := range export.Items {
+		if names[item.Type] == nil {
+			names[item.Type] = make(map[string]struct{})
+		}
+		title := itemTitle(item.Name)
+		name := uniqueName(names[item.Type], cmdutil.NormalizeName(path.Join(folders[item.FolderID], title)))
+
+		switch item.Type {
+		case bitwarden.LoginType:
+			login := item.Login
+			if login == nil {
+				login = &bitwarden.Login{}
+			}
+			var uri string
+			if len(login.URIs) > 0 {
+				uri = login.URIs[0].URI
+			}
+
+			var updatedAt int64
+			if revision, err := time.Parse(time.RFC3339, item.RevisionDate); err == nil {
+				updatedAt = revision.Unix()
+			}
+
+			r.entries = append(r.entries, &pb.Entry{
+				Name:              name,
+				Username:          login.Username,
+				Password:          login.Password,
+				URL:               uri,
+				Notes:             joinFields(item.Notes, bitwardenFields(item.Fields)...),
+				Expires:           "Never",
+				PasswordUpdatedAt: updatedAt,
+			})
+
+			if t := parseTOTP(name, login.TOTP); t != nil {
+				r.totps = append(r.totps, t)
+			}
+
+		case bitwarden.CardType:
+			card := item.Card
+			if card == nil {
+				card = &bitwarden.Card{}
+			}
+			var expireDate string
+			if card.ExpMonth != "" || card.ExpYear != "" {
+				expireDate = fmt.Sprintf("%02s/%s", card.ExpMonth, card.ExpYear)
+			}
+			fields := bitwardenFields(item.Fields)
+			if card.CardholderName != "" {
+				fields = append([]string{"Cardholder name: " + card.CardholderName}, fields...)
+			}
+
+			r.cards = append(r.cards, &pb.Card{
+				Name:         name,
+				Type:         card.Brand,
+				Number:       card.Number,
+				SecurityCode: card.Code,
+				ExpireDate:   expireDate,
+				Notes:        joinFields(item.Notes, fields...),
+			})
+
+		case bitwarden.SecureNoteType:
+			r.notes = append(r.notes, &pb.Note{
+				Name: name,
+				Text: joinFields(item.Notes, bitwardenFields(item.Fields)...),
+			})
+
+		case bitwarden.IdentityType:
+			id := item.Identity
+			if id == nil {
+				id = &bitwarden.Identity{}
+			}
+			r.identities = append(r.identities, bitwardenIdentity(name, *id, item))
+
+		case bitwarden.SSHKeyType:
+			key := item.SSHKey
+			if key == nil {
+				key = &bitwarden.SSHKey{}
+			}
+			r.addBitwardenSSHKey(name, *key, item)
+		}
+	}
+
+	return r
+}
+
+// addBitwardenSSHKey adds the key to the records, it's stored as a note if the private key can't be parsed
+// so it's not lost.
+func (r *records) addBitwardenSSHKey(name string, key bitwarden.SSHKey, item bitwarden.Item) {
+	var comment string
+	if _, c, _, _, err := ssh.ParseAuthorizedKey([]byte(key.PublicKey)); err == nil {
+		comment = c
+	}
+
+	k := &pb.SSHKey{Name: name, PrivateKey: key.PrivateKey, Comment: comment}
+	if err := cmdutil.ValidateSSHKey(k); err == nil {
+		r.sshKeys = append(r.sshKeys, k)
+		return
+	}
+
+	fields := []string{
+		"Private key:\n" + strings.TrimSpace(key.PrivateKey),
+		"Public key: " + key.PublicKey,
+		"Fingerprint: " + key.KeyFingerprint,
+	}
+	r.notes = append(r.notes, &pb.Note{
+		Name: name,
+		Text: joinFields(item.Notes, append(fields, bitwardenFields(item.Fields)...)...),
+	})
+}
+
+func bitwardenFields(fields []bitwarden.Field) []string {
+	lines := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if f.Type == bitwarden.LinkedField {
+			continue
+		}
+		lines = append(lines, f.Name+": "+f.Value)
+	}
+	return lines
+}
+
+// bitwardenIdentity maps the first document number found (passport, license or SSN) to the identity
+// number, the rest of the fields are stored in the notes.
+func bitwardenIdentity(name string, id bitwarden.Identity, item bitwarden.Item) *pb.Identity {
+	identity := &pb.Identity{
+		Name:     name,
+		FullName: strings.Join(strings.Fields(strings.Join([]string{id.FirstName, id.MiddleName, id.LastName}, " ")), " "),
+	}
+
+	documents := []struct {
+		kind   string
+		number *string
+	}{
+		{"Passport", &id.PassportNumber},
+		{"Driver license", &id.LicenseNumber},
+		{"SSN", &id.SSN},
+	}
+	for _, doc := range documents {
+		if *doc.number != "" {
+			identity.Type = doc.kind
+			identity.Number = *doc.number
+			*doc.number = ""
+			break
+		}
+	}
+
+	pairs := []struct {
+		key   string
+		value string
+	}{
+		{"Title", id.Title},
+		{"Username", id.Username},
+		{"Company", id.Company},
+		{"Email", id.Email},
+		{"Phone", id.Phone},
+		{"Address 1", id.Address1},
+		{"Address 2", id.Address2},
+		{"Address 3", id.Address3},
+		{"City", id.City},
+		{"State", id.State},
+		{"Postal code", id.PostalCode},
+		{"Country", id.Country},
+		{"SSN", id.SSN},
+		{"Passport number", id.PassportNumber},
+		{"License number", id.LicenseNumber},
+	}
+
+	fields := make([]string, 0, len(pairs)+len(item.Fields))
+	for _, p := range pairs {
+		if p.value != "" {
+			fields = append(fields, p.key+": "+p.value)
+		}
+	}
+	fields = append(fields, bitwardenFields(item.Fields)...)
+	identity.Notes = joinFields(item.Notes, fields...)
+
+	return identity
+}
+
+// joinFields returns the notes followed by the fields, one per line.
+func joinFields(notes string, fields ...string) string {
+	lines := make([]string, 0, len(fields)+1)
+	if notes != "" {
+		lines = append(lines, notes)
+	}
+	lines = append(lines, fields...)
+	return strings.Join(lines, "\n")
+}
+
+// itemTitle returns the title of an item, "/" is replaced to avoid creating unexpected directories.
+func itemTitle(title string) string {
+	title = strings.TrimSpace(strings.ReplaceAll(title, "/", "-"))
+	if title == "" {
+		return "untitled"
+	}
+	return title
+}
+
+func textFile(name, content string) *pb.File {
+	return &pb.File{
+		Name:      name,
+		Content:   []byte(content),
+		Size:      int64(len(content)),
+		CreatedAt: time.Now().Unix(),
+		UpdatedAt: time.Time{}.Unix(),
+	}
+}
@@ -0,0 +1,150 @@
+package importt
+
+import (
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/pkg/errors"
+)
+
+// csvLayout contains the header names (lowercased) of each entry field, the first one found is used.
+type csvLayout struct {
+	name     []string
+	dir      []string
+	username []string
+	password []string
+	url      []string
+	notes    []string
+	totp     []string
+	// Unix milliseconds
+	modified []string
+}
+
+// csvLayouts contains the layouts of the CSV files whose columns are identified by their header.
+var csvLayouts = map[string]csvLayout{
+	// Chromium based browsers (Chrome, Edge, Brave, Opera...)
+	"chrome": {
+		name:     []string{"name"},
+		username: []string{"username"},
+		password: []string{"password"},
+		url:      []string{"url"},
+		notes:    []string{"note"},
+	},
+	"firefox": {
+		username: []string{"username"},
+		password: []string{"password"},
+		url:      []string{"url"},
+		modified: []string{"timepasswordchanged"},
+	},
+	"safari": {
+		name:     []string{"title"},
+		username: []string{"username"},
+		password: []string{"password"},
+		url:      []string{"url"},
+		notes:    []string{"notes"},
+		totp:     []string{"otpauth"},
+	},
+	// Dashlane credentials.csv
+	"dashlane": {
+		name:     []string{"title"},
+		dir:      []string{"category"},
+		username: []string{"username", "login", "email"},
+		password: []string{"password"},
+		url:      []string{"url"},
+		notes:    []string{"note"},
+		totp:     []string{"otpurl", "otpsecret"},
+	},
+}
+
+// csvTable is a CSV file whose columns are accessed by their header name.
+type csvTable struct {
+	columns map[string]int
+	rows    [][]string
+}
+
+func newCSVTable(records [][]string) (*csvTable, error) {
+	if len(records) == 0 {
+		return nil, errors.New("the CSV file is empty")
+	}
+
+	columns := make(map[string]int, len(records[0]))
+	for i, h := range records[0] {
+		// Remove the BOM some exporters add
+		h = strings.TrimPrefix(h, "\ufeff")
+		columns[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+
+	return &csvTable{columns: columns, rows: records[1:]}, nil
+}
+
+// get returns the value of the first column found or an empty string.
+func (t *csvTable) get(row []string, names ...string) string {
+	for _, name := range names {
+		if i, ok := t.columns[name]; ok && i < len(row) {
+			return row[i]
+		}
+	}
+	return ""
+}
+
+// layoutRecords maps the rows of a CSV file to entries and TOTPs using the manager layout.
+func layoutRecords(manager string, records [][]string) (*records, error) {
+	layout, ok := csvLayouts[manager]
+	if !ok {
+		return nil, errors.Errorf("unsupported CSV layout %q", manager)
+	}
+
+	table, err := newCSVTable(records)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := table.columns[layout.password[0]]; !ok {
+		return nil, errors.Errorf("invalid %s CSV file: missing %q column", manager, layout.password[0])
+	}
+
+	r := newRecords()
+	names := make(map[string]struct{}, len(table.rows))
+	for _, row := range table.rows {
+		rawURL := table.get(row, layout.url...)
+		title := table.get(row, layout.name...)
+		if title == "" {
+			title = hostname(rawURL)
+		}
+		name := uniqueName(names, cmdutil.NormalizeName(path.Join(table.get(row, layout.dir...), itemTitle(title))))
+
+		var updatedAt int64
+		if ms, err := strconv.ParseInt(table.get(row, layout.modified...), 10, 64); err == nil {
+			updatedAt = ms / 1000
+		}
+
+		r.entries = append(r.entries, &pb.Entry{
+			Name:              name,
+			Username:          table.get(row, layout.username...),
+			Password:          table.get(row, layout.password...),
+			URL:               rawURL,
+			Notes:             table.get(row, layout.notes...),
+			Expires:           "Never",
+			PasswordUpdatedAt: updatedAt,
+		})
+
+		if t := parseTOTP(name, table.get(row, layout.totp...)); t != nil {
+			r.totps = append(r.totps, t)
+		}
+	}
+
+	return r, nil
+}
+
+// hostname returns the URL host without the "www." prefix, browsers don't store a name for the logins.
+func hostname(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		return rawURL
+	}
+	return strings.TrimPrefix(u.Hostname(), "www.")
+}
@@ -0,0 +1,133 @@
+package importt
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"path"
+	"strings"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/pkg/errors"
+)
+
+func readDashlaneZip(filePath string) (*records, error) {
+	zr, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening file")
+	}
+	defer zr.Close()
+
+	return dashlaneRecords(&zr.Reader)
+}
+
+// dashlaneRecords maps the CSV files of a Dashlane export: credentials to entries, payment cards to cards,
+// bank accounts to bank accounts and secure notes to notes. The rest of the files are ignored.
+func dashlaneRecords(zr *zip.Reader) (*records, error) {
+	r := newRecords()
+
+	for _, f := range zr.File {
+		base := strings.ToLower(path.Base(f.Name))
+		switch base {
+		case "credentials.csv", "payments.csv", "securenotes.csv":
+		default:
+			continue
+		}
+
+		data, err := readZipEntry(f)
+		if err != nil {
+			return nil, err
+		}
+		rows, err := parseCSV(data)
+		if err != nil {
+			return nil, errors.Wrap(err, f.Name)
+		}
+
+		if base == "credentials.csv" {
+			credentials, err := layoutRecords("dashlane", rows)
+			if err != nil {
+				return nil, err
+			}
+			r.entries = append(r.entries, credentials.entries...)
+			r.totps = append(r.totps, credentials.totps...)
+			continue
+		}
+
+		table, err := newCSVTable(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, f.Name)
+		}
+		if base == "payments.csv" {
+			addDashlanePayments(r, table)
+		} else {
+			addDashlaneNotes(r, table)
+		}
+	}
+
+	return r, nil
+}
+
+func addDashlanePayments(r *records, table *csvTable) {
+	names := make(map[string]struct{}, len(table.rows))
+	bankNames := make(map[string]struct{})
+	for _, row := range table.rows {
+		switch table.get(row, "type") {
+		case "", "payment_card":
+		case "bank":
+			r.banks = append(r.banks, &pb.BankAccount{
+				Name:          uniqueName(bankNames, cmdutil.NormalizeName(itemTitle(table.get(row, "account_name")))),
+				Bank:          table.get(row, "issuing_bank"),
+				Holder:        table.get(row, "account_holder"),
+				AccountNumber: table.get(row, "account_number"),
+				RoutingNumber: table.get(row, "routing_number"),
+			})
+			continue
+		default:
+			continue
+		}
+
+		var expireDate string
+		month, year := table.get(row, "expiration_month"), table.get(row, "expiration_year")
+		if month != "" || year != "" {
+			expireDate = fmt.Sprintf("%02s/%s", month, year)
+		}
+
+		var notes []string
+		if holder := table.get(row, "account_holder"); holder != "" {
+			notes = append(notes, "Cardholder name: "+holder)
+		}
+		if bank := table.get(row, "issuing_bank"); bank != "" {
+			notes = append(notes, "Issuing bank: "+bank)
+		}
+
+		r.cards = append(r.cards, &pb.Card{
+			Name:         uniqueName(names, cmdutil.NormalizeName(itemTitle(table.get(row, "account_name")))),
+			Number:       table.get(row, "cc_number"),
+			SecurityCode: table.get(row, "code"),
+			ExpireDate:   expireDate,
+			Notes:        strings.Join(notes, "\n"),
+		})
+	}
+}
+
+func addDashlaneNotes(r *records, table *csvTable) {
+	names := make(map[string]struct{}, len(table.rows))
+	for _, row := range table.rows {
+		name := uniqueName(names, cmdutil.NormalizeName(path.Join(table.get(row, "category"), itemTitle(table.get(row, "title")))))
+		r.notes = append(r.notes, &pb.Note{Name: name, Text: table.get(row, "note")})
+	}
+}
+
+func parseCSV(data []byte) ([][]string, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	// Dashlane and browsers don't always use the same number of fields per record
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, errors.Wrap(err, "reading csv data")
+	}
+	return records, nil
+}
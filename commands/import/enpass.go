@@ -0,0 +1,162 @@
+package importt
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/pkg/errors"
+)
+
+type enpassExport struct {
+	Folders []struct {
+		UUID  string `json:"uuid"`
+		Title string `json:"title"`
+	} `json:"folders"`
+	Items []enpassItem `json:"items"`
+}
+
+type enpassItem struct {
+	Title    string   `json:"title"`
+	Category string   `json:"category"`
+	Note     string   `json:"note"`
+	Folders  []string `json:"folders"`
+	Trashed  int      `json:"trashed"`
+	// Unix seconds
+	UpdatedAt   int64         `json:"updated_at"`
+	Fields      []enpassField `json:"fields"`
+	Attachments []struct {
+		Name string `json:"name"`
+		// Base64 encoded content
+		Data string `json:"data"`
+	} `json:"attachments"`
+}
+
+type enpassField struct {
+	Label   string `json:"label"`
+	Type    string `json:"type"`
+	Value   string `json:"value"`
+	Deleted int    `json:"deleted"`
+}
+
+func readEnpass(filePath string) (*records, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading file")
+	}
+
+	var export enpassExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, errors.Wrap(err, "decoding JSON")
+	}
+
+	return enpassRecords(&export)
+}
+
+// enpassRecords maps Enpass folders to directories, credit cards to cards, notes to notes and the rest
+// of the items to entries. Trashed items are skipped.
+func enpassRecords(export *enpassExport) (*records, error) {
+	folders := make(map[string]string, len(export.Folders))
+	for _, f := range export.Folders {
+		folders[f.UUID] = f.Title
+	}
+
+	r := newRecords()
+	names := make(map[string]map[string]struct{})
+	uniqueRecordName := func(kind, dir, title string) string {
+		if names[kind] == nil {
+			names[kind] = make(map[string]struct{})
+		}
+		return uniqueName(names[kind], cmdutil.NormalizeName(path.Join(dir, itemTitle(title))))
+	}
+
+	for _, item := range export.Items {
+		if item.Trashed != 0 {
+			continue
+		}
+
+		var dir string
+		if len(item.Folders) > 0 {
+			dir = folders[item.Folders[0]]
+		}
+
+		var name string
+		switch item.Category {
+		case "creditcard":
+			name = uniqueRecordName("card", dir, item.Title)
+			c := &pb.Card{Name: name}
+			fields := enpassFields(item.Fields, map[string]*string{
+				"ccType":   &c.Type,
+				"ccNumber": &c.Number,
+				"ccCvc":    &c.SecurityCode,
+				"ccExpiry": &c.ExpireDate,
+			})
+			c.Notes = joinFields(item.Note, fields...)
+			r.cards = append(r.cards, c)
+
+		case "note":
+			name = uniqueRecordName("note", dir, item.Title)
+			r.notes = append(r.notes, &pb.Note{
+				Name: name,
+				Text: joinFields(item.Note, enpassFields(item.Fields, nil)...),
+			})
+
+		default:
+			name = uniqueRecordName("entry", dir, item.Title)
+			e := &pb.Entry{Name: name, Expires: "Never", PasswordUpdatedAt: item.UpdatedAt}
+			var email, totpValue string
+			fields := enpassFields(item.Fields, map[string]*string{
+				"username": &e.Username,
+				"email":    &email,
+				"password": &e.Password,
+				"url":      &e.URL,
+				"totp":     &totpValue,
+			})
+			if e.Username == "" {
+				e.Username = email
+			} else if email != "" {
+				fields = append([]string{"E-mail: " + email}, fields...)
+			}
+			e.Notes = joinFields(item.Note, fields...)
+			r.entries = append(r.entries, e)
+
+			if t := parseTOTP(name, totpValue); t != nil {
+				r.totps = append(r.totps, t)
+			}
+		}
+
+		for _, a := range item.Attachments {
+			content, err := base64.StdEncoding.DecodeString(a.Data)
+			if err != nil {
+				return nil, errors.Wrapf(err, "decoding attachment %q", a.Name)
+			}
+			f := textFile(uniqueRecordName("file", name, a.Name), "")
+			f.Content = content
+			f.Size = int64(len(content))
+			r.files = append(r.files, f)
+		}
+	}
+
+	return r, nil
+}
+
+// enpassFields stores the first value of the fields whose type is in mapped and returns the rest
+// formatted as "label: value". Deleted, empty and section fields are skipped.
+func enpassFields(fields []enpassField, mapped map[string]*string) []string {
+	var lines []string
+	for _, f := range fields {
+		if f.Deleted != 0 || f.Value == "" || f.Type == "section" {
+			continue
+		}
+		if dst, ok := mapped[f.Type]; ok && *dst == "" {
+			*dst = f.Value
+			continue
+		}
+		lines = append(lines, f.Label+": "+f.Value)
+	}
+	return lines
+}
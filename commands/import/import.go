@@ -1,15 +1,15 @@
 package importt
 
 import (
+	"bufio"
 	"encoding/csv"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 
 	cmdutil "github.com/GGP1/kure/commands"
-	"github.com/GGP1/kure/db/entry"
-	"github.com/GGP1/kure/db/totp"
 	"github.com/GGP1/kure/pb"
 
 	"github.com/pkg/errors"
@@ -21,66 +21,188 @@ const example = `
 * Import
 kure import keepass -p path/to/file
 
+* Import from an encrypted KeePass database
+kure import keepassxc -p path/to/file.kdbx
+
+* Import from a Bitwarden JSON export
+kure import bitwarden -p path/to/file.json
+
+* Import from a 1Password export
+kure import 1password -p path/to/file.1pux
+
+* Import from a browser
+kure import chrome -p path/to/passwords.csv
+
+* Import from a Dashlane export (credentials, payments and secure notes)
+kure import dashlane -p path/to/export.zip
+
+* Import from the password store (defaults to ~/.password-store)
+kure import pass --gpg-key path/to/private.asc
+
+* Preview the changes without importing
+kure import bitwarden -p path/to/file.json --dry-run
+
+* Keep the records modified last
+kure import keepassxc -p path/to/file.kdbx -c keep-newest
+
+* Decide what to do on each conflict
+kure import 1password -p path/to/file.1pux -i
+
+* Import the cards and entries in the "work" directory from a kure export
+kure import kure -p path/to/vault.kure --types card,entry --prefix work/
+
 * Import and delete the file:
 kure import 1password -e -p path/to/file`
 
 type importOptions struct {
-	path  string
-	erase bool
+	path        string
+	gpgKey      string
+	conflict    string
+	types       []string
+	prefixes    []string
+	dryRun      bool
+	erase       bool
+	interactive bool
 }
 
 // NewCmd returns a new command.
-func NewCmd(db *bolt.DB) *cobra.Command {
+func NewCmd(db *bolt.DB, r io.Reader) *cobra.Command {
 	opts := importOptions{}
 	cmd := &cobra.Command{
 		Use:   "import <manager-name>",
 		Short: "Import entries",
 		Long: `Import entries from other password managers. Format: CSV.
 
-If an entry already exists it will be overwritten.
+KeePass databases (KDBX 4) can be imported directly when the file extension is ".kdbx", no plaintext file is needed. Groups are mapped to directories, attachments to files, TOTP fields to TOTPs and custom fields are appended to the entry notes.
+
+Bitwarden JSON exports (".json"), including password protected ones, and 1Password exports (".1pux") import cards, secure notes, identities and attachments as well. Cards are mapped to cards, secure notes, identities and documents to files, and custom fields are appended to the record notes.
+
+Chrome (and other Chromium based browsers), Firefox and Safari password CSV files are read using their headers. Dashlane exports can be either the credentials CSV or the whole ZIP archive, which includes payment cards and secure notes. Enpass exports must be in JSON format.
+
+The pass password store (the path defaults to $PASSWORD_STORE_DIR or ~/.password-store) is decrypted using the private key provided with --gpg-key. The first line of each file is mapped to the password, "username" and "url" lines to their fields and the remaining lines to the notes. The directory structure is preserved.
+
+Kure exports (created with "kure export kure") contain every record of a vault, encrypted with the passphrase chosen when exporting. Use the types flag to import only some kinds of records (bank, card, entry, file, identity, note, ssh or totp) and the prefix flag to import only the records whose name starts with one of the prefixes.
+
+Records identical to the ones stored are left unchanged. When a record already exists, the conflict flag decides what to do:
+	• overwrite: replace the stored record (default)
+	• skip: keep the stored record
+	• keep-newest: replace the stored record only if the imported one was modified later. Records without a modification time (cards, TOTPs and most CSV formats) are considered older
+	• rename: store the imported record with a numeric suffix
+
+Use the interactive flag to see the differences and choose what to do on each conflict and the dry-run flag to print the changes without modifying the database. TOTPs follow the decision taken on the entry with the same name.
 
 Delete the CSV used with the erase flag, the file will be deleted only if no errors were encountered.
 
 Supported:
 	• 1Password
 	• Bitwarden
+	• Chrome
+	• Dashlane
+	• Enpass
+	• Firefox
    	• Keepass/X/XC
-	• Lastpass`,
+	• Kure
+	• Lastpass
+	• pass
+	• Safari`,
 		Example: example,
-		Args:    cmdutil.SupportedManagers(),
-		RunE:    runImport(db, &opts),
+		Args:    cmdutil.SupportedImportSources(),
+		RunE:    runImport(db, r, &opts),
 		PostRun: func(cmd *cobra.Command, args []string) {
 			// Reset variables (session)
-			opts = importOptions{}
+			opts = importOptions{
+				conflict: overwrite,
+			}
 		},
 	}
 
 	f := cmd.Flags()
 	f.StringVarP(&opts.path, "path", "p", "", "source file path")
 	f.BoolVarP(&opts.erase, "erase", "e", false, "erase the file on exit (only if there are no errors)")
+	f.StringVar(&opts.gpgKey, "gpg-key", "", "GPG private key used to decrypt the password store (pass only)")
+	f.StringVarP(&opts.conflict, "conflict", "c", overwrite, "what to do with existing records [overwrite|skip|keep-newest|rename]")
+	f.BoolVarP(&opts.dryRun, "dry-run", "d", false, "print the changes without modifying the database")
+	f.BoolVarP(&opts.interactive, "interactive", "i", false, "choose what to do on each conflict")
+	f.StringSliceVarP(&opts.types, "types", "t", nil, "record types to import [bank|card|entry|file|identity|note|ssh|totp] (kure only)")
+	f.StringSliceVar(&opts.prefixes, "prefix", nil, "import only the records whose name starts with a prefix (kure only)")
+
+	cmd.MarkFlagsMutuallyExclusive("dry-run", "erase")
+	cmd.MarkFlagsMutuallyExclusive("dry-run", "interactive")
+	cmd.MarkFlagsMutuallyExclusive("conflict", "interactive")
 
 	return cmd
 }
 
-func runImport(db *bolt.DB, opts *importOptions) cmdutil.RunEFunc {
+func runImport(db *bolt.DB, r io.Reader, opts *importOptions) cmdutil.RunEFunc {
 	return func(cmd *cobra.Command, args []string) error {
 		manager := strings.Join(args, " ")
 		manager = strings.ToLower(manager)
 
-		if opts.path == "" {
-			return cmdutil.ErrInvalidPath
+		if err := validStrategy(opts.conflict); err != nil {
+			return err
+		}
+
+		filter := kureFilter{types: opts.types, prefixes: opts.prefixes}
+		if manager != "kure" && (len(filter.types) > 0 || len(filter.prefixes) > 0) {
+			return errors.New("the types and prefix flags are only supported when importing a kure export")
 		}
-		ext := filepath.Ext(opts.path)
-		if ext == "" || ext == "." {
-			opts.path += ".csv"
+		if err := filter.validate(); err != nil {
+			return err
 		}
 
-		records, err := readCSV(opts.path)
+		var (
+			in  *records
+			err error
+		)
+		switch manager {
+		case "kure":
+			if opts.path == "" {
+				return cmdutil.ErrInvalidPath
+			}
+			if ext := filepath.Ext(opts.path); ext == "" || ext == "." {
+				opts.path += ".kure"
+			}
+			in, err = readKure(opts.path, filter)
+
+		case "pass":
+			if opts.erase {
+				return errors.New("the erase flag is not supported when importing a password store")
+			}
+			if opts.path == "" {
+				opts.path = passwordStoreDir()
+			}
+			in, err = readPass(opts.path, opts.gpgKey)
+
+		default:
+			if opts.path == "" {
+				return cmdutil.ErrInvalidPath
+			}
+			ext := filepath.Ext(opts.path)
+			if ext == "" || ext == "." {
+				opts.path += ".csv"
+			}
+			in, err = readRecords(manager, opts.path, strings.ToLower(ext))
+		}
 		if err != nil {
 			return err
 		}
 
-		if err := createEntries(db, manager, records); err != nil {
+		res := &resolver{db: db, strategy: opts.conflict}
+		if opts.interactive {
+			res.r = bufio.NewReader(r)
+		}
+		out, changes, err := res.resolve(in)
+		if err != nil {
+			return err
+		}
+
+		if opts.dryRun {
+			printChanges(os.Stdout, changes)
+			fmt.Printf("\nDry run: %s. No records were modified\n", countChanges(changes))
+			return nil
+		}
+
+		if err := createRecords(db, out); err != nil {
 			return err
 		}
 
@@ -91,14 +213,66 @@ func runImport(db *bolt.DB, opts *importOptions) cmdutil.RunEFunc {
 			fmt.Println("Erased file at", opts.path)
 		}
 
-		fmt.Println("Successfully imported the entries from", manager)
+		fmt.Printf("Successfully imported the entries from %s (%s)\n", manager, countChanges(changes))
 		return nil
 	}
 }
 
-func createEntries(db *bolt.DB, manager string, records [][]string) error {
+// readRecords reads the records from the file using the format indicated by its extension.
+func readRecords(manager, path, ext string) (*records, error) {
+	switch {
+	case ext == ".kdbx":
+		if !isKeePass(manager) {
+			return nil, errors.Errorf("the KDBX format is only supported by KeePass, not by %s", manager)
+		}
+		return readKDBX(path)
+
+	case ext == ".1pux":
+		if manager != "1password" {
+			return nil, errors.Errorf("the 1PUX format is only supported by 1Password, not by %s", manager)
+		}
+		return read1PUX(path)
+
+	case ext == ".json" && manager == "bitwarden":
+		return readBitwarden(path)
+
+	case ext == ".json" && manager == "enpass":
+		return readEnpass(path)
+
+	case ext == ".zip" && manager == "dashlane":
+		return readDashlaneZip(path)
+
+	case manager == "enpass":
+		return nil, errors.New("Enpass exports must be in JSON format")
+
+	default:
+		rows, err := readCSV(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := csvLayouts[manager]; ok {
+			return layoutRecords(manager, rows)
+		}
+
+		return csvRecords(manager, rows), nil
+	}
+}
+
+// passwordStoreDir returns the default location of the password store.
+func passwordStoreDir() string {
+	if dir := os.Getenv("PASSWORD_STORE_DIR"); dir != "" {
+		return dir
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".password-store")
+}
+
+// csvRecords maps the rows of the CSV files whose columns are identified by their position.
+func csvRecords(manager string, records [][]string) *records {
 	// [1:] used to skip headers
 	records = records[:][1:]
+	r := newRecords()
 	entries := make([]*pb.Entry, len(records))
 
 	switch manager {
@@ -165,29 +339,24 @@ func createEntries(db *bolt.DB, manager string, records [][]string) error {
 				Expires:  "Never",
 			}
 
-			// Create TOTP if the entry has one
-			if err := createTOTP(db, name, record[9]); err != nil {
-				return err
+			// Create TOTP if the entry has one, Bitwarden uses 6 digits by default
+			if t := parseTOTP(name, record[9]); t != nil {
+				r.totps = append(r.totps, t)
 			}
 		}
 	}
 
-	return entry.Create(db, entries...)
+	r.entries = entries
+	return r
 }
 
-func createTOTP(db *bolt.DB, name, rawToken string) error {
-	if rawToken == "" {
-		return nil
-	}
-
-	t := &pb.TOTP{
-		Name: name,
-		Raw:  rawToken,
-		// Bitwarden uses 6 digits by default
-		Digits: 6,
+func isKeePass(manager string) bool {
+	switch manager {
+	case "keepass", "keepassx", "keepassxc":
+		return true
+	default:
+		return false
 	}
-
-	return totp.Create(db, t)
 }
 
 func readCSV(path string) ([][]string, error) {
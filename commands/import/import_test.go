@@ -1,14 +1,28 @@
 package importt
 
 import (
+	"archive/zip"
+	"bytes"
 	"os"
+	"path/filepath"
 	"runtime"
 	"testing"
+	"time"
 
 	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/db/bank"
+	"github.com/GGP1/kure/db/card"
 	"github.com/GGP1/kure/db/entry"
+	"github.com/GGP1/kure/db/file"
+	"github.com/GGP1/kure/db/identity"
+	"github.com/GGP1/kure/db/note"
+	"github.com/GGP1/kure/db/sshkey"
+	"github.com/GGP1/kure/db/totp"
+	"github.com/GGP1/kure/kdbx"
 	"github.com/GGP1/kure/pb"
 
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/protobuf/proto"
 )
@@ -83,9 +97,71 @@ func TestImport(t *testing.T) {
 				Expires:  "Never",
 			},
 		},
+		{
+			manager: "Chrome",
+			path:    "testdata/test_chrome.csv",
+			expected: &pb.Entry{
+				Name:     "chrome",
+				Username: "test@chrome.com",
+				Password: "chrome123",
+				URL:      "https://chrome.com/",
+				Notes:    "Notes",
+				Expires:  "Never",
+			},
+		},
+		{
+			manager: "Firefox",
+			path:    "testdata/test_firefox.csv",
+			// Firefox does not store names, the URL hostname is used instead
+			expected: &pb.Entry{
+				Name:              "mozilla.org",
+				Username:          "test@firefox.com",
+				Password:          "firefox123",
+				URL:               "https://www.mozilla.org/",
+				Expires:           "Never",
+				PasswordUpdatedAt: 1614298956,
+			},
+		},
+		{
+			manager: "Safari",
+			path:    "testdata/test_safari.csv",
+			expected: &pb.Entry{
+				Name:     "safari",
+				Username: "test@safari.com",
+				Password: "safari123",
+				URL:      "https://apple.com/",
+				Notes:    "Notes",
+				Expires:  "Never",
+			},
+		},
+		{
+			manager: "Dashlane",
+			path:    "testdata/test_dashlane.csv",
+			// kure will join categories with the entry names
+			expected: &pb.Entry{
+				Name:     "test/dashlane",
+				Username: "test@dashlane.com",
+				Password: "dashlane123",
+				URL:      "https://dashlane.com/",
+				Notes:    "Notes",
+				Expires:  "Never",
+			},
+		},
+		{
+			manager: "Enpass",
+			path:    "testdata/test_enpass.json",
+			expected: &pb.Entry{
+				Name:     "test/enpass",
+				Username: "test@enpass.io",
+				Password: "enpass123",
+				URL:      "https://enpass.io/",
+				Notes:    "Notes\nE-mail: other@enpass.io\nPet: Dog",
+				Expires:  "Never",
+			},
+		},
 	}
 
-	cmd := NewCmd(db)
+	cmd := NewCmd(db, nil)
 
 	for _, tc := range cases {
 		t.Run(tc.manager, func(t *testing.T) {
@@ -147,9 +223,39 @@ func TestInvalidImport(t *testing.T) {
 			manager: "1password",
 			path:    "testdata/test_invalid_entry.csv",
 		},
+		{
+			desc:    "KDBX unsupported manager",
+			manager: "bitwarden",
+			path:    "testdata/test.kdbx",
+		},
+		{
+			desc:    "1PUX unsupported manager",
+			manager: "bitwarden",
+			path:    "testdata/test.1pux",
+		},
+		{
+			desc:    "Enpass CSV",
+			manager: "enpass",
+			path:    "testdata/test_chrome.csv",
+		},
+		{
+			desc:    "Missing CSV columns",
+			manager: "firefox",
+			path:    "testdata/test_bitwarden.csv",
+		},
+		{
+			desc:    "Password store without key",
+			manager: "pass",
+			path:    "testdata",
+		},
+		{
+			desc:    "JSON unsupported manager",
+			manager: "1password",
+			path:    "testdata/test_bitwarden.json",
+		},
 	}
 
-	cmd := NewCmd(db)
+	cmd := NewCmd(db, nil)
 
 	for _, tc := range cases {
 		t.Run(tc.desc, func(t *testing.T) {
@@ -170,7 +276,7 @@ func TestImportAndErase(t *testing.T) {
 	tempFile.WriteString("test")
 	tempFile.Close()
 
-	cmd := NewCmd(db)
+	cmd := NewCmd(db, nil)
 	cmd.SetArgs([]string{"keepass"})
 	f := cmd.Flags()
 	f.Set("path", tempFile.Name())
@@ -193,7 +299,7 @@ func TestImportAndEraseError(t *testing.T) {
 	assert.NoError(t, err, "Failed creating temporary file")
 	tempFile.WriteString("test")
 
-	cmd := NewCmd(db)
+	cmd := NewCmd(db, nil)
 	cmd.SetArgs([]string{"lastpass"})
 	f := cmd.Flags()
 	f.Set("path", tempFile.Name())
@@ -204,18 +310,24 @@ func TestImportAndEraseError(t *testing.T) {
 	assert.Error(t, err)
 }
 
-func TestCreateTOTP(t *testing.T) {
-	db := cmdutil.SetContext(t)
-
+func TestParseTOTP(t *testing.T) {
 	cases := []struct {
-		desc string
-		name string
-		raw  string
+		desc     string
+		name     string
+		raw      string
+		expected *pb.TOTP
 	}{
 		{
-			desc: "Create",
-			name: "test",
-			raw:  "afrtgq",
+			desc:     "Create",
+			name:     "test",
+			raw:      "afrtgq",
+			expected: &pb.TOTP{Name: "test", Raw: "AFRTGQ", Digits: 6},
+		},
+		{
+			desc:     "Key URI",
+			name:     "test",
+			raw:      "otpauth://totp/test?secret=afrtgq&digits=8",
+			expected: &pb.TOTP{Name: "test", Raw: "AFRTGQ", Digits: 8},
 		},
 		{
 			desc: "Nothing",
@@ -225,18 +337,19 @@ func TestCreateTOTP(t *testing.T) {
 
 	for _, tc := range cases {
 		t.Run(tc.desc, func(t *testing.T) {
-			err := createTOTP(db, tc.name, tc.raw)
-			assert.NoError(t, err, "Failed creating TOTP")
+			got := parseTOTP(tc.name, tc.raw)
+			assert.Equal(t, tc.expected, got)
 		})
 	}
 }
 
 func TestArgs(t *testing.T) {
 	db := cmdutil.SetContext(t)
-	cmd := NewCmd(db)
+	cmd := NewCmd(db, nil)
 
 	t.Run("Supported", func(t *testing.T) {
-		list := []string{"1password", "bitwarden", "keepass", "keepassx", "keepassxc", "lastpass"}
+		list := []string{"1password", "bitwarden", "keepass", "keepassx", "keepassxc", "lastpass",
+			"chrome", "firefox", "safari", "dashlane", "enpass", "pass"}
 		for _, name := range list {
 			err := cmd.Args(cmd, []string{name})
 			assert.NoError(t, err)
@@ -253,5 +366,689 @@ func TestArgs(t *testing.T) {
 }
 
 func TestPostRun(t *testing.T) {
-	NewCmd(nil).PostRun(nil, nil)
+	NewCmd(nil, nil).PostRun(nil, nil)
+}
+
+func TestKDBXRecords(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	expires := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	database := &kdbx.Database{
+		Root: &kdbx.Group{
+			Name: "Root",
+			Entries: []*kdbx.Entry{
+				{
+					Title:    "Email",
+					Username: "user@example.com",
+					Password: "email123",
+					URL:      "https://mail.example.com",
+					Notes:    "Notes",
+					Expires:  expires,
+					Fields: []kdbx.Field{
+						{Key: "PIN", Value: "1234", Protected: true},
+						{Key: "otp", Value: "otpauth://totp/Email?secret=JBSWY3DPEHPK3PXP&period=30&digits=8"},
+					},
+					Attachments: []kdbx.Attachment{{Name: "Recovery.txt", Data: []byte("codes")}},
+				},
+				{Title: "Email", Password: "duplicated"},
+			},
+			Groups: []*kdbx.Group{
+				{
+					Name: "Work",
+					Entries: []*kdbx.Entry{
+						{
+							Title:    "GitHub",
+							Password: "github123",
+							Fields: []kdbx.Field{
+								{Key: "TOTP Seed", Value: "GEZDGNBVGY3TQOJQ"},
+								{Key: "TOTP Settings", Value: "30;7"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	err := createRecords(db, kdbxRecords(database))
+	assert.NoError(t, err)
+
+	cases := []*pb.Entry{
+		{
+			Name:     "email",
+			Username: "user@example.com",
+			Password: "email123",
+			URL:      "https://mail.example.com",
+			Notes:    "Notes\nPIN: 1234",
+			Expires:  expires.Local().Format(time.RFC1123Z),
+		},
+		{
+			Name:     "email (2)",
+			Password: "duplicated",
+			Expires:  "Never",
+		},
+		{
+			Name:     "work/github",
+			Password: "github123",
+			Expires:  "Never",
+		},
+	}
+
+	for _, expected := range cases {
+		got, err := entry.Get(db, expected.Name)
+		assert.NoError(t, err)
+		assert.True(t, proto.Equal(expected, got), expected.Name)
+	}
+
+	emailTOTP, err := totp.Get(db, "email")
+	assert.NoError(t, err)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", emailTOTP.Raw)
+	assert.Equal(t, int32(8), emailTOTP.Digits)
+
+	githubTOTP, err := totp.Get(db, "work/github")
+	assert.NoError(t, err)
+	assert.Equal(t, "GEZDGNBVGY3TQOJQ", githubTOTP.Raw)
+	assert.Equal(t, int32(7), githubTOTP.Digits)
+
+	f, err := file.Get(db, "email/recovery.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("codes"), f.Content)
+}
+
+func TestImportBitwardenJSON(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	cmd := NewCmd(db, nil)
+	cmd.SetArgs([]string{"bitwarden"})
+	cmd.Flags().Set("path", "testdata/test_bitwarden.json")
+
+	err := cmd.Execute()
+	assert.NoError(t, err)
+
+	gotEntry, err := entry.Get(db, "test/bitwarden")
+	assert.NoError(t, err)
+	expectedEntry := &pb.Entry{
+		Name:     "test/bitwarden",
+		Username: "test@bitwarden.com",
+		Password: "bitwarden123",
+		URL:      "https://bitwarden.com/",
+		Notes:    "Notes\nPIN: 1234",
+		Expires:  "Never",
+	}
+	assert.True(t, proto.Equal(expectedEntry, gotEntry))
+
+	gotTOTP, err := totp.Get(db, "test/bitwarden")
+	assert.NoError(t, err)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", gotTOTP.Raw)
+	assert.Equal(t, int32(8), gotTOTP.Digits)
+
+	gotCard, err := card.Get(db, "visa")
+	assert.NoError(t, err)
+	expectedCard := &pb.Card{
+		Name:         "visa",
+		Type:         "Visa",
+		Number:       "4111111111111111",
+		SecurityCode: "123",
+		ExpireDate:   "01/2030",
+		Notes:        "Cardholder name: John Doe",
+	}
+	assert.True(t, proto.Equal(expectedCard, gotCard))
+
+	gotNote, err := note.Get(db, "test/recovery codes")
+	assert.NoError(t, err)
+	assert.Equal(t, "abc-123", gotNote.Text)
+
+	gotIdentity, err := identity.Get(db, "me")
+	assert.NoError(t, err)
+	expectedIdentity := &pb.Identity{
+		Name:     "me",
+		Type:     "Passport",
+		FullName: "John Doe",
+		Number:   "X1234567",
+		Notes:    "Email: john@example.com",
+	}
+	assert.True(t, proto.Equal(expectedIdentity, gotIdentity))
+
+	gotKey, err := sshkey.Get(db, "test/github")
+	assert.NoError(t, err)
+	assert.Equal(t, "ssh-ed25519", gotKey.Type)
+	assert.Equal(t, "test@bitwarden.com", gotKey.Comment)
+	assert.Equal(t, "SHA256:lbmsoA0yIEcEiVDRnMWuzm+nV+3ZEEpVIURqFoeSspg", gotKey.Fingerprint)
+
+	// Keys that can't be parsed are stored as notes
+	gotNote, err = note.Get(db, "broken key")
+	assert.NoError(t, err)
+	assert.Equal(t, "Private key:\ninvalid\nPublic key: ssh-ed25519 AAAA\nFingerprint: SHA256:invalid", gotNote.Text)
+}
+
+func TestImport1PUX(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	exportData := `{
+		"accounts": [{
+			"vaults": [{
+				"attrs": {"name": "Personal"},
+				"items": [
+					{
+						"categoryUuid": "001",
+						"details": {
+							"loginFields": [
+								{"value": "user@example.com", "designation": "username"},
+								{"value": "email123", "designation": "password"}
+							],
+							"notesPlain": "Notes",
+							"sections": [{
+								"title": "",
+								"fields": [
+									{"title": "one-time password", "id": "TOTP_1", "value": {"totp": "otpauth://totp/email?secret=GEZDGNBVGY3TQOJQ&digits=7"}},
+									{"title": "PIN", "id": "pin", "value": {"concealed": "1234"}},
+									{"title": "recovery.txt", "id": "file1", "value": {"file": {"fileName": "recovery.txt", "documentId": "doc1"}}}
+								]
+							}]
+						},
+						"overview": {"title": "Email", "url": "https://mail.example.com"}
+					},
+					{
+						"categoryUuid": "002",
+						"details": {
+							"notesPlain": "",
+							"sections": [{
+								"fields": [
+									{"title": "cardholder name", "id": "cardholder", "value": {"string": "John Doe"}},
+									{"title": "type", "id": "type", "value": {"creditCardType": "mc"}},
+									{"title": "number", "id": "ccnum", "value": {"creditCardNumber": "5555555555554444"}},
+									{"title": "verification number", "id": "cvv", "value": {"concealed": "321"}},
+									{"title": "expiry date", "id": "expiry", "value": {"monthYear": 203102}}
+								]
+							}]
+						},
+						"overview": {"title": "Mastercard"}
+					},
+					{
+						"categoryUuid": "003",
+						"details": {"notesPlain": "secret note"},
+						"overview": {"title": "Note"}
+					},
+					{
+						"categoryUuid": "006",
+						"details": {"documentAttributes": {"fileName": "doc.pdf", "documentId": "doc2"}},
+						"overview": {"title": "Document"}
+					},
+					{
+						"categoryUuid": "106",
+						"details": {
+							"sections": [{
+								"fields": [
+									{"title": "full name", "id": "fullname", "value": {"string": "John Doe"}},
+									{"title": "number", "id": "number", "value": {"string": "X1234567"}},
+									{"title": "nationality", "id": "nationality", "value": {"string": "Atoll"}},
+									{"title": "issued on", "id": "issue_date", "value": {"date": 1577836800}}
+								]
+							}]
+						},
+						"overview": {"title": "Passport"}
+					},
+					{
+						"categoryUuid": "101",
+						"details": {
+							"sections": [{
+								"fields": [
+									{"title": "bank name", "id": "bankName", "value": {"string": "Kure Bank"}},
+									{"title": "account number", "id": "accountNo", "value": {"string": "31926819"}},
+									{"title": "IBAN", "id": "iban", "value": {"string": "GB82WEST12345698765432"}},
+									{"title": "PIN", "id": "telephonePin", "value": {"concealed": "1234"}}
+								]
+							}]
+						},
+						"overview": {"title": "Bank"}
+					},
+					{
+						"categoryUuid": "005",
+						"details": {"password": "wifi123"},
+						"overview": {"title": "WiFi"}
+					}
+				]
+			}]
+		}]
+	}`
+
+	path := filepath.Join(t.TempDir(), "test.1pux")
+	f, err := os.Create(path)
+	assert.NoError(t, err)
+	zw := zip.NewWriter(f)
+	files := map[string]string{
+		"export.data":                  exportData,
+		"files/doc1__recovery.txt":     "codes",
+		"files/doc2__doc.pdf":          "%PDF",
+		"export.attributes":            "{}",
+		"files/unreferenced__file.txt": "",
+	}
+	for name, content := range files {
+		w, err := zw.Create(name)
+		assert.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, zw.Close())
+	assert.NoError(t, f.Close())
+
+	cmd := NewCmd(db, nil)
+	cmd.SetArgs([]string{"1password"})
+	cmd.Flags().Set("path", path)
+
+	err = cmd.Execute()
+	assert.NoError(t, err)
+
+	entries := []*pb.Entry{
+		{
+			Name:     "email",
+			Username: "user@example.com",
+			Password: "email123",
+			URL:      "https://mail.example.com",
+			Notes:    "Notes\nPIN: 1234",
+			Expires:  "Never",
+		},
+		{
+			Name:     "wifi",
+			Password: "wifi123",
+			Expires:  "Never",
+		},
+	}
+	for _, expected := range entries {
+		got, err := entry.Get(db, expected.Name)
+		assert.NoError(t, err)
+		assert.True(t, proto.Equal(expected, got), expected.Name)
+	}
+
+	gotTOTP, err := totp.Get(db, "email")
+	assert.NoError(t, err)
+	assert.Equal(t, "GEZDGNBVGY3TQOJQ", gotTOTP.Raw)
+	assert.Equal(t, int32(7), gotTOTP.Digits)
+
+	gotCard, err := card.Get(db, "mastercard")
+	assert.NoError(t, err)
+	expectedCard := &pb.Card{
+		Name:         "mastercard",
+		Type:         "mc",
+		Number:       "5555555555554444",
+		SecurityCode: "321",
+		ExpireDate:   "02/2031",
+		Notes:        "cardholder name: John Doe",
+	}
+	assert.True(t, proto.Equal(expectedCard, gotCard))
+
+	expectedFiles := map[string]string{
+		"email/recovery.txt": "codes",
+		"document":           "%PDF",
+	}
+	for name, content := range expectedFiles {
+		got, err := file.Get(db, name)
+		assert.NoError(t, err)
+		assert.Equal(t, content, string(got.Content), name)
+	}
+
+	gotNote, err := note.Get(db, "note")
+	assert.NoError(t, err)
+	assert.Equal(t, "secret note", gotNote.Text)
+
+	gotIdentity, err := identity.Get(db, "passport")
+	assert.NoError(t, err)
+	expectedIdentity := &pb.Identity{
+		Name:      "passport",
+		Type:      "Passport",
+		FullName:  "John Doe",
+		Number:    "X1234567",
+		IssueDate: gotIdentity.IssueDate,
+		Notes:     "nationality: Atoll",
+	}
+	assert.NotEmpty(t, gotIdentity.IssueDate)
+	assert.True(t, proto.Equal(expectedIdentity, gotIdentity))
+
+	gotBank, err := bank.Get(db, "bank")
+	assert.NoError(t, err)
+	expectedBank := &pb.BankAccount{
+		Name:          "bank",
+		Bank:          "Kure Bank",
+		AccountNumber: "31926819",
+		Iban:          "GB82WEST12345698765432",
+		Pin:           "1234",
+	}
+	assert.True(t, proto.Equal(expectedBank, gotBank))
+}
+
+func TestImportEnpass(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	cmd := NewCmd(db, nil)
+	cmd.SetArgs([]string{"enpass"})
+	cmd.Flags().Set("path", "testdata/test_enpass.json")
+
+	err := cmd.Execute()
+	assert.NoError(t, err)
+
+	gotTOTP, err := totp.Get(db, "test/enpass")
+	assert.NoError(t, err)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", gotTOTP.Raw)
+
+	gotCard, err := card.Get(db, "visa")
+	assert.NoError(t, err)
+	expectedCard := &pb.Card{
+		Name:         "visa",
+		Type:         "Visa",
+		Number:       "4111111111111111",
+		SecurityCode: "123",
+		ExpireDate:   "01/30",
+		Notes:        "Cardholder: John Doe",
+	}
+	assert.True(t, proto.Equal(expectedCard, gotCard))
+
+	gotFile, err := file.Get(db, "test/enpass/recovery.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "codes", string(gotFile.Content))
+
+	gotNote, err := note.Get(db, "note")
+	assert.NoError(t, err)
+	assert.Equal(t, "secret note", gotNote.Text)
+
+	_, err = entry.Get(db, "trashed")
+	assert.Error(t, err, "Trashed items shouldn't be imported")
+}
+
+func TestDashlaneRecords(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	files := map[string]string{
+		"credentials.csv": "username,username2,username3,title,password,note,url,category,otpUrl\n" +
+			"user@example.com,,,Email,email123,,https://mail.example.com,,otpauth://totp/email?secret=JBSWY3DPEHPK3PXP\n",
+		"payments.csv": "type,account_name,account_holder,cc_number,code,expiration_month,expiration_year,routing_number,account_number,country,issuing_bank\n" +
+			"payment_card,Visa,John Doe,4111111111111111,123,1,2030,,,US,Bank\n" +
+			"bank,Savings,John Doe,,,,,123,456,US,Bank\n",
+		"securenotes.csv": "title,note\nNote,secret note\n",
+		"ids.csv":         "type,number,name\n",
+	}
+	for name, content := range files {
+		w, err := zw.Create(name)
+		assert.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, zw.Close())
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(t, err)
+
+	r, err := dashlaneRecords(zr)
+	assert.NoError(t, err)
+
+	assert.Len(t, r.entries, 1)
+	assert.Equal(t, "email", r.entries[0].Name)
+	assert.Equal(t, "email123", r.entries[0].Password)
+	assert.Len(t, r.totps, 1)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", r.totps[0].Raw)
+
+	assert.Len(t, r.cards, 1)
+	expectedCard := &pb.Card{
+		Name:         "visa",
+		Number:       "4111111111111111",
+		SecurityCode: "123",
+		ExpireDate:   "01/2030",
+		Notes:        "Cardholder name: John Doe\nIssuing bank: Bank",
+	}
+	assert.True(t, proto.Equal(expectedCard, r.cards[0]))
+
+	assert.Len(t, r.banks, 1)
+	expectedBank := &pb.BankAccount{
+		Name:          "savings",
+		Bank:          "Bank",
+		Holder:        "John Doe",
+		AccountNumber: "456",
+		RoutingNumber: "123",
+	}
+	assert.True(t, proto.Equal(expectedBank, r.banks[0]))
+
+	assert.Len(t, r.notes, 1)
+	assert.Equal(t, "note", r.notes[0].Name)
+	assert.Equal(t, "secret note", r.notes[0].Text)
+}
+
+func TestPassRecords(t *testing.T) {
+	key, err := openpgp.NewEntity("kure", "", "kure@example.com", nil)
+	assert.NoError(t, err)
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"email.gpg":         "email123\nusername: user@example.com\nurl: https://mail.example.com\nrecovery: abc",
+		"work/github.gpg":   "github123\notpauth://totp/github?secret=JBSWY3DPEHPK3PXP&digits=8",
+		".git/config":       "ignored",
+		".gpg-id":           "kure@example.com",
+		"work/ignored.txt":  "ignored",
+		".extensions/x.gpg": "ignored",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o700))
+
+		var buf bytes.Buffer
+		if filepath.Ext(name) == ".gpg" {
+			w, err := openpgp.Encrypt(&buf, []*openpgp.Entity{key}, nil, nil, nil)
+			assert.NoError(t, err)
+			_, err = w.Write([]byte(content))
+			assert.NoError(t, err)
+			assert.NoError(t, w.Close())
+		} else {
+			buf.WriteString(content)
+		}
+		assert.NoError(t, os.WriteFile(path, buf.Bytes(), 0o600))
+	}
+
+	r, err := passRecords(dir, openpgp.EntityList{key})
+	assert.NoError(t, err)
+
+	expected := []*pb.Entry{
+		{
+			Name:     "email",
+			Username: "user@example.com",
+			Password: "email123",
+			URL:      "https://mail.example.com",
+			Notes:    "recovery: abc",
+			Expires:  "Never",
+		},
+		{
+			Name:     "work/github",
+			Password: "github123",
+			Expires:  "Never",
+		},
+	}
+	assert.Len(t, r.entries, len(expected))
+	for i, e := range expected {
+		assert.True(t, proto.Equal(e, r.entries[i]), e.Name)
+	}
+
+	assert.Len(t, r.totps, 1)
+	assert.Equal(t, "work/github", r.totps[0].Name)
+	assert.Equal(t, int32(8), r.totps[0].Digits)
+
+	t.Run("Invalid key", func(t *testing.T) {
+		other, err := openpgp.NewEntity("other", "", "other@example.com", nil)
+		assert.NoError(t, err)
+		_, err = passRecords(dir, openpgp.EntityList{other})
+		assert.Error(t, err)
+	})
+}
+
+func TestReadGPGKey(t *testing.T) {
+	key, err := openpgp.NewEntity("kure", "", "kure@example.com", nil)
+	assert.NoError(t, err)
+
+	dir := t.TempDir()
+	privatePath := filepath.Join(dir, "private.asc")
+	var buf bytes.Buffer
+	w, err := armor.Encode(&buf, openpgp.PrivateKeyType, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, key.SerializePrivate(w, nil))
+	assert.NoError(t, w.Close())
+	assert.NoError(t, os.WriteFile(privatePath, buf.Bytes(), 0o600))
+
+	publicPath := filepath.Join(dir, "public.gpg")
+	buf.Reset()
+	assert.NoError(t, key.Serialize(&buf))
+	assert.NoError(t, os.WriteFile(publicPath, buf.Bytes(), 0o600))
+
+	keyring, err := readGPGKey(privatePath)
+	assert.NoError(t, err)
+	assert.False(t, isEncrypted(keyring))
+
+	_, err = readGPGKey(publicPath)
+	assert.Error(t, err, "Public keys can't decrypt the store")
+
+	_, err = readGPGKey("")
+	assert.Error(t, err)
+}
+
+func TestHostname(t *testing.T) {
+	cases := map[string]string{
+		"https://www.example.com/login": "example.com",
+		"https://accounts.example.com":  "accounts.example.com",
+		"android://app":                 "app",
+		"invalid":                       "invalid",
+	}
+
+	for rawURL, expected := range cases {
+		assert.Equal(t, expected, hostname(rawURL), rawURL)
+	}
+}
+
+func TestKureRecords(t *testing.T) {
+	a := &pb.Archive{
+		Cards: []*pb.Card{{Name: "work/visa"}, {Name: "mastercard"}},
+		Entries: []*pb.Entry{
+			{Name: "work/github", Password: "github123", Expires: "Never"},
+			{Name: "email", Password: "email123", Expires: "Never"},
+		},
+		Files:        []*pb.File{{Name: "work/notes.txt", Content: []byte("notes")}},
+		Totps:        []*pb.TOTP{{Name: "work/github", Raw: "JBSWY3DPEHPK3PXP", Digits: 6}},
+		Identities:   []*pb.Identity{{Name: "passport", Number: "X1234567"}},
+		BankAccounts: []*pb.BankAccount{{Name: "work/bank", AccountNumber: "31926819"}},
+		Notes:        []*pb.Note{{Name: "wifi", Text: "SSID: kure"}},
+		SshKeys:      []*pb.SSHKey{{Name: "work/id_ed25519"}},
+	}
+
+	cases := []struct {
+		desc     string
+		filter   kureFilter
+		expected []string
+	}{
+		{
+			desc:   "All",
+			filter: kureFilter{},
+			expected: []string{
+				"work/visa", "mastercard", "work/github", "email", "work/notes.txt", "work/github",
+				"passport", "work/bank", "wifi", "work/id_ed25519",
+			},
+		},
+		{
+			desc:     "New types",
+			filter:   kureFilter{types: []string{"identity", "bank", "note", "ssh"}},
+			expected: []string{"passport", "work/bank", "wifi", "work/id_ed25519"},
+		},
+		{
+			desc:     "Types",
+			filter:   kureFilter{types: []string{"entry", "totp"}},
+			expected: []string{"work/github", "email", "work/github"},
+		},
+		{
+			desc:     "Prefixes",
+			filter:   kureFilter{prefixes: []string{"work/", "mastercard"}},
+			expected: []string{"work/visa", "mastercard", "work/github", "work/notes.txt", "work/github", "work/bank", "work/id_ed25519"},
+		},
+		{
+			desc:     "Types and prefixes",
+			filter:   kureFilter{types: []string{"card"}, prefixes: []string{"work/"}},
+			expected: []string{"work/visa"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			r := kureRecords(a, tc.filter)
+
+			var got []string
+			for _, c := range r.cards {
+				got = append(got, c.Name)
+			}
+			for _, e := range r.entries {
+				got = append(got, e.Name)
+			}
+			for _, f := range r.files {
+				got = append(got, f.Name)
+			}
+			for _, t := range r.totps {
+				got = append(got, t.Name)
+			}
+			for _, i := range r.identities {
+				got = append(got, i.Name)
+			}
+			for _, b := range r.banks {
+				got = append(got, b.Name)
+			}
+			for _, n := range r.notes {
+				got = append(got, n.Name)
+			}
+			for _, k := range r.sshKeys {
+				got = append(got, k.Name)
+			}
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
+
+func TestKureFilterFlags(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	cases := []struct {
+		desc    string
+		manager string
+		flag    string
+		value   string
+	}{
+		{desc: "Invalid type", manager: "kure", flag: "types", value: "invalid"},
+		{desc: "Types with other source", manager: "chrome", flag: "types", value: "entry"},
+		{desc: "Prefix with other source", manager: "chrome", flag: "prefix", value: "work/"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			cmd := NewCmd(db, nil)
+			cmd.SetArgs([]string{tc.manager})
+			f := cmd.Flags()
+			f.Set("path", "testdata/test_chrome.csv")
+			f.Set(tc.flag, tc.value)
+
+			assert.Error(t, cmd.Execute())
+		})
+	}
+}
+
+func TestArchive(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	err := entry.Create(db, &pb.Entry{Name: "email", Password: "old", Expires: "Never"})
+	assert.NoError(t, err)
+
+	a := &pb.Archive{
+		Entries: []*pb.Entry{
+			{Name: "email", Password: "new", Expires: "Never"},
+			{Name: "github", Password: "secret", Expires: "Never"},
+		},
+	}
+	summary, err := Archive(db, a, skip)
+	assert.NoError(t, err)
+	assert.Equal(t, "1 create, 0 update, 0 rename, 1 skip, 0 unchanged", summary)
+
+	got, err := entry.Get(db, "email")
+	assert.NoError(t, err)
+	assert.Equal(t, "old", got.Password)
+
+	_, err = Archive(db, a, "invalid")
+	assert.Error(t, err)
 }
@@ -0,0 +1,208 @@
+package importt
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/kdbx"
+	"github.com/GGP1/kure/pb"
+	"github.com/GGP1/kure/terminal"
+
+	"github.com/pkg/errors"
+)
+
+// TOTP fields used by KeePassXC, KeePass 2.47+ and older plugins respectively.
+var totpFields = []string{"otp", "TimeOtp-Secret-Base32", "TimeOtp-Length", "TOTP Seed", "TOTP Settings"}
+
+func readKDBX(path string) (*records, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening file")
+	}
+	defer f.Close()
+
+	enclave, err := terminal.ScanPassword("KeePass database password", false)
+	if err != nil {
+		return nil, err
+	}
+	password, err := enclave.Open()
+	if err != nil {
+		return nil, errors.Wrap(err, "opening enclave")
+	}
+	defer password.Destroy()
+
+	database, err := kdbx.Decode(f, password.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	return kdbxRecords(database), nil
+}
+
+// kdbxRecords maps the KeePass database groups, entries, TOTPs and attachments to kure records.
+func kdbxRecords(database *kdbx.Database) *records {
+	r := newRecords()
+	if database.Root == nil {
+		return r
+	}
+
+	names := make(map[string]struct{})
+	var walk func(dir string, g *kdbx.Group)
+	walk = func(dir string, g *kdbx.Group) {
+		for _, e := range g.Entries {
+			name := uniqueName(names, cmdutil.NormalizeName(path.Join(dir, entryTitle(e))))
+			addKDBXEntry(r, name, e)
+		}
+
+		for _, sg := range g.Groups {
+			walk(path.Join(dir, sg.Name), sg)
+		}
+	}
+	// The root group is the database itself
+	walk("", database.Root)
+
+	return r
+}
+
+func addKDBXEntry(r *records, name string, e *kdbx.Entry) {
+	expires := "Never"
+	if !e.Expires.IsZero() {
+		expires = e.Expires.Local().Format(time.RFC1123Z)
+	}
+
+	var updatedAt int64
+	if !e.Modified.IsZero() {
+		updatedAt = e.Modified.Unix()
+	}
+
+	r.entries = append(r.entries, &pb.Entry{
+		Name:              name,
+		Username:          e.Username,
+		Password:          e.Password,
+		URL:               e.URL,
+		Notes:             notesWithFields(e),
+		Expires:           expires,
+		PasswordUpdatedAt: updatedAt,
+	})
+
+	if t := kdbxTOTP(name, e); t != nil {
+		r.totps = append(r.totps, t)
+	}
+
+	now := time.Now().Unix()
+	for _, a := range e.Attachments {
+		r.files = append(r.files, &pb.File{
+			Name:      cmdutil.NormalizeName(name + "/" + a.Name),
+			Content:   a.Data,
+			Size:      int64(len(a.Data)),
+			CreatedAt: now,
+			UpdatedAt: time.Time{}.Unix(),
+		})
+	}
+}
+
+// notesWithFields returns the entry notes followed by its custom fields, kure does not support them.
+func notesWithFields(e *kdbx.Entry) string {
+	var sb strings.Builder
+	sb.WriteString(e.Notes)
+
+	for _, f := range e.Fields {
+		if isTOTPField(f.Key) {
+			continue
+		}
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(&sb, "%s: %s", f.Key, f.Value)
+	}
+
+	return sb.String()
+}
+
+// kdbxTOTP returns the entry TOTP or nil if it has none.
+func kdbxTOTP(name string, e *kdbx.Entry) *pb.TOTP {
+	switch {
+	case e.Field("otp") != "":
+		return parseTOTP(name, e.Field("otp"))
+
+	case e.Field("TimeOtp-Secret-Base32") != "":
+		return newTOTP(name, e.Field("TimeOtp-Secret-Base32"), e.Field("TimeOtp-Length"))
+
+	case e.Field("TOTP Seed") != "":
+		// Format: "period;digits"
+		_, digits, _ := strings.Cut(e.Field("TOTP Settings"), ";")
+		return newTOTP(name, e.Field("TOTP Seed"), digits)
+
+	default:
+		return nil
+	}
+}
+
+// parseTOTP returns a TOTP from a key URI (otpauth://totp/...) or a raw secret, nil is returned if the value is empty or invalid.
+func parseTOTP(name, value string) *pb.TOTP {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+
+	if !strings.HasPrefix(strings.ToLower(value), "otpauth://") {
+		return newTOTP(name, value, "")
+	}
+
+	uri, err := url.Parse(value)
+	if err != nil {
+		return nil
+	}
+	query := uri.Query()
+	if query.Get("secret") == "" {
+		return nil
+	}
+	return newTOTP(name, query.Get("secret"), query.Get("digits"))
+}
+
+// newTOTP returns a TOTP using 6 digits if the ones specified are invalid.
+func newTOTP(name, secret, digits string) *pb.TOTP {
+	n, err := strconv.Atoi(digits)
+	if err != nil || n < 6 || n > 8 {
+		n = 6
+	}
+
+	return &pb.TOTP{
+		Name:   name,
+		Raw:    strings.ToUpper(strings.ReplaceAll(secret, " ", "")),
+		Digits: int32(n),
+	}
+}
+
+func isTOTPField(key string) bool {
+	for _, f := range totpFields {
+		if key == f {
+			return true
+		}
+	}
+	return false
+}
+
+func entryTitle(e *kdbx.Entry) string {
+	return itemTitle(e.Title)
+}
+
+// uniqueName appends a number to the name if it was already used, KeePass allows duplicated titles.
+func uniqueName(names map[string]struct{}, name string) string {
+	unique := name
+	for i := 2; ; i++ {
+		if _, ok := names[unique]; !ok {
+			break
+		}
+		unique = fmt.Sprintf("%s (%d)", name, i)
+	}
+
+	names[unique] = struct{}{}
+	return unique
+}
@@ -0,0 +1,150 @@
+package importt
+
+import (
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/GGP1/kure/archive"
+	"github.com/GGP1/kure/pb"
+	"github.com/GGP1/kure/terminal"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Record types that can be selected when importing a kure export.
+const (
+	typeBank     = "bank"
+	typeCard     = "card"
+	typeEntry    = "entry"
+	typeFile     = "file"
+	typeIdentity = "identity"
+	typeNote     = "note"
+	typeSSH      = "ssh"
+	typeTOTP     = "totp"
+)
+
+// kureFilter selects the records imported from a kure export.
+type kureFilter struct {
+	types    []string
+	prefixes []string
+}
+
+func (f kureFilter) validate() error {
+	for _, t := range f.types {
+		switch t {
+		case typeBank, typeCard, typeEntry, typeFile, typeIdentity, typeNote, typeSSH, typeTOTP:
+		default:
+			return errors.Errorf("invalid record type %q, use bank, card, entry, file, identity, note, ssh or totp", t)
+		}
+	}
+	return nil
+}
+
+// match reports whether a record of the type and name given must be imported.
+func (f kureFilter) match(recordType, name string) bool {
+	if len(f.types) > 0 && !slices.Contains(f.types, recordType) {
+		return false
+	}
+	if len(f.prefixes) == 0 {
+		return true
+	}
+	for _, prefix := range f.prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func readKure(path string, filter kureFilter) (*records, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening file")
+	}
+	defer f.Close()
+
+	enclave, err := terminal.ScanPassword("Export passphrase", false)
+	if err != nil {
+		return nil, err
+	}
+	password, err := enclave.Open()
+	if err != nil {
+		return nil, errors.Wrap(err, "opening enclave")
+	}
+	defer password.Destroy()
+
+	a, err := archive.Decode(f, password.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	return kureRecords(a, filter), nil
+}
+
+// kureRecords returns the archive records that match the filter.
+func kureRecords(a *pb.Archive, filter kureFilter) *records {
+	r := newRecords()
+	for _, c := range a.Cards {
+		if filter.match(typeCard, c.Name) {
+			r.cards = append(r.cards, c)
+		}
+	}
+	for _, e := range a.Entries {
+		if filter.match(typeEntry, e.Name) {
+			r.entries = append(r.entries, e)
+		}
+	}
+	for _, f := range a.Files {
+		if filter.match(typeFile, f.Name) {
+			r.files = append(r.files, f)
+		}
+	}
+	for _, t := range a.Totps {
+		if filter.match(typeTOTP, t.Name) {
+			r.totps = append(r.totps, t)
+		}
+	}
+	for _, i := range a.Identities {
+		if filter.match(typeIdentity, i.Name) {
+			r.identities = append(r.identities, i)
+		}
+	}
+	for _, b := range a.BankAccounts {
+		if filter.match(typeBank, b.Name) {
+			r.banks = append(r.banks, b)
+		}
+	}
+	for _, n := range a.Notes {
+		if filter.match(typeNote, n.Name) {
+			r.notes = append(r.notes, n)
+		}
+	}
+	for _, k := range a.SshKeys {
+		if filter.match(typeSSH, k.Name) {
+			r.sshKeys = append(r.sshKeys, k)
+		}
+	}
+	return r
+}
+
+// Archive stores the archive records in the database, the conflict strategy decides what to do with the
+// records that already exist. It returns a summary of the changes made.
+func Archive(db *bolt.DB, a *pb.Archive, conflict string) (string, error) {
+	if err := validStrategy(conflict); err != nil {
+		return "", err
+	}
+
+	res := &resolver{db: db, strategy: conflict}
+	out, changes, err := res.resolve(kureRecords(a, kureFilter{}))
+	if err != nil {
+		return "", err
+	}
+
+	if err := createRecords(db, out); err != nil {
+		return "", err
+	}
+
+	return countChanges(changes), nil
+}
@@ -0,0 +1,437 @@
+package importt
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/pkg/errors"
+)
+
+// 1Password item categories.
+const (
+	onePUXCreditCard    = "002"
+	onePUXSecureNote    = "003"
+	onePUXIdentity      = "004"
+	onePUXDocument      = "006"
+	onePUXBankAccount   = "101"
+	onePUXDriverLicense = "103"
+	onePUXPassport      = "106"
+	onePUXSSN           = "108"
+)
+
+// onePUXDocumentTypes contains the identity type of each 1Password identity category.
+var onePUXDocumentTypes = map[string]string{
+	onePUXIdentity:      "Identity",
+	onePUXDriverLicense: "Driver license",
+	onePUXPassport:      "Passport",
+	onePUXSSN:           "SSN",
+}
+
+type onePUX struct {
+	Accounts []struct {
+		Vaults []struct {
+			Attrs struct {
+				Name string `json:"name"`
+			} `json:"attrs"`
+			Items []onePUXItem `json:"items"`
+		} `json:"vaults"`
+	} `json:"accounts"`
+}
+
+type onePUXItem struct {
+	CategoryUUID string `json:"categoryUuid"`
+	// Unix seconds
+	UpdatedAt int64 `json:"updatedAt"`
+	Details   struct {
+		LoginFields []struct {
+			Value       string `json:"value"`
+			Designation string `json:"designation"`
+		} `json:"loginFields"`
+		NotesPlain         string          `json:"notesPlain"`
+		Password           string          `json:"password"`
+		Sections           []onePUXSection `json:"sections"`
+		DocumentAttributes *onePUXFile     `json:"documentAttributes"`
+	} `json:"details"`
+	Overview struct {
+		Title string `json:"title"`
+		URL   string `json:"url"`
+	} `json:"overview"`
+}
+
+type onePUXSection struct {
+	Title  string        `json:"title"`
+	Fields []onePUXField `json:"fields"`
+}
+
+type onePUXField struct {
+	Title string `json:"title"`
+	ID    string `json:"id"`
+	// Contains a single key with the value type and the value itself
+	Value map[string]json.RawMessage `json:"value"`
+}
+
+type onePUXFile struct {
+	FileName   string `json:"fileName"`
+	DocumentID string `json:"documentId"`
+}
+
+func read1PUX(filePath string) (*records, error) {
+	zr, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening file")
+	}
+	defer zr.Close()
+
+	return onePUXRecords(&zr.Reader)
+}
+
+// onePUXRecords maps 1Password items to kure records. Vaults are mapped to directories if there are more than one,
+// credit cards to cards, secure notes to notes, identity documents to identities, bank accounts to bank accounts,
+// documents to files and the rest of the items to entries.
+// Attachments are stored in files named after the item.
+func onePUXRecords(zr *zip.Reader) (*records, error) {
+	data, err := readZipFile(zr, "export.data")
+	if err != nil {
+		return nil, err
+	}
+
+	var export onePUX
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, errors.Wrap(err, "decoding export data")
+	}
+
+	vaults := 0
+	for _, account := range export.Accounts {
+		vaults += len(account.Vaults)
+	}
+
+	r := newRecords()
+	names := make(map[string]map[string]struct{})
+	uniqueRecordName := func(kind, dir, title string) string {
+		if names[kind] == nil {
+			names[kind] = make(map[string]struct{})
+		}
+		return uniqueName(names[kind], cmdutil.NormalizeName(path.Join(dir, itemTitle(title))))
+	}
+
+	for _, account := range export.Accounts {
+		for _, vault := range account.Vaults {
+			var dir string
+			if vaults > 1 {
+				dir = vault.Attrs.Name
+			}
+
+			for _, item := range vault.Items {
+				var name string
+				switch item.CategoryUUID {
+				case onePUXCreditCard:
+					name = uniqueRecordName("card", dir, item.Overview.Title)
+					r.cards = append(r.cards, onePUXCard(name, item))
+
+				case onePUXSecureNote:
+					name = uniqueRecordName("note", dir, item.Overview.Title)
+					r.notes = append(r.notes, &pb.Note{
+						Name: name,
+						Text: joinFields(item.Details.NotesPlain, onePUXFields(item, nil)...),
+					})
+
+				case onePUXIdentity, onePUXDriverLicense, onePUXPassport, onePUXSSN:
+					name = uniqueRecordName("identity", dir, item.Overview.Title)
+					r.identities = append(r.identities, onePUXIdentityDocument(name, item))
+
+				case onePUXBankAccount:
+					name = uniqueRecordName("bank", dir, item.Overview.Title)
+					r.banks = append(r.banks, onePUXBank(name, item))
+
+				case onePUXDocument:
+					name = uniqueRecordName("file", dir, item.Overview.Title)
+					if doc := item.Details.DocumentAttributes; doc != nil {
+						content, err := readZipDocument(zr, doc)
+						if err != nil {
+							return nil, err
+						}
+						f := textFile(name, "")
+						f.Content = content
+						f.Size = int64(len(content))
+						r.files = append(r.files, f)
+					}
+
+				default:
+					name = uniqueRecordName("entry", dir, item.Overview.Title)
+					e, t := onePUXEntry(name, item)
+					r.entries = append(r.entries, e)
+					if t != nil {
+						r.totps = append(r.totps, t)
+					}
+				}
+
+				for _, section := range item.Details.Sections {
+					for _, field := range section.Fields {
+						raw, ok := field.Value["file"]
+						if !ok {
+							continue
+						}
+						var attachment onePUXFile
+						if err := json.Unmarshal(raw, &attachment); err != nil {
+							return nil, errors.Wrap(err, "decoding attachment")
+						}
+						content, err := readZipDocument(zr, &attachment)
+						if err != nil {
+							return nil, err
+						}
+						f := textFile(uniqueRecordName("file", name, attachment.FileName), "")
+						f.Content = content
+						f.Size = int64(len(content))
+						r.files = append(r.files, f)
+					}
+				}
+			}
+		}
+	}
+
+	return r, nil
+}
+
+func onePUXEntry(name string, item onePUXItem) (*pb.Entry, *pb.TOTP) {
+	var username, password string
+	for _, f := range item.Details.LoginFields {
+		switch f.Designation {
+		case "username":
+			username = f.Value
+		case "password":
+			password = f.Value
+		}
+	}
+	if password == "" {
+		password = item.Details.Password
+	}
+
+	var t *pb.TOTP
+	mapped := map[string]*string{"username": &username, "password": &password}
+	fields := onePUXFields(item, func(f onePUXField, value string) bool {
+		if _, ok := f.Value["totp"]; ok {
+			if t == nil {
+				t = parseTOTP(name, value)
+			}
+			return true
+		}
+		if dst, ok := mapped[f.ID]; ok && *dst == "" {
+			*dst = value
+			return true
+		}
+		return false
+	})
+
+	e := &pb.Entry{
+		Name:              name,
+		Username:          username,
+		Password:          password,
+		URL:               item.Overview.URL,
+		Notes:             joinFields(item.Details.NotesPlain, fields...),
+		Expires:           "Never",
+		PasswordUpdatedAt: item.UpdatedAt,
+	}
+	return e, t
+}
+
+func onePUXCard(name string, item onePUXItem) *pb.Card {
+	card := &pb.Card{Name: name}
+	mapped := map[string]*string{
+		"type":   &card.Type,
+		"ccnum":  &card.Number,
+		"cvv":    &card.SecurityCode,
+		"expiry": &card.ExpireDate,
+	}
+	fields := onePUXFields(item, func(f onePUXField, value string) bool {
+		if dst, ok := mapped[f.ID]; ok {
+			*dst = value
+			return true
+		}
+		return false
+	})
+
+	card.Notes = joinFields(item.Details.NotesPlain, fields...)
+	return card
+}
+
+func onePUXIdentityDocument(name string, item onePUXItem) *pb.Identity {
+	identity := &pb.Identity{Name: name, Type: onePUXDocumentTypes[item.CategoryUUID]}
+	var firstName, initial, lastName string
+	mapped := map[string]*string{
+		"fullname":          &identity.FullName,
+		"name":              &identity.FullName,
+		"firstname":         &firstName,
+		"initial":           &initial,
+		"lastname":          &lastName,
+		"number":            &identity.Number,
+		"issuing_authority": &identity.Issuer,
+		"state":             &identity.Issuer,
+		"issue_date":        &identity.IssueDate,
+		"expiry_date":       &identity.ExpireDate,
+	}
+	fields := onePUXFields(item, func(f onePUXField, value string) bool {
+		if dst, ok := mapped[f.ID]; ok && *dst == "" {
+			*dst = value
+			return true
+		}
+		return false
+	})
+
+	if identity.FullName == "" {
+		identity.FullName = strings.Join(strings.Fields(firstName+" "+initial+" "+lastName), " ")
+	}
+	identity.Notes = joinFields(item.Details.NotesPlain, fields...)
+	return identity
+}
+
+func onePUXBank(name string, item onePUXItem) *pb.BankAccount {
+	b := &pb.BankAccount{Name: name}
+	mapped := map[string]*string{
+		"bankName":     &b.Bank,
+		"owner":        &b.Holder,
+		"accountNo":    &b.AccountNumber,
+		"routingNo":    &b.RoutingNumber,
+		"iban":         &b.Iban,
+		"swift":        &b.Swift,
+		"telephonePin": &b.Pin,
+	}
+	fields := onePUXFields(item, func(f onePUXField, value string) bool {
+		if dst, ok := mapped[f.ID]; ok {
+			*dst = value
+			return true
+		}
+		return false
+	})
+
+	b.Notes = joinFields(item.Details.NotesPlain, fields...)
+	return b
+}
+
+// onePUXFields returns the item section fields formatted as "title: value", skipping attachments, empty values
+// and those for which skip returns true.
+func onePUXFields(item onePUXItem, skip func(f onePUXField, value string) bool) []string {
+	var lines []string
+	for _, section := range item.Details.Sections {
+		for _, f := range section.Fields {
+			if _, ok := f.Value["file"]; ok {
+				continue
+			}
+			value := onePUXValue(f.Value)
+			if value == "" || (skip != nil && skip(f, value)) {
+				continue
+			}
+
+			title := f.Title
+			if title == "" {
+				title = f.ID
+			}
+			lines = append(lines, title+": "+value)
+		}
+	}
+	return lines
+}
+
+// onePUXValue returns the string representation of a field value.
+func onePUXValue(value map[string]json.RawMessage) string {
+	for kind, raw := range value {
+		switch kind {
+		case "date":
+			var unix int64
+			if err := json.Unmarshal(raw, &unix); err != nil || unix == 0 {
+				return ""
+			}
+			return time.Unix(unix, 0).UTC().Format("2006-01-02")
+
+		case "monthYear":
+			var monthYear int
+			if err := json.Unmarshal(raw, &monthYear); err != nil || monthYear == 0 {
+				return ""
+			}
+			// Format: YYYYMM
+			return fmt.Sprintf("%02d/%d", monthYear%100, monthYear/100)
+
+		case "email":
+			var email struct {
+				Address string `json:"email_address"`
+			}
+			if err := json.Unmarshal(raw, &email); err == nil {
+				return email.Address
+			}
+		}
+
+		var s string
+		if err := json.Unmarshal(raw, &s); err == nil {
+			return s
+		}
+
+		var obj map[string]interface{}
+		if err := json.Unmarshal(raw, &obj); err == nil {
+			return joinObject(obj)
+		}
+
+		if string(raw) == "null" {
+			return ""
+		}
+		return string(raw)
+	}
+	return ""
+}
+
+// joinObject returns the non-empty values of an object (an address, for instance) sorted by key.
+func joinObject(obj map[string]interface{}) string {
+	keys := make([]string, 0, len(obj))
+	for k, v := range obj {
+		if s, ok := v.(string); ok && s != "" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	values := make([]string, len(keys))
+	for i, k := range keys {
+		values[i] = obj[k].(string)
+	}
+	return strings.Join(values, ", ")
+}
+
+// readZipDocument reads a document stored in the "files" folder, whose name is "<documentId>__<fileName>".
+func readZipDocument(zr *zip.Reader, doc *onePUXFile) ([]byte, error) {
+	for _, f := range zr.File {
+		if strings.HasPrefix(f.Name, "files/"+doc.DocumentID) {
+			return readZipEntry(f)
+		}
+	}
+	return nil, errors.Errorf("attachment %q not found", doc.FileName)
+}
+
+func readZipFile(zr *zip.Reader, name string) ([]byte, error) {
+	for _, f := range zr.File {
+		if f.Name == name {
+			return readZipEntry(f)
+		}
+	}
+	return nil, errors.Errorf("%q not found, is the file a 1PUX export?", name)
+}
+
+func readZipEntry(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, errors.Wrapf(err, "opening %q", f.Name)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading %q", f.Name)
+	}
+	return data, nil
+}
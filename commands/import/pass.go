@@ -0,0 +1,214 @@
+package importt
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/pb"
+	"github.com/GGP1/kure/terminal"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/pkg/errors"
+)
+
+// Keys of the pass files that are mapped to entry fields, the rest are appended to the notes.
+var (
+	passUsernameKeys = []string{"username", "user", "login", "email"}
+	passURLKeys      = []string{"url", "website", "site"}
+)
+
+func readPass(dir, keyPath string) (*records, error) {
+	keyring, err := readGPGKey(keyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if isEncrypted(keyring) {
+		enclave, err := terminal.ScanPassword("GPG key passphrase", false)
+		if err != nil {
+			return nil, err
+		}
+		passphrase, err := enclave.Open()
+		if err != nil {
+			return nil, errors.Wrap(err, "opening enclave")
+		}
+		err = decryptKeys(keyring, passphrase.Bytes())
+		passphrase.Destroy()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return passRecords(dir, keyring)
+}
+
+// readGPGKey reads an armored or binary private key.
+func readGPGKey(path string) (openpgp.EntityList, error) {
+	if path == "" {
+		return nil, errors.New("a GPG private key is required to decrypt the password store, use --gpg-key")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading GPG key")
+	}
+
+	var keyring openpgp.EntityList
+	if isArmored(data) {
+		keyring, err = openpgp.ReadArmoredKeyRing(bytes.NewReader(data))
+	} else {
+		keyring, err = openpgp.ReadKeyRing(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "reading GPG key")
+	}
+
+	if len(keyring.DecryptionKeys()) == 0 {
+		return nil, errors.New("the GPG key does not contain a private key")
+	}
+
+	return keyring, nil
+}
+
+func isEncrypted(keyring openpgp.EntityList) bool {
+	for _, k := range keyring.DecryptionKeys() {
+		if k.PrivateKey != nil && k.PrivateKey.Encrypted {
+			return true
+		}
+	}
+	return false
+}
+
+func decryptKeys(keyring openpgp.EntityList, passphrase []byte) error {
+	for _, e := range keyring {
+		if err := e.DecryptPrivateKeys(passphrase); err != nil {
+			return errors.Wrap(err, "decrypting GPG key")
+		}
+	}
+	return nil
+}
+
+// passRecords decrypts the password store files and maps them to entries (and TOTPs) named after their
+// path relative to the store, without the ".gpg" extension.
+func passRecords(dir string, keyring openpgp.EntityList) (*records, error) {
+	r := newRecords()
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			// Skip the store git repository and other hidden directories
+			if path != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) != ".gpg" {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		name := cmdutil.NormalizeName(filepath.ToSlash(strings.TrimSuffix(rel, ".gpg")))
+
+		content, err := decryptPassFile(path, keyring)
+		if err != nil {
+			return errors.Wrapf(err, "decrypting %q", rel)
+		}
+
+		e, t := passEntry(name, content)
+		r.entries = append(r.entries, e)
+		if t != nil {
+			r.totps = append(r.totps, t)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func decryptPassFile(path string, keyring openpgp.EntityList) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var r io.Reader = bytes.NewReader(data)
+	if isArmored(data) {
+		block, err := armor.Decode(r)
+		if err != nil {
+			return nil, err
+		}
+		r = block.Body
+	}
+
+	md, err := openpgp.ReadMessage(r, keyring, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.ReadAll(md.UnverifiedBody)
+}
+
+// passEntry maps the content of a pass file to an entry. The first line is the password, the following
+// "key: value" lines are mapped to the username and URL when possible and the rest to the notes.
+// "otpauth://" lines, used by pass-otp, are mapped to a TOTP.
+func passEntry(name string, content []byte) (*pb.Entry, *pb.TOTP) {
+	e := &pb.Entry{Name: name, Expires: "Never"}
+	var (
+		t     *pb.TOTP
+		notes []string
+	)
+
+	sc := bufio.NewScanner(bytes.NewReader(content))
+	for i := 0; sc.Scan(); i++ {
+		line := sc.Text()
+		if i == 0 {
+			e.Password = line
+			continue
+		}
+
+		if strings.HasPrefix(strings.ToLower(line), "otpauth://") {
+			if t == nil {
+				t = parseTOTP(name, line)
+			}
+			continue
+		}
+
+		key, value, found := strings.Cut(line, ":")
+		if found {
+			key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+			if slices.Contains(passUsernameKeys, key) && e.Username == "" {
+				e.Username = value
+				continue
+			}
+			if slices.Contains(passURLKeys, key) && e.URL == "" {
+				e.URL = value
+				continue
+			}
+		}
+
+		notes = append(notes, line)
+	}
+
+	e.Notes = strings.TrimSpace(strings.Join(notes, "\n"))
+	return e, t
+}
+
+func isArmored(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("-----BEGIN PGP"))
+}
@@ -0,0 +1,507 @@
+package importt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	dbutil "github.com/GGP1/kure/db"
+	"github.com/GGP1/kure/db/bank"
+	"github.com/GGP1/kure/db/card"
+	"github.com/GGP1/kure/db/entry"
+	"github.com/GGP1/kure/db/file"
+	"github.com/GGP1/kure/db/identity"
+	"github.com/GGP1/kure/db/note"
+	"github.com/GGP1/kure/db/sshkey"
+	"github.com/GGP1/kure/db/totp"
+	"github.com/GGP1/kure/pb"
+	"github.com/GGP1/kure/terminal"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Conflict strategies.
+const (
+	overwrite  = "overwrite"
+	skip       = "skip"
+	keepNewest = "keep-newest"
+	rename     = "rename"
+)
+
+// Actions taken on the imported records.
+const (
+	actionCreate    = "create"
+	actionUpdate    = "update"
+	actionRename    = "rename"
+	actionSkip      = "skip"
+	actionUnchanged = "unchanged"
+)
+
+// records contains the kure records created from an export.
+type records struct {
+	entries    []*pb.Entry
+	cards      []*pb.Card
+	totps      []*pb.TOTP
+	files      []*pb.File
+	identities []*pb.Identity
+	banks      []*pb.BankAccount
+	notes      []*pb.Note
+	sshKeys    []*pb.SSHKey
+}
+
+func newRecords() *records {
+	return &records{
+		entries:    make([]*pb.Entry, 0),
+		cards:      make([]*pb.Card, 0),
+		totps:      make([]*pb.TOTP, 0),
+		files:      make([]*pb.File, 0),
+		identities: make([]*pb.Identity, 0),
+		banks:      make([]*pb.BankAccount, 0),
+		notes:      make([]*pb.Note, 0),
+		sshKeys:    make([]*pb.SSHKey, 0),
+	}
+}
+
+func createRecords(db *bolt.DB, r *records) error {
+	if err := entry.Create(db, r.entries...); err != nil {
+		return err
+	}
+
+	for _, c := range r.cards {
+		if err := card.Create(db, c); err != nil {
+			return err
+		}
+	}
+
+	for _, t := range r.totps {
+		if err := totp.Create(db, t); err != nil {
+			return err
+		}
+	}
+
+	for _, f := range r.files {
+		if err := file.Create(db, f); err != nil {
+			return err
+		}
+	}
+
+	for _, i := range r.identities {
+		if err := identity.Create(db, i); err != nil {
+			return err
+		}
+	}
+
+	for _, b := range r.banks {
+		if err := bank.Create(db, b); err != nil {
+			return err
+		}
+	}
+
+	for _, n := range r.notes {
+		if err := note.Create(db, n); err != nil {
+			return err
+		}
+	}
+
+	for _, k := range r.sshKeys {
+		if err := sshkey.Create(db, k); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// change describes what happens to an imported record.
+type change struct {
+	kind    string
+	name    string
+	newName string
+	action  string
+	diff    []fieldDiff
+}
+
+// fieldDiff is a field whose value differs between the stored record and the imported one.
+type fieldDiff struct {
+	field    string
+	old, new string
+	// Secret values are never printed
+	secret bool
+}
+
+// resolver decides what to do with the imported records that already exist.
+type resolver struct {
+	db       *bolt.DB
+	strategy string
+	// Used to ask the user what to do on each conflict, nil if the resolution is not interactive
+	r *bufio.Reader
+}
+
+func validStrategy(strategy string) error {
+	switch strategy {
+	case overwrite, skip, keepNewest, rename:
+		return nil
+	default:
+		return errors.Errorf("invalid conflict strategy %q, use overwrite, skip, keep-newest or rename", strategy)
+	}
+}
+
+// recordKind contains the functions used to resolve the conflicts of a record type.
+type recordKind[T dbutil.Record] struct {
+	name      string
+	listNames func(*bolt.DB) ([]string, error)
+	get       func(*bolt.DB, string) (T, error)
+	diff      func(old, new T) []fieldDiff
+	// updatedAt returns the time the record was last modified, nil if the type doesn't track it
+	updatedAt func(T) int64
+}
+
+var (
+	entryKind = recordKind[*pb.Entry]{
+		name: "entry", listNames: entry.ListNames, get: entry.Get, diff: entryDiff,
+		updatedAt: func(e *pb.Entry) int64 { return e.PasswordUpdatedAt },
+	}
+	cardKind = recordKind[*pb.Card]{name: "card", listNames: card.ListNames, get: card.Get, diff: cardDiff}
+	totpKind = recordKind[*pb.TOTP]{name: "totp", listNames: totp.ListNames, get: totp.Get, diff: totpDiff}
+	fileKind = recordKind[*pb.File]{
+		name: "file", listNames: file.ListNames, get: file.Get, diff: fileDiff,
+		updatedAt: func(f *pb.File) int64 { return max(f.CreatedAt, f.UpdatedAt) },
+	}
+	identityKind = recordKind[*pb.Identity]{name: "identity", listNames: identity.ListNames, get: identity.Get, diff: identityDiff}
+	bankKind     = recordKind[*pb.BankAccount]{name: "bank", listNames: bank.ListNames, get: bank.Get, diff: bankDiff}
+	noteKind     = recordKind[*pb.Note]{name: "note", listNames: note.ListNames, get: note.Get, diff: noteDiff}
+	sshKeyKind   = recordKind[*pb.SSHKey]{name: "ssh", listNames: sshkey.ListNames, get: sshkey.Get, diff: sshKeyDiff}
+)
+
+// resolve returns the records that must be written and the changes that will be made. Records identical
+// to the stored ones are left unchanged, TOTPs follow the decision taken on the entry with the same name.
+func (res *resolver) resolve(in *records) (*records, []change, error) {
+	out := newRecords()
+	var (
+		changes []change
+		err     error
+	)
+
+	if out.entries, err = resolveRecords(res, entryKind, in.entries, nil, &changes); err != nil {
+		return nil, nil, err
+	}
+	entryActions := make(map[string]change, len(changes))
+	for _, c := range changes {
+		entryActions[c.name] = c
+	}
+
+	if out.cards, err = resolveRecords(res, cardKind, in.cards, nil, &changes); err != nil {
+		return nil, nil, err
+	}
+	if out.totps, err = resolveRecords(res, totpKind, in.totps, entryActions, &changes); err != nil {
+		return nil, nil, err
+	}
+	if out.files, err = resolveRecords(res, fileKind, in.files, nil, &changes); err != nil {
+		return nil, nil, err
+	}
+	if out.identities, err = resolveRecords(res, identityKind, in.identities, nil, &changes); err != nil {
+		return nil, nil, err
+	}
+	if out.banks, err = resolveRecords(res, bankKind, in.banks, nil, &changes); err != nil {
+		return nil, nil, err
+	}
+	if out.notes, err = resolveRecords(res, noteKind, in.notes, nil, &changes); err != nil {
+		return nil, nil, err
+	}
+	if out.sshKeys, err = resolveRecords(res, sshKeyKind, in.sshKeys, nil, &changes); err != nil {
+		return nil, nil, err
+	}
+
+	return out, changes, nil
+}
+
+// resolveRecords returns the records of one type that must be written and appends the changes that will
+// be made. Records whose name is in follow take the action decided for that change instead of using the strategy.
+func resolveRecords[T dbutil.Record](res *resolver, k recordKind[T], in []T, follow map[string]change, changes *[]change) ([]T, error) {
+	names, err := existingNames(k.listNames(res.db))
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range in {
+		names.add(r.GetName())
+	}
+
+	out := make([]T, 0, len(in))
+	for _, r := range in {
+		c := change{kind: k.name, name: r.GetName(), action: actionCreate}
+		exists := names.exists(c.name)
+		var old T
+		if exists {
+			old, err = k.get(res.db, c.name)
+			if err != nil {
+				return nil, err
+			}
+			c.diff = k.diff(old, r)
+		}
+
+		if fc, ok := follow[c.name]; ok {
+			followChange(&c, fc, exists)
+		} else if exists {
+			newer := k.updatedAt != nil && k.updatedAt(r) > k.updatedAt(old)
+			res.decide(&c, newer, names)
+		}
+
+		*changes = append(*changes, c)
+		if write(&c) {
+			setName(r, c.writeName())
+			out = append(out, r)
+		}
+	}
+
+	return out, nil
+}
+
+// followChange sets the action taken on a record to the one decided for another, like a TOTP and its entry.
+func followChange(c *change, fc change, exists bool) {
+	switch fc.action {
+	case actionRename:
+		c.action, c.newName = actionRename, fc.newName
+	case actionSkip:
+		c.action = actionSkip
+	default:
+		if exists {
+			c.action = actionUpdate
+		}
+	}
+	if exists && len(c.diff) == 0 && c.action != actionRename {
+		c.action = actionUnchanged
+	}
+}
+
+// setName sets the name of the record.
+func setName(r dbutil.Record, name string) {
+	m := r.ProtoReflect()
+	m.Set(m.Descriptor().Fields().ByName("name"), protoreflect.ValueOfString(name))
+}
+
+// decide sets the action taken on a record that already exists. newer reports whether the imported
+// record was modified after the stored one, false if unknown.
+func (res *resolver) decide(c *change, newer bool, names *nameSet) {
+	if len(c.diff) == 0 {
+		c.action = actionUnchanged
+		return
+	}
+
+	strategy := res.strategy
+	if res.r != nil {
+		strategy = res.ask(c)
+	}
+
+	switch strategy {
+	case overwrite:
+		c.action = actionUpdate
+	case skip:
+		c.action = actionSkip
+	case keepNewest:
+		c.action = actionSkip
+		if newer {
+			c.action = actionUpdate
+		}
+	case rename:
+		c.action = actionRename
+		c.newName = uniqueName(names.all, c.name)
+	}
+}
+
+// ask prints the differences between the records and asks the user which strategy to use.
+func (res *resolver) ask(c *change) string {
+	fmt.Printf("\n%s %q already exists:\n", c.kind, c.name)
+	printDiff(c.diff)
+
+	for {
+		answer := terminal.Scanln(res.r, "[o]verwrite, [s]kip, [k]eep newest or [r]ename")
+		switch strings.ToLower(answer) {
+		case "o", "overwrite":
+			return overwrite
+		case "s", "skip", "":
+			return skip
+		case "k", "keep newest", keepNewest:
+			return keepNewest
+		case "r", "rename":
+			return rename
+		}
+	}
+}
+
+// write reports whether the record must be written to the database.
+func write(c *change) bool {
+	return c.action != actionSkip && c.action != actionUnchanged
+}
+
+func (c *change) writeName() string {
+	if c.action == actionRename {
+		return c.newName
+	}
+	return c.name
+}
+
+// nameSet contains the names of the stored records and the ones being imported.
+type nameSet struct {
+	existing map[string]struct{}
+	all      map[string]struct{}
+}
+
+func existingNames(names []string, err error) (*nameSet, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	set := &nameSet{
+		existing: make(map[string]struct{}, len(names)),
+		all:      make(map[string]struct{}, len(names)),
+	}
+	for _, name := range names {
+		set.existing[name] = struct{}{}
+		set.all[name] = struct{}{}
+	}
+	return set, nil
+}
+
+func (s *nameSet) add(name string) {
+	s.all[name] = struct{}{}
+}
+
+func (s *nameSet) exists(name string) bool {
+	_, ok := s.existing[name]
+	return ok
+}
+
+func entryDiff(old, new *pb.Entry) []fieldDiff {
+	return diffFields(
+		fieldDiff{field: "username", old: old.Username, new: new.Username},
+		fieldDiff{field: "password", old: old.Password, new: new.Password, secret: true},
+		fieldDiff{field: "url", old: old.URL, new: new.URL},
+		fieldDiff{field: "notes", old: old.Notes, new: new.Notes},
+		fieldDiff{field: "expires", old: old.Expires, new: new.Expires},
+	)
+}
+
+func cardDiff(old, new *pb.Card) []fieldDiff {
+	return diffFields(
+		fieldDiff{field: "type", old: old.Type, new: new.Type},
+		fieldDiff{field: "number", old: old.Number, new: new.Number, secret: true},
+		fieldDiff{field: "security code", old: old.SecurityCode, new: new.SecurityCode, secret: true},
+		fieldDiff{field: "expire date", old: old.ExpireDate, new: new.ExpireDate},
+		fieldDiff{field: "notes", old: old.Notes, new: new.Notes},
+	)
+}
+
+func totpDiff(old, new *pb.TOTP) []fieldDiff {
+	return diffFields(
+		fieldDiff{field: "secret", old: old.Raw, new: new.Raw, secret: true},
+		fieldDiff{field: "digits", old: fmt.Sprint(old.Digits), new: fmt.Sprint(new.Digits)},
+	)
+}
+
+func fileDiff(old, new *pb.File) []fieldDiff {
+	return diffFields(
+		fieldDiff{field: "content", old: string(old.Content), new: string(new.Content), secret: true},
+	)
+}
+
+func identityDiff(old, new *pb.Identity) []fieldDiff {
+	return diffFields(
+		fieldDiff{field: "type", old: old.Type, new: new.Type},
+		fieldDiff{field: "full name", old: old.FullName, new: new.FullName},
+		fieldDiff{field: "number", old: old.Number, new: new.Number, secret: true},
+		fieldDiff{field: "issuer", old: old.Issuer, new: new.Issuer},
+		fieldDiff{field: "issue date", old: old.IssueDate, new: new.IssueDate},
+		fieldDiff{field: "expire date", old: old.ExpireDate, new: new.ExpireDate},
+		fieldDiff{field: "notes", old: old.Notes, new: new.Notes},
+	)
+}
+
+func bankDiff(old, new *pb.BankAccount) []fieldDiff {
+	return diffFields(
+		fieldDiff{field: "bank", old: old.Bank, new: new.Bank},
+		fieldDiff{field: "holder", old: old.Holder, new: new.Holder},
+		fieldDiff{field: "account number", old: old.AccountNumber, new: new.AccountNumber, secret: true},
+		fieldDiff{field: "routing number", old: old.RoutingNumber, new: new.RoutingNumber},
+		fieldDiff{field: "iban", old: old.Iban, new: new.Iban, secret: true},
+		fieldDiff{field: "swift", old: old.Swift, new: new.Swift},
+		fieldDiff{field: "pin", old: old.Pin, new: new.Pin, secret: true},
+		fieldDiff{field: "notes", old: old.Notes, new: new.Notes},
+	)
+}
+
+func noteDiff(old, new *pb.Note) []fieldDiff {
+	return diffFields(
+		fieldDiff{field: "text", old: old.Text, new: new.Text, secret: true},
+	)
+}
+
+func sshKeyDiff(old, new *pb.SSHKey) []fieldDiff {
+	return diffFields(
+		fieldDiff{field: "private key", old: old.PrivateKey, new: new.PrivateKey, secret: true},
+		fieldDiff{field: "passphrase", old: old.Passphrase, new: new.Passphrase, secret: true},
+		fieldDiff{field: "comment", old: old.Comment, new: new.Comment},
+	)
+}
+
+func diffFields(fields ...fieldDiff) []fieldDiff {
+	var diff []fieldDiff
+	for _, f := range fields {
+		if f.old != f.new {
+			diff = append(diff, f)
+		}
+	}
+	return diff
+}
+
+func printDiff(diff []fieldDiff) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	for _, d := range diff {
+		if d.secret || strings.Contains(d.old+d.new, "\n") {
+			fmt.Fprintf(w, "  %s\t(changed)\n", d.field)
+			continue
+		}
+		fmt.Fprintf(w, "  %s\t%q -> %q\n", d.field, d.old, d.new)
+	}
+	w.Flush()
+}
+
+// printChanges prints a summary of the changes.
+func printChanges(w io.Writer, changes []change) {
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "ACTION\tTYPE\tNAME\tDETAILS")
+	for _, c := range changes {
+		var details string
+		switch c.action {
+		case actionRename:
+			details = "-> " + c.newName
+		case actionUpdate, actionSkip:
+			fields := make([]string, len(c.diff))
+			for i, d := range c.diff {
+				fields[i] = d.field
+			}
+			details = strings.Join(fields, ", ")
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", c.action, c.kind, c.name, details)
+	}
+	tw.Flush()
+}
+
+// countChanges returns a text with the number of records per action.
+func countChanges(changes []change) string {
+	counts := make(map[string]int)
+	for _, c := range changes {
+		counts[c.action]++
+	}
+
+	actions := []string{actionCreate, actionUpdate, actionRename, actionSkip, actionUnchanged}
+	parts := make([]string, 0, len(actions))
+	for _, a := range actions {
+		parts = append(parts, fmt.Sprintf("%d %s", counts[a], a))
+	}
+	return strings.Join(parts, ", ")
+}
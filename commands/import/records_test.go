@@ -0,0 +1,246 @@
+package importt
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/db/card"
+	"github.com/GGP1/kure/db/entry"
+	"github.com/GGP1/kure/db/file"
+	"github.com/GGP1/kure/db/totp"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestSetName(t *testing.T) {
+	for recordType, newRecord := range cmdutil.RecordTypes {
+		t.Run(recordType, func(t *testing.T) {
+			r := newRecord()
+			setName(r, "renamed")
+			assert.Equal(t, "renamed", r.GetName())
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	cases := []struct {
+		strategy string
+		expected map[string]string
+		renamed  string
+	}{
+		{
+			strategy: overwrite,
+			expected: map[string]string{
+				"entry/email":   actionUpdate,
+				"entry/old":     actionUpdate,
+				"entry/new":     actionCreate,
+				"card/visa":     actionUpdate,
+				"totp/email":    actionUpdate,
+				"file/notes":    actionUnchanged,
+				"entry/same":    actionUnchanged,
+				"totp/orphaned": actionUpdate,
+			},
+		},
+		{
+			strategy: skip,
+			expected: map[string]string{
+				"entry/email":   actionSkip,
+				"entry/old":     actionSkip,
+				"entry/new":     actionCreate,
+				"card/visa":     actionSkip,
+				"totp/email":    actionSkip,
+				"file/notes":    actionUnchanged,
+				"entry/same":    actionUnchanged,
+				"totp/orphaned": actionSkip,
+			},
+		},
+		{
+			strategy: keepNewest,
+			expected: map[string]string{
+				"entry/email":   actionUpdate,
+				"entry/old":     actionSkip,
+				"entry/new":     actionCreate,
+				"card/visa":     actionSkip,
+				"totp/email":    actionUpdate,
+				"file/notes":    actionUnchanged,
+				"entry/same":    actionUnchanged,
+				"totp/orphaned": actionSkip,
+			},
+		},
+		{
+			strategy: rename,
+			expected: map[string]string{
+				"entry/email":   actionRename,
+				"entry/old":     actionRename,
+				"entry/new":     actionCreate,
+				"card/visa":     actionRename,
+				"totp/email":    actionRename,
+				"file/notes":    actionUnchanged,
+				"entry/same":    actionUnchanged,
+				"totp/orphaned": actionRename,
+			},
+			renamed: "email (2)",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.strategy, func(t *testing.T) {
+			db := cmdutil.SetContext(t)
+			createStoredRecords(t, db)
+
+			res := &resolver{db: db, strategy: tc.strategy}
+			_, changes, err := res.resolve(importedRecords())
+			assert.NoError(t, err)
+
+			got := make(map[string]string, len(changes))
+			for _, c := range changes {
+				got[c.kind+"/"+c.name] = c.action
+				if c.action == actionRename && c.name == "email" {
+					assert.Equal(t, tc.renamed, c.newName)
+				}
+			}
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
+
+func TestResolveWrite(t *testing.T) {
+	db := cmdutil.SetContext(t)
+	createStoredRecords(t, db)
+
+	res := &resolver{db: db, strategy: rename}
+	out, _, err := res.resolve(importedRecords())
+	assert.NoError(t, err)
+	assert.NoError(t, createRecords(db, out))
+
+	stored, err := entry.Get(db, "email")
+	assert.NoError(t, err)
+	assert.Equal(t, "stored", stored.Password)
+
+	renamed, err := entry.Get(db, "email (2)")
+	assert.NoError(t, err)
+	assert.Equal(t, "imported", renamed.Password)
+
+	// The TOTP follows its entry
+	renamedTOTP, err := totp.Get(db, "email (2)")
+	assert.NoError(t, err)
+	assert.Equal(t, "IMPORTED", renamedTOTP.Raw)
+}
+
+func TestResolveInteractive(t *testing.T) {
+	db := cmdutil.SetContext(t)
+	createStoredRecords(t, db)
+
+	// Answers in order: email, old, visa, orphaned TOTP. Invalid answers are asked again
+	input := "invalid\no\nr\nk\n\n"
+	res := &resolver{db: db, strategy: overwrite, r: bufio.NewReader(strings.NewReader(input))}
+	_, changes, err := res.resolve(importedRecords())
+	assert.NoError(t, err)
+
+	got := make(map[string]string, len(changes))
+	for _, c := range changes {
+		got[c.kind+"/"+c.name] = c.action
+	}
+	assert.Equal(t, actionUpdate, got["entry/email"])
+	assert.Equal(t, actionRename, got["entry/old"])
+	assert.Equal(t, actionSkip, got["card/visa"])
+	assert.Equal(t, actionSkip, got["totp/orphaned"])
+}
+
+func TestImportDryRun(t *testing.T) {
+	db := cmdutil.SetContext(t)
+	stored := &pb.Entry{Name: "chrome", Password: "newer", Expires: "Never"}
+	assert.NoError(t, entry.Create(db, stored))
+
+	cmd := NewCmd(db, nil)
+	cmd.SetArgs([]string{"chrome"})
+	f := cmd.Flags()
+	f.Set("path", "testdata/test_chrome.csv")
+	f.Set("dry-run", "true")
+
+	err := cmd.Execute()
+	assert.NoError(t, err)
+
+	got, err := entry.Get(db, "chrome")
+	assert.NoError(t, err)
+	assert.Equal(t, "newer", got.Password)
+}
+
+func TestImportConflict(t *testing.T) {
+	db := cmdutil.SetContext(t)
+	stored := &pb.Entry{Name: "chrome", Password: "newer", Expires: "Never"}
+	assert.NoError(t, entry.Create(db, stored))
+
+	cmd := NewCmd(db, nil)
+	cmd.SetArgs([]string{"chrome"})
+	f := cmd.Flags()
+	f.Set("path", "testdata/test_chrome.csv")
+	f.Set("conflict", "skip")
+
+	err := cmd.Execute()
+	assert.NoError(t, err)
+
+	got, err := entry.Get(db, "chrome")
+	assert.NoError(t, err)
+	assert.Equal(t, "newer", got.Password)
+
+	t.Run("Invalid strategy", func(t *testing.T) {
+		f.Set("path", "testdata/test_chrome.csv")
+		f.Set("conflict", "invalid")
+		assert.Error(t, cmd.Execute())
+	})
+}
+
+func TestPrintChanges(t *testing.T) {
+	changes := []change{
+		{kind: "entry", name: "new", action: actionCreate},
+		{kind: "entry", name: "email", action: actionUpdate, diff: []fieldDiff{{field: "password"}, {field: "notes"}}},
+		{kind: "card", name: "visa", action: actionRename, newName: "visa (2)"},
+	}
+
+	var buf bytes.Buffer
+	printChanges(&buf, changes)
+
+	expected := "ACTION   TYPE    NAME    DETAILS\n" +
+		"create   entry   new     \n" +
+		"update   entry   email   password, notes\n" +
+		"rename   card    visa    -> visa (2)\n"
+	assert.Equal(t, expected, buf.String())
+	assert.Equal(t, "1 create, 1 update, 1 rename, 0 skip, 0 unchanged", countChanges(changes))
+}
+
+func createStoredRecords(t *testing.T, db *bolt.DB) {
+	t.Helper()
+	err := entry.Create(db,
+		&pb.Entry{Name: "email", Password: "stored", Expires: "Never", PasswordUpdatedAt: 100},
+		&pb.Entry{Name: "old", Password: "stored", Expires: "Never", PasswordUpdatedAt: 300},
+		&pb.Entry{Name: "same", Password: "same", Expires: "Never"},
+	)
+	assert.NoError(t, err)
+	assert.NoError(t, card.Create(db, &pb.Card{Name: "visa", Number: "4111111111111111"}))
+	assert.NoError(t, totp.Create(db, &pb.TOTP{Name: "email", Raw: "STORED", Digits: 6}))
+	assert.NoError(t, totp.Create(db, &pb.TOTP{Name: "orphaned", Raw: "STORED", Digits: 6}))
+	assert.NoError(t, file.Create(db, &pb.File{Name: "notes", Content: []byte("notes"), CreatedAt: 100}))
+}
+
+func importedRecords() *records {
+	return &records{
+		entries: []*pb.Entry{
+			{Name: "email", Password: "imported", Expires: "Never", PasswordUpdatedAt: 200},
+			{Name: "old", Password: "imported", Expires: "Never", PasswordUpdatedAt: 200},
+			{Name: "new", Password: "imported", Expires: "Never"},
+			{Name: "same", Password: "same", Expires: "Never"},
+		},
+		cards: []*pb.Card{{Name: "visa", Number: "5555555555554444"}},
+		totps: []*pb.TOTP{
+			{Name: "email", Raw: "IMPORTED", Digits: 6},
+			{Name: "orphaned", Raw: "IMPORTED", Digits: 6},
+		},
+		files: []*pb.File{{Name: "notes", Content: []byte("notes")}},
+	}
+}
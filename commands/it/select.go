@@ -4,9 +4,13 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/GGP1/kure/db/bank"
 	"github.com/GGP1/kure/db/card"
 	"github.com/GGP1/kure/db/entry"
 	"github.com/GGP1/kure/db/file"
+	"github.com/GGP1/kure/db/identity"
+	"github.com/GGP1/kure/db/note"
+	"github.com/GGP1/kure/db/sshkey"
 	"github.com/GGP1/kure/db/totp"
 
 	"github.com/AlecAivazis/survey/v2"
@@ -94,12 +98,27 @@ func selectName(db *bolt.DB, commands []string) (string, error) {
 		list, err = totp.ListNames(db)
 		message = "Choose a TOTP:"
 
+	case "bank":
+		list, err = bank.ListNames(db)
+		message = "Choose a bank account:"
+
 	case "card":
 		list, err = card.ListNames(db)
 
 	case "file":
 		list, err = file.ListNames(db)
 
+	case "identity":
+		list, err = identity.ListNames(db)
+		message = "Choose an identity:"
+
+	case "note":
+		list, err = note.ListNames(db)
+
+	case "ssh":
+		list, err = sshkey.ListNames(db)
+		message = "Choose an SSH key:"
+
 	case "ls", "copy", "edit", "rm":
 		list, err = entry.ListNames(db)
 		message = "Choose an entry:"
@@ -134,6 +134,15 @@ func printEntry(name string, e *pb.Entry, show bool) {
 	mp.Set("Password", e.Password)
 	mp.Set("URL", e.URL)
 	mp.Set("Expires", e.Expires)
+	if e.PreviousPassword != "" {
+		if !show {
+			e.PreviousPassword = "•••••••••••••••"
+		}
+		mp.Set("Previous password", e.PreviousPassword)
+	}
+	if cmdutil.RotationDue(e, time.Now()) {
+		mp.Set("Rotation", "DUE")
+	}
 	mp.Set("Notes", e.Notes)
 
 	fmt.Println(cmdutil.BuildBox(name, mp))
@@ -0,0 +1,58 @@
+package add
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/db/note"
+	"github.com/GGP1/kure/pb"
+	"github.com/GGP1/kure/terminal"
+
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+const example = `
+* Add a new note
+kure note add Sample`
+
+// NewCmd returns a new command.
+func NewCmd(db *bolt.DB, r io.Reader) *cobra.Command {
+	return &cobra.Command{
+		Use:   "add <name>",
+		Short: "Add a secure note",
+		Long: `Add a secure note.
+
+Secure notes store free text like Wi-Fi credentials, recovery codes or software licenses. Type "<" to finish writing the text.`,
+		Aliases: []string{"create", "new"},
+		Example: example,
+		Args:    cmdutil.MustNotExist(db, cmdutil.Note),
+		RunE:    runAdd(db, r),
+	}
+}
+
+func runAdd(db *bolt.DB, r io.Reader) cmdutil.RunEFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		name := strings.Join(args, " ")
+		name = cmdutil.NormalizeName(name)
+
+		n := input(name, r)
+		if err := note.Create(db, n); err != nil {
+			return err
+		}
+
+		fmt.Printf("\n%q added\n", name)
+		return nil
+	}
+}
+
+func input(name string, r io.Reader) *pb.Note {
+	reader := bufio.NewReader(r)
+	return &pb.Note{
+		Name: name,
+		Text: terminal.Scanlns(reader, "Text"),
+	}
+}
@@ -0,0 +1,59 @@
+package add
+
+import (
+	"bytes"
+	"testing"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/db/note"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdd(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	buf := bytes.NewBufferString("SSID: kure\nPassword: atoll<\n")
+	cmd := NewCmd(db, buf)
+	cmd.SetArgs([]string{"wifi"})
+
+	err := cmd.Execute()
+	assert.NoError(t, err)
+
+	got, err := note.Get(db, "wifi")
+	assert.NoError(t, err, "Note wasn't created correctly")
+	assert.Equal(t, "SSID: kure\nPassword: atoll", got.Text)
+}
+
+func TestAddErrors(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	err := note.Create(db, &pb.Note{Name: "test"})
+	assert.NoError(t, err)
+
+	cases := []struct {
+		desc string
+		name string
+	}{
+		{
+			desc: "Already exists",
+			name: "test",
+		},
+		{
+			desc: "Invalid name",
+			name: "",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			buf := bytes.NewBufferString("text<\n")
+			cmd := NewCmd(db, buf)
+			cmd.SetArgs([]string{tc.name})
+
+			err := cmd.Execute()
+			assert.Error(t, err)
+		})
+	}
+}
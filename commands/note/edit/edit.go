@@ -0,0 +1,212 @@
+package edit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/db/note"
+	"github.com/GGP1/kure/pb"
+	"github.com/GGP1/kure/sig"
+	"github.com/GGP1/kure/terminal"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+const example = `
+* Edit using the standard input
+kure note edit Sample
+
+* Edit using the text editor
+kure note edit Sample -i`
+
+type editOptions struct {
+	interactive bool
+}
+
+// NewCmd returns a new command.
+func NewCmd(db *bolt.DB) *cobra.Command {
+	opts := editOptions{}
+	cmd := &cobra.Command{
+		Use:   "edit <name>",
+		Short: "Edit a note",
+		Long: `Edit a note.
+
+If the name is edited, kure will remove the old note and create one with the new name.`,
+		Example: example,
+		Args:    cmdutil.MustExist(db, cmdutil.Note),
+		RunE:    runEdit(db, &opts),
+		PostRun: func(cmd *cobra.Command, args []string) {
+			// Reset variables (session)
+			opts = editOptions{}
+		},
+	}
+
+	cmd.Flags().BoolVarP(&opts.interactive, "it", "i", false, "use the text editor")
+
+	return cmd
+}
+
+func runEdit(db *bolt.DB, opts *editOptions) cmdutil.RunEFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		name := strings.Join(args, " ")
+		name = cmdutil.NormalizeName(name)
+
+		oldNote, err := note.Get(db, name)
+		if err != nil {
+			return err
+		}
+
+		if opts.interactive {
+			return useTextEditor(db, oldNote)
+		}
+
+		return useStdin(db, os.Stdin, oldNote)
+	}
+}
+
+func createTempFile(n *pb.Note) (string, error) {
+	f, err := os.CreateTemp("", "*.json")
+	if err != nil {
+		return "", errors.Wrap(err, "creating temporary file")
+	}
+
+	content, err := json.MarshalIndent(n, "", "  ")
+	if err != nil {
+		return "", errors.Wrap(err, "encoding note")
+	}
+
+	if _, err := f.Write(content); err != nil {
+		return "", errors.Wrap(err, "writing temporary file")
+	}
+
+	if err := f.Close(); err != nil {
+		return "", errors.Wrap(err, "closing temporary file")
+	}
+
+	return f.Name(), nil
+}
+
+// readTmpFile reads the modified file and formats the note.
+func readTmpFile(filename string) (*pb.Note, error) {
+	var n pb.Note
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading file")
+	}
+	defer f.Close()
+
+	if err := json.NewDecoder(f).Decode(&n); err != nil {
+		return nil, errors.Wrap(err, "decoding file")
+	}
+
+	return &n, nil
+}
+
+// updateNote takes the name of the note that's being edited to check if the name was
+// changed. If it was, it will remove the old one.
+func updateNote(db *bolt.DB, name string, n *pb.Note) error {
+	if n.Name == "" {
+		return cmdutil.ErrInvalidName
+	}
+
+	name = cmdutil.NormalizeName(name)
+	n.Name = cmdutil.NormalizeName(n.Name)
+
+	if err := note.Update(db, name, n); err != nil {
+		return err
+	}
+
+	fmt.Println(n.Name, "updated")
+	return nil
+}
+
+func useStdin(db *bolt.DB, r io.Reader, oldNote *pb.Note) error {
+	fmt.Println("Type '-' to clear the field or leave blank to use the current value")
+	reader := bufio.NewReader(r)
+
+	scanln := func(field, value string) string {
+		input := terminal.Scanln(reader, fmt.Sprintf("%s [%s]", field, value))
+		if input == "-" {
+			return ""
+		} else if input != "" {
+			return input
+		}
+		return value
+	}
+
+	newNote := &pb.Note{
+		Name: scanln("Name", oldNote.Name),
+	}
+
+	text := terminal.Scanlns(reader, fmt.Sprintf("Text [%s]", oldNote.Text))
+	if text == "" {
+		text = oldNote.Text
+	} else if text == "-" {
+		text = ""
+	}
+	newNote.Text = text
+
+	return updateNote(db, oldNote.Name, newNote)
+}
+
+func useTextEditor(db *bolt.DB, oldNote *pb.Note) error {
+	editor := cmdutil.SelectEditor()
+	bin, err := exec.LookPath(editor)
+	if err != nil {
+		return errors.Errorf("executable %q not found", editor)
+	}
+
+	filename, err := createTempFile(oldNote)
+	if err != nil {
+		return err
+	}
+
+	sig.Signal.AddCleanup(func() error { return cmdutil.Erase(filename) })
+	defer cmdutil.Erase(filename)
+
+	// Open the temporary file with the selected text editor
+	edit := exec.Command(bin, filename)
+	edit.Stdin = os.Stdin
+	edit.Stdout = os.Stdout
+
+	if err := edit.Start(); err != nil {
+		return errors.Wrapf(err, "running %s", editor)
+	}
+
+	done := make(chan struct{}, 1)
+	errCh := make(chan error, 1)
+	go cmdutil.WatchFile(filename, done, errCh)
+
+	// Block until an event is received or an error occurs
+	select {
+	case <-done:
+	case err := <-errCh:
+		return err
+	}
+
+	if err := edit.Wait(); err != nil {
+		return err
+	}
+
+	newNote, err := readTmpFile(filename)
+	if err != nil {
+		return err
+	}
+
+	rmTabs := func(old string) string {
+		return strings.ReplaceAll(old, "\t", "")
+	}
+	newNote.Name = rmTabs(newNote.Name)
+	newNote.Text = rmTabs(newNote.Text)
+
+	return updateNote(db, oldNote.Name, newNote)
+}
@@ -0,0 +1,166 @@
+package edit
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/config"
+	"github.com/GGP1/kure/db/note"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestEditErrors(t *testing.T) {
+	db := cmdutil.SetContext(t)
+	createNote(t, db, "test")
+
+	cases := []struct {
+		set  func()
+		desc string
+		name string
+		it   string
+	}{
+		{
+			desc: "Invalid name",
+			name: "",
+			set:  func() {},
+		},
+		{
+			desc: "Non-existent entry",
+			name: "non-existent",
+			it:   "true",
+			set: func() {
+				config.Set("editor", "non-existent")
+			},
+		},
+	}
+
+	cmd := NewCmd(db)
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			tc.set()
+			cmd.SetArgs([]string{tc.name})
+			cmd.Flags().Set("it", tc.it)
+
+			err := cmd.Execute()
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestCreateTempFile(t *testing.T) {
+	record := &pb.Note{Name: "test-create-file"}
+	filename, err := createTempFile(record)
+	assert.NoError(t, err, "Failed creating the file")
+	defer os.Remove(filename)
+
+	content, err := os.ReadFile(filename)
+	assert.NoError(t, err, "Failed reading the file")
+
+	var got pb.Note
+	err = json.Unmarshal(content, &got)
+	assert.NoError(t, err, "Failed reading the file")
+
+	if !reflect.DeepEqual(record, &got) {
+		t.Error("Expected notes to be deep equal")
+	}
+}
+
+func TestReadTmpFile(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		_, err := readTmpFile("testdata/test_read.json")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		dir, _ := os.Getwd()
+		os.Chdir("testdata")
+		// Go back to the initial directory
+		defer os.Chdir(dir)
+
+		cases := []struct {
+			desc     string
+			filename string
+		}{
+			{
+				desc:     "Does not exists",
+				filename: "does_not_exists.json",
+			},
+			{
+				desc:     "EOF",
+				filename: "test_read_EOF.json",
+			},
+		}
+
+		for _, tc := range cases {
+			t.Run(tc.desc, func(t *testing.T) {
+				_, err := readTmpFile(tc.filename)
+				assert.Error(t, err)
+			})
+		}
+	})
+}
+
+func TestUpdateNote(t *testing.T) {
+	db := cmdutil.SetContext(t)
+	name := "test_update"
+	createNote(t, db, name)
+
+	newName := "new_name"
+	newNote := &pb.Note{
+		Name: newName,
+		Text: "recovery codes",
+	}
+
+	err := updateNote(db, name, newNote)
+	assert.NoError(t, err)
+
+	n, err := note.Get(db, newName)
+	assert.NoError(t, err)
+	assert.Equal(t, newNote.Text, n.Text)
+
+	_, err = note.Get(db, name)
+	assert.Error(t, err, "The old note wasn't removed")
+
+	t.Run("Invalid name", func(t *testing.T) {
+		newNote.Name = ""
+		err := updateNote(db, "fail", newNote)
+		assert.Error(t, err)
+	})
+}
+
+func TestUseStdin(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	oldNote := &pb.Note{
+		Name: "test",
+		Text: "test\ntext",
+	}
+
+	buf := bytes.NewBufferString("\nnew text<\n")
+
+	err := useStdin(db, buf, oldNote)
+	assert.NoError(t, err)
+
+	got, err := note.Get(db, "test")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "new text", got.Text)
+}
+
+func TestPostRun(t *testing.T) {
+	NewCmd(nil).PostRun(nil, nil)
+}
+
+func createNote(t *testing.T, db *bolt.DB, name string) {
+	t.Helper()
+	err := note.Create(db, &pb.Note{Name: name})
+	assert.NoError(t, err, "Failed creating the note")
+}
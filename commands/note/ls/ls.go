@@ -0,0 +1,119 @@
+package ls
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/db/note"
+	"github.com/GGP1/kure/orderedmap"
+	"github.com/GGP1/kure/pb"
+	"github.com/GGP1/kure/terminal"
+	"github.com/GGP1/kure/tree"
+
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+const example = `
+* List one and show the QR code
+kure note ls Sample -q
+
+* Filter by name
+kure note ls Sample -f
+
+* List all
+kure note ls`
+
+type lsOptions struct {
+	filter, qr bool
+}
+
+// NewCmd returns a new command.
+func NewCmd(db *bolt.DB) *cobra.Command {
+	opts := lsOptions{}
+	cmd := &cobra.Command{
+		Use:     "ls <name>",
+		Short:   "List secure notes",
+		Example: example,
+		Args:    cmdutil.MustExistLs(db, cmdutil.Note),
+		RunE:    runLs(db, &opts),
+		PostRun: func(cmd *cobra.Command, args []string) {
+			// Reset variables (session)
+			opts = lsOptions{}
+		},
+	}
+
+	f := cmd.Flags()
+	f.BoolVarP(&opts.filter, "filter", "f", false, "filter by name")
+	f.BoolVarP(&opts.qr, "qr", "q", false, "display the QR code of the text on the terminal")
+
+	return cmd
+}
+
+func runLs(db *bolt.DB, opts *lsOptions) cmdutil.RunEFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		name := strings.Join(args, " ")
+		name = cmdutil.NormalizeName(name)
+
+		// List all
+		if name == "" {
+			notes, err := note.ListNames(db)
+			if err != nil {
+				return err
+			}
+
+			tree.Print(notes)
+			return nil
+		}
+
+		// Filter by name
+		if opts.filter {
+			notes, err := note.ListNames(db)
+			if err != nil {
+				return err
+			}
+
+			var matches []string
+			for _, note := range notes {
+				matched, err := regexp.MatchString(name, note)
+				if err != nil {
+					return err
+				}
+
+				if matched {
+					matches = append(matches, note)
+				}
+			}
+
+			if len(matches) == 0 {
+				return errors.New("no notes were found")
+			}
+
+			tree.Print(matches)
+			return nil
+		}
+
+		// List one
+		n, err := note.Get(db, name)
+		if err != nil {
+			return err
+		}
+
+		if opts.qr {
+			return terminal.DisplayQRCode(n.Text)
+		}
+
+		printNote(name, n)
+		return nil
+	}
+}
+
+func printNote(name string, n *pb.Note) {
+	mp := orderedmap.New()
+	mp.Set("Text", n.Text)
+
+	fmt.Println(cmdutil.BuildBox(name, mp))
+}
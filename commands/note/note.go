@@ -0,0 +1,36 @@
+package note
+
+import (
+	"os"
+
+	nadd "github.com/GGP1/kure/commands/note/add"
+	ncopy "github.com/GGP1/kure/commands/note/copy"
+	nedit "github.com/GGP1/kure/commands/note/edit"
+	nls "github.com/GGP1/kure/commands/note/ls"
+	nrm "github.com/GGP1/kure/commands/note/rm"
+
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+const example = `
+kure note (add|copy|edit|ls|rm)`
+
+// NewCmd returns a new command.
+func NewCmd(db *bolt.DB) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "note",
+		Short:   "Secure note operations",
+		Example: example,
+	}
+
+	cmd.AddCommand(
+		nadd.NewCmd(db, os.Stdin),
+		ncopy.NewCmd(db),
+		nedit.NewCmd(db),
+		nls.NewCmd(db),
+		nrm.NewCmd(db, os.Stdin),
+	)
+
+	return cmd
+}
@@ -0,0 +1,68 @@
+package rm
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/db/note"
+	"github.com/GGP1/kure/terminal"
+
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+const example = `
+* Remove a note
+kure note rm Sample
+
+* Remove a directory
+kure note rm SampleDir/
+
+* Remove multiple notes
+kure note rm Sample Sample2 Sample3`
+
+// NewCmd returns the a new command.
+func NewCmd(db *bolt.DB, r io.Reader) *cobra.Command {
+	return &cobra.Command{
+		Use:     "rm <names>",
+		Short:   "Remove notes or directories",
+		Example: example,
+		Args:    cmdutil.MustExist(db, cmdutil.Note, true),
+		RunE:    runRm(db, r),
+	}
+}
+
+func runRm(db *bolt.DB, r io.Reader) cmdutil.RunEFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		if !terminal.Confirm(r, "Are you sure you want to proceed?") {
+			return nil
+		}
+
+		names := make([]string, 0, len(args))
+		for _, name := range args {
+			name = cmdutil.NormalizeName(name, true)
+
+			if !strings.HasSuffix(name, "/") {
+				names = append(names, name)
+				fmt.Println("Remove:", name)
+				continue
+			}
+
+			notes, err := note.ListNames(db)
+			if err != nil {
+				return err
+			}
+
+			for _, n := range notes {
+				if strings.HasPrefix(n, name) {
+					names = append(names, n)
+					fmt.Println("Remove:", n)
+				}
+			}
+		}
+
+		return note.Remove(db, names...)
+	}
+}
@@ -0,0 +1,190 @@
+package cmdutil
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/GGP1/kure/config"
+
+	"github.com/GGP1/atoll"
+
+	"github.com/awnumar/memguard"
+	"github.com/pkg/errors"
+	"github.com/spf13/cast"
+)
+
+// maxAttempts is the number of passwords generated before giving up on satisfying a profile rules.
+const maxAttempts = 1000
+
+// ProfileFlags are the password generation flags that can't be used along with a profile.
+var ProfileFlags = []string{"length", "levels", "include", "exclude", "repeat"}
+
+// Profile contains the rules used to generate passwords for a specific site.
+type Profile struct {
+	Name    string
+	Include string
+	Exclude string
+	// Sets of characters, the password must contain at least one character of each of them
+	Required       []string
+	Levels         []int
+	Length         uint64
+	MaxLength      uint64
+	NoLeadingDigit bool
+	Repeat         bool
+}
+
+// GetProfile returns the password generation profile with the name specified from the configuration.
+func GetProfile(name string) (*Profile, error) {
+	if name == "" || strings.Contains(name, ".") {
+		return nil, errors.Errorf("invalid profile name %q", name)
+	}
+
+	mp, ok := config.Get("gen.profiles." + name).(map[string]interface{})
+	if !ok {
+		return nil, errors.Errorf("profile %q does not exist", name)
+	}
+
+	p := &Profile{
+		Name:           name,
+		Include:        cast.ToString(mp["include"]),
+		Exclude:        cast.ToString(mp["exclude"]),
+		Required:       cast.ToStringSlice(mp["required"]),
+		Levels:         cast.ToIntSlice(mp["levels"]),
+		Length:         cast.ToUint64(mp["length"]),
+		MaxLength:      cast.ToUint64(mp["max_length"]),
+		NoLeadingDigit: cast.ToBool(mp["no_leading_digit"]),
+		Repeat:         true,
+	}
+	if repeat, ok := mp["repeat"]; ok {
+		p.Repeat = cast.ToBool(repeat)
+	}
+	if len(p.Levels) == 0 {
+		p.Levels = []int{1, 2, 3, 4, 5}
+	}
+	if p.Length == 0 {
+		p.Length = p.MaxLength
+	}
+
+	if err := p.validate(); err != nil {
+		return nil, errors.Wrapf(err, "profile %q", name)
+	}
+
+	return p, nil
+}
+
+// Generate returns a random password that satisfies the profile rules.
+func (p *Profile) Generate() (string, error) {
+	password, err := p.Password()
+	if err != nil {
+		return "", err
+	}
+
+	for i := 0; i < maxAttempts; i++ {
+		secret, err := atoll.NewSecret(password)
+		if err != nil {
+			return "", err
+		}
+
+		if p.Validate(string(secret)) == nil {
+			return string(secret), nil
+		}
+		memguard.WipeBytes(secret)
+	}
+
+	return "", errors.Errorf("couldn't generate a password that satisfies the %q profile rules", p.Name)
+}
+
+// Password returns the atoll password used to generate secrets with the profile parameters.
+func (p *Profile) Password() (*atoll.Password, error) {
+	levels, err := ParseLevels(p.Levels)
+	if err != nil {
+		return nil, err
+	}
+
+	return &atoll.Password{
+		Length:  p.Length,
+		Levels:  levels,
+		Include: p.Include,
+		Exclude: p.Exclude,
+		Repeat:  p.Repeat,
+	}, nil
+}
+
+// Validate returns an error if the password does not comply with the profile rules.
+func (p *Profile) Validate(password string) error {
+	if p.MaxLength > 0 && uint64(len([]rune(password))) > p.MaxLength {
+		return errors.Errorf("the password exceeds the maximum length of %d characters", p.MaxLength)
+	}
+
+	for _, set := range p.Required {
+		if !strings.ContainsAny(password, set) {
+			return errors.Errorf("the password must contain one of %q", set)
+		}
+	}
+
+	if p.NoLeadingDigit && password != "" && unicode.IsDigit([]rune(password)[0]) {
+		return errors.New("the password must not start with a digit")
+	}
+
+	return nil
+}
+
+func (p *Profile) validate() error {
+	if p.Length < 1 {
+		return ErrInvalidLength
+	}
+
+	if p.MaxLength > 0 && p.Length > p.MaxLength {
+		return errors.Errorf("length exceeds the maximum of %d characters", p.MaxLength)
+	}
+
+	levels, err := ParseLevels(p.Levels)
+	if err != nil {
+		return err
+	}
+
+	var pool strings.Builder
+	for _, lvl := range levels {
+		pool.WriteString(string(lvl))
+	}
+	pool.WriteString(p.Include)
+
+	chars := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(p.Exclude, r) {
+			return -1
+		}
+		return r
+	}, pool.String())
+
+	for _, set := range p.Required {
+		if !strings.ContainsAny(chars, set) {
+			return errors.Errorf("required characters %q are not part of the levels or included characters", set)
+		}
+	}
+
+	return nil
+}
+
+// ParseLevels converts the levels numbers used in flags to atoll levels.
+func ParseLevels(levels []int) ([]atoll.Level, error) {
+	atollLevels := make([]atoll.Level, len(levels))
+	for i, lvl := range levels {
+		switch lvl {
+		case 1:
+			atollLevels[i] = atoll.Lower
+		case 2:
+			atollLevels[i] = atoll.Upper
+		case 3:
+			atollLevels[i] = atoll.Digit
+		case 4:
+			atollLevels[i] = atoll.Space
+		case 5:
+			atollLevels[i] = atoll.Special
+
+		default:
+			return nil, errors.Errorf("invalid level [%d]", lvl)
+		}
+	}
+
+	return atollLevels, nil
+}
@@ -0,0 +1,107 @@
+package cmdutil
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/GGP1/kure/config"
+
+	"github.com/GGP1/atoll"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetProfile(t *testing.T) {
+	config.Reset()
+	config.Set("gen.profiles.bank", map[string]interface{}{
+		"max_length":       12,
+		"levels":           []interface{}{1, 2, 3},
+		"exclude":          "0O1l",
+		"required":         []interface{}{"0123456789"},
+		"no_leading_digit": true,
+	})
+
+	profile, err := GetProfile("bank")
+	assert.NoError(t, err)
+
+	expected := &Profile{
+		Name:           "bank",
+		Exclude:        "0O1l",
+		Required:       []string{"0123456789"},
+		Levels:         []int{1, 2, 3},
+		Length:         12,
+		MaxLength:      12,
+		NoLeadingDigit: true,
+		Repeat:         true,
+	}
+	assert.Equal(t, expected, profile)
+
+	for i := 0; i < 20; i++ {
+		password, err := profile.Generate()
+		assert.NoError(t, err)
+
+		assert.Len(t, password, 12)
+		assert.False(t, unicode.IsDigit(rune(password[0])), "password starts with a digit")
+		assert.True(t, strings.ContainsAny(password, "0123456789"), "password has no digits")
+		assert.False(t, strings.ContainsAny(password, "0O1l"), "password contains excluded characters")
+	}
+}
+
+func TestGetProfileErrors(t *testing.T) {
+	config.Reset()
+	config.Set("gen.profiles.invalid_level", map[string]interface{}{"length": 8, "levels": []interface{}{6}})
+	config.Set("gen.profiles.no_length", map[string]interface{}{"levels": []interface{}{1}})
+	config.Set("gen.profiles.too_long", map[string]interface{}{"length": 20, "max_length": 10})
+	config.Set("gen.profiles.unsatisfiable", map[string]interface{}{
+		"length":   8,
+		"levels":   []interface{}{1},
+		"required": []interface{}{"!@#"},
+	})
+
+	cases := []string{"", "a.b", "non-existent", "invalid_level", "no_length", "too_long", "unsatisfiable"}
+	for _, name := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := GetProfile(name)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestProfileValidate(t *testing.T) {
+	profile := &Profile{
+		Required:       []string{"!@#"},
+		MaxLength:      8,
+		NoLeadingDigit: true,
+	}
+
+	cases := []struct {
+		desc     string
+		password string
+		fail     bool
+	}{
+		{desc: "Valid", password: "abc!"},
+		{desc: "Too long", password: "abcdefgh!", fail: true},
+		{desc: "Missing required", password: "abcd", fail: true},
+		{desc: "Leading digit", password: "1abc!", fail: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			err := profile.Validate(tc.password)
+			if tc.fail {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestParseLevels(t *testing.T) {
+	levels, err := ParseLevels([]int{1, 2, 3, 4, 5})
+	assert.NoError(t, err)
+	assert.Equal(t, []atoll.Level{atoll.Lower, atoll.Upper, atoll.Digit, atoll.Space, atoll.Special}, levels)
+
+	_, err = ParseLevels([]int{0})
+	assert.Error(t, err)
+}
@@ -0,0 +1,172 @@
+package receive
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/GGP1/kure/bundle"
+	cmdutil "github.com/GGP1/kure/commands"
+	importt "github.com/GGP1/kure/commands/import"
+	authDB "github.com/GGP1/kure/db/auth"
+	"github.com/GGP1/kure/terminal"
+	"github.com/GGP1/kure/vault"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+const example = `
+* Receive a bundle from a file
+kure receive contract.kureb
+
+* Paste an armored bundle
+kure receive
+
+* Keep the stored records when they already exist
+kure receive bundle.txt --conflict skip`
+
+type receiveOptions struct {
+	conflict string
+}
+
+// NewCmd returns a new command.
+func NewCmd(db *bolt.DB, r io.Reader) *cobra.Command {
+	opts := receiveOptions{
+		conflict: "rename",
+	}
+	cmd := &cobra.Command{
+		Use:   "receive [path]",
+		Short: "Import the records shared with you",
+		Long: `Import the records of a bundle created with "kure share" into the vault in use.
+
+The bundle is read from the file specified, which can contain the bundle itself, an armored text block or the text encoded in its QR code. If no path is passed, paste the text, the armored block ends at its last line.
+
+Bundles encrypted with a passphrase ask for it, bundles sealed with your public key are opened with the private key stored in the default vault, so they can only be received there.
+
+When a record already exists, the conflict flag decides what to do:
+	• overwrite: replace the stored record
+	• skip: keep the stored record
+	• keep-newest: replace the stored record only if the received one was modified later
+	• rename: store the received record with a numeric suffix (default)`,
+		Example: example,
+		Args:    cobra.MaximumNArgs(1),
+		RunE:    runReceive(db, r, &opts),
+		PostRun: func(cmd *cobra.Command, args []string) {
+			// Reset variables (session)
+			opts = receiveOptions{
+				conflict: "rename",
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.conflict, "conflict", "c", "rename", "what to do with existing records [overwrite|skip|keep-newest|rename]")
+
+	return cmd
+}
+
+func runReceive(db *bolt.DB, r io.Reader, opts *receiveOptions) cmdutil.RunEFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		var (
+			text []byte
+			err  error
+		)
+		if len(args) > 0 {
+			text, err = os.ReadFile(args[0])
+			if err != nil {
+				return errors.Wrap(err, "reading bundle")
+			}
+		} else {
+			fmt.Fprintln(cmd.OutOrStdout(), "Paste the bundle:")
+			text, err = readText(r)
+			if err != nil {
+				return err
+			}
+		}
+
+		data, err := bundle.Unarmor(text)
+		if err != nil {
+			return err
+		}
+
+		b, err := decode(db, data)
+		if err != nil {
+			return err
+		}
+
+		summary, err := importt.Archive(db, b.Archive, opts.conflict)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Successfully received the records (%s)\n", summary)
+		return nil
+	}
+}
+
+// decode opens the bundle with the member private key if it's sealed, otherwise it asks for the passphrase.
+func decode(db *bolt.DB, data []byte) (*bundle.Bundle, error) {
+	sealed, err := bundle.Sealed(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if sealed {
+		if vault.Active() != vault.DefaultName {
+			return nil, errors.New("sealed bundles can only be received in the default vault, use \"--vault default\"")
+		}
+		privateKey, err := authDB.GetMemberKey(db)
+		if err != nil {
+			return nil, err
+		}
+		if privateKey == nil {
+			return nil, errors.New("there is no key pair, generate one with \"kure team key\" and share the public key")
+		}
+		return bundle.Open(data, privateKey)
+	}
+
+	enclave, err := terminal.ScanPassword("Bundle passphrase", false)
+	if err != nil {
+		return nil, err
+	}
+	password, err := enclave.Open()
+	if err != nil {
+		return nil, errors.Wrap(err, "opening enclave")
+	}
+	defer password.Destroy()
+
+	return bundle.Decode(data, password.Bytes())
+}
+
+// readText reads a single line or, if it starts an armored block, every line up to the end of the block.
+func readText(r io.Reader) ([]byte, error) {
+	reader := bufio.NewReader(r)
+	var buf bytes.Buffer
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, errors.Wrap(err, "reading bundle")
+		}
+
+		// Leading empty lines are skipped
+		line = strings.TrimSpace(line)
+		if line != "" {
+			buf.WriteString(line + "\n")
+		}
+
+		armored := bytes.HasPrefix(buf.Bytes(), []byte("-----BEGIN "))
+		if err == io.EOF || (buf.Len() > 0 && (!armored || strings.HasPrefix(line, "-----END "))) {
+			break
+		}
+	}
+
+	if buf.Len() == 0 {
+		return nil, errors.New("no bundle was provided")
+	}
+	return buf.Bytes(), nil
+}
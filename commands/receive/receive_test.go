@@ -0,0 +1,146 @@
+package receive
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GGP1/kure/bundle"
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/crypt"
+	authDB "github.com/GGP1/kure/db/auth"
+	"github.com/GGP1/kure/db/entry"
+	"github.com/GGP1/kure/db/file"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestReceive(t *testing.T) {
+	db := cmdutil.SetContext(t)
+	publicKey := memberKey(t, db)
+
+	assert.NoError(t, entry.Create(db, &pb.Entry{Name: "github", Password: "mine", Expires: "Never"}))
+
+	b := &bundle.Bundle{
+		Archive: &pb.Archive{
+			Entries: []*pb.Entry{{Name: "github", Password: "shared", Expires: "Never"}},
+			Files:   []*pb.File{{Name: "id.txt", Content: []byte("content")}},
+		},
+		Expires: time.Now().Add(time.Hour),
+	}
+	data, err := bundle.Seal(b, publicKey)
+	assert.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "bundle")
+	assert.NoError(t, os.WriteFile(path, data, 0o600))
+
+	var out bytes.Buffer
+	cmd := NewCmd(db, nil)
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{path})
+	assert.NoError(t, cmd.Execute())
+	assert.Equal(t, "Successfully received the records (1 create, 0 update, 1 rename, 0 skip, 0 unchanged)\n", out.String())
+
+	e, err := entry.Get(db, "github")
+	assert.NoError(t, err)
+	assert.Equal(t, "mine", e.Password)
+
+	f, err := file.Get(db, "id.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("content"), f.Content)
+}
+
+func TestReceiveArmored(t *testing.T) {
+	db := cmdutil.SetContext(t)
+	publicKey := memberKey(t, db)
+
+	b := &bundle.Bundle{
+		Archive: &pb.Archive{Entries: []*pb.Entry{{Name: "github", Password: "shared", Expires: "Never"}}},
+	}
+	data, err := bundle.Seal(b, publicKey)
+	assert.NoError(t, err)
+
+	// Text after the armored block must not be read
+	input := "\n" + string(bundle.Armor(data)) + "password\n"
+	cmd := NewCmd(db, strings.NewReader(input))
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetArgs([]string{"--conflict", "overwrite"})
+	assert.NoError(t, cmd.Execute())
+
+	e, err := entry.Get(db, "github")
+	assert.NoError(t, err)
+	assert.Equal(t, "shared", e.Password)
+}
+
+func TestReceiveErrors(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	_, otherKey, err := crypt.GenerateKeyPair()
+	assert.NoError(t, err)
+	data, err := bundle.Seal(&bundle.Bundle{Archive: &pb.Archive{}}, otherKey)
+	assert.NoError(t, err)
+	sealed := base64.StdEncoding.EncodeToString(data)
+
+	cases := []struct {
+		desc  string
+		input string
+		setup func()
+	}{
+		{desc: "Empty", input: "\n"},
+		{desc: "Invalid", input: "not a bundle\n"},
+		{desc: "No key pair", input: sealed},
+		{desc: "Other recipient", input: sealed, setup: func() { memberKey(t, db) }},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			if tc.setup != nil {
+				tc.setup()
+			}
+			cmd := NewCmd(db, strings.NewReader(tc.input))
+			cmd.SetOut(&bytes.Buffer{})
+			assert.Error(t, cmd.Execute())
+		})
+	}
+
+	cmd := NewCmd(db, nil)
+	cmd.SetArgs([]string{"non-existent"})
+	assert.Error(t, cmd.Execute())
+}
+
+func TestReadText(t *testing.T) {
+	cases := []struct {
+		desc     string
+		input    string
+		expected string
+	}{
+		{desc: "Single line", input: "S1VSQg==\nnext\n", expected: "S1VSQg==\n"},
+		{desc: "No newline", input: "S1VSQg==", expected: "S1VSQg==\n"},
+		{
+			desc:     "Armored",
+			input:    "\n  -----BEGIN KURE BUNDLE-----\nS1VS\nQg==\n-----END KURE BUNDLE-----\nnext\n",
+			expected: "-----BEGIN KURE BUNDLE-----\nS1VS\nQg==\n-----END KURE BUNDLE-----\n",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := readText(strings.NewReader(tc.input))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, string(got))
+		})
+	}
+}
+
+// memberKey stores a new key pair in the database and returns the public key.
+func memberKey(t *testing.T, db *bolt.DB) []byte {
+	privateKey, publicKey, err := crypt.GenerateKeyPair()
+	assert.NoError(t, err)
+	assert.NoError(t, authDB.SetMemberKey(db, privateKey))
+	return publicKey
+}
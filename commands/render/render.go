@@ -0,0 +1,159 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/template"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/commands/run"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+const example = `
+* Render a template to the standard output
+kure render config.yaml.tmpl
+
+* Render a template to a file
+kure render config.yaml.tmpl -o config.yaml
+
+* Check that every reference in a template exists
+kure render config.yaml.tmpl --check`
+
+type renderOptions struct {
+	output string
+	check  bool
+}
+
+// NewCmd returns a new command.
+func NewCmd(db *bolt.DB) *cobra.Command {
+	opts := renderOptions{}
+	cmd := &cobra.Command{
+		Use:   "render <template> [-o output] [--check]",
+		Short: "Render a template with secrets from the vault",
+		Long: `Render a Go text/template file with secrets from the vault.
+
+Functions:
+• entry "name" ["field"]: password (default), username, url, notes, expires
+• card "name" ["field"]: number (default), type, security_code, expire_date, notes
+• totp "name": current TOTP code
+• file "name": file content
+
+The output is written to the standard output or to a file only readable by the current user.
+
+With --check, the template is not rendered and every reference is validated instead, suggesting similar names for those that don't exist.`,
+		Example: example,
+		Args:    cobra.ExactArgs(1),
+		RunE:    runRender(db, &opts),
+		PostRun: func(cmd *cobra.Command, args []string) {
+			// Reset variables (session)
+			opts = renderOptions{}
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVarP(&opts.output, "output", "o", "", "destination file path (default: standard output)")
+	f.BoolVar(&opts.check, "check", false, "only validate that every reference exists")
+
+	return cmd
+}
+
+func runRender(db *bolt.DB, opts *renderOptions) cmdutil.RunEFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		text, err := os.ReadFile(args[0])
+		if err != nil {
+			return errors.Wrap(err, "reading template")
+		}
+
+		var errs []error
+		resolve := func(ref run.Reference) (string, error) {
+			if opts.check {
+				if err := ref.Check(db); err != nil {
+					errs = append(errs, err)
+				}
+				return "", nil
+			}
+			value, err := ref.Resolve(db)
+			return string(value), err
+		}
+
+		tmpl, err := template.New(filepath.Base(args[0])).
+			Option("missingkey=error").
+			Funcs(funcMap(resolve)).
+			Parse(string(text))
+		if err != nil {
+			return errors.Wrap(err, "parsing template")
+		}
+
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, nil); err != nil {
+			return errors.Wrap(err, "rendering template")
+		}
+
+		if opts.check {
+			if len(errs) > 0 {
+				for _, err := range errs {
+					fmt.Fprintln(cmd.ErrOrStderr(), "error:", err)
+				}
+				return errors.Errorf("%d invalid references", len(errs))
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), "All references exist")
+			return nil
+		}
+
+		if opts.output == "" {
+			_, err := buf.WriteTo(cmd.OutOrStdout())
+			return err
+		}
+
+		return writeFile(opts.output, buf.Bytes())
+	}
+}
+
+// funcMap returns the template functions, resolve is called with every reference.
+func funcMap(resolve func(run.Reference) (string, error)) template.FuncMap {
+	fn := func(recordType string) func(string, ...string) (string, error) {
+		return func(name string, field ...string) (string, error) {
+			if len(field) > 1 {
+				return "", errors.Errorf("%s: expected at most one field, got %d", recordType, len(field))
+			}
+
+			ref, err := run.NewReference(recordType, name, append(field, "")[0])
+			if err != nil {
+				return "", err
+			}
+			return resolve(ref)
+		}
+	}
+
+	return template.FuncMap{
+		"entry": fn("entry"),
+		"card":  fn("card"),
+		"totp":  fn("totp"),
+		"file":  fn("file"),
+	}
+}
+
+// writeFile writes content to a file only readable by the current user, replacing it if it exists.
+func writeFile(path string, content []byte) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return errors.Wrap(err, "creating the file")
+	}
+	defer f.Close()
+
+	// The permissions of an existing file are not modified by OpenFile
+	if err := f.Chmod(0o600); err != nil {
+		return errors.Wrap(err, "setting file permissions")
+	}
+
+	if _, err := f.Write(content); err != nil {
+		return errors.Wrap(err, "writing the file")
+	}
+	return f.Close()
+}
@@ -0,0 +1,148 @@
+package render
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/db/card"
+	"github.com/GGP1/kure/db/entry"
+	"github.com/GGP1/kure/db/file"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestRender(t *testing.T) {
+	db := setup(t)
+
+	text := `user: {{ entry "prod/db" "username" }}
+pass: {{ entry "prod/db" }}
+card: {{ card "visa" "security_code" }}
+cert: {{ file "cert.pem" | printf "%q" }}
+`
+	expected := `user: admin
+pass: secret
+card: 123
+cert: "content\n"
+`
+
+	t.Run("Stdout", func(t *testing.T) {
+		var out bytes.Buffer
+		cmd := NewCmd(db)
+		cmd.SetOut(&out)
+		cmd.SetArgs([]string{writeTemplate(t, text)})
+
+		err := cmd.Execute()
+		assert.NoError(t, err)
+		assert.Equal(t, expected, out.String())
+	})
+
+	t.Run("File", func(t *testing.T) {
+		output := filepath.Join(t.TempDir(), "config.yaml")
+		err := os.WriteFile(output, []byte("previous content that is longer"), 0o644)
+		assert.NoError(t, err)
+
+		cmd := NewCmd(db)
+		cmd.SetArgs([]string{writeTemplate(t, text), "-o", output})
+
+		err = cmd.Execute()
+		assert.NoError(t, err)
+
+		got, err := os.ReadFile(output)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, string(got))
+
+		info, err := os.Stat(output)
+		assert.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	})
+}
+
+func TestCheck(t *testing.T) {
+	db := setup(t)
+
+	t.Run("Valid", func(t *testing.T) {
+		var out bytes.Buffer
+		cmd := NewCmd(db)
+		cmd.SetOut(&out)
+		cmd.SetArgs([]string{writeTemplate(t, `{{ entry "prod/db" }} {{ card "visa" "number" }}`), "--check"})
+
+		err := cmd.Execute()
+		assert.NoError(t, err)
+		assert.Equal(t, "All references exist\n", out.String())
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		var out, errOut bytes.Buffer
+		cmd := NewCmd(db)
+		cmd.SetOut(&out)
+		cmd.SetErr(&errOut)
+		cmd.SetArgs([]string{writeTemplate(t, `{{ entry "prod/bd" }} {{ file "cert.pen" }} {{ entry "prod/db" }}`), "--check"})
+
+		err := cmd.Execute()
+		assert.EqualError(t, err, "2 invalid references")
+		assert.Contains(t, errOut.String(), `"prod/bd" does not exist. Did you mean "prod/db"?`)
+		assert.Contains(t, errOut.String(), `"cert.pen" does not exist. Did you mean "cert.pem"?`)
+	})
+}
+
+func TestRenderErrors(t *testing.T) {
+	db := setup(t)
+
+	cases := []struct {
+		desc string
+		text string
+	}{
+		{desc: "Non existent", text: `{{ entry "non-existent" }}`},
+		{desc: "Invalid field", text: `{{ entry "prod/db" "number" }}`},
+		{desc: "Too many fields", text: `{{ card "visa" "number" "type" }}`},
+		{desc: "Invalid syntax", text: `{{ entry "prod/db" `},
+		{desc: "Undefined function", text: `{{ note "prod/db" }}`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			cmd := NewCmd(db)
+			cmd.SetArgs([]string{writeTemplate(t, tc.text)})
+
+			err := cmd.Execute()
+			assert.Error(t, err)
+		})
+	}
+
+	t.Run("Non existent template", func(t *testing.T) {
+		cmd := NewCmd(db)
+		cmd.SetArgs([]string{filepath.Join(t.TempDir(), "non-existent.tmpl")})
+
+		err := cmd.Execute()
+		assert.Error(t, err)
+	})
+}
+
+func TestPostRun(t *testing.T) {
+	NewCmd(nil).PostRun(nil, nil)
+}
+
+func setup(t *testing.T) *bolt.DB {
+	db := cmdutil.SetContext(t)
+
+	err := entry.Create(db, &pb.Entry{Name: "prod/db", Username: "admin", Password: "secret", Expires: "Never"})
+	assert.NoError(t, err)
+	err = card.Create(db, &pb.Card{Name: "visa", Number: "4111111111111111", SecurityCode: "123"})
+	assert.NoError(t, err)
+	err = file.Create(db, &pb.File{Name: "cert.pem", Content: []byte("content\n")})
+	assert.NoError(t, err)
+
+	return db
+}
+
+func writeTemplate(t *testing.T, text string) string {
+	path := filepath.Join(t.TempDir(), "template.tmpl")
+	err := os.WriteFile(path, []byte(text), 0o600)
+	assert.NoError(t, err)
+	return path
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/GGP1/kure/auth"
 	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/config"
 	"github.com/GGP1/kure/crypt"
 	dbutil "github.com/GGP1/kure/db"
 	"github.com/GGP1/kure/db/bucket"
@@ -32,6 +33,10 @@ WARNING: this command is computationally expensive, it may cause memory (OOM) an
 
 func runRestore(db *bolt.DB) cmdutil.RunEFunc {
 	return func(cmd *cobra.Command, args []string) error {
+		if config.IsSet("auth.member") {
+			return errors.New("team vaults have no master password, their key is rotated when a member is removed")
+		}
+
 		buckets := bucket.GetNames()
 		logs := make([]*log, 0, len(buckets))
 
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/config"
 	"github.com/GGP1/kure/db/bucket"
 	"github.com/GGP1/kure/db/card"
 	"github.com/GGP1/kure/db/entry"
@@ -79,3 +80,11 @@ func TestWriteLogs(t *testing.T) {
 	err = writeLogs(db, []*log{l})
 	assert.NoError(t, err, "Failed writing logs")
 }
+
+func TestRestoreTeamVault(t *testing.T) {
+	db := cmdutil.SetContext(t)
+	config.Set("auth.member", "alice")
+
+	cmd := NewCmd(db)
+	assert.Error(t, cmd.Execute())
+}
@@ -8,24 +8,42 @@ import (
 	cmdutil "github.com/GGP1/kure/commands"
 	tfa "github.com/GGP1/kure/commands/2fa"
 	"github.com/GGP1/kure/commands/add"
+	"github.com/GGP1/kure/commands/audit"
 	"github.com/GGP1/kure/commands/backup"
+	"github.com/GGP1/kure/commands/backup/snapshot"
+	"github.com/GGP1/kure/commands/bank"
 	"github.com/GGP1/kure/commands/card"
 	"github.com/GGP1/kure/commands/clear"
 	"github.com/GGP1/kure/commands/config"
 	"github.com/GGP1/kure/commands/copy"
+	"github.com/GGP1/kure/commands/credential"
+	"github.com/GGP1/kure/commands/diff"
 	"github.com/GGP1/kure/commands/edit"
 	"github.com/GGP1/kure/commands/export"
 	"github.com/GGP1/kure/commands/file"
 	"github.com/GGP1/kure/commands/gen"
+	"github.com/GGP1/kure/commands/identity"
 	importt "github.com/GGP1/kure/commands/import"
 	"github.com/GGP1/kure/commands/it"
 	"github.com/GGP1/kure/commands/ls"
+	"github.com/GGP1/kure/commands/note"
+	"github.com/GGP1/kure/commands/receive"
+	"github.com/GGP1/kure/commands/render"
 	"github.com/GGP1/kure/commands/restore"
 	"github.com/GGP1/kure/commands/rm"
 	"github.com/GGP1/kure/commands/rotate"
+	"github.com/GGP1/kure/commands/run"
 	"github.com/GGP1/kure/commands/session"
+	"github.com/GGP1/kure/commands/share"
+	"github.com/GGP1/kure/commands/ssh"
+	"github.com/GGP1/kure/commands/sshagent"
 	"github.com/GGP1/kure/commands/stats"
+	"github.com/GGP1/kure/commands/sync"
+	"github.com/GGP1/kure/commands/team"
+	vaultcmd "github.com/GGP1/kure/commands/vault"
+	"github.com/GGP1/kure/vault"
 
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	bolt "go.etcd.io/bbolt"
 )
@@ -38,8 +56,20 @@ var statelessCommands = map[string]struct{}{
 	"--version": {},
 }
 
+// statelessSubcommands contains the subcommands that do not require opening the database, mapped by their parent.
+var statelessSubcommands = map[string]map[string]struct{}{
+	"vault": {
+		"create": {},
+		"ls":     {},
+		"use":    {},
+	},
+}
+
 type rootOptions struct {
+	vault   string
 	version bool
+	// ID of the last transaction committed before running a command
+	txID int
 }
 
 // NewCmd returns a new command.
@@ -54,29 +84,59 @@ func NewCmd(db *bolt.DB) *cobra.Command {
 			HiddenDefaultCmd: true,
 		},
 		RunE: runRoot(&opts),
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// The vault is selected before opening the database, it cannot be changed afterwards (session)
+			name := opts.vault
+			opts.vault = ""
+			if name != "" && name != vault.Active() {
+				return errors.Errorf("the %q vault is in use, start a new session to use %q", vault.Active(), name)
+			}
+
+			opts.txID = lastTxID(db)
+			return nil
+		},
+		PersistentPostRun: func(cmd *cobra.Command, args []string) {
+			autoSnapshot(db, &opts)
+		},
 	}
 
+	cmd.PersistentFlags().StringVar(&opts.vault, "vault", "", "name of the vault to use")
 	cmd.Flags().BoolVarP(&opts.version, "version", "v", false, "display kure version")
 	cmd.AddCommand(
 		tfa.NewCmd(db),
 		add.NewCmd(db, os.Stdin),
-		backup.NewCmd(db),
+		audit.NewCmd(db),
+		backup.NewCmd(db, os.Stdin),
+		bank.NewCmd(db),
 		card.NewCmd(db),
 		clear.NewCmd(),
 		config.NewCmd(db),
 		copy.NewCmd(db),
+		credential.NewCmd(db),
+		diff.NewCmd(db, os.Stdin),
 		edit.NewCmd(db),
 		export.NewCmd(db),
 		file.NewCmd(db),
 		gen.NewCmd(),
-		importt.NewCmd(db),
+		identity.NewCmd(db),
+		importt.NewCmd(db, os.Stdin),
 		it.NewCmd(db),
 		ls.NewCmd(db),
+		note.NewCmd(db),
+		receive.NewCmd(db, os.Stdin),
+		render.NewCmd(db),
 		restore.NewCmd(db),
-		rotate.NewCmd(db),
+		rotate.NewCmd(db, os.Stdin),
 		rm.NewCmd(db, os.Stdin),
+		run.NewCmd(db),
 		session.NewCmd(os.Stdin),
+		share.NewCmd(db),
+		ssh.NewCmd(db),
+		sshagent.NewCmd(db, os.Stdin),
 		stats.NewCmd(db),
+		sync.NewCmd(db),
+		team.NewCmd(db, os.Stdin),
+		vaultcmd.NewCmd(db, os.Stdin),
 	)
 
 	return cmd
@@ -108,8 +168,51 @@ func printVersion() {
 	fmt.Printf("[%s] %s %s\n", bi.GoVersion, bi.Main.Version, lastCommitHash)
 }
 
-// IsStatelessCommand returns true if the specified command does not require opening the database.
-func IsStatelessCommand(command string) bool {
-	_, ok := statelessCommands[command]
+// autoSnapshot takes a snapshot of the database if the command modified it or the latest one is too old,
+// depending on the configuration.
+func autoSnapshot(db *bolt.DB, opts *rootOptions) {
+	if db == nil {
+		return
+	}
+
+	txID := lastTxID(db)
+	wrote := txID != opts.txID
+	opts.txID = txID
+
+	if _, err := snapshot.Auto(db, wrote); err != nil {
+		// Do not fail the command, it has already been executed successfully
+		fmt.Fprintln(os.Stderr, "error: taking database snapshot:", err)
+	}
+}
+
+// lastTxID returns the ID of the last transaction committed, it changes every time the database is modified.
+func lastTxID(db *bolt.DB) int {
+	if db == nil {
+		return 0
+	}
+
+	var id int
+	_ = db.View(func(tx *bolt.Tx) error {
+		id = tx.ID()
+		return nil
+	})
+	return id
+}
+
+// IsStatelessCommand returns true if the command specified by the arguments does not require opening
+// the database.
+func IsStatelessCommand(args ...string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	if _, ok := statelessCommands[args[0]]; ok {
+		return true
+	}
+
+	subcommands, ok := statelessSubcommands[args[0]]
+	if !ok || len(args) < 2 {
+		return false
+	}
+	_, ok = subcommands[args[1]]
 	return ok
 }
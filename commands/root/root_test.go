@@ -44,8 +44,16 @@ func TestHasExample(t *testing.T) {
 func TestRunnable(t *testing.T) {
 	cmd := root.NewCmd(nil)
 	exceptions := map[string]struct{}{
+		"audit":      {},
+		"bank":       {},
 		"card":       {},
+		"credential": {},
 		"file":       {},
+		"identity":   {},
+		"note":       {},
+		"ssh":        {},
+		"team":       {},
+		"vault":      {},
 		"completion": {},
 	}
 
@@ -109,3 +117,25 @@ func TestIsStatelessCommand(t *testing.T) {
 		})
 	}
 }
+
+func TestIsStatelessSubcommand(t *testing.T) {
+	cases := []struct {
+		desc     string
+		args     []string
+		expected bool
+	}{
+		{desc: "vault", args: []string{"vault"}, expected: false},
+		{desc: "vault ls", args: []string{"vault", "ls"}, expected: true},
+		{desc: "vault use", args: []string{"vault", "use", "work"}, expected: true},
+		{desc: "vault create", args: []string{"vault", "create", "work"}, expected: true},
+		{desc: "vault move", args: []string{"vault", "move", "entry:github"}, expected: false},
+		{desc: "note ls", args: []string{"note", "ls"}, expected: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got := root.IsStatelessCommand(tc.args...)
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
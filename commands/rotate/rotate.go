@@ -2,12 +2,16 @@ package rotate
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/GGP1/atoll"
 	cmdutil "github.com/GGP1/kure/commands"
 	"github.com/GGP1/kure/db/entry"
+	"github.com/GGP1/kure/pb"
 	"github.com/GGP1/kure/terminal"
 
 	"github.com/pkg/errors"
@@ -20,22 +24,38 @@ const example = `
 kure rotate Sample
 
 * Rotate a password using a new custom one
-kure rotate Sample -c`
+kure rotate Sample -c
+
+* Rotate all the passwords past their rotation policy
+kure rotate --due
+
+* Confirm the new password was set on the site
+kure rotate Sample --confirm`
 
 type rotateOptions struct {
-	copy, custom bool
-	timeout      time.Duration
+	confirm, copy, custom, due bool
+	timeout                    time.Duration
 }
 
 // NewCmd returns a new command.
-func NewCmd(db *bolt.DB) *cobra.Command {
+func NewCmd(db *bolt.DB, r io.Reader) *cobra.Command {
 	opts := rotateOptions{}
 	cmd := &cobra.Command{
-		Use:     "rotate <name>",
-		Short:   "Rotate an entry's password",
+		Use:   "rotate <name>",
+		Short: "Rotate an entry's password",
+		Long: `Rotate an entry's password.
+
+The old password is kept until the rotation is confirmed, so it can still be retrieved in case the new one couldn't be set on the site.
+
+Use the due flag to rotate, one by one and asking for confirmation, the passwords past their rotation policy. Policies are defined in the configuration file under the "rotation.policies" key.`,
 		Example: example,
-		Args:    cmdutil.MustExist(db, cmdutil.Entry),
-		RunE:    runRotate(db, &opts),
+		Args: func(cmd *cobra.Command, args []string) error {
+			if opts.due {
+				return nil
+			}
+			return cmdutil.MustExist(db, cmdutil.Entry)(cmd, args)
+		},
+		RunE: runRotate(db, r, &opts),
 		PostRun: func(cmd *cobra.Command, args []string) {
 			// Reset variables (session)
 			opts = rotateOptions{}
@@ -45,13 +65,23 @@ func NewCmd(db *bolt.DB) *cobra.Command {
 	f := cmd.Flags()
 	f.BoolVarP(&opts.copy, "copy", "c", false, "copy new password to clipboard")
 	f.BoolVar(&opts.custom, "custom", false, "use a custom password")
+	f.BoolVar(&opts.due, "due", false, "rotate all passwords past their rotation policy")
+	f.BoolVar(&opts.confirm, "confirm", false, "confirm the new password was set and discard the old one")
 	f.DurationVarP(&opts.timeout, "timeout", "t", 0, "clipboard clearing timeout")
 
+	cmd.MarkFlagsMutuallyExclusive("due", "confirm")
+	cmd.MarkFlagsMutuallyExclusive("due", "custom")
+	cmd.MarkFlagsMutuallyExclusive("due", "copy")
+
 	return cmd
 }
 
-func runRotate(db *bolt.DB, opts *rotateOptions) cmdutil.RunEFunc {
+func runRotate(db *bolt.DB, r io.Reader, opts *rotateOptions) cmdutil.RunEFunc {
 	return func(cmd *cobra.Command, args []string) error {
+		if opts.due {
+			return rotateDue(db, r)
+		}
+
 		name := strings.Join(args, " ")
 		name = cmdutil.NormalizeName(name)
 
@@ -60,25 +90,46 @@ func runRotate(db *bolt.DB, opts *rotateOptions) cmdutil.RunEFunc {
 			return err
 		}
 
+		if opts.confirm {
+			if e.PreviousPassword == "" {
+				return errors.Errorf("%q has no pending rotation", name)
+			}
+
+			e.PreviousPassword = ""
+			if err := entry.Update(db, name, e); err != nil {
+				return err
+			}
+
+			fmt.Printf("%q rotation confirmed\n", name)
+			return nil
+		}
+
 		fmt.Printf("Old password: %s\n", e.Password)
 
+		var password string
 		if opts.custom {
-			e.Password, err = readPassword()
+			password, err = readPassword()
 			if err != nil {
 				return err
 			}
+
+			if e.Profile != "" {
+				profile, err := cmdutil.GetProfile(e.Profile)
+				if err != nil {
+					return err
+				}
+				if err := profile.Validate(password); err != nil {
+					return err
+				}
+			}
 		} else {
-			// Generate a password with the same parameters as the old one
-			secret := atoll.SecretFromString(e.Password)
-			password, err := secret.Generate()
+			password, err = NewPassword(e)
 			if err != nil {
 				return err
 			}
-
-			e.Password = string(password)
 		}
 
-		if err := entry.Update(db, name, e); err != nil {
+		if err := SetPassword(db, e, password); err != nil {
 			return err
 		}
 
@@ -91,6 +142,84 @@ func runRotate(db *bolt.DB, opts *rotateOptions) cmdutil.RunEFunc {
 	}
 }
 
+// rotateDue asks the user whether to rotate each of the entries past their rotation
+// policy and prints a summary of the entries pending confirmation.
+func rotateDue(db *bolt.DB, r io.Reader) error {
+	entries, err := entry.List(db)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	pending := make([]*pb.Entry, 0)
+	for _, e := range entries {
+		if cmdutil.RotationDue(e, now) && terminal.Confirm(r, fmt.Sprintf("Rotate %q password?", e.Name)) {
+			password, err := NewPassword(e)
+			if err != nil {
+				return errors.Wrapf(err, "rotating %q", e.Name)
+			}
+
+			if err := SetPassword(db, e, password); err != nil {
+				return err
+			}
+		}
+
+		if e.PreviousPassword != "" {
+			pending = append(pending, e)
+		}
+	}
+
+	printPending(pending)
+	return nil
+}
+
+// SetPassword sets the new password to the entry, keeping the old one until the rotation is confirmed.
+func SetPassword(db *bolt.DB, e *pb.Entry, password string) error {
+	// If there is a rotation pending, the previous password is still the one set on the site
+	if e.PreviousPassword == "" {
+		e.PreviousPassword = e.Password
+	}
+	e.Password = password
+	e.PasswordUpdatedAt = time.Now().Unix()
+
+	return entry.Update(db, e.Name, e)
+}
+
+// NewPassword generates a random password using the entry's profile or, if it has none,
+// the same parameters as its current password.
+func NewPassword(e *pb.Entry) (string, error) {
+	if e.Profile != "" {
+		profile, err := cmdutil.GetProfile(e.Profile)
+		if err != nil {
+			return "", err
+		}
+		return profile.Generate()
+	}
+
+	secret := atoll.SecretFromString(e.Password)
+	password, err := secret.Generate()
+	if err != nil {
+		return "", err
+	}
+
+	return string(password), nil
+}
+
+func printPending(entries []*pb.Entry) {
+	if len(entries) == 0 {
+		fmt.Println("\nNo passwords are pending confirmation")
+		return
+	}
+
+	fmt.Println("\nThe following passwords must be set manually, confirm them using \"kure rotate <name> --confirm\"")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tURL")
+	for _, e := range entries {
+		fmt.Fprintf(w, "%s\t%s\n", e.Name, e.URL)
+	}
+	w.Flush()
+}
+
 func readPassword() (string, error) {
 	enclave, err := terminal.ScanPassword("New password", true)
 	if err != nil {
@@ -1,10 +1,13 @@
 package rotate
 
 import (
+	"bytes"
 	"testing"
+	"time"
 
 	"github.com/GGP1/atoll"
 	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/config"
 	"github.com/GGP1/kure/db/entry"
 	"github.com/GGP1/kure/pb"
 
@@ -19,7 +22,7 @@ func TestRotate(t *testing.T) {
 	err := entry.Create(db, &pb.Entry{Name: name, Password: password})
 	assert.NoError(t, err)
 
-	cmd := NewCmd(db)
+	cmd := NewCmd(db, nil)
 	cmd.SetArgs([]string{name})
 
 	err = cmd.Execute()
@@ -29,6 +32,8 @@ func TestRotate(t *testing.T) {
 	assert.NoError(t, err)
 
 	assert.NotEqual(t, password, updatedEntry.Password)
+	assert.NotZero(t, updatedEntry.PasswordUpdatedAt)
+	assert.Equal(t, password, updatedEntry.PreviousPassword)
 	assert.Equal(t, len(password), len(updatedEntry.Password))
 
 	oldSecret := atoll.SecretFromString(password)
@@ -56,7 +61,7 @@ func TestRotateErrors(t *testing.T) {
 
 	for _, tc := range cases {
 		t.Run(tc.desc, func(t *testing.T) {
-			cmd := NewCmd(db)
+			cmd := NewCmd(db, nil)
 			cmd.SetArgs([]string{tc.name})
 
 			err := cmd.Execute()
@@ -64,3 +69,81 @@ func TestRotateErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestRotateProfile(t *testing.T) {
+	db := cmdutil.SetContext(t)
+	config.Set("gen.profiles.pin", map[string]interface{}{
+		"length": 6,
+		"levels": []interface{}{3},
+	})
+
+	name := "test"
+	err := entry.Create(db, &pb.Entry{Name: name, Password: "password", Profile: "pin"})
+	assert.NoError(t, err)
+
+	cmd := NewCmd(db, nil)
+	cmd.SetArgs([]string{name})
+
+	err = cmd.Execute()
+	assert.NoError(t, err)
+
+	updatedEntry, err := entry.Get(db, name)
+	assert.NoError(t, err)
+	assert.Regexp(t, "^[0-9]{6}$", updatedEntry.Password)
+}
+
+func TestRotateDue(t *testing.T) {
+	db := cmdutil.SetContext(t)
+	config.Set("rotation.policies", map[string]interface{}{"work/": 90})
+
+	old := time.Now().Add(-100 * 24 * time.Hour).Unix()
+	entries := []*pb.Entry{
+		{Name: "work/github", Password: "github", PasswordUpdatedAt: old},
+		{Name: "work/gitlab", Password: "gitlab", PasswordUpdatedAt: old},
+		{Name: "personal", Password: "personal", PasswordUpdatedAt: old},
+	}
+	for _, e := range entries {
+		err := entry.Create(db, e)
+		assert.NoError(t, err)
+	}
+
+	cmd := NewCmd(db, bytes.NewBufferString("y\nn\n"))
+	cmd.Flags().Set("due", "true")
+
+	err := cmd.Execute()
+	assert.NoError(t, err)
+
+	github, err := entry.Get(db, "work/github")
+	assert.NoError(t, err)
+	assert.NotEqual(t, "github", github.Password)
+	assert.Equal(t, "github", github.PreviousPassword)
+
+	for _, name := range []string{"work/gitlab", "personal"} {
+		e, err := entry.Get(db, name)
+		assert.NoError(t, err)
+		assert.Equal(t, old, e.PasswordUpdatedAt)
+		assert.Empty(t, e.PreviousPassword)
+	}
+
+	t.Run("Confirm", func(t *testing.T) {
+		cmd := NewCmd(db, nil)
+		cmd.SetArgs([]string{"work/github"})
+		cmd.Flags().Set("confirm", "true")
+
+		err := cmd.Execute()
+		assert.NoError(t, err)
+
+		e, err := entry.Get(db, "work/github")
+		assert.NoError(t, err)
+		assert.Empty(t, e.PreviousPassword)
+
+		// Nothing left to confirm
+		cmd.Flags().Set("confirm", "true")
+		err = cmd.Execute()
+		assert.Error(t, err)
+	})
+}
+
+func TestPostRun(t *testing.T) {
+	NewCmd(nil, nil).PostRun(nil, nil)
+}
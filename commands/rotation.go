@@ -0,0 +1,61 @@
+package cmdutil
+
+import (
+	"strings"
+	"time"
+
+	"github.com/GGP1/kure/config"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/spf13/cast"
+)
+
+// day is the unit used to define rotation policies.
+const day = 24 * time.Hour
+
+// RotationPolicy returns the interval at which the entry password must be rotated, zero means it has no policy.
+//
+// Policies are looked up in the "rotation.policies" configuration key, where each key may be an
+// entry name, a directory (ending with a slash) or "*" (all entries) and each value a number of days.
+// The most specific policy is the one used.
+func RotationPolicy(name string) time.Duration {
+	policies, ok := config.Get("rotation.policies").(map[string]interface{})
+	if !ok {
+		return 0
+	}
+
+	var (
+		match string
+		days  int
+		found bool
+	)
+	for key, value := range policies {
+		switch {
+		case key == name:
+			return time.Duration(cast.ToInt(value)) * day
+
+		case key == "*" && !found:
+			days = cast.ToInt(value)
+			found = true
+
+		case strings.HasSuffix(key, "/") && strings.HasPrefix(name, key) && len(key) > len(match):
+			match = key
+			days = cast.ToInt(value)
+			found = true
+		}
+	}
+
+	return time.Duration(days) * day
+}
+
+// RotationDue returns whether the entry password is past its rotation policy.
+//
+// Passwords whose last update is unknown are considered due if the entry has a policy.
+func RotationDue(e *pb.Entry, now time.Time) bool {
+	policy := RotationPolicy(e.Name)
+	if policy <= 0 {
+		return false
+	}
+
+	return now.Sub(time.Unix(e.PasswordUpdatedAt, 0)) >= policy
+}
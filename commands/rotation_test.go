@@ -0,0 +1,82 @@
+package cmdutil
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GGP1/kure/config"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRotationPolicy(t *testing.T) {
+	config.Reset()
+	config.Set("rotation.policies", map[string]interface{}{
+		"*":             180,
+		"work/":         90,
+		"work/bank/":    30,
+		"work/bank/pin": 7,
+	})
+
+	cases := []struct {
+		name     string
+		expected time.Duration
+	}{
+		{name: "personal", expected: 180 * day},
+		{name: "work/github", expected: 90 * day},
+		{name: "work/bank/account", expected: 30 * day},
+		{name: "work/bank/pin", expected: 7 * day},
+		{name: "workshop", expected: 180 * day},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, RotationPolicy(tc.name))
+		})
+	}
+
+	t.Run("No policies", func(t *testing.T) {
+		config.Reset()
+		assert.Zero(t, RotationPolicy("personal"))
+	})
+}
+
+func TestRotationDue(t *testing.T) {
+	config.Reset()
+	config.Set("rotation.policies", map[string]interface{}{"work/": 90})
+	now := time.Now()
+
+	cases := []struct {
+		desc     string
+		entry    *pb.Entry
+		expected bool
+	}{
+		{
+			desc:     "Due",
+			entry:    &pb.Entry{Name: "work/github", PasswordUpdatedAt: now.Add(-100 * day).Unix()},
+			expected: true,
+		},
+		{
+			desc:     "Not due",
+			entry:    &pb.Entry{Name: "work/github", PasswordUpdatedAt: now.Add(-10 * day).Unix()},
+			expected: false,
+		},
+		{
+			desc:     "Unknown update",
+			entry:    &pb.Entry{Name: "work/github"},
+			expected: true,
+		},
+		{
+			desc:     "No policy",
+			entry:    &pb.Entry{Name: "personal"},
+			expected: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			assert.Equal(t, tc.expected, RotationDue(tc.entry, now))
+		})
+	}
+}
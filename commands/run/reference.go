@@ -0,0 +1,147 @@
+package run
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	tfa "github.com/GGP1/kure/commands/2fa"
+	"github.com/GGP1/kure/db/card"
+	"github.com/GGP1/kure/db/entry"
+	"github.com/GGP1/kure/db/file"
+	"github.com/GGP1/kure/db/totp"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Reference points to a record field, its format is "<type>:<name>[#field]".
+//
+// If the field is omitted, the default one of the type is used (entry password, card number,
+// TOTP code and file content).
+type Reference struct {
+	Type  string
+	Name  string
+	Field string
+}
+
+// Fields supported by each record type, the first one is the default.
+var referenceFields = map[string][]string{
+	"entry": {"password", "username", "url", "notes", "expires"},
+	"card":  {"number", "type", "security_code", "expire_date", "notes"},
+	"totp":  {"code"},
+	"file":  {"content"},
+}
+
+// NewReference returns a reference to the field of a record, if field is empty the default one is used.
+func NewReference(recordType, name, field string) (Reference, error) {
+	fields, ok := referenceFields[recordType]
+	if !ok {
+		return Reference{}, errors.Errorf("invalid reference type %q, use entry, card, totp or file", recordType)
+	}
+
+	name = cmdutil.NormalizeName(name)
+	if name == "" {
+		return Reference{}, errors.Errorf("invalid %s reference, missing name", recordType)
+	}
+
+	if field == "" {
+		field = fields[0]
+	}
+	if !slices.Contains(fields, field) {
+		return Reference{}, errors.Errorf("invalid %s field %q, use %s", recordType, field, strings.Join(fields, ", "))
+	}
+
+	return Reference{Type: recordType, Name: name, Field: field}, nil
+}
+
+// ParseReference parses and validates a reference.
+func ParseReference(s string) (Reference, error) {
+	recordType, name, ok := strings.Cut(s, ":")
+	if !ok {
+		return Reference{}, errors.Errorf("invalid reference %q, the format is <type>:<name>[#field]", s)
+	}
+
+	field := ""
+	if i := strings.LastIndexByte(name, '#'); i != -1 {
+		name, field = name[:i], name[i+1:]
+		if field == "" {
+			return Reference{}, errors.Errorf("invalid reference %q, missing field", s)
+		}
+	}
+
+	return NewReference(recordType, name, field)
+}
+
+// String returns the reference in the "<type>:<name>#field" format.
+func (r Reference) String() string {
+	return r.Type + ":" + r.Name + "#" + r.Field
+}
+
+// Check returns an error suggesting similar names if the record the reference points to does not exist.
+func (r Reference) Check(db *bolt.DB) error {
+	mustExist := cmdutil.MustExist(db, cmdutil.Entry)
+	switch r.Type {
+	case "card":
+		mustExist = cmdutil.MustExist(db, cmdutil.Card)
+	case "totp":
+		mustExist = cmdutil.MustExist(db, cmdutil.TOTP)
+	case "file":
+		mustExist = cmdutil.MustExist(db, cmdutil.File)
+	}
+
+	if err := mustExist(nil, []string{r.Name}); err != nil {
+		return errors.Wrap(err, r.Type)
+	}
+	return nil
+}
+
+// Resolve returns the value of the field the reference points to.
+func (r Reference) Resolve(db *bolt.DB) ([]byte, error) {
+	switch r.Type {
+	case "entry":
+		e, err := entry.Get(db, r.Name)
+		if err != nil {
+			return nil, errors.Wrap(err, r.String())
+		}
+		values := map[string]string{
+			"password": e.Password,
+			"username": e.Username,
+			"url":      e.URL,
+			"notes":    e.Notes,
+			"expires":  e.Expires,
+		}
+		return []byte(values[r.Field]), nil
+
+	case "card":
+		c, err := card.Get(db, r.Name)
+		if err != nil {
+			return nil, errors.Wrap(err, r.String())
+		}
+		values := map[string]string{
+			"number":        c.Number,
+			"type":          c.Type,
+			"security_code": c.SecurityCode,
+			"expire_date":   c.ExpireDate,
+			"notes":         c.Notes,
+		}
+		return []byte(values[r.Field]), nil
+
+	case "totp":
+		t, err := totp.Get(db, r.Name)
+		if err != nil {
+			return nil, errors.Wrap(err, r.String())
+		}
+		return []byte(tfa.GenerateTOTP(t.Raw, time.Now(), int(t.Digits))), nil
+
+	case "file":
+		f, err := file.Get(db, r.Name)
+		if err != nil {
+			return nil, errors.Wrap(err, r.String())
+		}
+		return f.Content, nil
+	}
+
+	return nil, errors.Errorf("invalid reference type %q", r.Type)
+}
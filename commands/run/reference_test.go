@@ -0,0 +1,137 @@
+package run
+
+import (
+	"testing"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/db/card"
+	"github.com/GGP1/kure/db/entry"
+	"github.com/GGP1/kure/db/file"
+	"github.com/GGP1/kure/db/totp"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseReference(t *testing.T) {
+	cases := []struct {
+		desc     string
+		ref      string
+		expected Reference
+	}{
+		{
+			desc:     "Default field",
+			ref:      "entry:prod/db",
+			expected: Reference{Type: "entry", Name: "prod/db", Field: "password"},
+		},
+		{
+			desc:     "Field",
+			ref:      "entry:prod/db#username",
+			expected: Reference{Type: "entry", Name: "prod/db", Field: "username"},
+		},
+		{
+			desc:     "Normalized name",
+			ref:      "card:  Visa#security_code",
+			expected: Reference{Type: "card", Name: "visa", Field: "security_code"},
+		},
+		{
+			desc:     "Name with hash",
+			ref:      "file:c#/main.c#content",
+			expected: Reference{Type: "file", Name: "c#/main.c", Field: "content"},
+		},
+		{
+			desc:     "TOTP",
+			ref:      "totp:github",
+			expected: Reference{Type: "totp", Name: "github", Field: "code"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := ParseReference(tc.ref)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
+
+func TestParseReferenceErrors(t *testing.T) {
+	cases := []struct {
+		desc string
+		ref  string
+	}{
+		{desc: "Missing type", ref: "prod/db"},
+		{desc: "Invalid type", ref: "note:prod/db"},
+		{desc: "Missing name", ref: "entry:#password"},
+		{desc: "Invalid field", ref: "entry:prod/db#number"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			_, err := ParseReference(tc.ref)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	err := entry.Create(db, &pb.Entry{Name: "prod/db", Username: "admin", Password: "secret", Expires: "Never"})
+	assert.NoError(t, err)
+	err = card.Create(db, &pb.Card{Name: "visa", Number: "4111111111111111", SecurityCode: "123"})
+	assert.NoError(t, err)
+	err = file.Create(db, &pb.File{Name: "key.pem", Content: []byte("content")})
+	assert.NoError(t, err)
+	err = totp.Create(db, &pb.TOTP{Name: "github", Raw: "JBSWY3DPEHPK3PXP", Digits: 6})
+	assert.NoError(t, err)
+
+	cases := []struct {
+		ref      string
+		expected string
+	}{
+		{ref: "entry:prod/db", expected: "secret"},
+		{ref: "entry:prod/db#username", expected: "admin"},
+		{ref: "entry:prod/db#expires", expected: "Never"},
+		{ref: "card:visa", expected: "4111111111111111"},
+		{ref: "card:visa#security_code", expected: "123"},
+		{ref: "file:key.pem", expected: "content"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.ref, func(t *testing.T) {
+			ref, err := ParseReference(tc.ref)
+			assert.NoError(t, err)
+
+			got, err := ref.Resolve(db)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, string(got))
+		})
+	}
+
+	t.Run("TOTP", func(t *testing.T) {
+		got, err := Reference{Type: "totp", Name: "github", Field: "code"}.Resolve(db)
+		assert.NoError(t, err)
+		assert.Len(t, got, 6)
+	})
+
+	t.Run("Non existent", func(t *testing.T) {
+		_, err := Reference{Type: "entry", Name: "non-existent", Field: "password"}.Resolve(db)
+		assert.Error(t, err)
+	})
+}
+
+func TestCheck(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	err := entry.Create(db, &pb.Entry{Name: "prod/db", Expires: "Never"})
+	assert.NoError(t, err)
+
+	ref, err := NewReference("entry", "prod/db", "")
+	assert.NoError(t, err)
+	assert.NoError(t, ref.Check(db))
+
+	ref, err = NewReference("entry", "prod/bd", "username")
+	assert.NoError(t, err)
+	assert.EqualError(t, ref.Check(db), `entry: "prod/bd" does not exist. Did you mean "prod/db"?`)
+}
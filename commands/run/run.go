@@ -0,0 +1,238 @@
+package run
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/sig"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+const example = `
+* Inject an entry password into an environment variable
+kure run --env DB_PASS=entry:prod/db#password -- ./server
+
+* Inject multiple secrets, a TOTP code and a card number
+kure run -e USER=entry:github#username -e TOKEN=entry:github -e OTP=totp:github -- ./deploy.sh
+
+* Write a file content to a temporary file and pass its path
+kure run --file TLS_KEY=file:certs/server.key -- sh -c './server --key "$TLS_KEY"'`
+
+// exitTimeout is how long the command has to exit after being interrupted before it's killed.
+const exitTimeout = 5 * time.Second
+
+type runOptions struct {
+	env    []string
+	files  []string
+	tmpDir string
+}
+
+// NewCmd returns a new command.
+func NewCmd(db *bolt.DB) *cobra.Command {
+	opts := runOptions{}
+	cmd := &cobra.Command{
+		Use:   "run [-e NAME=reference] [-f NAME=reference] -- <command> [args]",
+		Short: "Run a command with secrets injected into its environment",
+		Long: `Run a command with secrets injected into its environment.
+
+References have the format <type>:<name>[#field]:
+• entry: password (default), username, url, notes, expires
+• card: number (default), type, security_code, expire_date, notes
+• totp: code (default), generated when the command starts
+• file: content (default)
+
+Values passed with --env are set as environment variables. Values passed with --file are written to temporary files only readable by the current user and the variable is set to the file path instead.
+
+Secret files are never written to disk: they are created in a memory-backed directory ($XDG_RUNTIME_DIR or /dev/shm). If none is available, a directory must be specified with --tmp-dir. The files are overwritten and removed once the command exits or kure is interrupted.`,
+		Example: example,
+		Args:    cobra.MinimumNArgs(1),
+		RunE:    runRun(db, &opts),
+		PostRun: func(cmd *cobra.Command, args []string) {
+			// Reset variables (session)
+			opts = runOptions{}
+		},
+	}
+
+	f := cmd.Flags()
+	// Stop parsing flags after the command name so its own flags are passed through
+	f.SetInterspersed(false)
+	f.StringArrayVarP(&opts.env, "env", "e", nil, "environment variable set to a secret (NAME=reference)")
+	f.StringArrayVarP(&opts.files, "file", "f", nil, "environment variable set to the path of a file containing a secret (NAME=reference)")
+	f.StringVar(&opts.tmpDir, "tmp-dir", "", "directory where secret files are created (default: a memory-backed directory)")
+
+	return cmd
+}
+
+func runRun(db *bolt.DB, opts *runOptions) cmdutil.RunEFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		if len(opts.env) == 0 && len(opts.files) == 0 {
+			return errors.New("no secrets specified, use --env or --file")
+		}
+
+		env, err := resolveVars(db, opts.env)
+		if err != nil {
+			return err
+		}
+		for name, value := range env {
+			if bytes.IndexByte(value, 0) != -1 {
+				return errors.Errorf("%s: environment variables can't contain null characters, use --file instead", name)
+			}
+		}
+
+		files, err := resolveVars(db, opts.files)
+		if err != nil {
+			return err
+		}
+
+		child := exec.Command(args[0], args[1:]...)
+		child.Stdin = cmd.InOrStdin()
+		child.Stdout = cmd.OutOrStdout()
+		child.Stderr = cmd.ErrOrStderr()
+
+		// Closed when the command exits
+		exited := make(chan struct{})
+		// Registered before the files erasure so the command is interrupted first
+		sig.Signal.AddCleanup(func() error {
+			// Let the command finish instead of exiting right after the cleanups
+			sig.Signal.KeepAlive()
+			if child.Process == nil {
+				return nil
+			}
+			if err := child.Process.Signal(os.Interrupt); err != nil {
+				return err
+			}
+
+			// Wait for the command to exit so the secret files are removed afterwards
+			select {
+			case <-exited:
+			case <-time.After(exitTimeout):
+				return child.Process.Kill()
+			}
+			return nil
+		})
+
+		if len(files) > 0 {
+			secretFiles, err := newTempFiles(opts.tmpDir)
+			if err != nil {
+				return err
+			}
+			sig.Signal.AddCleanup(secretFiles.erase)
+			defer secretFiles.erase()
+
+			for name, content := range files {
+				path, err := secretFiles.write(name, content)
+				if err != nil {
+					return err
+				}
+				env[name] = []byte(path)
+			}
+		}
+
+		child.Env = os.Environ()
+		for name, value := range env {
+			child.Env = append(child.Env, name+"="+string(value))
+		}
+
+		err = child.Run()
+		close(exited)
+		if err != nil {
+			return errors.Wrapf(err, "running %s", args[0])
+		}
+		return nil
+	}
+}
+
+// resolveVars parses the NAME=reference pairs and resolves the references.
+func resolveVars(db *bolt.DB, pairs []string) (map[string][]byte, error) {
+	vars := make(map[string][]byte, len(pairs))
+	for _, pair := range pairs {
+		name, ref, ok := strings.Cut(pair, "=")
+		if !ok || name == "" {
+			return nil, errors.Errorf("invalid variable %q, the format is NAME=reference", pair)
+		}
+		if strings.ContainsAny(name, "=\x00") {
+			return nil, errors.Errorf("invalid variable name %q", name)
+		}
+
+		reference, err := ParseReference(ref)
+		if err != nil {
+			return nil, err
+		}
+		value, err := reference.Resolve(db)
+		if err != nil {
+			return nil, err
+		}
+		vars[name] = value
+	}
+
+	return vars, nil
+}
+
+// tempFiles holds the secret files passed to the command.
+type tempFiles struct {
+	dir   string
+	paths []string
+}
+
+// newTempFiles creates a private directory inside dir, or inside a memory-backed directory if it's empty.
+func newTempFiles(dir string) (*tempFiles, error) {
+	if dir == "" {
+		dir = memoryDir()
+		if dir == "" {
+			return nil, errors.New("no memory-backed directory available for secret files, specify one with --tmp-dir")
+		}
+	}
+
+	dir, err := os.MkdirTemp(dir, "kure-run-")
+	if err != nil {
+		return nil, errors.Wrap(err, "creating secrets directory")
+	}
+	return &tempFiles{dir: dir}, nil
+}
+
+// write creates a file only readable by the current user with the content specified.
+func (t *tempFiles) write(name string, content []byte) (string, error) {
+	path := filepath.Join(t.dir, fmt.Sprintf("%d-%s", len(t.paths), filepath.Base(name)))
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		return "", errors.Wrap(err, "writing secret file")
+	}
+	t.paths = append(t.paths, path)
+	return path, nil
+}
+
+// erase overwrites and removes the files and their directory. It's safe to call it multiple times.
+func (t *tempFiles) erase() error {
+	for _, path := range t.paths {
+		if err := cmdutil.Erase(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	t.paths = nil
+
+	if err := os.RemoveAll(t.dir); err != nil {
+		return errors.Wrap(err, "removing secrets directory")
+	}
+	return nil
+}
+
+// memoryDir returns a directory backed by memory (tmpfs) or an empty string if there is none.
+func memoryDir() string {
+	for _, dir := range []string{os.Getenv("XDG_RUNTIME_DIR"), "/dev/shm"} {
+		if dir == "" {
+			continue
+		}
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+	}
+	return ""
+}
@@ -0,0 +1,109 @@
+package run
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/db/entry"
+	"github.com/GGP1/kure/db/file"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	err := entry.Create(db, &pb.Entry{Name: "prod/db", Username: "admin", Password: "secret", Expires: "Never"})
+	assert.NoError(t, err)
+	err = file.Create(db, &pb.File{Name: "key.pem", Content: []byte("private key")})
+	assert.NoError(t, err)
+
+	t.Run("Environment", func(t *testing.T) {
+		var out bytes.Buffer
+		cmd := NewCmd(db)
+		cmd.SetOut(&out)
+		cmd.SetArgs([]string{"-e", "DB_USER=entry:prod/db#username", "--env", "DB_PASS=entry:prod/db",
+			"sh", "-c", `printf "%s:%s" "$DB_USER" "$DB_PASS"`})
+
+		err := cmd.Execute()
+		assert.NoError(t, err)
+		assert.Equal(t, "admin:secret", out.String())
+	})
+
+	t.Run("File", func(t *testing.T) {
+		var out bytes.Buffer
+		dir := t.TempDir()
+		cmd := NewCmd(db)
+		cmd.SetOut(&out)
+		cmd.SetArgs([]string{"--tmp-dir", dir, "-f", "KEY=file:key.pem",
+			"sh", "-c", `printf "%s\n" "$KEY"; cat "$KEY"`})
+
+		err := cmd.Execute()
+		assert.NoError(t, err)
+
+		path, content, _ := strings.Cut(out.String(), "\n")
+		assert.Equal(t, "private key", content)
+		assert.True(t, strings.HasPrefix(path, dir))
+
+		_, err = os.Stat(path)
+		assert.ErrorIs(t, err, os.ErrNotExist, "The secret file wasn't removed")
+		entries, err := os.ReadDir(dir)
+		assert.NoError(t, err)
+		assert.Empty(t, entries, "The secrets directory wasn't removed")
+	})
+}
+
+func TestRunErrors(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	err := file.Create(db, &pb.File{Name: "binary", Content: []byte("a\x00b")})
+	assert.NoError(t, err)
+
+	cases := []struct {
+		desc string
+		args []string
+	}{
+		{
+			desc: "No secrets",
+			args: []string{"true"},
+		},
+		{
+			desc: "Invalid variable",
+			args: []string{"-e", "entry:prod/db", "true"},
+		},
+		{
+			desc: "Invalid reference",
+			args: []string{"-e", "PASS=prod/db", "true"},
+		},
+		{
+			desc: "Non existent record",
+			args: []string{"-e", "PASS=entry:non-existent", "true"},
+		},
+		{
+			desc: "Null character",
+			args: []string{"-e", "BIN=file:binary", "true"},
+		},
+		{
+			desc: "Exit status",
+			args: []string{"-f", "BIN=file:binary", "--tmp-dir", t.TempDir(), "false"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			cmd := NewCmd(db)
+			cmd.SetArgs(tc.args)
+
+			err := cmd.Execute()
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestPostRun(t *testing.T) {
+	NewCmd(nil).PostRun(nil, nil)
+}
@@ -0,0 +1,315 @@
+package session
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// interpreter executes the commands and scripts entered during a session.
+type interpreter struct {
+	root    *cobra.Command
+	timeout *timeout
+	// Scripts source code by alias
+	scripts map[string]string
+	// Variables defined in the session, they are kept until it's closed
+	vars   *scope
+	errOut io.Writer
+	// Exit status of the last command executed
+	status int
+}
+
+// scope contains the variables and the arguments of a script or the session.
+type scope struct {
+	parent *scope
+	vars   map[string]string
+	args   []string
+	// Whether the scope belongs to a script
+	script bool
+}
+
+func newInterpreter(root *cobra.Command, timeout *timeout, scripts map[string]string) *interpreter {
+	return &interpreter{
+		root:    root,
+		timeout: timeout,
+		scripts: scripts,
+		vars:    &scope{vars: make(map[string]string)},
+		errOut:  os.Stderr,
+	}
+}
+
+// lookup returns the value of a variable, positional arguments are taken from the innermost script.
+func (s *scope) lookup(name string) (string, bool) {
+	if isNumber(name) {
+		n, _ := strconv.Atoi(name)
+		if n < 1 || n > len(s.args) {
+			return "", false
+		}
+		return s.args[n-1], true
+	}
+
+	for sc := s; sc != nil; sc = sc.parent {
+		if v, ok := sc.vars[name]; ok {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// run executes the input in the session scope.
+func (i *interpreter) run(text string) error {
+	l, err := parseScript(text)
+	if err != nil {
+		return err
+	}
+	return i.runList(l, i.vars)
+}
+
+// runList executes the list items sequentially. Commands failures are printed and set the exit status,
+// other errors (like undefined variables or failed command substitutions) stop the execution and are returned.
+func (i *interpreter) runList(l *list, sc *scope) error {
+	for _, ao := range l.items {
+		if err := i.runAndOr(ao, sc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (i *interpreter) runAndOr(ao *andOr, sc *scope) error {
+	if err := i.runNode(ao.first, sc); err != nil {
+		return err
+	}
+
+	for _, item := range ao.rest {
+		if item.op == tokenAnd && i.status != 0 || item.op == tokenOr && i.status == 0 {
+			continue
+		}
+		if err := i.runNode(item.node, sc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (i *interpreter) runNode(n node, sc *scope) error {
+	switch n := n.(type) {
+	case *ifClause:
+		if err := i.runList(n.cond, sc); err != nil {
+			return err
+		}
+		if i.status == 0 {
+			return i.runList(n.then, sc)
+		}
+		if n.els != nil {
+			return i.runList(n.els, sc)
+		}
+		i.status = 0
+		return nil
+
+	case *pipeline:
+		return i.runPipeline(n, sc)
+
+	default:
+		return errors.Errorf("invalid node %T", n)
+	}
+}
+
+// runPipeline executes the commands passing the output of each one as the last argument of the next.
+func (i *interpreter) runPipeline(p *pipeline, sc *scope) error {
+	var extra []string
+	for idx, cmd := range p.commands {
+		if idx == len(p.commands)-1 {
+			return i.runCommand(cmd, sc, extra)
+		}
+
+		out, err := i.capture(func() error { return i.runCommand(cmd, sc, extra) })
+		if err != nil || i.status != 0 {
+			return err
+		}
+		extra = []string{out}
+	}
+	return nil
+}
+
+func (i *interpreter) runCommand(cmd *simpleCommand, sc *scope, extra []string) error {
+	args, err := i.expandWords(cmd.words, sc)
+	if err != nil {
+		return err
+	}
+
+	if cmd.assign != "" {
+		sc.vars[cmd.assign] = args[0]
+		i.status = 0
+		return nil
+	}
+
+	args = append(args, extra...)
+	if args[0] == "kure" {
+		args = args[1:]
+	}
+
+	if len(args) > 0 {
+		if args[0] == "params" {
+			return i.bindParams(args[1:], sc)
+		}
+
+		if script, ok := i.scripts[args[0]]; ok {
+			return i.runScript(args[0], script, args[1:], sc)
+		}
+	}
+
+	i.status = 0
+	if ran := runSessionCommand(args, i.timeout); ran {
+		return nil
+	}
+
+	i.root.SetArgs(args)
+	subCmd, _, _ := i.root.Find(args)
+	if subCmd.Name() == "session" {
+		return nil
+	}
+
+	if err := i.root.Execute(); err != nil {
+		if subCmd.PostRun != nil {
+			// Force PostRun to reset options variables (as it isn't executed on failure)
+			subCmd.PostRun(nil, nil)
+		}
+		fmt.Fprintln(i.errOut, "error:", err)
+		i.status = 1
+		return nil
+	}
+
+	cleanup(subCmd)
+	return nil
+}
+
+// runScript executes a script in a new scope, its variables are discarded when it finishes.
+func (i *interpreter) runScript(alias, script string, args []string, sc *scope) error {
+	if sc.script {
+		return errors.Errorf("script %q: scripts can't run other scripts", alias)
+	}
+
+	l, err := parseScript(script)
+	if err != nil {
+		return errors.Wrapf(err, "script %q", alias)
+	}
+
+	scriptScope := &scope{
+		parent: sc,
+		vars:   make(map[string]string),
+		args:   args,
+		script: true,
+	}
+	i.status = 0
+	return errors.Wrapf(i.runList(l, scriptScope), "script %q", alias)
+}
+
+// bindParams assigns the script arguments to the names specified, in order. Names may have a default
+// value (name=value) that's used when the argument isn't passed.
+func (i *interpreter) bindParams(params []string, sc *scope) error {
+	if !sc.script {
+		return errors.New("params can only be used in scripts")
+	}
+
+	for idx, param := range params {
+		name, def, hasDefault := strings.Cut(param, "=")
+		if !identifier.MatchString(name) {
+			return errors.Errorf("invalid parameter name %q", name)
+		}
+
+		switch {
+		case idx < len(sc.args):
+			sc.vars[name] = sc.args[idx]
+		case hasDefault:
+			sc.vars[name] = def
+		default:
+			return errors.Errorf("missing argument %q", name)
+		}
+	}
+
+	i.status = 0
+	return nil
+}
+
+func (i *interpreter) expandWords(words []word, sc *scope) ([]string, error) {
+	args := make([]string, 0, len(words))
+	for _, w := range words {
+		arg, err := i.expand(w, sc)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	return args, nil
+}
+
+// expand returns the word with its variables and command substitutions replaced.
+func (i *interpreter) expand(w word, sc *scope) (string, error) {
+	var sb strings.Builder
+	for _, p := range w.parts {
+		switch p.kind {
+		case literalPart:
+			sb.WriteString(p.text)
+
+		case variablePart:
+			if p.text == "?" {
+				sb.WriteString(strconv.Itoa(i.status))
+				continue
+			}
+			v, ok := sc.lookup(p.text)
+			if !ok {
+				if isNumber(p.text) {
+					return "", errors.Errorf("argument $%s wasn't provided", p.text)
+				}
+				return "", errors.Errorf("undefined variable %q", p.text)
+			}
+			sb.WriteString(v)
+
+		case commandPart:
+			out, err := i.capture(func() error { return i.runList(p.command, sc) })
+			if err != nil {
+				return "", err
+			}
+			if i.status != 0 {
+				// Never run a command with the output of a failed one
+				return "", errors.New("command substitution failed")
+			}
+			sb.WriteString(out)
+		}
+	}
+	return sb.String(), nil
+}
+
+// capture executes fn and returns what it wrote to the standard output, without the trailing newlines.
+func (i *interpreter) capture(fn func() error) (string, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return "", errors.Wrap(err, "capturing output")
+	}
+	defer r.Close()
+
+	stdout, sessionOut, rootOut := os.Stdout, cmdParams.out, i.root.OutOrStdout()
+	os.Stdout, cmdParams.out = w, w
+	i.root.SetOut(w)
+
+	output := make(chan []byte)
+	go func() {
+		out, _ := io.ReadAll(r)
+		output <- out
+	}()
+
+	err = fn()
+
+	os.Stdout, cmdParams.out = stdout, sessionOut
+	i.root.SetOut(rootOut)
+	w.Close()
+	out := <-output
+
+	return strings.TrimRight(string(out), "\r\n"), err
+}
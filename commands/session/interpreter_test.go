@@ -0,0 +1,112 @@
+package session
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterpreter(t *testing.T) {
+	cases := []struct {
+		desc     string
+		text     string
+		expected string
+	}{
+		{desc: "Sequence", text: "echo a; echo b", expected: "a\nb\n"},
+		{desc: "And", text: "echo a && fail && echo b", expected: "a\n"},
+		{desc: "Or", text: "fail || echo a || echo b", expected: "a\n"},
+		{desc: "Quotes", text: `echo "a  b" c`, expected: "a  b,c\n"},
+		{desc: "Variables", text: `x=1; y="$x 2"; echo ${y}3 \$x`, expected: "1 23,$x\n"},
+		{desc: "Exit status", text: "fail; echo $?; echo $?", expected: "1\n0\n"},
+		{desc: "Pipeline", text: "echo a | echo b | echo c", expected: "c,b,a\n"},
+		{desc: "Failed pipeline", text: "fail | echo a || echo b", expected: "b\n"},
+		{desc: "Command substitution", text: `x=$(echo a; echo b); echo "[$x]" $(echo c)`, expected: "[a\nb],c\n"},
+		{desc: "If", text: "if echo a; then echo b; else echo c; fi", expected: "a\nb\n"},
+		{desc: "Else", text: "if fail; then echo b; else echo c; fi", expected: "c\n"},
+		{desc: "If without else", text: "if fail; then echo b; fi && echo c", expected: "c\n"},
+		{desc: "Script", text: "greet kure", expected: "hello,kure\n"},
+		{desc: "Script default", text: "greet", expected: "hello,world\n"},
+		{desc: "Script positional", text: "login github", expected: "copy,-u,github\ncopy,github\n"},
+		{desc: "Script scope", text: "greet kure; echo $name || echo undefined", expected: "hello,kure\n"},
+		{desc: "Kure prefix", text: "kure echo a", expected: "a\n"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			i, out := newTestInterpreter()
+			_ = i.run(tc.text)
+			assert.Equal(t, tc.expected, out.String())
+		})
+	}
+}
+
+func TestInterpreterErrors(t *testing.T) {
+	cases := []struct {
+		desc string
+		text string
+	}{
+		{desc: "Syntax error", text: "echo a &&"},
+		{desc: "Undefined variable", text: "echo $name"},
+		{desc: "Missing argument", text: "login"},
+		{desc: "Missing parameter", text: "required"},
+		{desc: "Params outside script", text: "params name"},
+		{desc: "Script running a script", text: "nested"},
+		{desc: "Failed command substitution", text: "echo $(fail)"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			i, out := newTestInterpreter()
+			err := i.run(tc.text + "; echo unreachable")
+			assert.Error(t, err)
+			assert.NotContains(t, out.String(), "unreachable")
+		})
+	}
+}
+
+func TestSessionVariables(t *testing.T) {
+	i, out := newTestInterpreter()
+	assert.NoError(t, i.run("name=github"))
+	assert.NoError(t, i.run("echo $name"))
+	assert.Equal(t, "github\n", out.String())
+}
+
+// newTestInterpreter returns an interpreter with a root command containing "echo", which prints its
+// arguments joined by commas, and "fail", which always returns an error.
+func newTestInterpreter() (*interpreter, *bytes.Buffer) {
+	root := &cobra.Command{Use: "kure", SilenceErrors: true, SilenceUsage: true}
+	root.AddCommand(
+		&cobra.Command{
+			Use:                "echo",
+			DisableFlagParsing: true,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				fmt.Fprintln(cmd.OutOrStdout(), strings.Join(args, ","))
+				return nil
+			},
+		},
+		&cobra.Command{
+			Use: "fail",
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return errors.New("failed")
+			},
+		},
+	)
+
+	var out bytes.Buffer
+	root.SetOut(&out)
+
+	scripts := map[string]string{
+		"greet":    "params name=world; echo hello $name",
+		"login":    "echo copy -u $1 && echo copy $1",
+		"required": "params name; echo $name",
+		"nested":   "greet",
+	}
+	i := newInterpreter(root, &timeout{}, scripts)
+	i.errOut = &bytes.Buffer{}
+	return i, &out
+}
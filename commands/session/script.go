@@ -0,0 +1,563 @@
+package session
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Session scripts grammar:
+//
+//	list     = andOr { ";" andOr } [ ";" ]
+//	andOr    = element { ( "&&" | "||" ) element }
+//	element  = ifClause | pipeline
+//	ifClause = "if" list "then" list [ "else" list ] "fi"
+//	pipeline = command { "|" command }
+//	command  = name "=" word | word { word }
+//
+// Newlines are equivalent to semicolons. Words are separated by spaces unless they are enclosed by
+// double quotes and may contain variables ($name, ${name}, $1, $?) and command substitutions ($(list)).
+// A dollar sign that doesn't start any of them is kept, "\$" is always a literal one.
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenAnd
+	tokenOr
+	tokenPipe
+	tokenSemicolon
+	tokenEOF
+)
+
+var tokenNames = map[tokenKind]string{
+	tokenAnd:       "&&",
+	tokenOr:        "||",
+	tokenPipe:      "|",
+	tokenSemicolon: ";",
+	tokenEOF:       "end of input",
+}
+
+var (
+	identifier = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+	// Words that are keywords when they are the first word of a command
+	keywords = map[string]struct{}{"if": {}, "then": {}, "else": {}, "fi": {}}
+)
+
+type token struct {
+	kind tokenKind
+	word word
+}
+
+func (t token) String() string {
+	if t.kind == tokenWord {
+		return t.word.String()
+	}
+	return tokenNames[t.kind]
+}
+
+type partKind int
+
+const (
+	literalPart partKind = iota
+	variablePart
+	commandPart
+)
+
+// part is a piece of a word: a literal text, a variable or a command whose output is substituted.
+type part struct {
+	kind partKind
+	// Literal text or variable name
+	text    string
+	command *list
+	quoted  bool
+}
+
+// word is a command argument, the concatenation of its parts. Expanding a word always produces a single
+// argument, even if it contains spaces.
+type word struct {
+	parts []part
+}
+
+// keyword returns the text of the word if it's a keyword that wasn't quoted.
+func (w word) keyword() string {
+	if len(w.parts) != 1 || w.parts[0].kind != literalPart || w.parts[0].quoted {
+		return ""
+	}
+	if _, ok := keywords[w.parts[0].text]; !ok {
+		return ""
+	}
+	return w.parts[0].text
+}
+
+// String returns the word as it was written, without quotes.
+func (w word) String() string {
+	var sb strings.Builder
+	for _, p := range w.parts {
+		switch p.kind {
+		case literalPart:
+			sb.WriteString(p.text)
+		case variablePart:
+			sb.WriteString("${" + p.text + "}")
+		case commandPart:
+			sb.WriteString("$(...)")
+		}
+	}
+	return sb.String()
+}
+
+// list is a sequence of and-or lists separated by semicolons.
+type list struct {
+	items []*andOr
+}
+
+// andOr is a sequence of elements where each one is executed depending on the previous one exit status.
+type andOr struct {
+	first node
+	rest  []andOrItem
+}
+
+type andOrItem struct {
+	// tokenAnd or tokenOr
+	op   tokenKind
+	node node
+}
+
+// node is either a pipeline or an if clause.
+type node interface{}
+
+// pipeline is a sequence of commands where the output of each one is passed as the last argument of the next.
+type pipeline struct {
+	commands []*simpleCommand
+}
+
+// ifClause executes then if the exit status of the condition is zero, otherwise it executes els.
+type ifClause struct {
+	cond, then, els *list
+}
+
+// simpleCommand is a command or, if assign isn't empty, an assignment of words[0] to a variable.
+type simpleCommand struct {
+	assign string
+	words  []word
+}
+
+// parseScript parses the text received.
+func parseScript(text string) (*list, error) {
+	tokens, err := lex(text)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	l, err := p.parseList()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, errors.Errorf("syntax error: unexpected %q", t)
+	}
+
+	return l, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// peekKeyword returns the keyword at the current position, if any.
+func (p *parser) peekKeyword() string {
+	if t := p.peek(); t.kind == tokenWord {
+		return t.word.keyword()
+	}
+	return ""
+}
+
+// parseList parses and-or lists until the end of the input or a keyword that ends a clause.
+func (p *parser) parseList() (*list, error) {
+	l := &list{}
+	for {
+		for p.peek().kind == tokenSemicolon {
+			p.next()
+		}
+
+		switch p.peekKeyword() {
+		case "then", "else", "fi":
+			return l, nil
+		}
+		if p.peek().kind == tokenEOF {
+			return l, nil
+		}
+
+		ao, err := p.parseAndOr()
+		if err != nil {
+			return nil, err
+		}
+		l.items = append(l.items, ao)
+
+		if t := p.peek(); t.kind != tokenSemicolon && t.kind != tokenEOF {
+			return nil, errors.Errorf("syntax error: unexpected %q", t)
+		}
+	}
+}
+
+func (p *parser) parseAndOr() (*andOr, error) {
+	first, err := p.parseElement()
+	if err != nil {
+		return nil, err
+	}
+
+	ao := &andOr{first: first}
+	for {
+		op := p.peek().kind
+		if op != tokenAnd && op != tokenOr {
+			return ao, nil
+		}
+		p.next()
+
+		n, err := p.parseElement()
+		if err != nil {
+			return nil, err
+		}
+		ao.rest = append(ao.rest, andOrItem{op: op, node: n})
+	}
+}
+
+func (p *parser) parseElement() (node, error) {
+	if p.peekKeyword() == "if" {
+		return p.parseIf()
+	}
+	return p.parsePipeline()
+}
+
+func (p *parser) parseIf() (*ifClause, error) {
+	cond, err := p.parseClause("if", "then")
+	if err != nil {
+		return nil, err
+	}
+
+	then, err := p.parseClause("then", "else", "fi")
+	if err != nil {
+		return nil, err
+	}
+
+	clause := &ifClause{cond: cond, then: then}
+	if p.peekKeyword() == "else" {
+		els, err := p.parseClause("else", "fi")
+		if err != nil {
+			return nil, err
+		}
+		clause.els = els
+	}
+
+	if p.peekKeyword() != "fi" {
+		return nil, errors.New("syntax error: missing \"fi\"")
+	}
+	p.next()
+
+	return clause, nil
+}
+
+// parseClause consumes the keyword and parses a non-empty list that must be followed by one of the
+// end keywords, which is not consumed.
+func (p *parser) parseClause(keyword string, end ...string) (*list, error) {
+	p.next()
+	l, err := p.parseList()
+	if err != nil {
+		return nil, err
+	}
+	if len(l.items) == 0 {
+		return nil, errors.Errorf("syntax error: empty %q clause", keyword)
+	}
+
+	kw := p.peekKeyword()
+	for _, e := range end {
+		if kw == e {
+			return l, nil
+		}
+	}
+	return nil, errors.Errorf("syntax error: missing %q", end[0])
+}
+
+func (p *parser) parsePipeline() (*pipeline, error) {
+	pl := &pipeline{}
+	for {
+		cmd, err := p.parseCommand()
+		if err != nil {
+			return nil, err
+		}
+		pl.commands = append(pl.commands, cmd)
+
+		if p.peek().kind != tokenPipe {
+			break
+		}
+		p.next()
+	}
+
+	if len(pl.commands) > 1 {
+		for _, cmd := range pl.commands {
+			if cmd.assign != "" {
+				return nil, errors.New("syntax error: assignments can't be part of a pipeline")
+			}
+		}
+	}
+
+	return pl, nil
+}
+
+func (p *parser) parseCommand() (*simpleCommand, error) {
+	t := p.peek()
+	if t.kind != tokenWord {
+		return nil, errors.Errorf("syntax error: unexpected %q", t)
+	}
+	if kw := t.word.keyword(); kw != "" {
+		return nil, errors.Errorf("syntax error: unexpected %q", kw)
+	}
+
+	cmd := &simpleCommand{}
+	for p.peek().kind == tokenWord {
+		cmd.words = append(cmd.words, p.next().word)
+	}
+
+	if name, value, ok := splitAssignment(cmd.words[0]); ok {
+		if len(cmd.words) > 1 {
+			return nil, errors.Errorf("syntax error: unexpected %q after the assignment of %q", cmd.words[1], name)
+		}
+		cmd.assign = name
+		cmd.words[0] = value
+	}
+
+	return cmd, nil
+}
+
+// splitAssignment returns the variable name and the value if the word has the form name=value.
+func splitAssignment(w word) (string, word, bool) {
+	if len(w.parts) == 0 || w.parts[0].kind != literalPart || w.parts[0].quoted {
+		return "", word{}, false
+	}
+
+	name, value, ok := strings.Cut(w.parts[0].text, "=")
+	if !ok || !identifier.MatchString(name) {
+		return "", word{}, false
+	}
+
+	parts := make([]part, 0, len(w.parts))
+	if value != "" {
+		parts = append(parts, part{kind: literalPart, text: value})
+	}
+	parts = append(parts, w.parts[1:]...)
+	return name, word{parts: parts}, true
+}
+
+// lex splits the text into tokens.
+func lex(text string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(text); {
+		switch c := text[i]; {
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+
+		case c == ';' || c == '\n':
+			tokens = append(tokens, token{kind: tokenSemicolon})
+			i++
+
+		case strings.HasPrefix(text[i:], "&&"):
+			tokens = append(tokens, token{kind: tokenAnd})
+			i += 2
+
+		case strings.HasPrefix(text[i:], "||"):
+			tokens = append(tokens, token{kind: tokenOr})
+			i += 2
+
+		case c == '|':
+			tokens = append(tokens, token{kind: tokenPipe})
+			i++
+
+		default:
+			w, n, err := lexWord(text[i:])
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenWord, word: w})
+			i += n
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF}), nil
+}
+
+// lexWord returns the word at the start of the text and its length.
+func lexWord(text string) (word, int, error) {
+	var (
+		w       word
+		literal strings.Builder
+		quoted  bool
+	)
+	// flush appends the literal text as a part, empty quoted strings are kept
+	flush := func(keepEmpty bool) {
+		if literal.Len() > 0 || keepEmpty {
+			w.parts = append(w.parts, part{kind: literalPart, text: literal.String(), quoted: quoted})
+			literal.Reset()
+		}
+	}
+
+	i := 0
+	for i < len(text) {
+		c := text[i]
+		if !quoted && (c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == ';' || c == '|' ||
+			strings.HasPrefix(text[i:], "&&")) {
+			break
+		}
+
+		switch {
+		case c == '"':
+			flush(quoted)
+			quoted = !quoted
+			i++
+
+		case c == '\\' && i+1 < len(text) && text[i+1] == '$':
+			literal.WriteByte('$')
+			i += 2
+
+		case c == '$':
+			p, n, err := lexDollar(text[i:])
+			if err != nil {
+				return word{}, 0, err
+			}
+			if n == 0 {
+				literal.WriteByte(c)
+				i++
+				continue
+			}
+			flush(false)
+			p.quoted = quoted
+			w.parts = append(w.parts, p)
+			i += n
+
+		default:
+			literal.WriteByte(c)
+			i++
+		}
+	}
+
+	if quoted {
+		return word{}, 0, errors.New("syntax error: unterminated quote")
+	}
+	flush(false)
+
+	return w, i, nil
+}
+
+// lexDollar returns the variable or command substitution at the start of the text and its length.
+// The length is zero if the dollar sign is a literal one.
+func lexDollar(text string) (part, int, error) {
+	if len(text) < 2 {
+		return part{}, 0, nil
+	}
+
+	switch c := text[1]; {
+	case c == '(':
+		end, err := matchParen(text)
+		if err != nil {
+			return part{}, 0, err
+		}
+		l, err := parseScript(text[2:end])
+		if err != nil {
+			return part{}, 0, err
+		}
+		if len(l.items) == 0 {
+			return part{}, 0, errors.New("syntax error: empty command substitution")
+		}
+		return part{kind: commandPart, command: l}, end + 1, nil
+
+	case c == '{':
+		end := strings.IndexByte(text, '}')
+		if end == -1 {
+			return part{}, 0, errors.New("syntax error: missing \"}\"")
+		}
+		name := text[2:end]
+		if !identifier.MatchString(name) && !isNumber(name) && name != "?" {
+			return part{}, 0, errors.Errorf("syntax error: invalid variable name %q", name)
+		}
+		return part{kind: variablePart, text: name}, end + 1, nil
+
+	case c == '?':
+		return part{kind: variablePart, text: "?"}, 2, nil
+
+	case isDigit(c):
+		n := 1
+		for n < len(text) && isDigit(text[n]) {
+			n++
+		}
+		return part{kind: variablePart, text: text[1:n]}, n, nil
+
+	case c == '_' || isLetter(c):
+		n := 1
+		for n < len(text) && (text[n] == '_' || isLetter(text[n]) || isDigit(text[n])) {
+			n++
+		}
+		return part{kind: variablePart, text: text[1:n]}, n, nil
+
+	default:
+		return part{}, 0, nil
+	}
+}
+
+// matchParen returns the index of the parenthesis closing the command substitution at the start of the text.
+func matchParen(text string) (int, error) {
+	depth := 0
+	quoted := false
+	for i := 1; i < len(text); i++ {
+		switch text[i] {
+		case '"':
+			quoted = !quoted
+		case '(':
+			if !quoted {
+				depth++
+			}
+		case ')':
+			if quoted {
+				continue
+			}
+			depth--
+			if depth == 0 {
+				return i, nil
+			}
+		}
+	}
+	return 0, errors.New("syntax error: missing \")\"")
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNumber(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isDigit(s[i]) {
+			return false
+		}
+	}
+	return true
+}
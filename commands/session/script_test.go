@@ -0,0 +1,112 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLex(t *testing.T) {
+	cases := []struct {
+		desc     string
+		text     string
+		expected []string
+	}{
+		{
+			desc:     "Words",
+			text:     "copy  -u github",
+			expected: []string{"copy", "-u", "github", "end of input"},
+		},
+		{
+			desc:     "Operators",
+			text:     "ls && copy x || 2fa;ls -q|copy\nstats",
+			expected: []string{"ls", "&&", "copy", "x", "||", "2fa", ";", "ls", "-q", "|", "copy", ";", "stats", "end of input"},
+		},
+		{
+			desc:     "Quotes",
+			text:     `file touch "file with spaces" "a;b&&c|d"`,
+			expected: []string{"file", "touch", "file with spaces", "a;b&&c|d", "end of input"},
+		},
+		{
+			desc:     "Variables",
+			text:     `copy $name ${name}s $1 $? \$name $ x$`,
+			expected: []string{"copy", "${name}", "${name}s", "${1}", "${?}", "$name", "$", "x$", "end of input"},
+		},
+		{
+			desc:     "Command substitution",
+			text:     `copy $(ls -q "a b")`,
+			expected: []string{"copy", "$(...)", "end of input"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			tokens, err := lex(tc.text)
+			assert.NoError(t, err)
+
+			got := make([]string, 0, len(tokens))
+			for _, token := range tokens {
+				got = append(got, token.String())
+			}
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
+
+func TestParseScript(t *testing.T) {
+	l, err := parseScript(`name=github; if ls $name; then copy -u $name && copy $name; else echo "not found" || x=1; fi | cat`)
+	assert.Error(t, err, "An if clause can't be part of a pipeline")
+
+	l, err = parseScript(`name="git hub"; if ls $name; then copy -u $name && copy $name; else echo "not found"; fi`)
+	assert.NoError(t, err)
+	assert.Len(t, l.items, 2)
+
+	assign := l.items[0].first.(*pipeline).commands[0]
+	assert.Equal(t, "name", assign.assign)
+	assert.Equal(t, "git hub", assign.words[0].String())
+
+	clause, ok := l.items[1].first.(*ifClause)
+	assert.True(t, ok)
+	assert.Len(t, clause.cond.items, 1)
+	assert.Len(t, clause.then.items, 1)
+	assert.Len(t, clause.then.items[0].rest, 1)
+	assert.Equal(t, tokenAnd, clause.then.items[0].rest[0].op)
+	assert.Len(t, clause.els.items, 1)
+
+	l, err = parseScript("ls -q | copy")
+	assert.NoError(t, err)
+	assert.Len(t, l.items[0].first.(*pipeline).commands, 2)
+
+	l, err = parseScript(`echo "if" fi`)
+	assert.NoError(t, err, "Keywords are words when they are quoted or not the first word")
+	assert.Len(t, l.items[0].first.(*pipeline).commands[0].words, 3)
+}
+
+func TestParseScriptErrors(t *testing.T) {
+	cases := []struct {
+		desc string
+		text string
+	}{
+		{desc: "Unterminated quote", text: `copy "github`},
+		{desc: "Missing closing parenthesis", text: "copy $(ls -q"},
+		{desc: "Missing closing brace", text: "copy ${name"},
+		{desc: "Invalid variable name", text: "copy ${na-me}"},
+		{desc: "Empty command substitution", text: "copy $()"},
+		{desc: "Leading operator", text: "&& ls"},
+		{desc: "Trailing operator", text: "ls ||"},
+		{desc: "Empty pipeline", text: "ls | | copy"},
+		{desc: "Missing fi", text: "if ls; then copy"},
+		{desc: "Missing then", text: "if ls; copy; fi"},
+		{desc: "Empty then", text: "if ls; then fi"},
+		{desc: "Unexpected keyword", text: "fi"},
+		{desc: "Assignment with arguments", text: "name=github copy"},
+		{desc: "Assignment in pipeline", text: "ls | name=github"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			_, err := parseScript(tc.text)
+			assert.Error(t, err)
+		})
+	}
+}
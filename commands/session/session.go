@@ -10,6 +10,7 @@ import (
 	cmdutil "github.com/GGP1/kure/commands"
 	"github.com/GGP1/kure/config"
 	"github.com/GGP1/kure/sig"
+	"github.com/GGP1/kure/vault"
 	"github.com/pkg/errors"
 
 	"github.com/chzyer/readline"
@@ -36,10 +37,22 @@ func NewCmd(r io.Reader) *cobra.Command {
 		Short: "Run a session",
 		Long: `Sessions let you do multiple operations by providing the master password once.
 		
-They support running scripts using the logical AND (&&) operator and executing pre-defined ones from the configuration file by using their aliases.
+They support a small scripting language and executing pre-defined scripts from the configuration file by using their aliases:
+• cmd1; cmd2 - run the commands sequentially (also separated by new lines in scripts).
+• cmd1 && cmd2 - run the second command only if the first one succeeded.
+• cmd1 || cmd2 - run the second command only if the first one failed.
+• cmd1 | cmd2 - pass the output of the first command as the last argument of the second one.
+• name=value - define a variable, used with $name or ${name}. $? is the exit status of the last command.
+• $(cmd) - replace the expression with the command output.
+• if cmd; then cmds; else cmds; fi - run commands conditionally.
+• "text" - group words into a single argument, \$ writes a literal dollar sign.
+
+Scripts receive their arguments as $1, $2, ... or by name using "params name [name=default]...". Their variables are discarded when they finish.
 
 During a session, the master password is encrypted and stored inside a protected buffer.
 
+The prompt is preceded by the name of the vault in use, unless it's the default one.
+
 Session commands:
 • block - block execution (to be manually unlocked).
 • exit|quit|Ctrl+C - close the session.
@@ -75,8 +88,13 @@ func runSession(r io.Reader, opts *sessionOptions) cmdutil.RunEFunc {
 			timer:    time.NewTimer(opts.timeout),
 		}
 
+		prompt := opts.prefix + " "
+		if name := vault.Active(); name != vault.DefaultName {
+			prompt = fmt.Sprintf("(%s) %s", name, prompt)
+		}
+
 		rl, err := readline.NewEx(&readline.Config{
-			Prompt: opts.prefix + " ",
+			Prompt: prompt,
 			Stdin:  io.NopCloser(r),
 		})
 		if err != nil {
@@ -99,57 +117,23 @@ func runSession(r io.Reader, opts *sessionOptions) cmdutil.RunEFunc {
 }
 
 func startSession(cmd *cobra.Command, rl *readline.Instance, timeout *timeout) {
-	root := cmd.Root()
 	// The configuration is populated on start and changes inside the session won't have effect until restart.
 	scripts := config.GetStringMapString("session.scripts")
+	interpreter := newInterpreter(cmd.Root(), timeout, scripts)
 
 	for {
 		// Force a garbage collection so the memory used by argon2 isn't reserved
 		// for us by the system while idle
 		runtime.GC()
 
-		commands, err := scanInput(rl, timeout, scripts)
+		text, err := scanInput(rl, timeout)
 		if err != nil {
 			fmt.Fprintln(os.Stderr, "error:", err)
 			continue
 		}
 
-		if err := execute(root, commands, timeout); err != nil {
+		if err := interpreter.run(text); err != nil {
 			fmt.Fprintln(os.Stderr, "error:", err)
 		}
 	}
 }
-
-func execute(root *cobra.Command, commands [][]string, timeout *timeout) error {
-	for _, args := range commands {
-		args = removeEmptyItems(args)
-		if len(args) == 0 || args[0] == "" {
-			continue
-		}
-
-		if args[0] == "kure" {
-			args = args[1:]
-		}
-
-		if ran := runSessionCommand(args, timeout); ran {
-			continue
-		}
-
-		root.SetArgs(args)
-		subCmd, _, _ := root.Find(args)
-		if subCmd.Name() == "session" {
-			continue
-		}
-
-		if err := root.Execute(); err != nil {
-			if subCmd.PostRun != nil {
-				// Force PostRun to reset options variables (as it isn't executed on failure)
-				subCmd.PostRun(nil, nil)
-			}
-			return err
-		}
-
-		cleanup(subCmd)
-	}
-	return nil
-}
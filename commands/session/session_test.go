@@ -4,46 +4,47 @@ import (
 	"bytes"
 	"testing"
 
-	"github.com/GGP1/kure/config"
-
 	"github.com/stretchr/testify/assert"
 )
 
-func TestExecute(t *testing.T) {
+func TestRun(t *testing.T) {
 	scripts := map[string]string{
-		"login": "copy -u $1 && copy $1",
+		"show": "pwd; timeout",
 	}
-	config.Set("session.scripts", scripts)
 
 	cases := []struct {
 		desc string
-		args [][]string
+		text string
 	}{
 		{
 			desc: "Root command",
-			args: [][]string{{"kure", "stats"}},
+			text: "kure stats",
 		},
 		{
 			desc: "Session command",
-			args: [][]string{{"pwd"}},
+			text: "pwd",
 		},
 		{
 			desc: "Help command",
-			args: [][]string{{"kure"}},
+			text: "kure",
 		},
 		{
 			desc: "No command",
-			args: [][]string{},
+			text: "",
+		},
+		{
+			desc: "Script",
+			text: "show && timeout",
 		},
 	}
 
 	cmd := NewCmd(&bytes.Buffer{})
 	cmd.RunE = nil
-	root := cmd.Root()
+	i := newInterpreter(cmd.Root(), &timeout{}, scripts)
 
 	for _, tc := range cases {
 		t.Run(tc.desc, func(t *testing.T) {
-			err := execute(root, tc.args, &timeout{})
+			err := i.run(tc.text)
 			assert.NoError(t, err, "Failed executing command")
 		})
 	}
@@ -0,0 +1,250 @@
+package share
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/GGP1/kure/archive"
+	"github.com/GGP1/kure/bundle"
+	cmdutil "github.com/GGP1/kure/commands"
+	dbutil "github.com/GGP1/kure/db"
+	"github.com/GGP1/kure/db/file"
+	"github.com/GGP1/kure/pb"
+	"github.com/GGP1/kure/terminal"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+	"google.golang.org/protobuf/proto"
+)
+
+const example = `
+* Share an entry and its TOTP protected with a passphrase
+kure share github
+
+* Share a card addressed to a public key
+kure share card:visa --recipient HXhVh1Gy5nWNqg4eBIyMJvFTLMrZDumqpZMLHMEEmAw=
+
+* Share a file in a bundle that expires in a day
+kure share file:contract.pdf --output contract.kureb --expires 24h
+
+* Display the bundle as a QR code
+kure share entry:wifi --qr`
+
+type shareOptions struct {
+	output    string
+	recipient string
+	expires   time.Duration
+	qr        bool
+}
+
+// NewCmd returns a new command.
+func NewCmd(db *bolt.DB) *cobra.Command {
+	opts := shareOptions{}
+	cmd := &cobra.Command{
+		Use:   "share <name>...",
+		Short: "Share records with another user",
+		Long: `Create an encrypted bundle with one or more records to share them with another user, who imports it with "kure receive".
+
+Names may be prefixed with the record type (<type>:<name>), otherwise every record with that name is included, for example an entry and its TOTP. Types: bank, card, entry, file, identity, note, ssh and totp. The previous password of entries with a pending rotation and their password profile are not shared.
+
+The bundle is encrypted with a passphrase, asked for when sharing, or sealed with the recipient's public key (obtained with "kure team key"), so only the recipient can open it.
+
+It's written as an armored text block unless an output file is specified or the QR code flag is used. The expiration time is encrypted along with the records, expired bundles can't be received.`,
+		Example: example,
+		Args:    cobra.MinimumNArgs(1),
+		RunE:    runShare(db, &opts),
+		PostRun: func(cmd *cobra.Command, args []string) {
+			// Reset variables (session)
+			opts = shareOptions{}
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVarP(&opts.output, "output", "o", "", "write the bundle to a file")
+	f.StringVarP(&opts.recipient, "recipient", "r", "", "seal the bundle with the recipient's public key")
+	f.DurationVarP(&opts.expires, "expires", "e", 0, "time until the bundle expires (0 to never expire)")
+	f.BoolVarP(&opts.qr, "qr", "q", false, "display the bundle as a QR code")
+
+	cmd.MarkFlagsMutuallyExclusive("output", "qr")
+
+	return cmd
+}
+
+func runShare(db *bolt.DB, opts *shareOptions) cmdutil.RunEFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		if opts.expires < 0 {
+			return errors.New("the expiration time must not be negative")
+		}
+
+		records, err := getRecords(db, args)
+		if err != nil {
+			return err
+		}
+
+		b := &bundle.Bundle{Archive: newArchive(records)}
+		if opts.expires > 0 {
+			b.Expires = time.Now().Add(opts.expires)
+		}
+
+		data, err := encode(b, opts.recipient)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case opts.output != "":
+			f, err := os.OpenFile(opts.output, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+			if err != nil {
+				return errors.Wrap(err, "creating the file")
+			}
+			if _, err := f.Write(data); err != nil {
+				f.Close()
+				return errors.Wrap(err, "writing bundle")
+			}
+			if err := f.Close(); err != nil {
+				return errors.Wrap(err, "closing file")
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Shared %d records in %s\n", len(records), opts.output)
+
+		case opts.qr:
+			return terminal.DisplayQRCode(base64.StdEncoding.EncodeToString(data))
+
+		default:
+			_, _ = cmd.OutOrStdout().Write(bundle.Armor(data))
+		}
+
+		return nil
+	}
+}
+
+// encode seals the bundle with the recipient public key, or encrypts it with a passphrase if it's empty.
+func encode(b *bundle.Bundle, recipient string) ([]byte, error) {
+	if recipient != "" {
+		publicKey, err := base64.StdEncoding.DecodeString(strings.TrimSpace(recipient))
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid public key")
+		}
+		return bundle.Seal(b, publicKey)
+	}
+
+	enclave, err := terminal.ScanPassword("Bundle passphrase", true)
+	if err != nil {
+		return nil, err
+	}
+	password, err := enclave.Open()
+	if err != nil {
+		return nil, errors.Wrap(err, "opening enclave")
+	}
+	defer password.Destroy()
+
+	return bundle.Encode(b, password.Bytes(), archive.VaultParams())
+}
+
+// getRecords returns the records specified, names without a type match the records of every type.
+func getRecords(db *bolt.DB, args []string) ([]dbutil.Record, error) {
+	types := make([]string, 0, len(cmdutil.RecordTypes))
+	for t := range cmdutil.RecordTypes {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+
+	records := make([]dbutil.Record, 0, len(args))
+	for _, arg := range args {
+		recordType, name, ok := strings.Cut(arg, ":")
+		if _, valid := cmdutil.RecordTypes[recordType]; ok && valid {
+			name = cmdutil.NormalizeName(name)
+			if name == "" {
+				return nil, errors.Errorf("invalid record %q", arg)
+			}
+
+			record, err := get(db, name, recordType)
+			if err != nil {
+				return nil, errors.Wrap(err, recordType)
+			}
+			records = append(records, record)
+			continue
+		}
+
+		name = cmdutil.NormalizeName(arg)
+		found := false
+		for _, t := range types {
+			if !exists(db, name, cmdutil.RecordTypes[t]()) {
+				continue
+			}
+			record, err := get(db, name, t)
+			if err != nil {
+				return nil, errors.Wrap(err, t)
+			}
+			records = append(records, record)
+			found = true
+		}
+		if !found {
+			return nil, errors.Errorf("record %q does not exist", name)
+		}
+	}
+
+	return records, nil
+}
+
+// get returns the record, files are decompressed as bundles contain their original content.
+func get(db *bolt.DB, name, recordType string) (dbutil.Record, error) {
+	if recordType == "file" {
+		return file.Get(db, name)
+	}
+
+	record := cmdutil.RecordTypes[recordType]()
+	if err := dbutil.Get(db, name, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// exists reports whether a record of the same type and with the name passed is stored.
+func exists(db *bolt.DB, name string, record dbutil.Record) bool {
+	if !dbutil.Allowed(name) {
+		return false
+	}
+
+	found := false
+	_ = db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(dbutil.GetBucketName(record))
+		found = b != nil && b.Get(dbutil.XorName([]byte(name))) != nil
+		return nil
+	})
+	return found
+}
+
+// newArchive returns an archive containing the records. The previous password and the profile of the
+// entries are removed, they are only meaningful to the owner.
+func newArchive(records []dbutil.Record) *pb.Archive {
+	a := &pb.Archive{CreatedAt: time.Now().Unix()}
+	for _, record := range records {
+		switch r := record.(type) {
+		case *pb.BankAccount:
+			a.BankAccounts = append(a.BankAccounts, r)
+		case *pb.Card:
+			a.Cards = append(a.Cards, r)
+		case *pb.Entry:
+			e := proto.Clone(r).(*pb.Entry)
+			e.PreviousPassword = ""
+			e.Profile = ""
+			a.Entries = append(a.Entries, e)
+		case *pb.File:
+			a.Files = append(a.Files, r)
+		case *pb.Identity:
+			a.Identities = append(a.Identities, r)
+		case *pb.Note:
+			a.Notes = append(a.Notes, r)
+		case *pb.SSHKey:
+			a.SshKeys = append(a.SshKeys, r)
+		case *pb.TOTP:
+			a.Totps = append(a.Totps, r)
+		}
+	}
+	return a
+}
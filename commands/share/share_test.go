@@ -0,0 +1,147 @@
+package share
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/GGP1/kure/bundle"
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/crypt"
+	"github.com/GGP1/kure/db/entry"
+	"github.com/GGP1/kure/db/file"
+	"github.com/GGP1/kure/db/totp"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestShare(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	assert.NoError(t, entry.Create(db, &pb.Entry{Name: "github", Password: "secret", Expires: "Never"}))
+	assert.NoError(t, totp.Create(db, &pb.TOTP{Name: "github", Raw: "JBSWY3DPEHPK3PXP", Digits: 6}))
+	assert.NoError(t, file.Create(db, &pb.File{Name: "id.txt", Content: []byte("content")}))
+
+	privateKey, publicKey, err := crypt.GenerateKeyPair()
+	assert.NoError(t, err)
+
+	output := filepath.Join(t.TempDir(), "bundle")
+	var out bytes.Buffer
+	cmd := NewCmd(db)
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"github", "file:id.txt",
+		"--recipient", base64.StdEncoding.EncodeToString(publicKey),
+		"--output", output,
+		"--expires", "1h",
+	})
+	assert.NoError(t, cmd.Execute())
+	assert.Equal(t, "Shared 3 records in "+output+"\n", out.String())
+
+	data, err := os.ReadFile(output)
+	assert.NoError(t, err)
+
+	b, err := bundle.Open(data, privateKey)
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), b.Expires, time.Minute)
+	assert.Len(t, b.Archive.Entries, 1)
+	assert.Equal(t, "secret", b.Archive.Entries[0].Password)
+	assert.Len(t, b.Archive.Totps, 1)
+	assert.Len(t, b.Archive.Files, 1)
+	// Files contain their original content
+	assert.Equal(t, []byte("content"), b.Archive.Files[0].Content)
+}
+
+func TestShareArmored(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	assert.NoError(t, entry.Create(db, &pb.Entry{Name: "github", Expires: "Never"}))
+
+	privateKey, publicKey, err := crypt.GenerateKeyPair()
+	assert.NoError(t, err)
+
+	var out bytes.Buffer
+	cmd := NewCmd(db)
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"entry:github", "-r", base64.StdEncoding.EncodeToString(publicKey)})
+	assert.NoError(t, cmd.Execute())
+
+	data, err := bundle.Unarmor(out.Bytes())
+	assert.NoError(t, err)
+	b, err := bundle.Open(data, privateKey)
+	assert.NoError(t, err)
+	assert.True(t, b.Expires.IsZero())
+	assert.Len(t, b.Archive.Entries, 1)
+}
+
+func TestShareEntryPrivateFields(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	err := entry.Create(db, &pb.Entry{
+		Name:             "github",
+		Password:         "new",
+		PreviousPassword: "old-password",
+		Profile:          "strict",
+		Expires:          "Never",
+	})
+	assert.NoError(t, err)
+
+	privateKey, publicKey, err := crypt.GenerateKeyPair()
+	assert.NoError(t, err)
+
+	var out bytes.Buffer
+	cmd := NewCmd(db)
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"entry:github", "-r", base64.StdEncoding.EncodeToString(publicKey)})
+	assert.NoError(t, cmd.Execute())
+
+	data, err := bundle.Unarmor(out.Bytes())
+	assert.NoError(t, err)
+	b, err := bundle.Open(data, privateKey)
+	assert.NoError(t, err)
+
+	e := b.Archive.Entries[0]
+	assert.Equal(t, "new", e.Password)
+	assert.Empty(t, e.PreviousPassword)
+	assert.Empty(t, e.Profile)
+	content, err := proto.Marshal(b.Archive)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(content), "old-password")
+
+	// The stored entry is not modified
+	stored, err := entry.Get(db, "github")
+	assert.NoError(t, err)
+	assert.Equal(t, "old-password", stored.PreviousPassword)
+}
+
+func TestShareErrors(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	assert.NoError(t, entry.Create(db, &pb.Entry{Name: "github", Expires: "Never"}))
+	recipient := base64.StdEncoding.EncodeToString(make([]byte, 32))
+
+	cases := []struct {
+		desc string
+		args []string
+	}{
+		{desc: "No records", args: []string{}},
+		{desc: "Non-existent", args: []string{"gitlab", "-r", recipient}},
+		{desc: "Non-existent type", args: []string{"card:github", "-r", recipient}},
+		{desc: "Empty name", args: []string{"entry:", "-r", recipient}},
+		{desc: "Negative expiration", args: []string{"github", "-r", recipient, "-e", "-1h"}},
+		{desc: "Invalid recipient", args: []string{"github", "-r", "invalid"}},
+		{desc: "Output and QR", args: []string{"github", "-r", recipient, "-o", "bundle", "-q"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			cmd := NewCmd(db)
+			cmd.SetArgs(tc.args)
+			assert.Error(t, cmd.Execute())
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package cmdutil
+
+import (
+	"strings"
+
+	"github.com/GGP1/kure/pb"
+	"github.com/GGP1/kure/sshagent"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// ValidateSSHKey parses the private key and sets the public key, fingerprint and type of the record.
+//
+// If the key is encrypted and the passphrase is empty, the error returned wraps a *ssh.PassphraseMissingError.
+func ValidateSSHKey(key *pb.SSHKey) error {
+	key.PrivateKey = strings.TrimSpace(key.PrivateKey) + "\n"
+	key.Comment = strings.TrimSpace(key.Comment)
+
+	signer, err := sshagent.Signer(key)
+	if err != nil {
+		return err
+	}
+
+	pub := signer.PublicKey()
+	key.PublicKey = strings.TrimSpace(string(ssh.MarshalAuthorizedKey(pub)))
+	if key.Comment != "" {
+		key.PublicKey += " " + key.Comment
+	}
+	key.Fingerprint = ssh.FingerprintSHA256(pub)
+	key.Type = pub.Type()
+	return nil
+}
@@ -0,0 +1,131 @@
+package add
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/db/sshkey"
+	"github.com/GGP1/kure/pb"
+	"github.com/GGP1/kure/terminal"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+	"golang.org/x/crypto/ssh"
+)
+
+const example = `
+* Add a key
+kure ssh add github -p ~/.ssh/id_ed25519
+
+* Add a key with a comment
+kure ssh add github -p ~/.ssh/id_ed25519 -c "user@host"`
+
+type addOptions struct {
+	path, comment string
+}
+
+// NewCmd returns a new command.
+func NewCmd(db *bolt.DB) *cobra.Command {
+	opts := addOptions{}
+	cmd := &cobra.Command{
+		Use:   "add <name>",
+		Short: "Add an SSH key",
+		Long: `Add an SSH private key in the OpenSSH or PEM format.
+
+The public key, fingerprint and type are derived from the private key. If the key is encrypted, the passphrase is requested and stored along with it so the agent can use the key without prompting.
+
+When no comment is specified, the one in the public key file next to the private key (<path>.pub) is used, if it exists.`,
+		Aliases: []string{"create", "new"},
+		Example: example,
+		Args:    cmdutil.MustNotExist(db, cmdutil.SSHKey),
+		RunE:    runAdd(db, &opts),
+		PostRun: func(cmd *cobra.Command, args []string) {
+			// Reset variables (session)
+			opts = addOptions{}
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVarP(&opts.path, "path", "p", "", "private key file path")
+	f.StringVarP(&opts.comment, "comment", "c", "", "key comment")
+
+	return cmd
+}
+
+func runAdd(db *bolt.DB, opts *addOptions) cmdutil.RunEFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		name := strings.Join(args, " ")
+		name = cmdutil.NormalizeName(name)
+
+		if opts.path == "" {
+			return cmdutil.ErrInvalidPath
+		}
+
+		privateKey, err := os.ReadFile(opts.path)
+		if err != nil {
+			return errors.Wrap(err, "reading private key")
+		}
+
+		comment := opts.comment
+		if comment == "" {
+			comment = readComment(opts.path + ".pub")
+		}
+
+		key := &pb.SSHKey{
+			Name:       name,
+			PrivateKey: string(privateKey),
+			Comment:    comment,
+			CreatedAt:  time.Now().Unix(),
+		}
+		if err := validate(key); err != nil {
+			return err
+		}
+
+		if err := sshkey.Create(db, key); err != nil {
+			return err
+		}
+
+		fmt.Printf("\n%q added (%s %s)\n", name, key.Type, key.Fingerprint)
+		return nil
+	}
+}
+
+// validate validates the key, requesting its passphrase if it's encrypted.
+func validate(key *pb.SSHKey) error {
+	err := cmdutil.ValidateSSHKey(key)
+	var missing *ssh.PassphraseMissingError
+	if !errors.As(err, &missing) {
+		return err
+	}
+
+	enclave, err := terminal.ScanPassword("Passphrase", false)
+	if err != nil {
+		return err
+	}
+	passphrase, err := enclave.Open()
+	if err != nil {
+		return errors.Wrap(err, "opening enclave")
+	}
+	key.Passphrase = passphrase.String()
+	passphrase.Destroy()
+
+	return cmdutil.ValidateSSHKey(key)
+}
+
+// readComment returns the comment of the public key file, if any.
+func readComment(path string) string {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+
+	_, comment, _, _, err := ssh.ParseAuthorizedKey(content)
+	if err != nil {
+		return ""
+	}
+	return comment
+}
@@ -0,0 +1,142 @@
+package add
+
+import (
+	"crypto/ed25519"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/db/sshkey"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestAdd(t *testing.T) {
+	db := cmdutil.SetContext(t)
+	path := writeKey(t)
+
+	cases := []struct {
+		desc            string
+		name            string
+		comment         string
+		expectedComment string
+	}{
+		{
+			desc:            "Public key comment",
+			name:            "github",
+			expectedComment: "test@kure",
+		},
+		{
+			desc:            "Comment flag",
+			name:            "gitlab",
+			comment:         "user@host",
+			expectedComment: "user@host",
+		},
+	}
+
+	cmd := NewCmd(db)
+	f := cmd.Flags()
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			cmd.SetArgs([]string{tc.name})
+			f.Set("path", path)
+			f.Set("comment", tc.comment)
+
+			err := cmd.Execute()
+			assert.NoError(t, err)
+
+			got, err := sshkey.Get(db, tc.name)
+			assert.NoError(t, err, "SSH key wasn't created correctly")
+			assert.Equal(t, tc.expectedComment, got.Comment)
+			assert.Equal(t, ssh.KeyAlgoED25519, got.Type)
+			assert.NotEmpty(t, got.Fingerprint)
+			assert.NotZero(t, got.CreatedAt)
+		})
+	}
+}
+
+func TestAddErrors(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	err := sshkey.Create(db, &pb.SSHKey{Name: "test"})
+	assert.NoError(t, err)
+
+	invalidKey := filepath.Join(t.TempDir(), "invalid")
+	err = os.WriteFile(invalidKey, []byte("not a key"), 0o600)
+	assert.NoError(t, err)
+
+	cases := []struct {
+		desc string
+		name string
+		path string
+	}{
+		{
+			desc: "Already exists",
+			name: "test",
+			path: writeKey(t),
+		},
+		{
+			desc: "Invalid name",
+			name: "",
+			path: writeKey(t),
+		},
+		{
+			desc: "Missing path",
+			name: "missing-path",
+		},
+		{
+			desc: "Non existent file",
+			name: "non-existent",
+			path: "non-existent",
+		},
+		{
+			desc: "Invalid key",
+			name: "invalid-key",
+			path: invalidKey,
+		},
+	}
+
+	cmd := NewCmd(db)
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			cmd.SetArgs([]string{tc.name})
+			cmd.Flags().Set("path", tc.path)
+
+			err := cmd.Execute()
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestPostRun(t *testing.T) {
+	NewCmd(nil).PostRun(nil, nil)
+}
+
+// writeKey writes an ed25519 private key and its public key to a temporary directory
+// and returns the private key path.
+func writeKey(t *testing.T) string {
+	t.Helper()
+
+	priv := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	assert.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(priv)
+	assert.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "id_ed25519")
+	err = os.WriteFile(path, pem.EncodeToMemory(block), 0o600)
+	assert.NoError(t, err)
+
+	pub := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(signer.PublicKey()))) + " test@kure\n"
+	err = os.WriteFile(path+".pub", []byte(pub), 0o644)
+	assert.NoError(t, err)
+
+	return path
+}
@@ -0,0 +1,69 @@
+package copy
+
+import (
+	"strings"
+	"time"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/db/sshkey"
+
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+const example = `
+* Copy the public key
+kure ssh copy Sample
+
+* Copy the fingerprint
+kure ssh copy Sample -f
+
+* Copy and clean after 30s
+kure ssh copy Sample -t 30s`
+
+type copyOptions struct {
+	timeout     time.Duration
+	fingerprint bool
+}
+
+// NewCmd returns a new command.
+func NewCmd(db *bolt.DB) *cobra.Command {
+	opts := copyOptions{}
+	cmd := &cobra.Command{
+		Use:     "copy <name>",
+		Short:   "Copy SSH public key",
+		Long:    "Copy the public key in the authorized_keys format or its fingerprint. The private key is never copied.",
+		Aliases: []string{"cp"},
+		Example: example,
+		Args:    cmdutil.MustExist(db, cmdutil.SSHKey),
+		RunE:    runCopy(db, &opts),
+		PostRun: func(cmd *cobra.Command, args []string) {
+			// Reset variables (session)
+			opts = copyOptions{}
+		},
+	}
+
+	f := cmd.Flags()
+	f.BoolVarP(&opts.fingerprint, "fingerprint", "f", false, "copy the key fingerprint")
+	f.DurationVarP(&opts.timeout, "timeout", "t", 0, "clipboard clearing timeout")
+
+	return cmd
+}
+
+func runCopy(db *bolt.DB, opts *copyOptions) cmdutil.RunEFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		name := strings.Join(args, " ")
+		name = cmdutil.NormalizeName(name)
+
+		key, err := sshkey.Get(db, name)
+		if err != nil {
+			return err
+		}
+
+		if opts.fingerprint {
+			return cmdutil.WriteClipboard(cmd, opts.timeout, "Fingerprint", key.Fingerprint)
+		}
+
+		return cmdutil.WriteClipboard(cmd, opts.timeout, "Public key", key.PublicKey)
+	}
+}
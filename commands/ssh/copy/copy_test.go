@@ -0,0 +1,100 @@
+package copy
+
+import (
+	"testing"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/db/sshkey"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/atotto/clipboard"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCopy(t *testing.T) {
+	if clipboard.Unsupported {
+		t.Skip("No clipboard utilities available")
+	}
+	db := cmdutil.SetContext(t)
+
+	record := &pb.SSHKey{
+		Name:        "test",
+		PublicKey:   "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIBq2tUZmg0PnfSwSqLLKVBEKrIVNjMLHnKlNqtAGuQI7",
+		Fingerprint: "SHA256:2xVJgpXBVOWdvFVXHzeFuqCuDe2jRNCsmHr+kjRx2YE",
+	}
+	err := sshkey.Create(db, record)
+	assert.NoError(t, err, "Failed creating the  SSH key")
+
+	cases := []struct {
+		desc        string
+		value       string
+		fingerprint string
+		timeout     string
+	}{
+		{
+			desc:  "Copy public key",
+			value: record.PublicKey,
+		},
+		{
+			desc:        "Copy fingerprint",
+			value:       record.Fingerprint,
+			fingerprint: "true",
+		},
+		{
+			desc:    "Copy w/Timeout",
+			value:   "",
+			timeout: "1ns",
+		},
+	}
+
+	cmd := NewCmd(db)
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			cmd.SetArgs([]string{record.Name})
+			cmd.Flags().Set("fingerprint", tc.fingerprint)
+			cmd.Flags().Set("timeout", tc.timeout)
+
+			err := cmd.Execute()
+			assert.NoError(t, err)
+
+			got, err := clipboard.ReadAll()
+			assert.NoError(t, err, "Failed reading from clipboard")
+
+			assert.Equal(t, tc.value, got)
+		})
+	}
+}
+
+func TestCopyErrors(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	cases := []struct {
+		desc string
+		name string
+	}{
+		{
+			desc: "Invalid name",
+			name: "",
+		},
+		{
+			desc: "Non existent  SSH key",
+			name: "non-existent",
+		},
+	}
+
+	cmd := NewCmd(db)
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			cmd.SetArgs([]string{tc.name})
+
+			err := cmd.Execute()
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestPostRun(t *testing.T) {
+	NewCmd(nil).PostRun(nil, nil)
+}
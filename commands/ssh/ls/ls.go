@@ -0,0 +1,129 @@
+package ls
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/db/sshkey"
+	"github.com/GGP1/kure/orderedmap"
+	"github.com/GGP1/kure/pb"
+	"github.com/GGP1/kure/terminal"
+	"github.com/GGP1/kure/tree"
+
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+const example = `
+* List one and show the QR code
+kure ssh ls Sample -q
+
+* Filter by name
+kure ssh ls Sample -f
+
+* List all
+kure ssh ls`
+
+type lsOptions struct {
+	filter, qr bool
+}
+
+// NewCmd returns a new command.
+func NewCmd(db *bolt.DB) *cobra.Command {
+	opts := lsOptions{}
+	cmd := &cobra.Command{
+		Use:     "ls <name>",
+		Short:   "List SSH keys",
+		Example: example,
+		Args:    cmdutil.MustExistLs(db, cmdutil.SSHKey),
+		RunE:    runLs(db, &opts),
+		PostRun: func(cmd *cobra.Command, args []string) {
+			// Reset variables (session)
+			opts = lsOptions{}
+		},
+	}
+
+	f := cmd.Flags()
+	f.BoolVarP(&opts.filter, "filter", "f", false, "filter by name")
+	f.BoolVarP(&opts.qr, "qr", "q", false, "display the QR code of the public key on the terminal")
+
+	return cmd
+}
+
+func runLs(db *bolt.DB, opts *lsOptions) cmdutil.RunEFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		name := strings.Join(args, " ")
+		name = cmdutil.NormalizeName(name)
+
+		// List all
+		if name == "" {
+			keys, err := sshkey.ListNames(db)
+			if err != nil {
+				return err
+			}
+
+			tree.Print(keys)
+			return nil
+		}
+
+		// Filter by name
+		if opts.filter {
+			keys, err := sshkey.ListNames(db)
+			if err != nil {
+				return err
+			}
+
+			var matches []string
+			for _, key := range keys {
+				matched, err := regexp.MatchString(name, key)
+				if err != nil {
+					return err
+				}
+
+				if matched {
+					matches = append(matches, key)
+				}
+			}
+
+			if len(matches) == 0 {
+				return errors.New("no SSH keys were found")
+			}
+
+			tree.Print(matches)
+			return nil
+		}
+
+		// List one
+		key, err := sshkey.Get(db, name)
+		if err != nil {
+			return err
+		}
+
+		if opts.qr {
+			return terminal.DisplayQRCode(key.PublicKey)
+		}
+
+		printKey(name, key)
+		return nil
+	}
+}
+
+func printKey(name string, key *pb.SSHKey) {
+	mp := orderedmap.New()
+	mp.Set("Type", key.Type)
+	mp.Set("Fingerprint", key.Fingerprint)
+	mp.Set("Comment", key.Comment)
+	mp.Set("Public key", key.PublicKey)
+	if key.Passphrase != "" {
+		mp.Set("Encrypted", "Yes")
+	}
+	if key.CreatedAt != 0 {
+		mp.Set("Created at", time.Unix(key.CreatedAt, 0).String())
+	}
+
+	fmt.Println(cmdutil.BuildBox(name, mp))
+}
@@ -0,0 +1,119 @@
+package ls
+
+import (
+	"testing"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/db/sshkey"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLs(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	err := sshkey.Create(db, &pb.SSHKey{
+		Name:        "test",
+		PublicKey:   "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIBq2tUZmg0PnfSwSqLLKVBEKrIVNjMLHnKlNqtAGuQI7 test@kure",
+		Comment:     "test@kure",
+		Fingerprint: "SHA256:2xVJgpXBVOWdvFVXHzeFuqCuDe2jRNCsmHr+kjRx2YE",
+		Type:        "ssh-ed25519",
+		Passphrase:  "passphrase",
+		CreatedAt:   1,
+	})
+	assert.NoError(t, err, "Failed creating the SSH key")
+
+	cases := []struct {
+		desc   string
+		name   string
+		filter string
+		qr     string
+	}{
+		{
+			desc: "List one",
+			name: "test",
+		},
+		{
+			desc: "List one and show qr",
+			name: "test",
+			qr:   "true",
+		},
+		{
+			desc:   "Filter by name",
+			name:   "te*",
+			filter: "true",
+		},
+		{
+			desc: "List all",
+			name: "",
+		},
+	}
+
+	cmd := NewCmd(db)
+	f := cmd.Flags()
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			cmd.SetArgs([]string{tc.name})
+			f.Set("filter", tc.filter)
+			f.Set("qr", tc.qr)
+
+			err := cmd.Execute()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestLsErrors(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	err := sshkey.Create(db, &pb.SSHKey{Name: "test"})
+	assert.NoError(t, err, "Failed creating the SSH key")
+
+	cases := []struct {
+		desc   string
+		name   string
+		filter string
+		qr     string
+	}{
+		{
+			desc:   "Note does not exist",
+			name:   "non-existent",
+			filter: "false",
+		},
+		{
+			desc:   "No SSH keys found",
+			name:   "non-existent",
+			filter: "true",
+		},
+		{
+			desc:   "Filter syntax error",
+			name:   "[error",
+			filter: "true",
+		},
+		{
+			desc: "No data to encode",
+			name: "test",
+			qr:   "true",
+		},
+	}
+
+	cmd := NewCmd(db)
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			cmd.SetArgs([]string{tc.name})
+			f := cmd.Flags()
+			f.Set("filter", tc.filter)
+			f.Set("qr", tc.qr)
+
+			err := cmd.Execute()
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestPostRun(t *testing.T) {
+	NewCmd(nil).PostRun(nil, nil)
+}
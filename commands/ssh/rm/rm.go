@@ -0,0 +1,68 @@
+package rm
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/db/sshkey"
+	"github.com/GGP1/kure/terminal"
+
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+const example = `
+* Remove an SSH key
+kure ssh rm Sample
+
+* Remove a directory
+kure ssh rm SampleDir/
+
+* Remove multiple SSH keys
+kure ssh rm Sample Sample2 Sample3`
+
+// NewCmd returns the a new command.
+func NewCmd(db *bolt.DB, r io.Reader) *cobra.Command {
+	return &cobra.Command{
+		Use:     "rm <names>",
+		Short:   "Remove SSH keys or directories",
+		Example: example,
+		Args:    cmdutil.MustExist(db, cmdutil.SSHKey, true),
+		RunE:    runRm(db, r),
+	}
+}
+
+func runRm(db *bolt.DB, r io.Reader) cmdutil.RunEFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		if !terminal.Confirm(r, "Are you sure you want to proceed?") {
+			return nil
+		}
+
+		names := make([]string, 0, len(args))
+		for _, name := range args {
+			name = cmdutil.NormalizeName(name, true)
+
+			if !strings.HasSuffix(name, "/") {
+				names = append(names, name)
+				fmt.Println("Remove:", name)
+				continue
+			}
+
+			keys, err := sshkey.ListNames(db)
+			if err != nil {
+				return err
+			}
+
+			for _, n := range keys {
+				if strings.HasPrefix(n, name) {
+					names = append(names, n)
+					fmt.Println("Remove:", n)
+				}
+			}
+		}
+
+		return sshkey.Remove(db, names...)
+	}
+}
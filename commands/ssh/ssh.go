@@ -0,0 +1,35 @@
+package ssh
+
+import (
+	"os"
+
+	sadd "github.com/GGP1/kure/commands/ssh/add"
+	scopy "github.com/GGP1/kure/commands/ssh/copy"
+	sls "github.com/GGP1/kure/commands/ssh/ls"
+	srm "github.com/GGP1/kure/commands/ssh/rm"
+
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+const example = `
+kure ssh (add|copy|ls|rm)`
+
+// NewCmd returns a new command.
+func NewCmd(db *bolt.DB) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "ssh",
+		Short:   "SSH key operations",
+		Long:    "SSH key operations. Use kure ssh-agent to serve the keys to SSH clients.",
+		Example: example,
+	}
+
+	cmd.AddCommand(
+		sadd.NewCmd(db),
+		scopy.NewCmd(db),
+		sls.NewCmd(db),
+		srm.NewCmd(db, os.Stdin),
+	)
+
+	return cmd
+}
@@ -0,0 +1,158 @@
+package sshagent
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/pb"
+	"github.com/GGP1/kure/sig"
+	"github.com/GGP1/kure/sshagent"
+	"github.com/GGP1/kure/terminal"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+const example = `
+* Start the agent on a temporary socket
+kure ssh-agent
+
+* Start the agent on a fixed socket and confirm every use of a key
+kure ssh-agent -s ~/.ssh/kure.sock -c
+
+* Use it from another terminal
+export SSH_AUTH_SOCK=/tmp/kure-ssh-1234/agent.sock
+ssh-add -l`
+
+type agentOptions struct {
+	socket  string
+	confirm bool
+}
+
+// NewCmd returns a new command.
+func NewCmd(db *bolt.DB, r io.Reader) *cobra.Command {
+	opts := agentOptions{}
+	cmd := &cobra.Command{
+		Use:   "ssh-agent",
+		Short: "Serve SSH keys through an ssh-agent socket",
+		Long: `Serve the SSH keys stored in kure to SSH clients implementing the ssh-agent protocol on a local Unix socket.
+
+Keys are decrypted only when a client requests a signature and are never written to disk. Adding or removing keys through the agent (ssh-add) is not supported, use "kure ssh" instead.
+
+Set the SSH_AUTH_SOCK environment variable to the socket path printed on start to use the agent.`,
+		Example: example,
+		RunE:    runAgent(db, r, &opts),
+		PostRun: func(cmd *cobra.Command, args []string) {
+			// Reset variables (session)
+			opts = agentOptions{}
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVarP(&opts.socket, "socket", "s", "", "unix socket path (default: a temporary directory)")
+	f.BoolVarP(&opts.confirm, "confirm", "c", false, "ask for confirmation every time a key is used")
+
+	return cmd
+}
+
+func runAgent(db *bolt.DB, r io.Reader, opts *agentOptions) cmdutil.RunEFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		l, cleanup, err := listen(opts.socket)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+
+		sig.Signal.AddCleanup(func() error {
+			// Do not exit after a signal as we are handling the shutdown
+			sig.Signal.KeepAlive()
+			fmt.Println("Shutting down agent...")
+			return l.Close()
+		})
+
+		var confirm sshagent.ConfirmFunc
+		if opts.confirm {
+			confirm = confirmFunc(r)
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "SSH_AUTH_SOCK=%s; export SSH_AUTH_SOCK;\n", l.Addr())
+		fmt.Println("Serving SSH keys (Press Ctrl+C to quit)")
+
+		return sshagent.New(db, confirm).Serve(l)
+	}
+}
+
+// listen creates the unix socket, only accessible by the current user. If the path is empty,
+// it's created inside a temporary directory that is removed by the cleanup function.
+func listen(path string) (net.Listener, func(), error) {
+	if path != "" {
+		if _, err := os.Stat(path); err == nil {
+			return nil, nil, errors.Errorf("%q already exists", path)
+		}
+	}
+
+	// The socket is created inside a directory only accessible by the current user so it's never
+	// exposed before setting its permissions, custom paths use one next to them to move it later
+	parent := ""
+	if path != "" {
+		parent = filepath.Dir(path)
+	}
+	dir, err := os.MkdirTemp(parent, "kure-ssh-")
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "creating socket directory")
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+	tmpPath := filepath.Join(dir, "agent.sock")
+
+	l, err := net.Listen("unix", tmpPath)
+	if err != nil {
+		cleanup()
+		return nil, nil, errors.Wrap(err, "listening on socket")
+	}
+
+	if err := os.Chmod(tmpPath, 0o600); err != nil {
+		l.Close()
+		cleanup()
+		return nil, nil, errors.Wrap(err, "setting socket permissions")
+	}
+
+	if path == "" {
+		return l, cleanup, nil
+	}
+
+	// The listener would try to remove the socket from its previous path when closed
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	if err := os.Rename(tmpPath, path); err != nil {
+		l.Close()
+		cleanup()
+		return nil, nil, errors.Wrap(err, "moving socket")
+	}
+	cleanup()
+
+	moved := &socketListener{Listener: l, addr: &net.UnixAddr{Name: path, Net: "unix"}}
+	return moved, func() { os.Remove(path) }, nil
+}
+
+// socketListener is a listener whose socket was moved after being created.
+type socketListener struct {
+	net.Listener
+	addr net.Addr
+}
+
+// Addr returns the socket current path.
+func (l *socketListener) Addr() net.Addr {
+	return l.addr
+}
+
+// confirmFunc returns a function that asks the user to confirm the use of a key.
+func confirmFunc(r io.Reader) sshagent.ConfirmFunc {
+	return func(key *pb.SSHKey) bool {
+		fmt.Println()
+		return terminal.Confirm(r, fmt.Sprintf("Allow the use of %q (%s)?", key.Name, key.Fingerprint))
+	}
+}
@@ -0,0 +1,81 @@
+package sshagent
+
+import (
+	"bytes"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListen(t *testing.T) {
+	t.Run("Temporary directory", func(t *testing.T) {
+		l, cleanup, err := listen("")
+		assert.NoError(t, err)
+
+		path := l.Addr().String()
+		info, err := os.Stat(path)
+		assert.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+		l.Close()
+		cleanup()
+		_, err = os.Stat(filepath.Dir(path))
+		assert.True(t, os.IsNotExist(err), "Expected the socket directory to be removed")
+	})
+
+	t.Run("Custom path", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "kure.sock")
+		l, cleanup, err := listen(path)
+		assert.NoError(t, err)
+
+		assert.Equal(t, path, l.Addr().String())
+		info, err := os.Stat(path)
+		assert.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+		// Only the socket is left in the directory
+		files, err := os.ReadDir(filepath.Dir(path))
+		assert.NoError(t, err)
+		assert.Len(t, files, 1)
+
+		conn, err := net.Dial("unix", path)
+		assert.NoError(t, err)
+		conn.Close()
+
+		l.Close()
+		cleanup()
+		_, err = os.Stat(path)
+		assert.True(t, os.IsNotExist(err), "Expected the socket to be removed")
+	})
+}
+
+func TestAgentErrors(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	path := filepath.Join(t.TempDir(), "exists.sock")
+	err := os.WriteFile(path, nil, 0o600)
+	assert.NoError(t, err)
+
+	cmd := NewCmd(db, nil)
+	cmd.SetArgs([]string{"--socket", path})
+
+	err = cmd.Execute()
+	assert.Error(t, err)
+}
+
+func TestConfirmFunc(t *testing.T) {
+	key := &pb.SSHKey{Name: "test"}
+
+	assert.True(t, confirmFunc(bytes.NewBufferString("y\n"))(key))
+	assert.False(t, confirmFunc(bytes.NewBufferString("n\n"))(key))
+}
+
+func TestPostRun(t *testing.T) {
+	NewCmd(nil, nil).PostRun(nil, nil)
+}
@@ -48,19 +48,27 @@ func runStats(db *bolt.DB, opts *statsOptions) cmdutil.RunEFunc {
 		}
 		defer tx.Rollback()
 
+		nBanks := tx.Bucket(bucket.Bank.GetName()).Stats().KeyN
 		nCards := tx.Bucket(bucket.Card.GetName()).Stats().KeyN
 		nEntries := tx.Bucket(bucket.Entry.GetName()).Stats().KeyN
 		nFiles := tx.Bucket(bucket.File.GetName()).Stats().KeyN
+		nIdentities := tx.Bucket(bucket.Identity.GetName()).Stats().KeyN
+		nNotes := tx.Bucket(bucket.Note.GetName()).Stats().KeyN
+		nSSHKeys := tx.Bucket(bucket.SSH.GetName()).Stats().KeyN
 		nTOTPs := tx.Bucket(bucket.TOTP.GetName()).Stats().KeyN
-		total := nCards + nEntries + nFiles + nTOTPs
+		total := nBanks + nCards + nEntries + nFiles + nIdentities + nNotes + nSSHKeys + nTOTPs
 
 		if opts.json {
 			stats := map[string]int{
-				"cards":   nCards,
-				"entries": nEntries,
-				"files":   nFiles,
-				"totps":   nTOTPs,
-				"total":   total,
+				"bank_accounts": nBanks,
+				"cards":         nCards,
+				"entries":       nEntries,
+				"files":         nFiles,
+				"identities":    nIdentities,
+				"notes":         nNotes,
+				"ssh_keys":      nSSHKeys,
+				"totps":         nTOTPs,
+				"total":         total,
 			}
 			if err := json.NewEncoder(os.Stdout).Encode(stats); err != nil {
 				return errors.Wrap(err, "encoding statistics to JSON")
@@ -71,13 +79,17 @@ func runStats(db *bolt.DB, opts *statsOptions) cmdutil.RunEFunc {
 		fmt.Printf(`
      STATISTICS
 ────────────────────
+Number of bank accounts: %d
 Number of cards: %d
 Number of entries: %d
 Number of files: %d
+Number of identities: %d
+Number of notes: %d
+Number of SSH keys: %d
 Number of TOTPs: %d
 
 Total elements: %d
-`, nCards, nEntries, nFiles, nTOTPs, total)
+`, nBanks, nCards, nEntries, nFiles, nIdentities, nNotes, nSSHKeys, nTOTPs, total)
 
 		return nil
 	}
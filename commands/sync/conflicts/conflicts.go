@@ -0,0 +1,64 @@
+package conflicts
+
+import (
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/commands/sync/replica"
+
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+const example = `
+kure sync conflicts`
+
+// NewCmd returns a new command.
+func NewCmd(db *bolt.DB) *cobra.Command {
+	return &cobra.Command{
+		Use:   "conflicts",
+		Short: "List the synchronization conflicts",
+		Long: `List the records modified concurrently on this and other devices.
+
+The local version is kept until the conflict is resolved with "kure sync resolve".`,
+		Example: example,
+		Args:    cobra.NoArgs,
+		RunE:    runConflicts(db),
+	}
+}
+
+func runConflicts(db *bolt.DB) cmdutil.RunEFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		conflicts, err := replica.Conflicts(db)
+		if err != nil {
+			return err
+		}
+
+		out := cmd.OutOrStdout()
+		if len(conflicts) == 0 {
+			fmt.Fprintln(out, "There are no conflicts")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(w, "Record\tLocal\tRemote")
+		for _, c := range conflicts {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", c.Remote.Key(), describe(c.Local, c.LocalHost), describe(c.Remote, c.RemoteHost))
+		}
+		return w.Flush()
+	}
+}
+
+// describe returns the last modification made to the record.
+func describe(r *replica.Record, host string) string {
+	action := "modified"
+	if r.Deleted {
+		action = "deleted"
+	}
+	if host == "" {
+		host = r.Device
+	}
+	return fmt.Sprintf("%s on %s (%s)", action, r.Modified.Local().Format(time.DateTime), host)
+}
@@ -0,0 +1,39 @@
+package conflicts
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/commands/sync/replica"
+	"github.com/GGP1/kure/db/entry"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConflicts(t *testing.T) {
+	laptop := cmdutil.SetContext(t)
+	desktop := cmdutil.SetContext(t)
+
+	var out bytes.Buffer
+	cmd := NewCmd(desktop)
+	cmd.SetOut(&out)
+	assert.NoError(t, cmd.Execute())
+	assert.Equal(t, "There are no conflicts\n", out.String())
+
+	store, err := replica.NewFolder(t.TempDir())
+	assert.NoError(t, err)
+	assert.NoError(t, entry.Create(laptop, &pb.Entry{Name: "github", Password: "laptop", Expires: "Never"}))
+	assert.NoError(t, entry.Create(desktop, &pb.Entry{Name: "github", Password: "desktop", Expires: "Never"}))
+
+	_, err = replica.Sync(context.Background(), laptop, store)
+	assert.NoError(t, err)
+	_, err = replica.Sync(context.Background(), desktop, store)
+	assert.NoError(t, err)
+
+	out.Reset()
+	assert.NoError(t, cmd.Execute())
+	assert.Regexp(t, `Record\s+Local\s+Remote\nentry:github\s+modified on .+\s+modified on .+\n`, out.String())
+}
@@ -0,0 +1,96 @@
+package replica
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// maxChangeSetSize is the maximum size of the change sets accepted by the server.
+const maxChangeSetSize = 1 << 30
+
+// Handler returns the sync server handler, it stores the change sets in dir and rejects the
+// requests that do not carry the token.
+//
+// The server never sees the records as the change sets are encrypted by the devices.
+func Handler(dir, token string) (http.Handler, error) {
+	if dir == "" {
+		return nil, errors.New("invalid directory")
+	}
+	if token == "" {
+		return nil, errors.New("the token must not be empty")
+	}
+
+	store := &folder{dir: dir}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		names, err := store.List(r.Context())
+		if err != nil {
+			http.Error(w, "listing change sets", http.StatusInternalServerError)
+			return
+		}
+		if names == nil {
+			names = []string{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(names)
+	})
+	mux.HandleFunc("GET /{name}", func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		if !validName.MatchString(name) {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/octet-stream")
+		http.ServeFile(w, r, filepath.Join(dir, name))
+	})
+	mux.HandleFunc("PUT /{name}", func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		if !validName.MatchString(name) {
+			http.Error(w, "invalid change set name", http.StatusBadRequest)
+			return
+		}
+
+		body := http.MaxBytesReader(w, r.Body, maxChangeSetSize)
+		if err := writeFile(dir, name, body); err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				http.Error(w, "change set too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, "storing change set", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("DELETE /{name}", func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		if !validName.MatchString(name) {
+			http.Error(w, "invalid change set name", http.StatusBadRequest)
+			return
+		}
+
+		if err := store.Delete(r.Context(), name); err != nil {
+			http.Error(w, "removing change set", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		got, ok := strings.CutPrefix(auth, "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}), nil
+}
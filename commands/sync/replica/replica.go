@@ -0,0 +1,687 @@
+// Package replica synchronizes the records of a database stored in multiple devices.
+//
+// Every device keeps track of the version of its records using version vectors and publishes its
+// state as a change set, encrypted with the master key, in a store shared with the other devices.
+// Merging the change sets of the other devices applies their modifications record by record, the
+// changes made concurrently on both sides are kept as conflicts to be resolved manually.
+package replica
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/GGP1/kure/crypt"
+	dbutil "github.com/GGP1/kure/db"
+	"github.com/GGP1/kure/db/bucket"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	deviceKey = []byte("device")
+	// Host name and database path the device ID belongs to
+	locationKey = []byte("location")
+	// ID replaced when the location changed, its change set is removed in the next synchronization
+	previousKey = []byte("previous")
+	stateKey    = []byte("state")
+)
+
+// Record contains the synchronization information of a record.
+type Record struct {
+	Type     string    `json:"type"`
+	Name     string    `json:"name"`
+	Version  Version   `json:"version"`
+	Hash     string    `json:"hash,omitempty"`
+	Modified time.Time `json:"modified"`
+	// Device that made the last modification
+	Device  string `json:"device"`
+	Deleted bool   `json:"deleted,omitempty"`
+	// Record encrypted, it's only set in change sets and conflicts
+	Data []byte `json:"data,omitempty"`
+}
+
+// Key returns the record identifier, "<type>:<name>".
+func (r *Record) Key() string {
+	return r.Type + ":" + r.Name
+}
+
+// State is the synchronization state of a device.
+type State struct {
+	// ID of this device, it's stored in plain text to avoid decrypting the state to read it
+	Device string `json:"-"`
+	// Device IDs mapped to their host names
+	Devices   map[string]string  `json:"devices"`
+	Records   map[string]*Record `json:"records"`
+	Conflicts map[string]*Record `json:"conflicts"`
+	LastSync  time.Time          `json:"last_sync"`
+}
+
+// ChangeSet contains the state of a device and the content of its records.
+type ChangeSet struct {
+	Device  string    `json:"device"`
+	Host    string    `json:"host"`
+	Time    time.Time `json:"time"`
+	Records []*Record `json:"records"`
+}
+
+// Conflict is a record modified concurrently in this and another device.
+type Conflict struct {
+	Local      *Record
+	Remote     *Record
+	LocalHost  string
+	RemoteHost string
+}
+
+// Result contains a summary of the synchronization.
+type Result struct {
+	// Number of change sets from other devices merged
+	Devices int
+	// Number of records modified in this device since the last synchronization
+	Pushed int
+	// Number of records modified by the changes of other devices
+	Pulled int
+	// Number of conflicts pending to be resolved
+	Conflicts int
+}
+
+// Sync merges the change sets of the other devices into the database and publishes the changes
+// made in this one.
+func Sync(ctx context.Context, db *bolt.DB, store Store) (*Result, error) {
+	device, err := deviceID(db)
+	if err != nil {
+		return nil, err
+	}
+
+	changeSets, err := fetch(ctx, store, device)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Devices: len(changeSets)}
+	var (
+		own      []byte
+		previous *ChangeSet
+	)
+	err = db.Update(func(tx *bolt.Tx) error {
+		s, err := loadState(tx)
+		if err != nil {
+			return err
+		}
+		previous = previousChangeSet(tx, changeSets, s.LastSync)
+
+		now := time.Now().UTC()
+		local := scan(tx)
+		result.Pushed = s.detectChanges(local, now)
+
+		changed := make(map[string]struct{})
+		for _, cs := range changeSets {
+			s.Devices[cs.Device] = cs.Host
+			for _, r := range cs.Records {
+				if err := s.merge(r, local, changed); err != nil {
+					return errors.Wrapf(err, "merging %s", r.Key())
+				}
+			}
+		}
+
+		if err := apply(tx, local, changed); err != nil {
+			return err
+		}
+		result.Pulled = len(changed)
+		result.Conflicts = len(s.Conflicts)
+
+		s.LastSync = now
+		own, err = s.changeSet(local, now)
+		if err != nil {
+			return err
+		}
+		return saveState(tx, s)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := store.Put(ctx, device+changeSetExt, own); err != nil {
+		return nil, errors.Wrap(err, "publishing change set")
+	}
+
+	if previous != nil {
+		if err := store.Delete(ctx, previous.Device+changeSetExt); err != nil {
+			return nil, errors.Wrap(err, "removing previous change set")
+		}
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket.Sync.GetName()).Delete(previousKey)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "removing previous device ID")
+	}
+
+	return result, nil
+}
+
+// previousChangeSet returns the change set published by this database under the ID it had before
+// changing location, or nil if there is none.
+//
+// A change set published after the last synchronization belongs to the database this one was copied
+// from, which still uses the ID, and must be kept.
+func previousChangeSet(tx *bolt.Tx, changeSets []*ChangeSet, lastSync time.Time) *ChangeSet {
+	previous := tx.Bucket(bucket.Sync.GetName()).Get(previousKey)
+	if previous == nil {
+		return nil
+	}
+
+	for _, cs := range changeSets {
+		if cs.Device == string(previous) && !cs.Time.After(lastSync) {
+			return cs
+		}
+	}
+	return nil
+}
+
+// Conflicts returns the conflicts pending to be resolved sorted by key.
+func Conflicts(db *bolt.DB) ([]Conflict, error) {
+	var conflicts []Conflict
+	err := db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket.Sync.GetName())
+		if b == nil {
+			return nil
+		}
+
+		s, err := decodeState(b.Get(stateKey))
+		if err != nil {
+			return err
+		}
+		s.Device = string(b.Get(deviceKey))
+
+		for key, remote := range s.Conflicts {
+			conflicts = append(conflicts, Conflict{
+				Local:      s.Records[key],
+				Remote:     remote,
+				LocalHost:  s.Devices[s.Device],
+				RemoteHost: s.Devices[remote.Device],
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(conflicts, func(i, j int) bool {
+		return conflicts[i].Remote.Key() < conflicts[j].Remote.Key()
+	})
+	return conflicts, nil
+}
+
+// Resolve resolves the conflict of the record with the key specified keeping the local or remote
+// version. The resolution is published to the other devices in the next synchronization.
+func Resolve(db *bolt.DB, key string, keepRemote bool) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		s, err := loadState(tx)
+		if err != nil {
+			return err
+		}
+
+		remote, ok := s.Conflicts[key]
+		if !ok {
+			return errors.Errorf("there is no conflict for %q", key)
+		}
+		local := s.Records[key]
+
+		if keepRemote {
+			b := tx.Bucket(bucketName(remote.Type))
+			xorName := dbutil.XorName([]byte(remote.Name))
+			if remote.Deleted {
+				if err := b.Delete(xorName); err != nil {
+					return errors.Wrap(err, "deleting record")
+				}
+			} else if err := b.Put(xorName, remote.Data); err != nil {
+				return errors.Wrap(err, "storing record")
+			}
+			local.Hash = remote.Hash
+			local.Deleted = remote.Deleted
+		}
+
+		// The new version succeeds both, the other devices will take it without conflicts
+		local.Version = local.Version.Merge(remote.Version)
+		s.touch(local, time.Now().UTC())
+		delete(s.Conflicts, key)
+
+		return saveState(tx, s)
+	})
+}
+
+// detectChanges compares the records stored with the state of the last synchronization and
+// increments the version of the ones modified. It returns the number of records modified.
+func (s *State) detectChanges(local map[string]*Record, now time.Time) int {
+	n := 0
+	for key, l := range local {
+		r, ok := s.Records[key]
+		if ok && !r.Deleted && r.Hash == l.Hash {
+			continue
+		}
+
+		if !ok {
+			r = &Record{Type: l.Type, Name: l.Name, Version: Version{}}
+			s.Records[key] = r
+		}
+		r.Hash = l.Hash
+		r.Deleted = false
+		s.touch(r, now)
+		n++
+	}
+
+	for key, r := range s.Records {
+		if _, ok := local[key]; ok || r.Deleted {
+			continue
+		}
+		r.Hash = ""
+		r.Deleted = true
+		s.touch(r, now)
+		n++
+	}
+
+	return n
+}
+
+// merge compares a record from another device with the local one and takes it if it's newer,
+// if both were modified concurrently and their content differ, it's stored as a conflict.
+func (s *State) merge(remote *Record, local map[string]*Record, changed map[string]struct{}) error {
+	if err := validate(remote); err != nil {
+		return err
+	}
+
+	key := remote.Key()
+	current, ok := s.Records[key]
+	if !ok {
+		s.take(remote, local, changed)
+		return nil
+	}
+
+	switch current.Version.Compare(remote.Version) {
+	case Equal, After:
+		return nil
+
+	case Before:
+		s.take(remote, local, changed)
+
+	case Concurrent:
+		equal, err := sameContent(current, local[key], remote)
+		if err != nil {
+			return err
+		}
+		if !equal {
+			// Keep the existing conflict only if it's newer than the remote record
+			if c, ok := s.Conflicts[key]; ok {
+				if o := c.Version.Compare(remote.Version); o == After || o == Equal {
+					return nil
+				}
+			}
+			s.Conflicts[key] = remote
+			return nil
+		}
+		current.Version = current.Version.Merge(remote.Version)
+	}
+
+	// Discard the conflicts superseded by the current version
+	if c, ok := s.Conflicts[key]; ok {
+		if o := c.Version.Compare(s.Records[key].Version); o == Before || o == Equal {
+			delete(s.Conflicts, key)
+		}
+	}
+	return nil
+}
+
+// take replaces the local record with the remote one.
+func (s *State) take(remote *Record, local map[string]*Record, changed map[string]struct{}) {
+	key := remote.Key()
+	r := *remote
+	r.Data = nil
+	s.Records[key] = &r
+
+	if remote.Deleted {
+		if _, ok := local[key]; ok {
+			delete(local, key)
+			changed[key] = struct{}{}
+		}
+		return
+	}
+
+	local[key] = &Record{Type: remote.Type, Name: remote.Name, Hash: remote.Hash, Data: remote.Data}
+	changed[key] = struct{}{}
+}
+
+// touch increments the version of the record and records who modified it.
+func (s *State) touch(r *Record, now time.Time) {
+	if r.Version == nil {
+		r.Version = Version{}
+	}
+	r.Version[s.Device]++
+	r.Modified = now
+	r.Device = s.Device
+}
+
+// changeSet returns the encrypted change set of the device.
+func (s *State) changeSet(local map[string]*Record, now time.Time) ([]byte, error) {
+	cs := ChangeSet{
+		Device:  s.Device,
+		Host:    s.Devices[s.Device],
+		Time:    now,
+		Records: make([]*Record, 0, len(s.Records)),
+	}
+	for key, r := range s.Records {
+		record := *r
+		if !r.Deleted {
+			record.Data = local[key].Data
+		}
+		cs.Records = append(cs.Records, &record)
+	}
+
+	data, err := json.Marshal(cs)
+	if err != nil {
+		return nil, errors.Wrap(err, "encoding change set")
+	}
+
+	encrypted, err := crypt.Encrypt(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "encrypting change set")
+	}
+	return encrypted, nil
+}
+
+// fetch returns the change sets of the other devices.
+func fetch(ctx context.Context, store Store, device string) ([]*ChangeSet, error) {
+	names, err := store.List(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "listing change sets")
+	}
+	sort.Strings(names)
+
+	changeSets := make([]*ChangeSet, 0, len(names))
+	for _, name := range names {
+		if name == device+changeSetExt {
+			continue
+		}
+
+		encrypted, err := store.Get(ctx, name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "fetching change set %q", name)
+		}
+
+		cs, err := decodeChangeSet(encrypted)
+		if err != nil {
+			return nil, errors.Wrapf(err, "change set %q", name)
+		}
+		if cs.Device+changeSetExt != name {
+			return nil, errors.Errorf("change set %q belongs to device %q", name, cs.Device)
+		}
+		changeSets = append(changeSets, cs)
+	}
+
+	return changeSets, nil
+}
+
+func decodeChangeSet(encrypted []byte) (*ChangeSet, error) {
+	data, err := decrypt(encrypted)
+	if err != nil {
+		return nil, errors.Wrap(err, "the change set couldn't be decrypted, all the devices must use the same credentials")
+	}
+
+	cs := &ChangeSet{}
+	if err := json.Unmarshal(data, cs); err != nil {
+		return nil, errors.Wrap(err, "decoding change set")
+	}
+	return cs, nil
+}
+
+// scan returns the records stored in the database.
+func scan(tx *bolt.Tx) map[string]*Record {
+	records := make(map[string]*Record)
+	for _, name := range bucket.GetNames() {
+		b := tx.Bucket(name)
+		if b == nil {
+			continue
+		}
+
+		recordType := strings.TrimPrefix(string(name), "kure_")
+		_ = b.ForEach(func(k, v []byte) error {
+			r := &Record{
+				Type: recordType,
+				Name: string(dbutil.XorName(k)),
+				Hash: hash(v),
+				Data: bytes.Clone(v),
+			}
+			records[r.Key()] = r
+			return nil
+		})
+	}
+	return records
+}
+
+// apply writes the records modified to the database.
+func apply(tx *bolt.Tx, local map[string]*Record, changed map[string]struct{}) error {
+	for key := range changed {
+		recordType, name, _ := strings.Cut(key, ":")
+		b := tx.Bucket(bucketName(recordType))
+		if b == nil {
+			return errors.Errorf("bucket %q does not exist", recordType)
+		}
+
+		xorName := dbutil.XorName([]byte(name))
+		r, ok := local[key]
+		if !ok {
+			if err := b.Delete(xorName); err != nil {
+				return errors.Wrapf(err, "deleting %s", key)
+			}
+			continue
+		}
+
+		if err := b.Put(xorName, r.Data); err != nil {
+			return errors.Wrapf(err, "storing %s", key)
+		}
+	}
+	return nil
+}
+
+// sameContent returns whether the local and remote records have the same content, decrypting
+// them if necessary as the same record encrypted twice produces different outputs.
+func sameContent(current, local, remote *Record) (bool, error) {
+	if current.Deleted || remote.Deleted {
+		return current.Deleted == remote.Deleted, nil
+	}
+	if current.Hash == remote.Hash {
+		return true, nil
+	}
+
+	a, err := crypt.Decrypt(local.Data)
+	if err != nil {
+		return false, errors.Wrap(err, "decrypting local record")
+	}
+	b, err := decrypt(remote.Data)
+	if err != nil {
+		return false, errors.Wrap(err, "decrypting remote record")
+	}
+	return bytes.Equal(a, b), nil
+}
+
+// validate verifies that a record received from another device is valid and sets its hash.
+func validate(r *Record) error {
+	if bucketName(r.Type) == nil {
+		return errors.Errorf("invalid record type %q", r.Type)
+	}
+	if r.Name == "" || strings.ContainsRune(r.Name, '\x00') {
+		return errors.New("invalid record name")
+	}
+	if r.Deleted {
+		r.Hash = ""
+		r.Data = nil
+		return nil
+	}
+	if len(r.Data) == 0 {
+		return errors.New("missing record content")
+	}
+	// Do not rely on the hash received
+	r.Hash = hash(r.Data)
+	return nil
+}
+
+// loadState returns the synchronization state, creating it on the first synchronization.
+func loadState(tx *bolt.Tx) (*State, error) {
+	device, err := getDevice(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := decodeState(tx.Bucket(bucket.Sync.GetName()).Get(stateKey))
+	if err != nil {
+		return nil, err
+	}
+
+	s.Device = device
+	if host, err := os.Hostname(); err == nil {
+		s.Devices[device] = host
+	}
+	return s, nil
+}
+
+// getDevice returns the ID of this device, generating one if the database was never synchronized or
+// if it was copied from another device (or path), so two devices never publish the same change set.
+func getDevice(tx *bolt.Tx) (string, error) {
+	b, err := tx.CreateBucketIfNotExists(bucket.Sync.GetName())
+	if err != nil {
+		return "", errors.Wrap(err, "creating sync bucket")
+	}
+
+	location := []byte(deviceLocation(tx.DB()))
+	id := b.Get(deviceKey)
+	if id != nil {
+		stored := b.Get(locationKey)
+		if bytes.Equal(stored, location) {
+			return string(id), nil
+		}
+		if stored == nil {
+			// Databases synchronized before the location was stored
+			if err := b.Put(locationKey, location); err != nil {
+				return "", errors.Wrap(err, "storing device location")
+			}
+			return string(id), nil
+		}
+
+		// Keep the oldest ID if the location changed again before synchronizing, the newer ones
+		// didn't publish any change set
+		if b.Get(previousKey) == nil {
+			if err := b.Put(previousKey, id); err != nil {
+				return "", errors.Wrap(err, "storing previous device ID")
+			}
+		}
+	}
+
+	id = make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", errors.Wrap(err, "generating device ID")
+	}
+	device := hex.EncodeToString(id)
+	if err := b.Put(deviceKey, []byte(device)); err != nil {
+		return "", errors.Wrap(err, "storing device ID")
+	}
+	if err := b.Put(locationKey, location); err != nil {
+		return "", errors.Wrap(err, "storing device location")
+	}
+	return device, nil
+}
+
+// deviceLocation returns the host name and the absolute path of the database file.
+func deviceLocation(db *bolt.DB) string {
+	host, _ := os.Hostname()
+	path, err := filepath.Abs(db.Path())
+	if err != nil {
+		path = db.Path()
+	}
+	return host + "\x00" + path
+}
+
+func decodeState(encrypted []byte) (*State, error) {
+	s := &State{}
+	if encrypted != nil {
+		data, err := decrypt(encrypted)
+		if err != nil {
+			return nil, errors.Wrap(err, "decrypting sync state")
+		}
+		if err := json.Unmarshal(data, s); err != nil {
+			return nil, errors.Wrap(err, "decoding sync state")
+		}
+	}
+
+	if s.Devices == nil {
+		s.Devices = make(map[string]string)
+	}
+	if s.Records == nil {
+		s.Records = make(map[string]*Record)
+	}
+	if s.Conflicts == nil {
+		s.Conflicts = make(map[string]*Record)
+	}
+	return s, nil
+}
+
+func saveState(tx *bolt.Tx, s *State) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return errors.Wrap(err, "encoding sync state")
+	}
+
+	encrypted, err := crypt.Encrypt(data)
+	if err != nil {
+		return errors.Wrap(err, "encrypting sync state")
+	}
+
+	if err := tx.Bucket(bucket.Sync.GetName()).Put(stateKey, encrypted); err != nil {
+		return errors.Wrap(err, "storing sync state")
+	}
+	return nil
+}
+
+func deviceID(db *bolt.DB) (string, error) {
+	var id string
+	err := db.Update(func(tx *bolt.Tx) error {
+		var err error
+		id, err = getDevice(tx)
+		return err
+	})
+	return id, err
+}
+
+// bucketName returns the name of the bucket where the records of the type specified are stored.
+func bucketName(recordType string) []byte {
+	name := []byte("kure_" + recordType)
+	for _, n := range bucket.GetNames() {
+		if bytes.Equal(n, name) {
+			return n
+		}
+	}
+	return nil
+}
+
+// decrypt is like crypt.Decrypt but it doesn't panic with invalid input.
+func decrypt(encrypted []byte) ([]byte, error) {
+	// Nonce (12 bytes) + salt (32 bytes)
+	if len(encrypted) < 44 {
+		return nil, errors.New("invalid input: too short")
+	}
+	return crypt.Decrypt(encrypted)
+}
+
+func hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,302 @@
+package replica
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/db/bucket"
+	"github.com/GGP1/kure/db/entry"
+	"github.com/GGP1/kure/db/note"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestSync(t *testing.T) {
+	laptop := cmdutil.SetContext(t)
+	desktop := cmdutil.SetContext(t)
+	store, err := NewFolder(t.TempDir())
+	assert.NoError(t, err)
+
+	err = entry.Create(laptop, &pb.Entry{Name: "github", Password: "1", Expires: "Never"})
+	assert.NoError(t, err)
+	err = note.Create(laptop, &pb.Note{Name: "todo", Text: "sync"})
+	assert.NoError(t, err)
+
+	result := sync(t, laptop, store)
+	assert.Equal(t, &Result{Devices: 0, Pushed: 2}, result)
+
+	result = sync(t, desktop, store)
+	assert.Equal(t, &Result{Devices: 1, Pulled: 2}, result)
+	assertPassword(t, desktop, "github", "1")
+
+	// Modify a record on one device and delete another on the other one
+	err = entry.Update(desktop, "github", &pb.Entry{Name: "github", Password: "2", Expires: "Never"})
+	assert.NoError(t, err)
+	err = note.Remove(laptop, "todo")
+	assert.NoError(t, err)
+
+	sync(t, desktop, store)
+	result = sync(t, laptop, store)
+	assert.Equal(t, &Result{Devices: 1, Pushed: 1, Pulled: 1}, result)
+	assertPassword(t, laptop, "github", "2")
+
+	result = sync(t, desktop, store)
+	assert.Equal(t, &Result{Devices: 1, Pulled: 1}, result)
+	_, err = note.Get(desktop, "todo")
+	assert.Error(t, err, "The note should have been deleted")
+
+	// Nothing changed
+	result = sync(t, laptop, store)
+	assert.Equal(t, &Result{Devices: 1}, result)
+}
+
+func TestSyncConflict(t *testing.T) {
+	laptop := cmdutil.SetContext(t)
+	desktop := cmdutil.SetContext(t)
+	store, err := NewFolder(t.TempDir())
+	assert.NoError(t, err)
+
+	err = entry.Create(laptop, &pb.Entry{Name: "github", Password: "1", Expires: "Never"})
+	assert.NoError(t, err)
+	sync(t, laptop, store)
+	sync(t, desktop, store)
+
+	// Modify the record on both devices without synchronizing
+	err = entry.Update(laptop, "github", &pb.Entry{Name: "github", Password: "laptop", Expires: "Never"})
+	assert.NoError(t, err)
+	err = entry.Update(desktop, "github", &pb.Entry{Name: "github", Password: "desktop", Expires: "Never"})
+	assert.NoError(t, err)
+
+	sync(t, laptop, store)
+	result := sync(t, desktop, store)
+	assert.Equal(t, 1, result.Conflicts)
+	// The local version is kept until the conflict is resolved
+	assertPassword(t, desktop, "github", "desktop")
+
+	result = sync(t, laptop, store)
+	assert.Equal(t, 1, result.Conflicts)
+
+	conflicts, err := Conflicts(desktop)
+	assert.NoError(t, err)
+	assert.Len(t, conflicts, 1)
+	assert.Equal(t, "entry:github", conflicts[0].Remote.Key())
+	assert.Equal(t, "entry:github", conflicts[0].Local.Key())
+
+	err = Resolve(desktop, "entry:github", true)
+	assert.NoError(t, err)
+	assertPassword(t, desktop, "github", "laptop")
+
+	err = Resolve(desktop, "entry:github", true)
+	assert.Error(t, err, "The conflict was already resolved")
+
+	// The resolution supersedes both versions
+	result = sync(t, desktop, store)
+	assert.Zero(t, result.Conflicts)
+	result = sync(t, laptop, store)
+	assert.Zero(t, result.Conflicts)
+	assertPassword(t, laptop, "github", "laptop")
+
+	conflicts, err = Conflicts(laptop)
+	assert.NoError(t, err)
+	assert.Empty(t, conflicts)
+}
+
+func TestSyncSameContent(t *testing.T) {
+	laptop := cmdutil.SetContext(t)
+	desktop := cmdutil.SetContext(t)
+	store, err := NewFolder(t.TempDir())
+	assert.NoError(t, err)
+
+	// The same record created independently on both devices
+	e := &pb.Entry{Name: "github", Password: "1", Expires: "Never"}
+	assert.NoError(t, entry.Create(laptop, e))
+	assert.NoError(t, entry.Create(desktop, e))
+
+	sync(t, laptop, store)
+	result := sync(t, desktop, store)
+	assert.Zero(t, result.Conflicts)
+	assert.Zero(t, result.Pulled)
+}
+
+func TestSyncCopiedDatabase(t *testing.T) {
+	laptop := cmdutil.SetContext(t)
+	store, err := NewFolder(t.TempDir())
+	assert.NoError(t, err)
+
+	err = entry.Create(laptop, &pb.Entry{Name: "github", Password: "1", Expires: "Never"})
+	assert.NoError(t, err)
+	sync(t, laptop, store)
+
+	// Set up the desktop copying the laptop database
+	path := filepath.Join(t.TempDir(), "kure.db")
+	err = laptop.View(func(tx *bolt.Tx) error { return tx.CopyFile(path, 0o600) })
+	assert.NoError(t, err)
+	desktop, err := bolt.Open(path, 0o600, nil)
+	assert.NoError(t, err)
+	defer desktop.Close()
+
+	laptopID, err := deviceID(laptop)
+	assert.NoError(t, err)
+	desktopID, err := deviceID(desktop)
+	assert.NoError(t, err)
+	assert.NotEqual(t, laptopID, desktopID)
+
+	// Modify different records on each device
+	err = entry.Create(laptop, &pb.Entry{Name: "gitlab", Password: "laptop", Expires: "Never"})
+	assert.NoError(t, err)
+	err = entry.Create(desktop, &pb.Entry{Name: "bitbucket", Password: "desktop", Expires: "Never"})
+	assert.NoError(t, err)
+
+	sync(t, laptop, store)
+	result := sync(t, desktop, store)
+	assert.Equal(t, &Result{Devices: 1, Pushed: 1, Pulled: 1}, result)
+	result = sync(t, laptop, store)
+	assert.Equal(t, &Result{Devices: 1, Pulled: 1}, result)
+
+	for _, db := range []*bolt.DB{laptop, desktop} {
+		assertPassword(t, db, "github", "1")
+		assertPassword(t, db, "gitlab", "laptop")
+		assertPassword(t, db, "bitbucket", "desktop")
+	}
+
+	// The ID is kept in the following synchronizations
+	id, err := deviceID(desktop)
+	assert.NoError(t, err)
+	assert.Equal(t, desktopID, id)
+
+	// The laptop change set was published after the copy, so it isn't removed
+	names, err := store.List(context.Background())
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{laptopID + changeSetExt, desktopID + changeSetExt}, names)
+}
+
+func TestSyncMovedDatabase(t *testing.T) {
+	db := cmdutil.SetContext(t)
+	store, err := NewFolder(t.TempDir())
+	assert.NoError(t, err)
+
+	err = entry.Create(db, &pb.Entry{Name: "github", Password: "1", Expires: "Never"})
+	assert.NoError(t, err)
+	sync(t, db, store)
+	oldID, err := deviceID(db)
+	assert.NoError(t, err)
+
+	// Simulate a host name change
+	err = db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket.Sync.GetName()).Put(locationKey, []byte("old-host\x00/old/kure.db"))
+	})
+	assert.NoError(t, err)
+
+	sync(t, db, store)
+	newID, err := deviceID(db)
+	assert.NoError(t, err)
+	assert.NotEqual(t, oldID, newID)
+
+	names, err := store.List(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{newID + changeSetExt}, names)
+
+	err = db.View(func(tx *bolt.Tx) error {
+		assert.Nil(t, tx.Bucket(bucket.Sync.GetName()).Get(previousKey))
+		return nil
+	})
+	assert.NoError(t, err)
+
+	result := sync(t, db, store)
+	assert.Equal(t, &Result{}, result)
+	assertPassword(t, db, "github", "1")
+}
+
+func TestSyncInvalidChangeSet(t *testing.T) {
+	db := cmdutil.SetContext(t)
+	store, err := NewFolder(t.TempDir())
+	assert.NoError(t, err)
+
+	err = store.Put(context.Background(), "0123456789abcdef"+changeSetExt, []byte("invalid"))
+	assert.NoError(t, err)
+
+	_, err = Sync(context.Background(), db, store)
+	assert.Error(t, err)
+}
+
+func TestServer(t *testing.T) {
+	handler, err := Handler(t.TempDir(), "token")
+	assert.NoError(t, err)
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+
+	laptop := cmdutil.SetContext(t)
+	desktop := cmdutil.SetContext(t)
+	store, err := NewServer(ts.Client(), ts.URL, "token")
+	assert.NoError(t, err)
+
+	err = entry.Create(laptop, &pb.Entry{Name: "github", Password: "1", Expires: "Never"})
+	assert.NoError(t, err)
+
+	sync(t, laptop, store)
+	result := sync(t, desktop, store)
+	assert.Equal(t, 1, result.Pulled)
+	assertPassword(t, desktop, "github", "1")
+
+	laptopID, err := deviceID(laptop)
+	assert.NoError(t, err)
+	err = store.Delete(context.Background(), laptopID+changeSetExt)
+	assert.NoError(t, err)
+	names, err := store.List(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, names, 1)
+
+	unauthorized, err := NewServer(ts.Client(), ts.URL, "invalid")
+	assert.NoError(t, err)
+	_, err = Sync(context.Background(), desktop, unauthorized)
+	assert.Error(t, err)
+
+	res, err := ts.Client().Get(ts.URL + "/../../etc/passwd")
+	assert.NoError(t, err)
+	res.Body.Close()
+	assert.NotEqual(t, http.StatusOK, res.StatusCode)
+}
+
+func TestVersionCompare(t *testing.T) {
+	cases := []struct {
+		desc     string
+		a, b     Version
+		expected Ordering
+	}{
+		{desc: "Empty", a: Version{}, b: nil, expected: Equal},
+		{desc: "Equal", a: Version{"a": 1, "b": 2}, b: Version{"a": 1, "b": 2}, expected: Equal},
+		{desc: "Zero counter", a: Version{"a": 1, "b": 0}, b: Version{"a": 1}, expected: Equal},
+		{desc: "Before", a: Version{"a": 1}, b: Version{"a": 1, "b": 1}, expected: Before},
+		{desc: "After", a: Version{"a": 2, "b": 1}, b: Version{"a": 1, "b": 1}, expected: After},
+		{desc: "Concurrent", a: Version{"a": 2}, b: Version{"a": 1, "b": 1}, expected: Concurrent},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.a.Compare(tc.b))
+		})
+	}
+
+	merged := Version{"a": 2}.Merge(Version{"a": 1, "b": 1})
+	assert.Equal(t, Version{"a": 2, "b": 1}, merged)
+}
+
+func sync(t *testing.T, db *bolt.DB, store Store) *Result {
+	t.Helper()
+	result, err := Sync(context.Background(), db, store)
+	assert.NoError(t, err)
+	return result
+}
+
+func assertPassword(t *testing.T, db *bolt.DB, name, expected string) {
+	t.Helper()
+	e, err := entry.Get(db, name)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, e.Password)
+}
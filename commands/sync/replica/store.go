@@ -0,0 +1,208 @@
+package replica
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// changeSetExt is the extension of the change set files.
+const changeSetExt = ".changes"
+
+// validName matches the change set names, the device ID in hexadecimal followed by the extension.
+var validName = regexp.MustCompile(`^[0-9a-f]{16}\` + changeSetExt + `$`)
+
+// Store is where the devices exchange their change sets.
+type Store interface {
+	// List returns the names of the change sets available.
+	List(ctx context.Context) ([]string, error)
+	// Get returns the content of a change set.
+	Get(ctx context.Context, name string) ([]byte, error)
+	// Put creates or replaces a change set.
+	Put(ctx context.Context, name string, content []byte) error
+	// Delete removes a change set, it doesn't fail if it doesn't exist.
+	Delete(ctx context.Context, name string) error
+}
+
+type folder struct {
+	dir string
+}
+
+// NewFolder returns a store that keeps the change sets in a directory, usually one shared
+// between the devices using a network file system or a file synchronization service.
+func NewFolder(dir string) (Store, error) {
+	if dir == "" {
+		return nil, errors.New("invalid directory")
+	}
+	return &folder{dir: dir}, nil
+}
+
+func (f *folder) List(_ context.Context) ([]string, error) {
+	files, err := os.ReadDir(f.dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "reading directory")
+	}
+
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		if !file.IsDir() && validName.MatchString(file.Name()) {
+			names = append(names, file.Name())
+		}
+	}
+	return names, nil
+}
+
+func (f *folder) Get(_ context.Context, name string) ([]byte, error) {
+	if !validName.MatchString(name) {
+		return nil, errors.Errorf("invalid change set name %q", name)
+	}
+
+	content, err := os.ReadFile(filepath.Join(f.dir, name))
+	if err != nil {
+		return nil, errors.Wrap(err, "reading change set")
+	}
+	return content, nil
+}
+
+func (f *folder) Put(_ context.Context, name string, content []byte) error {
+	if !validName.MatchString(name) {
+		return errors.Errorf("invalid change set name %q", name)
+	}
+	return writeFile(f.dir, name, bytes.NewReader(content))
+}
+
+func (f *folder) Delete(_ context.Context, name string) error {
+	if !validName.MatchString(name) {
+		return errors.Errorf("invalid change set name %q", name)
+	}
+	if err := os.Remove(filepath.Join(f.dir, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return errors.Wrap(err, "removing change set")
+	}
+	return nil
+}
+
+type server struct {
+	client *http.Client
+	url    string
+	token  string
+}
+
+// NewServer returns a store that exchanges the change sets with a sync server.
+func NewServer(client *http.Client, url, token string) (Store, error) {
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return nil, errors.Errorf("invalid url %q", url)
+	}
+	return &server{client: client, url: strings.TrimSuffix(url, "/"), token: token}, nil
+}
+
+func (s *server) List(ctx context.Context) ([]string, error) {
+	body, err := s.do(ctx, http.MethodGet, "/", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	if err := json.Unmarshal(body, &names); err != nil {
+		return nil, errors.Wrap(err, "decoding change sets list")
+	}
+	return names, nil
+}
+
+func (s *server) Get(ctx context.Context, name string) ([]byte, error) {
+	if !validName.MatchString(name) {
+		return nil, errors.Errorf("invalid change set name %q", name)
+	}
+	return s.do(ctx, http.MethodGet, "/"+name, nil)
+}
+
+func (s *server) Put(ctx context.Context, name string, content []byte) error {
+	if !validName.MatchString(name) {
+		return errors.Errorf("invalid change set name %q", name)
+	}
+	_, err := s.do(ctx, http.MethodPut, "/"+name, content)
+	return err
+}
+
+func (s *server) Delete(ctx context.Context, name string) error {
+	if !validName.MatchString(name) {
+		return errors.Errorf("invalid change set name %q", name)
+	}
+	_, err := s.do(ctx, http.MethodDelete, "/"+name, nil)
+	return err
+}
+
+func (s *server) do(ctx context.Context, method, path string, content []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.url+path, bytes.NewReader(content))
+	if err != nil {
+		return nil, errors.Wrap(err, "creating request")
+	}
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "sending request")
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading response")
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, errors.Errorf("%s %s: %s", method, path, statusError(res.StatusCode, body))
+	}
+	return body, nil
+}
+
+func statusError(code int, body []byte) string {
+	msg := strings.TrimSpace(string(body))
+	if msg == "" {
+		return fmt.Sprintf("status %d", code)
+	}
+	return fmt.Sprintf("status %d: %s", code, msg)
+}
+
+// writeFile writes the content to a temporary file and renames it so readers never see
+// a change set half written.
+func writeFile(dir, name string, r io.Reader) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return errors.Wrap(err, "creating directory")
+	}
+
+	f, err := os.CreateTemp(dir, name+".*.tmp")
+	if err != nil {
+		return errors.Wrap(err, "creating temporary file")
+	}
+	tmp := f.Name()
+
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return errors.Wrap(err, "writing change set")
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return errors.Wrap(err, "writing change set")
+	}
+
+	if err := os.Rename(tmp, filepath.Join(dir, name)); err != nil {
+		os.Remove(tmp)
+		return errors.Wrap(err, "writing change set")
+	}
+	return nil
+}
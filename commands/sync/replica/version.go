@@ -0,0 +1,58 @@
+package replica
+
+// Ordering is the result of comparing two versions.
+type Ordering int
+
+// Versions ordering.
+const (
+	Equal Ordering = iota
+	Before
+	After
+	Concurrent
+)
+
+// Version is a version vector, it maps a device ID to the number of modifications made by it.
+type Version map[string]uint64
+
+// Compare returns the ordering of v relative to o.
+func (v Version) Compare(o Version) Ordering {
+	var less, greater bool
+	for id, n := range v {
+		switch m := o[id]; {
+		case n < m:
+			less = true
+		case n > m:
+			greater = true
+		}
+	}
+	for id, m := range o {
+		if _, ok := v[id]; !ok && m > 0 {
+			less = true
+		}
+	}
+
+	switch {
+	case less && greater:
+		return Concurrent
+	case less:
+		return Before
+	case greater:
+		return After
+	default:
+		return Equal
+	}
+}
+
+// Merge returns a new version containing the highest counter of each device.
+func (v Version) Merge(o Version) Version {
+	merged := make(Version, len(v))
+	for id, n := range v {
+		merged[id] = n
+	}
+	for id, m := range o {
+		if m > merged[id] {
+			merged[id] = m
+		}
+	}
+	return merged
+}
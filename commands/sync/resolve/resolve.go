@@ -0,0 +1,69 @@
+package resolve
+
+import (
+	"fmt"
+	"strings"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/commands/sync/replica"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+const example = `
+* Keep the local version
+kure sync resolve entry:github --keep local
+
+* Keep the version of the other device
+kure sync resolve note:ideas --keep remote`
+
+type resolveOptions struct {
+	keep string
+}
+
+// NewCmd returns a new command.
+func NewCmd(db *bolt.DB) *cobra.Command {
+	opts := resolveOptions{}
+	cmd := &cobra.Command{
+		Use:   "resolve <type>:<name>",
+		Short: "Resolve a synchronization conflict",
+		Long: `Resolve a synchronization conflict keeping the local version or the one of the other device.
+
+The resolution is published to the other devices in the next synchronization.`,
+		Example: example,
+		Args:    cobra.ExactArgs(1),
+		RunE:    runResolve(db, &opts),
+		PostRun: func(cmd *cobra.Command, args []string) {
+			// Reset variables (session)
+			opts = resolveOptions{}
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.keep, "keep", "", "version to keep: local or remote")
+
+	return cmd
+}
+
+func runResolve(db *bolt.DB, opts *resolveOptions) cmdutil.RunEFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		if opts.keep != "local" && opts.keep != "remote" {
+			return errors.Errorf("invalid keep value %q, use local or remote", opts.keep)
+		}
+
+		recordType, name, ok := strings.Cut(args[0], ":")
+		name = cmdutil.NormalizeName(name)
+		if !ok || recordType == "" || name == "" {
+			return errors.Errorf("invalid record %q, use the format <type>:<name>", args[0])
+		}
+
+		key := recordType + ":" + name
+		if err := replica.Resolve(db, key, opts.keep == "remote"); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Conflict on %s resolved keeping the %s version\n", key, opts.keep)
+		return nil
+	}
+}
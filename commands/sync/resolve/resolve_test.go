@@ -0,0 +1,66 @@
+package resolve
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/commands/sync/replica"
+	"github.com/GGP1/kure/db/entry"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolve(t *testing.T) {
+	laptop := cmdutil.SetContext(t)
+	desktop := cmdutil.SetContext(t)
+
+	store, err := replica.NewFolder(t.TempDir())
+	assert.NoError(t, err)
+	assert.NoError(t, entry.Create(laptop, &pb.Entry{Name: "github", Password: "laptop", Expires: "Never"}))
+	assert.NoError(t, entry.Create(desktop, &pb.Entry{Name: "github", Password: "desktop", Expires: "Never"}))
+
+	_, err = replica.Sync(context.Background(), laptop, store)
+	assert.NoError(t, err)
+	_, err = replica.Sync(context.Background(), desktop, store)
+	assert.NoError(t, err)
+
+	var out bytes.Buffer
+	cmd := NewCmd(desktop)
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"entry:GitHub", "--keep", "remote"})
+	assert.NoError(t, cmd.Execute())
+	assert.Equal(t, "Conflict on entry:github resolved keeping the remote version\n", out.String())
+
+	e, err := entry.Get(desktop, "github")
+	assert.NoError(t, err)
+	assert.Equal(t, "laptop", e.Password)
+}
+
+func TestResolveErrors(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	cases := []struct {
+		desc string
+		args []string
+	}{
+		{desc: "Invalid keep", args: []string{"entry:github", "--keep", "both"}},
+		{desc: "Invalid record", args: []string{"github", "--keep", "local"}},
+		{desc: "Missing name", args: []string{"entry:", "--keep", "local"}},
+		{desc: "No conflict", args: []string{"entry:github", "--keep", "local"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			cmd := NewCmd(db)
+			cmd.SetArgs(tc.args)
+			assert.Error(t, cmd.Execute())
+		})
+	}
+}
+
+func TestPostRun(t *testing.T) {
+	NewCmd(nil).PostRun(nil, nil)
+}
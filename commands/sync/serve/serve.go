@@ -0,0 +1,123 @@
+package serve
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/commands/sync/replica"
+	"github.com/GGP1/kure/config"
+	"github.com/GGP1/kure/db/entry"
+	"github.com/GGP1/kure/sig"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+const example = `
+* Serve the change sets stored in /srv/kure
+kure sync serve --dir /srv/kure
+
+* Listen on all interfaces using TLS
+kure sync serve --dir /srv/kure --addr :7000 --cert cert.pem --key key.pem`
+
+type serveOptions struct {
+	addr string
+	cert string
+	dir  string
+	key  string
+}
+
+// NewCmd returns a new command.
+func NewCmd(db *bolt.DB) *cobra.Command {
+	opts := serveOptions{
+		addr: "localhost:7000",
+	}
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Start a sync server",
+		Long: `Start a server where devices exchange their change sets.
+
+Clients authenticate using the password of the entry specified in "sync.credentials" as a bearer token. The change sets are encrypted by the devices, the server only stores them.`,
+		Example: example,
+		Args:    cobra.NoArgs,
+		RunE:    runServe(db, &opts),
+		PostRun: func(cmd *cobra.Command, args []string) {
+			// Reset variables (session)
+			opts = serveOptions{
+				addr: "localhost:7000",
+			}
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVar(&opts.addr, "addr", "localhost:7000", "address the server listens on")
+	f.StringVar(&opts.cert, "cert", "", "TLS certificate file")
+	f.StringVar(&opts.dir, "dir", "", "directory where change sets are stored")
+	f.StringVar(&opts.key, "key", "", "TLS private key file")
+
+	cmd.MarkFlagsRequiredTogether("cert", "key")
+
+	return cmd
+}
+
+func runServe(db *bolt.DB, opts *serveOptions) cmdutil.RunEFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		name := config.GetString("sync.credentials")
+		if name == "" {
+			return errors.New("sync.credentials must be set, the entry's password is used to authenticate clients")
+		}
+		e, err := entry.Get(db, cmdutil.NormalizeName(name))
+		if err != nil {
+			return errors.Wrap(err, "sync credentials")
+		}
+
+		handler, err := replica.Handler(opts.dir, e.Password)
+		if err != nil {
+			return err
+		}
+
+		listener, err := net.Listen("tcp", opts.addr)
+		if err != nil {
+			return errors.Wrap(err, "starting server")
+		}
+
+		server := &http.Server{
+			Handler:           handler,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		sig.Signal.AddCleanup(func() error {
+			// Do not exit after a signal as we are handling the shutdown
+			sig.Signal.KeepAlive()
+			fmt.Fprintln(cmd.OutOrStdout(), "Shutting down server...")
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			if err := server.Shutdown(ctx); err != nil {
+				return errors.Wrap(err, "graceful shutdown")
+			}
+			return nil
+		})
+
+		scheme := "http"
+		if opts.cert != "" {
+			scheme = "https"
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Serving change sets on %s://%s (Press Ctrl+C to quit)\n", scheme, listener.Addr())
+
+		if opts.cert != "" {
+			err = server.ServeTLS(listener, opts.cert, opts.key)
+		} else {
+			err = server.Serve(listener)
+		}
+		if err != nil && err != http.ErrServerClosed {
+			return errors.Wrap(err, "serving")
+		}
+		return nil
+	}
+}
@@ -0,0 +1,43 @@
+package serve
+
+import (
+	"testing"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/config"
+	"github.com/GGP1/kure/db/entry"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServeErrors(t *testing.T) {
+	db := cmdutil.SetContext(t)
+	assert.NoError(t, entry.Create(db, &pb.Entry{Name: "sync", Password: "token", Expires: "Never"}))
+
+	cases := []struct {
+		desc        string
+		credentials string
+		args        []string
+	}{
+		{desc: "Missing credentials", args: []string{"--dir", t.TempDir()}},
+		{desc: "Credentials do not exist", credentials: "non-existent", args: []string{"--dir", t.TempDir()}},
+		{desc: "Missing directory", credentials: "sync"},
+		{desc: "Invalid address", credentials: "sync", args: []string{"--dir", t.TempDir(), "--addr", "invalid:address:1"}},
+		{desc: "Certificate without key", credentials: "sync", args: []string{"--dir", t.TempDir(), "--cert", "cert.pem"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			config.Set("sync.credentials", tc.credentials)
+
+			cmd := NewCmd(db)
+			cmd.SetArgs(tc.args)
+			assert.Error(t, cmd.Execute())
+		})
+	}
+}
+
+func TestPostRun(t *testing.T) {
+	NewCmd(nil).PostRun(nil, nil)
+}
@@ -0,0 +1,122 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/commands/sync/conflicts"
+	"github.com/GGP1/kure/commands/sync/replica"
+	"github.com/GGP1/kure/commands/sync/resolve"
+	"github.com/GGP1/kure/commands/sync/serve"
+	"github.com/GGP1/kure/config"
+	"github.com/GGP1/kure/db/entry"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+// defaultTimeout is the maximum time a synchronization can take if the configuration doesn't specify one.
+const defaultTimeout = time.Minute
+
+const example = `
+* Synchronize the database with the other devices
+kure sync
+
+* List the conflicts pending to be resolved
+kure sync conflicts
+
+* Resolve a conflict keeping the version of the other device
+kure sync resolve entry:github --keep remote
+
+* Start a sync server
+kure sync serve --dir /srv/kure`
+
+// NewCmd returns a new command.
+func NewCmd(db *bolt.DB) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Synchronize the database with other devices",
+		Long: `Synchronize the database with other devices.
+
+Every device publishes its records, encrypted with the master key, in a store shared with the others: a folder or a sync server, configured under the "sync" key. The devices must use the same credentials.
+
+Synchronizing merges the changes made on the other devices record by record. If a record was modified on both sides since the last synchronization, the local version is kept and the other one is stored as a conflict, use the conflicts and resolve subcommands to handle them.`,
+		Example: example,
+		Args:    cobra.NoArgs,
+		RunE:    runSync(db),
+	}
+
+	cmd.AddCommand(
+		conflicts.NewCmd(db),
+		resolve.NewCmd(db),
+		serve.NewCmd(db),
+	)
+
+	return cmd
+}
+
+func runSync(db *bolt.DB) cmdutil.RunEFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		store, timeout, err := getStore(db)
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		result, err := replica.Sync(ctx, db, store)
+		if err != nil {
+			return err
+		}
+
+		out := cmd.OutOrStdout()
+		fmt.Fprintf(out, "Synchronized with %d devices: %d records pushed, %d pulled\n",
+			result.Devices, result.Pushed, result.Pulled)
+		if result.Conflicts > 0 {
+			fmt.Fprintf(out, "There are %d conflicts pending, list them with \"kure sync conflicts\"\n", result.Conflicts)
+		}
+		return nil
+	}
+}
+
+// getStore returns the store and timeout defined under the "sync" configuration key.
+func getStore(db *bolt.DB) (replica.Store, time.Duration, error) {
+	timeout := defaultTimeout
+	if config.IsSet("sync.timeout") {
+		timeout = config.GetDuration("sync.timeout")
+		if timeout <= 0 {
+			return nil, 0, errors.New("invalid sync timeout")
+		}
+	}
+
+	dir := config.GetString("sync.dir")
+	url := config.GetString("sync.url")
+	switch {
+	case dir != "" && url != "":
+		return nil, 0, errors.New("sync.dir and sync.url are mutually exclusive")
+
+	case dir != "":
+		store, err := replica.NewFolder(dir)
+		return store, timeout, err
+
+	case url != "":
+		var token string
+		if name := config.GetString("sync.credentials"); name != "" {
+			e, err := entry.Get(db, cmdutil.NormalizeName(name))
+			if err != nil {
+				return nil, 0, errors.Wrap(err, "sync credentials")
+			}
+			token = e.Password
+		}
+		store, err := replica.NewServer(http.DefaultClient, url, token)
+		return store, timeout, err
+
+	default:
+		return nil, 0, errors.New("sync is not configured, set sync.dir or sync.url")
+	}
+}
@@ -0,0 +1,80 @@
+package sync
+
+import (
+	"bytes"
+	"testing"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/config"
+	"github.com/GGP1/kure/db/entry"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSync(t *testing.T) {
+	laptop := cmdutil.SetContext(t)
+	desktop := cmdutil.SetContext(t)
+	config.Set("sync.dir", t.TempDir())
+
+	err := entry.Create(laptop, &pb.Entry{Name: "github", Password: "1", Expires: "Never"})
+	assert.NoError(t, err)
+
+	var out bytes.Buffer
+	cmd := NewCmd(laptop)
+	cmd.SetOut(&out)
+	cmd.SetArgs(nil)
+	assert.NoError(t, cmd.Execute())
+	assert.Equal(t, "Synchronized with 0 devices: 1 records pushed, 0 pulled\n", out.String())
+
+	out.Reset()
+	cmd = NewCmd(desktop)
+	cmd.SetOut(&out)
+	cmd.SetArgs(nil)
+	assert.NoError(t, cmd.Execute())
+	assert.Equal(t, "Synchronized with 1 devices: 0 records pushed, 1 pulled\n", out.String())
+
+	e, err := entry.Get(desktop, "github")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", e.Password)
+}
+
+func TestSyncErrors(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	cases := []struct {
+		desc   string
+		config map[string]interface{}
+	}{
+		{
+			desc:   "Not configured",
+			config: map[string]interface{}{},
+		},
+		{
+			desc:   "Dir and URL",
+			config: map[string]interface{}{"dir": t.TempDir(), "url": "http://localhost:7000"},
+		},
+		{
+			desc:   "Invalid URL",
+			config: map[string]interface{}{"url": "localhost:7000"},
+		},
+		{
+			desc:   "Missing credentials",
+			config: map[string]interface{}{"url": "http://localhost:7000", "credentials": "non-existent"},
+		},
+		{
+			desc:   "Invalid timeout",
+			config: map[string]interface{}{"dir": t.TempDir(), "timeout": "0s"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			config.Set("sync", tc.config)
+
+			cmd := NewCmd(db)
+			cmd.SetArgs(nil)
+			assert.Error(t, cmd.Execute())
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package access
+
+import (
+	"fmt"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/commands/team/invite"
+	"github.com/GGP1/kure/db/team"
+
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+const example = `
+* Grant access to the records under "shared/" and "ops/"
+kure team access bob --prefix shared/,ops/
+
+* Make a member admin
+kure team access bob --admin`
+
+type accessOptions struct {
+	prefixes []string
+	admin    bool
+}
+
+// NewCmd returns a new command.
+func NewCmd(db *bolt.DB) *cobra.Command {
+	opts := accessOptions{}
+	cmd := &cobra.Command{
+		Use:   "access <member>",
+		Short: "Set the records a member can access",
+		Long: `Set the prefixes of the record names a member can access, replacing the previous ones. Only admins can change the access list.
+
+Every member holds the vault key, the access list is enforced by kure. Remove a member to revoke its access to the records.`,
+		Example: example,
+		Args:    cobra.ExactArgs(1),
+		RunE:    runAccess(db, &opts),
+		PostRun: func(cmd *cobra.Command, args []string) {
+			// Reset variables (session)
+			opts = accessOptions{}
+		},
+	}
+
+	f := cmd.Flags()
+	f.BoolVarP(&opts.admin, "admin", "a", false, "grant access to all the records and the members management")
+	f.StringSliceVarP(&opts.prefixes, "prefix", "p", nil, "prefix of the record names the member can access")
+
+	return cmd
+}
+
+func runAccess(db *bolt.DB, opts *accessOptions) cmdutil.RunEFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		if err := invite.RequireAdmin(db); err != nil {
+			return err
+		}
+
+		prefixes, err := invite.ParsePrefixes(opts.admin, opts.prefixes)
+		if err != nil {
+			return err
+		}
+
+		name := args[0]
+		if err := team.SetAccess(db, name, opts.admin, prefixes); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Access of %q updated\n", name)
+		return nil
+	}
+}
@@ -0,0 +1,153 @@
+package create
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"time"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/commands/team/key"
+	"github.com/GGP1/kure/config"
+	authDB "github.com/GGP1/kure/db/auth"
+	"github.com/GGP1/kure/db/team"
+	"github.com/GGP1/kure/vault"
+
+	"github.com/awnumar/memguard"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+const example = `
+* Create a team vault in a shared folder
+kure team create acme --path /mnt/shared/acme.db
+
+* Create a team vault using a custom member name
+kure team create acme --member alice@acme.com`
+
+type createOptions struct {
+	member string
+	path   string
+}
+
+// NewCmd returns a new command.
+func NewCmd(db *bolt.DB) *cobra.Command {
+	opts := createOptions{}
+	cmd := &cobra.Command{
+		Use:   "create <name>",
+		Short: "Create a team vault",
+		Long: `Create a team vault with you as its admin.
+
+The vault key is generated randomly and sealed with your member public key. The database is created next to the default one, named after the vault, unless a path is specified. The vault is saved in the configuration file under "vaults.<name>".
+
+Your member name defaults to the current user name.`,
+		Example: example,
+		Args:    cobra.ExactArgs(1),
+		RunE:    runCreate(db, &opts),
+		PostRun: func(cmd *cobra.Command, args []string) {
+			// Reset variables (session)
+			opts = createOptions{}
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVarP(&opts.member, "member", "m", "", "your member name")
+	f.StringVarP(&opts.path, "path", "p", "", "database path")
+
+	return cmd
+}
+
+func runCreate(db *bolt.DB, opts *createOptions) cmdutil.RunEFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if err := vault.ValidateName(name); err != nil {
+			return err
+		}
+		if _, err := vault.Get(name); err == nil {
+			return errors.Errorf("vault %q already exists", name)
+		}
+
+		member := opts.member
+		if member == "" {
+			u, err := user.Current()
+			if err != nil {
+				return errors.Wrap(err, "getting user name, use --member")
+			}
+			member = u.Username
+		}
+
+		path := opts.path
+		if path == "" {
+			def, _ := vault.Get(vault.DefaultName)
+			path = filepath.Join(filepath.Dir(def.Path), name+".db")
+		}
+		path = filepath.Clean(path)
+		if _, err := os.Stat(path); err == nil {
+			return errors.Errorf("%q already exists", path)
+		}
+
+		publicKey, err := key.GetPublicKey(db)
+		if err != nil {
+			return err
+		}
+
+		if err := createTeam(path, team.Member{Name: member, PublicKey: publicKey}); err != nil {
+			return err
+		}
+
+		if err := vault.Create(name, path, ""); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Team vault %q created, use it with \"kure --vault %s\"\n", name, name)
+		return nil
+	}
+}
+
+// createTeam creates the database, registers a random vault key and adds the admin. The current
+// credentials are restored afterwards.
+func createTeam(path string, admin team.Member) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return errors.Wrap(err, "creating the directory")
+	}
+
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 200 * time.Millisecond})
+	if err != nil {
+		return errors.Wrap(err, "creating the database")
+	}
+
+	vaultKey := make([]byte, 32)
+	nameKey := make([]byte, 32)
+	_, _ = rand.Read(vaultKey)
+	_, _ = rand.Read(nameKey)
+
+	prevAuth := config.Get("auth")
+	config.Set("auth", map[string]interface{}{
+		// The enclave wipes the buffer passed
+		"password":   memguard.NewEnclave(bytes.Clone(vaultKey)),
+		"iterations": team.Argon2.Iterations,
+		"memory":     team.Argon2.Memory,
+		"threads":    team.Argon2.Threads,
+		"key":        nameKey,
+	})
+
+	err = authDB.Register(db, nameKey, authDB.Params{Argon2: team.Argon2, Team: true})
+	if err == nil {
+		err = team.Init(db, vaultKey, admin)
+	}
+
+	config.Set("auth", prevAuth)
+	if cErr := db.Close(); err == nil {
+		err = cErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return err
+	}
+
+	return nil
+}
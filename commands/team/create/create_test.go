@@ -0,0 +1,92 @@
+package create
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/GGP1/kure/auth"
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/config"
+	authDB "github.com/GGP1/kure/db/auth"
+	"github.com/GGP1/kure/db/team"
+
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestCreate(t *testing.T) {
+	db := cmdutil.SetContext(t)
+	dir := setConfig(t)
+	personal := config.Get("auth")
+
+	var out bytes.Buffer
+	cmd := NewCmd(db)
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"acme", "--member", "alice"})
+	assert.NoError(t, cmd.Execute())
+	assert.Equal(t, "Team vault \"acme\" created, use it with \"kure --vault acme\"\n", out.String())
+	assert.Equal(t, personal, config.Get("auth"), "Credentials must be restored")
+
+	path := filepath.Join(dir, "acme.db")
+	assert.Equal(t, path, config.GetString("vaults.acme.path"))
+
+	teamDB, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	assert.NoError(t, err)
+	defer teamDB.Close()
+
+	memberKey, err := authDB.GetMemberKey(db)
+	assert.NoError(t, err)
+	restore, err := auth.SwitchTeam(teamDB, memberKey)
+	assert.NoError(t, err)
+	defer restore()
+
+	member, err := team.Current(teamDB)
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", member.Name)
+	assert.True(t, member.Admin)
+}
+
+func TestCreateErrors(t *testing.T) {
+	db := cmdutil.SetContext(t)
+	dir := setConfig(t)
+	existing := filepath.Join(dir, "existing.db")
+	assert.NoError(t, os.WriteFile(existing, nil, 0o600))
+	config.Set("vaults.work.path", filepath.Join(dir, "work.db"))
+
+	cases := []struct {
+		desc string
+		args []string
+	}{
+		{desc: "Reserved name", args: []string{"default"}},
+		{desc: "Vault exists", args: []string{"work"}},
+		{desc: "File exists", args: []string{"acme", "--path", existing}},
+		{desc: "Invalid member", args: []string{"acme", "--member", "a l i c e"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			cmd := NewCmd(db)
+			cmd.SetArgs(tc.args)
+			assert.Error(t, cmd.Execute())
+		})
+	}
+
+	_, err := os.Stat(filepath.Join(dir, "acme.db"))
+	assert.ErrorIs(t, err, os.ErrNotExist, "The database must be removed on failure")
+}
+
+func setConfig(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "kure.yaml")
+	content := "database:\n  path: " + filepath.Join(dir, "kure.db") + "\n"
+	assert.NoError(t, os.WriteFile(filename, []byte(content), 0o600))
+
+	config.SetFilename(filename)
+	config.Set("database.path", filepath.Join(dir, "kure.db"))
+	return dir
+}
@@ -0,0 +1,117 @@
+package invite
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/db/team"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+const example = `
+* Invite a member granting access to the records under "shared/"
+kure team invite bob HXhVh1Gy5nWNqg4eBIyMJvFTLMrZDumqpZMLHMEEmAw= --prefix shared/
+
+* Invite an admin
+kure team invite carol 8pT3KFeLpjyRrXKpHPhQ8tdSzmHXHpA0JsYOZZx7mWg= --admin`
+
+type inviteOptions struct {
+	prefixes []string
+	admin    bool
+}
+
+// NewCmd returns a new command.
+func NewCmd(db *bolt.DB) *cobra.Command {
+	opts := inviteOptions{}
+	cmd := &cobra.Command{
+		Use:   "invite <member> <public-key>",
+		Short: "Invite a member to the team vault",
+		Long: `Invite a member to the team vault in use, only admins can invite members.
+
+The vault key is sealed with the member public key, obtained with "kure team key". Members can access the records whose names start with one of the prefixes granted, admins can access all of them.`,
+		Example: example,
+		Args:    cobra.ExactArgs(2),
+		RunE:    runInvite(db, &opts),
+		PostRun: func(cmd *cobra.Command, args []string) {
+			// Reset variables (session)
+			opts = inviteOptions{}
+		},
+	}
+
+	f := cmd.Flags()
+	f.BoolVarP(&opts.admin, "admin", "a", false, "grant access to all the records and the members management")
+	f.StringSliceVarP(&opts.prefixes, "prefix", "p", nil, "prefix of the record names the member can access")
+
+	return cmd
+}
+
+func runInvite(db *bolt.DB, opts *inviteOptions) cmdutil.RunEFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		if err := RequireAdmin(db); err != nil {
+			return err
+		}
+
+		prefixes, err := ParsePrefixes(opts.admin, opts.prefixes)
+		if err != nil {
+			return err
+		}
+
+		publicKey, err := base64.StdEncoding.DecodeString(strings.TrimSpace(args[1]))
+		if err != nil {
+			return errors.Wrap(err, "invalid public key")
+		}
+
+		member := team.Member{
+			Name:      args[0],
+			PublicKey: publicKey,
+			Prefixes:  prefixes,
+			Admin:     opts.admin,
+		}
+		if err := team.Add(db, member); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Member %q invited\n", member.Name)
+		return nil
+	}
+}
+
+// ParsePrefixes normalizes the prefixes, members that aren't admins must be granted at least one.
+func ParsePrefixes(admin bool, prefixes []string) ([]string, error) {
+	if admin {
+		if len(prefixes) > 0 {
+			return nil, errors.New("admins can access all the records, prefixes can't be specified")
+		}
+		return nil, nil
+	}
+
+	result := make([]string, 0, len(prefixes))
+	for _, p := range prefixes {
+		p = cmdutil.NormalizeName(p, true)
+		if p == "" {
+			return nil, errors.New("prefixes must not be empty")
+		}
+		result = append(result, p)
+	}
+	if len(result) == 0 {
+		return nil, errors.New("grant access to at least one prefix or use --admin")
+	}
+	return result, nil
+}
+
+// RequireAdmin returns an error if the member in use isn't an admin of the team vault.
+func RequireAdmin(db *bolt.DB) error {
+	member, err := team.Current(db)
+	if err != nil {
+		return err
+	}
+	if !member.Admin {
+		return errors.New("only admins can manage the members of the team vault")
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package key
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/crypt"
+	authDB "github.com/GGP1/kure/db/auth"
+	"github.com/GGP1/kure/vault"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+const example = `
+kure team key`
+
+// NewCmd returns a new command.
+func NewCmd(db *bolt.DB) *cobra.Command {
+	return &cobra.Command{
+		Use:   "key",
+		Short: "Display your member public key",
+		Long: `Display the public key used to invite you to team vaults and to seal the bundles shared with you, share it with a team admin or the sender.
+
+The key pair is generated the first time, the private key is stored in the default vault encrypted with its master password.`,
+		Example: example,
+		Args:    cobra.NoArgs,
+		RunE:    runKey(db),
+	}
+}
+
+func runKey(db *bolt.DB) cmdutil.RunEFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		publicKey, err := GetPublicKey(db)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(cmd.OutOrStdout(), base64.StdEncoding.EncodeToString(publicKey))
+		return nil
+	}
+}
+
+// GetPublicKey returns the member public key, the key pair is generated and stored if it doesn't exist.
+func GetPublicKey(db *bolt.DB) ([]byte, error) {
+	if vault.Active() != vault.DefaultName {
+		return nil, errors.New("member keys are stored in the default vault, use \"--vault default\"")
+	}
+
+	privateKey, err := authDB.GetMemberKey(db)
+	if err != nil {
+		return nil, err
+	}
+
+	if privateKey == nil {
+		var publicKey []byte
+		privateKey, publicKey, err = crypt.GenerateKeyPair()
+		if err != nil {
+			return nil, err
+		}
+		if err := authDB.SetMemberKey(db, privateKey); err != nil {
+			return nil, err
+		}
+		return publicKey, nil
+	}
+
+	return crypt.PublicKey(privateKey)
+}
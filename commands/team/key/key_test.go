@@ -0,0 +1,49 @@
+package key
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/config"
+	"github.com/GGP1/kure/crypt"
+	authDB "github.com/GGP1/kure/db/auth"
+	"github.com/GGP1/kure/vault"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKey(t *testing.T) {
+	db := cmdutil.SetContext(t)
+
+	var out bytes.Buffer
+	cmd := NewCmd(db)
+	cmd.SetOut(&out)
+	assert.NoError(t, cmd.Execute())
+
+	publicKey, err := base64.StdEncoding.DecodeString(strings.TrimSpace(out.String()))
+	assert.NoError(t, err)
+
+	privateKey, err := authDB.GetMemberKey(db)
+	assert.NoError(t, err)
+	expected, err := crypt.PublicKey(privateKey)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, publicKey)
+
+	// The key pair is generated only once
+	got, err := GetPublicKey(db)
+	assert.NoError(t, err)
+	assert.Equal(t, publicKey, got)
+}
+
+func TestKeyNotDefaultVault(t *testing.T) {
+	db := cmdutil.SetContext(t)
+	config.Set("vaults.work.path", "work.db")
+	assert.NoError(t, vault.Select("work"))
+	t.Cleanup(func() { vault.Select(vault.DefaultName) })
+
+	cmd := NewCmd(db)
+	assert.Error(t, cmd.Execute())
+}
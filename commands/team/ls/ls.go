@@ -0,0 +1,58 @@
+package ls
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/db/team"
+
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+const example = `
+kure team ls`
+
+// NewCmd returns a new command.
+func NewCmd(db *bolt.DB) *cobra.Command {
+	return &cobra.Command{
+		Use:     "ls",
+		Short:   "List the members of the team vault",
+		Long:    `List the members of the team vault in use and the records they can access, you are marked with an asterisk.`,
+		Example: example,
+		Args:    cobra.NoArgs,
+		RunE:    runLs(db),
+	}
+}
+
+func runLs(db *bolt.DB) cmdutil.RunEFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		current, err := team.Current(db)
+		if err != nil {
+			return err
+		}
+
+		members, err := team.List(db)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', 0)
+		fmt.Fprintln(w, "  Member\tAccess")
+		for _, m := range members {
+			mark := " "
+			if m.Name == current.Name {
+				mark = "*"
+			}
+
+			access := strings.Join(m.Prefixes, ", ")
+			if m.Admin {
+				access = "admin"
+			}
+			fmt.Fprintf(w, "%s %s\t%s\n", mark, m.Name, access)
+		}
+		return w.Flush()
+	}
+}
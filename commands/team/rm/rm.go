@@ -0,0 +1,57 @@
+package rm
+
+import (
+	"fmt"
+	"io"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/commands/team/invite"
+	"github.com/GGP1/kure/db/team"
+	"github.com/GGP1/kure/terminal"
+
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+const example = `
+kure team rm bob`
+
+// NewCmd returns a new command.
+func NewCmd(db *bolt.DB, r io.Reader) *cobra.Command {
+	return &cobra.Command{
+		Use:   "rm <member>",
+		Short: "Remove a member from the team vault",
+		Long: `Remove a member from the team vault in use, only admins can remove members.
+
+The vault key is rotated: the records are encrypted with a new one, sealed for the remaining members, so the member removed can't decrypt them anymore. Other members with an open session must restart it.
+
+Copies of the database made before the removal, like backups, are still readable by the member removed.`,
+		Example: example,
+		Args:    cobra.ExactArgs(1),
+		RunE:    runRm(db, r),
+	}
+}
+
+func runRm(db *bolt.DB, r io.Reader) cmdutil.RunEFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		if err := invite.RequireAdmin(db); err != nil {
+			return err
+		}
+
+		name := args[0]
+		if _, err := team.Get(db, name); err != nil {
+			return err
+		}
+
+		if !terminal.Confirm(r, fmt.Sprintf("Remove %q and rotate the vault key?", name)) {
+			return nil
+		}
+
+		if err := team.Remove(db, name); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Member %q removed and vault key rotated\n", name)
+		return nil
+	}
+}
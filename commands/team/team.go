@@ -0,0 +1,43 @@
+package team
+
+import (
+	"io"
+
+	"github.com/GGP1/kure/commands/team/access"
+	"github.com/GGP1/kure/commands/team/create"
+	"github.com/GGP1/kure/commands/team/invite"
+	"github.com/GGP1/kure/commands/team/key"
+	"github.com/GGP1/kure/commands/team/ls"
+	"github.com/GGP1/kure/commands/team/rm"
+
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+const example = `
+kure team (access|create|invite|key|ls|rm)`
+
+// NewCmd returns a new command.
+func NewCmd(db *bolt.DB, r io.Reader) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "team",
+		Short: "Team vault operations",
+		Long: `Team vault operations.
+
+Team vaults are shared between multiple members. Their records are encrypted with a random vault key, sealed with the X25519 public key of every member, so each member unlocks the vault with the master password of their default vault, where their private key is stored.
+
+The database file is the only thing that has to be shared, it can be placed in a shared folder. An access list records the prefixes of the record names each member can access, admins can access all of them and manage the members.`,
+		Example: example,
+	}
+
+	cmd.AddCommand(
+		access.NewCmd(db),
+		create.NewCmd(db),
+		invite.NewCmd(db),
+		key.NewCmd(db),
+		ls.NewCmd(db),
+		rm.NewCmd(db, r),
+	)
+
+	return cmd
+}
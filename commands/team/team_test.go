@@ -0,0 +1,138 @@
+package team
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GGP1/kure/auth"
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/config"
+	"github.com/GGP1/kure/crypt"
+	authDB "github.com/GGP1/kure/db/auth"
+	"github.com/GGP1/kure/db/entry"
+	"github.com/GGP1/kure/db/team"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestMembers(t *testing.T) {
+	teamDB, _ := setContext(t)
+	assert.NoError(t, entry.Create(teamDB,
+		&pb.Entry{Name: "shared/github", Expires: "Never"},
+		&pb.Entry{Name: "ops/aws", Expires: "Never"},
+	))
+
+	bobKey, bobPub, err := crypt.GenerateKeyPair()
+	assert.NoError(t, err)
+
+	out, err := execute(teamDB, "", "invite", "bob", base64.StdEncoding.EncodeToString(bobPub), "--prefix", "Shared/")
+	assert.NoError(t, err)
+	assert.Equal(t, "Member \"bob\" invited\n", out)
+
+	out, err = execute(teamDB, "", "ls")
+	assert.NoError(t, err)
+	assert.Equal(t, "  Member   Access\n* alice    admin\n  bob      shared/\n", out)
+
+	// Bob can access only the records under "shared/"
+	restore, err := auth.SwitchTeam(teamDB, bobKey)
+	assert.NoError(t, err)
+	names, err := entry.ListNames(teamDB)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"shared/github"}, names)
+	_, err = execute(teamDB, "", "invite", "carol", base64.StdEncoding.EncodeToString(bobPub), "--admin")
+	assert.Error(t, err, "Not an admin")
+	restore()
+
+	out, err = execute(teamDB, "", "access", "bob", "--prefix", "shared/,ops/")
+	assert.NoError(t, err)
+	assert.Equal(t, "Access of \"bob\" updated\n", out)
+	bob, err := team.Get(teamDB, "bob")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"shared/", "ops/"}, bob.Prefixes)
+
+	out, err = execute(teamDB, "y\n", "rm", "bob")
+	assert.NoError(t, err)
+	assert.Equal(t, "Member \"bob\" removed and vault key rotated\n", out)
+
+	_, err = auth.SwitchTeam(teamDB, bobKey)
+	assert.Error(t, err)
+	names, err = entry.ListNames(teamDB)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ops/aws", "shared/github"}, names)
+}
+
+func TestMembersErrors(t *testing.T) {
+	teamDB, personal := setContext(t)
+	_, pub, err := crypt.GenerateKeyPair()
+	assert.NoError(t, err)
+	publicKey := base64.StdEncoding.EncodeToString(pub)
+
+	cases := []struct {
+		desc string
+		args []string
+	}{
+		{desc: "No prefixes", args: []string{"invite", "bob", publicKey}},
+		{desc: "Admin with prefixes", args: []string{"invite", "bob", publicKey, "--admin", "--prefix", "shared/"}},
+		{desc: "Invalid public key", args: []string{"invite", "bob", "invalid", "--admin"}},
+		{desc: "Empty prefix", args: []string{"access", "alice", "--prefix", "/"}},
+		{desc: "Last admin", args: []string{"access", "alice", "--prefix", "shared/"}},
+		{desc: "Remove missing member", args: []string{"rm", "bob"}},
+		{desc: "Remove last admin", args: []string{"rm", "alice"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			_, err := execute(teamDB, "y\n", tc.args...)
+			assert.Error(t, err)
+		})
+	}
+
+	// Commands run in a vault that isn't a team vault
+	config.Set("auth.member", nil)
+	_, err = execute(personal, "", "ls")
+	assert.Error(t, err)
+}
+
+// setContext creates a team vault with "alice" as admin and sets its credentials to the configuration.
+func setContext(t *testing.T) (*bolt.DB, *bolt.DB) {
+	t.Helper()
+
+	personal := cmdutil.SetContext(t)
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "kure.yaml")
+	assert.NoError(t, os.WriteFile(filename, []byte("editor: vim\n"), 0o600))
+	config.SetFilename(filename)
+	config.Set("database.path", filepath.Join(dir, "kure.db"))
+
+	path := filepath.Join(dir, "acme.db")
+	_, err := execute(personal, "", "create", "acme", "--member", "alice", "--path", path)
+	assert.NoError(t, err)
+
+	teamDB, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	assert.NoError(t, err)
+	t.Cleanup(func() { teamDB.Close() })
+
+	memberKey, err := authDB.GetMemberKey(personal)
+	assert.NoError(t, err)
+	_, err = auth.SwitchTeam(teamDB, memberKey)
+	assert.NoError(t, err)
+
+	return teamDB, personal
+}
+
+// execute runs the command and returns its output.
+func execute(db *bolt.DB, input string, args ...string) (string, error) {
+	var out bytes.Buffer
+	cmd := NewCmd(db, strings.NewReader(input))
+	cmd.SetOut(&out)
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return out.String(), err
+}
@@ -10,12 +10,18 @@ import (
 	"time"
 
 	"github.com/GGP1/kure/config"
+	dbutil "github.com/GGP1/kure/db"
+	"github.com/GGP1/kure/db/bank"
 	"github.com/GGP1/kure/db/bucket"
 	"github.com/GGP1/kure/db/card"
 	"github.com/GGP1/kure/db/entry"
 	"github.com/GGP1/kure/db/file"
+	"github.com/GGP1/kure/db/identity"
+	"github.com/GGP1/kure/db/note"
+	"github.com/GGP1/kure/db/sshkey"
 	"github.com/GGP1/kure/db/totp"
 	"github.com/GGP1/kure/orderedmap"
+	"github.com/GGP1/kure/pb"
 	"github.com/GGP1/kure/sig"
 	"github.com/GGP1/kure/terminal"
 
@@ -36,6 +42,18 @@ var (
 	ErrInvalidPath = errors.New("invalid path")
 )
 
+// RecordTypes maps the record type names to a function returning an empty record.
+var RecordTypes = map[string]func() dbutil.Record{
+	"bank":     func() dbutil.Record { return &pb.BankAccount{} },
+	"card":     func() dbutil.Record { return &pb.Card{} },
+	"entry":    func() dbutil.Record { return &pb.Entry{} },
+	"file":     func() dbutil.Record { return &pb.File{} },
+	"identity": func() dbutil.Record { return &pb.Identity{} },
+	"note":     func() dbutil.Record { return &pb.Note{} },
+	"ssh":      func() dbutil.Record { return &pb.SSHKey{} },
+	"totp":     func() dbutil.Record { return &pb.TOTP{} },
+}
+
 const (
 	// Card object
 	Card object = iota
@@ -45,6 +63,14 @@ const (
 	File
 	// TOTP object
 	TOTP
+	// Bank account object
+	Bank
+	// Identity object
+	Identity
+	// Note object
+	Note
+	// SSH key object
+	SSHKey
 
 	// Box
 	hBar       = "─"
@@ -324,6 +350,15 @@ func MustNotExist(db *bolt.DB, obj object, allowDir ...bool) cobra.PositionalArg
 	}
 }
 
+// Mask replaces all the characters of s with bullets but the last n ones.
+func Mask(s string, n int) string {
+	runes := []rune(s)
+	for i := 0; i < len(runes)-n; i++ {
+		runes[i] = '•'
+	}
+	return string(runes)
+}
+
 // NormalizeName sanitizes the user input name.
 func NormalizeName(name string, allowDir ...bool) string {
 	if name == "" {
@@ -391,18 +426,37 @@ func SetContext(t testing.TB) *bolt.DB {
 	return db
 }
 
-// SupportedManagers validates if the password manager used to import/export records is supported.
+// SupportedManagers validates if the password manager or format used to export records is supported.
 func SupportedManagers() cobra.PositionalArgs {
 	return func(_ *cobra.Command, args []string) error {
 		manager := strings.Join(args, " ")
 
 		switch strings.ToLower(manager) {
-		case "1password", "bitwarden", "keepass", "keepassx", "keepassxc", "lastpass":
+		case "1password", "bitwarden", "keepass", "keepassx", "keepassxc", "kure", "lastpass", "json", "ndjson":
+
+		default:
+			return errors.Errorf(`%q is not supported
+
+Supported managers: 1Password, Bitwarden, Keepass/X/XC, Kure, Lastpass
+Supported formats: JSON, NDJSON`, manager)
+		}
+		return nil
+	}
+}
+
+// SupportedImportSources validates if the password manager or browser used to import records is supported.
+func SupportedImportSources() cobra.PositionalArgs {
+	return func(_ *cobra.Command, args []string) error {
+		source := strings.Join(args, " ")
+
+		switch strings.ToLower(source) {
+		case "1password", "bitwarden", "keepass", "keepassx", "keepassxc", "kure", "lastpass",
+			"chrome", "firefox", "safari", "dashlane", "enpass", "pass":
 
 		default:
 			return errors.Errorf(`%q is not supported
 
-Supported managers: 1Password, Bitwarden, Keepass/X/XC, Lastpass`, manager)
+Supported sources: 1Password, Bitwarden, Chrome, Dashlane, Enpass, Firefox, Keepass/X/XC, Kure, Lastpass, pass, Safari`, source)
 		}
 		return nil
 	}
@@ -587,6 +641,22 @@ func listNames(db *bolt.DB, obj object) ([]string, string, error) {
 	case TOTP:
 		objType = "TOTP"
 		records, err = totp.ListNames(db)
+
+	case Bank:
+		objType = "bank account"
+		records, err = bank.ListNames(db)
+
+	case Identity:
+		objType = "identity"
+		records, err = identity.ListNames(db)
+
+	case Note:
+		objType = "note"
+		records, err = note.ListNames(db)
+
+	case SSHKey:
+		objType = "SSH key"
+		records, err = sshkey.ListNames(db)
 	}
 	if err != nil {
 		return nil, "", err
@@ -349,6 +349,26 @@ func TestMustNotExist(t *testing.T) {
 	})
 }
 
+func TestMask(t *testing.T) {
+	cases := []struct {
+		desc     string
+		s        string
+		n        int
+		expected string
+	}{
+		{desc: "Last four", s: "31926819", n: 4, expected: "••••6819"},
+		{desc: "All", s: "1234", n: 0, expected: "••••"},
+		{desc: "Shorter than n", s: "12", n: 4, expected: "12"},
+		{desc: "Empty", s: "", n: 4, expected: ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Mask(tc.s, tc.n))
+		})
+	}
+}
+
 func TestNormalizeName(t *testing.T) {
 	cases := []struct {
 		desc     string
@@ -418,7 +438,7 @@ func TestSelectEditor(t *testing.T) {
 
 func TestSupportedManagers(t *testing.T) {
 	t.Run("Supported", func(t *testing.T) {
-		list := []string{"1password", "bitwarden", "keepass", "keepassx", "keepassxc", "lastpass"}
+		list := []string{"1password", "bitwarden", "keepass", "keepassx", "keepassxc", "kure", "lastpass", "json", "ndjson"}
 		for _, name := range list {
 			err := SupportedManagers()(nil, []string{name})
 			assert.NoError(t, err)
@@ -434,6 +454,25 @@ func TestSupportedManagers(t *testing.T) {
 	})
 }
 
+func TestSupportedImportSources(t *testing.T) {
+	t.Run("Supported", func(t *testing.T) {
+		list := []string{"1password", "bitwarden", "keepass", "keepassx", "keepassxc", "kure", "lastpass",
+			"chrome", "firefox", "safari", "dashlane", "enpass", "pass"}
+		for _, name := range list {
+			err := SupportedImportSources()(nil, []string{name})
+			assert.NoError(t, err)
+		}
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		list := []string{"", "unsupported"}
+		for _, name := range list {
+			err := SupportedImportSources()(nil, []string{name})
+			assert.Error(t, err)
+		}
+	})
+}
+
 func TestWatchFile(t *testing.T) {
 	f, err := os.CreateTemp("", "*")
 	assert.NoError(t, err)
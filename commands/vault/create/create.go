@@ -0,0 +1,120 @@
+package create
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/GGP1/kure/auth"
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/config"
+	"github.com/GGP1/kure/vault"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+const example = `
+* Create a vault next to the default database
+kure vault create work
+
+* Create a vault in a custom location using a key file
+kure vault create personal --path /media/usb/personal.db --keyfile /media/usb/key`
+
+type createOptions struct {
+	keyfile string
+	path    string
+}
+
+// NewCmd returns a new command.
+func NewCmd(r io.Reader) *cobra.Command {
+	opts := createOptions{}
+	cmd := &cobra.Command{
+		Use:   "create <name>",
+		Short: "Create a vault",
+		Long: `Create a vault and register its master password.
+
+The database is created next to the default one, named after the vault, unless a path is specified. The vault is saved in the configuration file under "vaults.<name>".`,
+		Example: example,
+		Args:    cobra.ExactArgs(1),
+		RunE:    runCreate(r, &opts),
+		PostRun: func(cmd *cobra.Command, args []string) {
+			// Reset variables (session)
+			opts = createOptions{}
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVarP(&opts.keyfile, "keyfile", "k", "", "key file path")
+	f.StringVarP(&opts.path, "path", "p", "", "database path")
+
+	return cmd
+}
+
+func runCreate(r io.Reader, opts *createOptions) cmdutil.RunEFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if err := vault.ValidateName(name); err != nil {
+			return err
+		}
+		if _, err := vault.Get(name); err == nil {
+			return errors.Errorf("vault %q already exists", name)
+		}
+
+		path := opts.path
+		if path == "" {
+			def, _ := vault.Get(vault.DefaultName)
+			path = filepath.Join(filepath.Dir(def.Path), name+".db")
+		}
+		path = filepath.Clean(path)
+
+		if _, err := os.Stat(path); err == nil {
+			return errors.Errorf("%q already exists", path)
+		}
+
+		if err := register(path, r, opts.keyfile); err != nil {
+			return err
+		}
+
+		if err := vault.Create(name, path, opts.keyfile); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Vault %q created, use it with \"kure --vault %s\" or \"kure vault use %s\"\n", name, name, name)
+		return nil
+	}
+}
+
+// register creates the database and registers its credentials. The current ones are restored afterwards.
+func register(path string, r io.Reader, keyfile string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return errors.Wrap(err, "creating the directory")
+	}
+
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 200 * time.Millisecond})
+	if err != nil {
+		return errors.Wrap(err, "creating the database")
+	}
+
+	prevAuth := config.Get("auth")
+	prevKeyfile := config.Get("keyfile.path")
+	config.Set("auth", nil)
+	config.Set("keyfile.path", keyfile)
+
+	err = auth.Register(db, r)
+
+	config.Set("auth", prevAuth)
+	config.Set("keyfile.path", prevKeyfile)
+	if cErr := db.Close(); err == nil {
+		err = cErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package create
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/GGP1/kure/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateErrors(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "existing.db")
+	assert.NoError(t, os.WriteFile(existing, nil, 0o600))
+
+	config.Reset()
+	config.Set("database.path", filepath.Join(dir, "kure.db"))
+	config.Set("vaults.work.path", filepath.Join(dir, "work.db"))
+
+	cases := []struct {
+		desc string
+		args []string
+	}{
+		{desc: "Reserved name", args: []string{"default"}},
+		{desc: "Invalid name", args: []string{"my.vault"}},
+		{desc: "Already exists", args: []string{"work"}},
+		{desc: "File exists", args: []string{"existing"}},
+		{desc: "Custom path exists", args: []string{"personal", "--path", existing}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			cmd := NewCmd(nil)
+			cmd.SetArgs(tc.args)
+			assert.Error(t, cmd.Execute())
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package ls
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/vault"
+
+	"github.com/spf13/cobra"
+)
+
+const example = `
+kure vault ls`
+
+// NewCmd returns a new command.
+func NewCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "ls",
+		Short:   "List vaults",
+		Long:    `List vaults, the one in use is marked with an asterisk.`,
+		Example: example,
+		Args:    cobra.NoArgs,
+		RunE:    runLs(),
+	}
+}
+
+func runLs() cmdutil.RunEFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		vaults, err := vault.List()
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', 0)
+		for _, v := range vaults {
+			mark := " "
+			if v.Name == vault.Active() {
+				mark = "*"
+			}
+			fmt.Fprintf(w, "%s %s\t%s\n", mark, v.Name, v.Path)
+		}
+		return w.Flush()
+	}
+}
@@ -0,0 +1,27 @@
+package ls
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/GGP1/kure/config"
+	"github.com/GGP1/kure/vault"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLs(t *testing.T) {
+	config.Reset()
+	config.Set("database.path", "kure.db")
+	config.Set("vaults.work.path", "work.db")
+	assert.NoError(t, vault.Select("work"))
+	t.Cleanup(func() { vault.Select(vault.DefaultName) })
+
+	var out bytes.Buffer
+	cmd := NewCmd()
+	cmd.SetOut(&out)
+	assert.NoError(t, cmd.Execute())
+
+	expected := "  default   kure.db\n* work      work.db\n"
+	assert.Equal(t, expected, out.String())
+}
@@ -0,0 +1,182 @@
+package move
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/GGP1/kure/auth"
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/config"
+	dbutil "github.com/GGP1/kure/db"
+	authDB "github.com/GGP1/kure/db/auth"
+	"github.com/GGP1/kure/vault"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+const example = `
+* Move an entry and a note to the "work" vault
+kure vault move entry:github note:ideas --to work
+
+* Copy a file to the default vault
+kure vault move file:id.png --to default --copy`
+
+type moveOptions struct {
+	to   string
+	copy bool
+}
+
+// NewCmd returns a new command.
+func NewCmd(db *bolt.DB, r io.Reader) *cobra.Command {
+	opts := moveOptions{}
+	cmd := &cobra.Command{
+		Use:   "move <type>:<name>...",
+		Short: "Move records to another vault",
+		Long: `Move records to another vault.
+
+Records are decrypted with the credentials of the vault in use and encrypted with the destination vault ones. The master password in use is tried first, if it doesn't unlock the destination vault, its password is requested. Team vaults are unlocked with the member key, so records can be moved to them from the default vault.
+
+Types: bank, card, entry, file, identity, note, ssh and totp.`,
+		Example: example,
+		Args:    cobra.MinimumNArgs(1),
+		RunE:    runMove(db, r, &opts),
+		PostRun: func(cmd *cobra.Command, args []string) {
+			// Reset variables (session)
+			opts = moveOptions{}
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVarP(&opts.to, "to", "t", "", "destination vault")
+	f.BoolVarP(&opts.copy, "copy", "c", false, "keep the records in the vault in use")
+
+	return cmd
+}
+
+func runMove(db *bolt.DB, r io.Reader, opts *moveOptions) cmdutil.RunEFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		if opts.to == "" {
+			return errors.New("the destination vault must be specified with --to")
+		}
+		if opts.to == vault.Active() {
+			return errors.Errorf("the %q vault is already in use", opts.to)
+		}
+		dest, err := vault.Get(opts.to)
+		if err != nil {
+			return err
+		}
+
+		records, err := getRecords(db, args)
+		if err != nil {
+			return err
+		}
+
+		if err := putRecords(db, dest, r, records); err != nil {
+			return err
+		}
+
+		action := "Moved"
+		if opts.copy {
+			action = "Copied"
+		} else {
+			for _, record := range records {
+				if err := dbutil.Remove(db, dbutil.GetBucketName(record), record.GetName()); err != nil {
+					return err
+				}
+			}
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "%s %d records to the %q vault\n", action, len(records), dest.Name)
+		return nil
+	}
+}
+
+// getRecords returns the decrypted records specified in the format <type>:<name>.
+func getRecords(db *bolt.DB, args []string) ([]dbutil.Record, error) {
+	records := make([]dbutil.Record, 0, len(args))
+	for _, arg := range args {
+		recordType, name, ok := strings.Cut(arg, ":")
+		name = cmdutil.NormalizeName(name)
+		newRecord, valid := cmdutil.RecordTypes[recordType]
+		if !ok || !valid || name == "" {
+			return nil, errors.Errorf("invalid record %q, use the format <type>:<name>", arg)
+		}
+
+		record := newRecord()
+		if err := dbutil.Get(db, name, record); err != nil {
+			return nil, errors.Wrap(err, recordType)
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// putRecords encrypts and stores the records in the destination vault using its credentials.
+func putRecords(source *bolt.DB, dest vault.Vault, r io.Reader, records []dbutil.Record) error {
+	if _, err := os.Stat(dest.Path); err != nil {
+		return errors.Wrapf(err, "%q vault", dest.Name)
+	}
+
+	db, err := bolt.Open(dest.Path, 0o600, &bolt.Options{Timeout: 200 * time.Millisecond})
+	if err != nil {
+		return errors.Wrapf(err, "opening %q vault", dest.Name)
+	}
+	defer db.Close()
+
+	restore, err := switchTo(db, source, dest, r)
+	if err != nil {
+		return errors.Wrapf(err, "%q vault", dest.Name)
+	}
+	defer restore()
+
+	return db.Update(func(tx *bolt.Tx) error {
+		for _, record := range records {
+			b, err := tx.CreateBucketIfNotExists(dbutil.GetBucketName(record))
+			if err != nil {
+				return errors.Wrap(err, "creating bucket")
+			}
+
+			if b.Get(dbutil.XorName([]byte(record.GetName()))) != nil {
+				return errors.Errorf("%q already exists in the %q vault", record.GetName(), dest.Name)
+			}
+
+			if err := dbutil.Put(b, record); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// switchTo sets the credentials of the destination vault to the configuration. Team vaults are
+// unlocked with the member key stored in the source vault.
+func switchTo(db, source *bolt.DB, dest vault.Vault, r io.Reader) (func(), error) {
+	// Key files are read from the configuration if the password is requested
+	prevKeyfile := config.Get("keyfile.path")
+	config.Set("keyfile.path", dest.Keyfile)
+	defer config.Set("keyfile.path", prevKeyfile)
+
+	restore, err := auth.Switch(db, r, false)
+	switch {
+	case errors.Is(err, auth.ErrInvalidPassword):
+		return auth.Switch(db, r, true)
+
+	case errors.Is(err, auth.ErrTeamVault):
+		memberKey, err := authDB.GetMemberKey(source)
+		if err != nil {
+			return nil, err
+		}
+		if memberKey == nil {
+			return nil, errors.New("the vault in use has no member key")
+		}
+		return auth.SwitchTeam(db, memberKey)
+	}
+
+	return restore, err
+}
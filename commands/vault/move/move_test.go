@@ -0,0 +1,125 @@
+package move
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/GGP1/kure/auth"
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/config"
+	authDB "github.com/GGP1/kure/db/auth"
+	"github.com/GGP1/kure/db/entry"
+	"github.com/GGP1/kure/db/note"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestMove(t *testing.T) {
+	db := cmdutil.SetContext(t)
+	dest := createVault(t)
+
+	assert.NoError(t, entry.Create(db, &pb.Entry{Name: "github", Password: "secret", Expires: "Never"}))
+	assert.NoError(t, note.Create(db, &pb.Note{Name: "ideas", Text: "kure"}))
+
+	var out bytes.Buffer
+	cmd := NewCmd(db, nil)
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"entry:github", "note:ideas", "--to", "work"})
+	assert.NoError(t, cmd.Execute())
+	assert.Equal(t, "Moved 2 records to the \"work\" vault\n", out.String())
+
+	_, err := entry.Get(db, "github")
+	assert.Error(t, err)
+	_, err = note.Get(db, "ideas")
+	assert.Error(t, err)
+
+	destDB, err := bolt.Open(dest, 0o600, &bolt.Options{Timeout: time.Second})
+	assert.NoError(t, err)
+	defer destDB.Close()
+
+	// The records are readable only with the destination vault key
+	restore, err := auth.Switch(destDB, nil, false)
+	assert.NoError(t, err)
+	defer restore()
+
+	e, err := entry.Get(destDB, "github")
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", e.Password)
+	n, err := note.Get(destDB, "ideas")
+	assert.NoError(t, err)
+	assert.Equal(t, "kure", n.Text)
+}
+
+func TestCopy(t *testing.T) {
+	db := cmdutil.SetContext(t)
+	createVault(t)
+
+	assert.NoError(t, entry.Create(db, &pb.Entry{Name: "github", Expires: "Never"}))
+
+	var out bytes.Buffer
+	cmd := NewCmd(db, nil)
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"entry:github", "--to", "work", "--copy"})
+	assert.NoError(t, cmd.Execute())
+	assert.Equal(t, "Copied 1 records to the \"work\" vault\n", out.String())
+
+	_, err := entry.Get(db, "github")
+	assert.NoError(t, err)
+
+	// The record already exists in the destination
+	cmd.SetArgs([]string{"entry:github", "--to", "work"})
+	assert.Error(t, cmd.Execute())
+	_, err = entry.Get(db, "github")
+	assert.NoError(t, err)
+}
+
+func TestMoveErrors(t *testing.T) {
+	db := cmdutil.SetContext(t)
+	createVault(t)
+	config.Set("vaults.missing.path", filepath.Join(t.TempDir(), "missing.db"))
+
+	assert.NoError(t, entry.Create(db, &pb.Entry{Name: "github", Expires: "Never"}))
+
+	cases := []struct {
+		desc string
+		args []string
+	}{
+		{desc: "No destination", args: []string{"entry:github"}},
+		{desc: "Vault in use", args: []string{"entry:github", "--to", "default"}},
+		{desc: "Vault does not exist", args: []string{"entry:github", "--to", "personal"}},
+		{desc: "Database does not exist", args: []string{"entry:github", "--to", "missing"}},
+		{desc: "Invalid format", args: []string{"github", "--to", "work"}},
+		{desc: "Invalid type", args: []string{"password:github", "--to", "work"}},
+		{desc: "Record does not exist", args: []string{"entry:gitlab", "--to", "work"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			cmd := NewCmd(db, nil)
+			cmd.SetArgs(tc.args)
+			assert.Error(t, cmd.Execute())
+		})
+	}
+}
+
+// createVault registers a database with the same password but a different key and adds it
+// to the configuration as the "work" vault.
+func createVault(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "work.db")
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	assert.NoError(t, err)
+
+	params := authDB.Params{Argon2: authDB.Argon2{Iterations: 1, Memory: 1, Threads: 1}}
+	key := []byte("98765432109876543210987654321098")
+	assert.NoError(t, authDB.Register(db, key, params))
+	assert.NoError(t, db.Close())
+
+	config.Set("vaults.work.path", path)
+	return path
+}
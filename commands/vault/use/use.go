@@ -0,0 +1,43 @@
+package use
+
+import (
+	"fmt"
+
+	cmdutil "github.com/GGP1/kure/commands"
+	"github.com/GGP1/kure/vault"
+
+	"github.com/spf13/cobra"
+)
+
+const example = `
+* Use the "work" vault by default
+kure vault use work
+
+* Go back to the database under the "database" key
+kure vault use default`
+
+// NewCmd returns a new command.
+func NewCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "use <name>",
+		Short: "Set the default vault",
+		Long: `Set the vault used when none is specified with the --vault flag or the KURE_VAULT environment variable.
+
+A running session keeps using the vault it was started with.`,
+		Example: example,
+		Args:    cobra.ExactArgs(1),
+		RunE:    runUse(),
+	}
+}
+
+func runUse() cmdutil.RunEFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if err := vault.SetDefault(name); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Using the %q vault by default\n", name)
+		return nil
+	}
+}
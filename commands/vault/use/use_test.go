@@ -0,0 +1,47 @@
+package use
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/GGP1/kure/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUse(t *testing.T) {
+	filename := setConfig(t)
+
+	var out bytes.Buffer
+	cmd := NewCmd()
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"work"})
+	assert.NoError(t, cmd.Execute())
+	assert.Equal(t, "Using the \"work\" vault by default\n", out.String())
+
+	c := config.New()
+	assert.NoError(t, c.Load(filename))
+	assert.Equal(t, "work", c.Get("vault"))
+}
+
+func TestUseInvalidVault(t *testing.T) {
+	setConfig(t)
+
+	cmd := NewCmd()
+	cmd.SetArgs([]string{"missing"})
+	assert.Error(t, cmd.Execute())
+}
+
+func setConfig(t *testing.T) string {
+	t.Helper()
+
+	filename := filepath.Join(t.TempDir(), "kure.yaml")
+	content := "database:\n  path: kure.db\nvaults:\n  work:\n    path: work.db\n"
+	assert.NoError(t, os.WriteFile(filename, []byte(content), 0o600))
+
+	config.Reset()
+	assert.NoError(t, config.Load(filename))
+	return filename
+}
@@ -0,0 +1,39 @@
+package vault
+
+import (
+	"io"
+
+	"github.com/GGP1/kure/commands/vault/create"
+	"github.com/GGP1/kure/commands/vault/ls"
+	"github.com/GGP1/kure/commands/vault/move"
+	"github.com/GGP1/kure/commands/vault/use"
+
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+const example = `
+kure vault (create|ls|move|use)`
+
+// NewCmd returns a new command.
+func NewCmd(db *bolt.DB, r io.Reader) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "vault",
+		Short: "Vault operations",
+		Long: `Vault operations.
+
+Vaults are independent databases with their own credentials, declared in the configuration file under "vaults.<name>". The database under the "database" key is the "default" vault.
+
+The vault used is taken from the --vault flag, the KURE_VAULT environment variable or the "vault" configuration key, in that order.`,
+		Example: example,
+	}
+
+	cmd.AddCommand(
+		create.NewCmd(r),
+		ls.NewCmd(),
+		move.NewCmd(db, r),
+		use.NewCmd(),
+	)
+
+	return cmd
+}
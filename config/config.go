@@ -164,6 +164,27 @@ func Write(filename string, exclusive bool) error {
 	return config.Write(filename, flags)
 }
 
+// Persist sets the values and writes them to the configuration file. Unlike Write, the values
+// modified at runtime aren't included in the file.
+func Persist(values map[string]interface{}) error {
+	filename := config.filename
+	if filename == "" {
+		return errors.New("no configuration file was specified")
+	}
+
+	c := New()
+	if err := c.Load(filename); err != nil {
+		return errors.Wrap(err, "reading configuration file")
+	}
+
+	for k, v := range values {
+		c.Set(k, v)
+		Set(k, v)
+	}
+
+	return c.Write(filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC)
+}
+
 // WriteStruct writes the configuration empty structure to the given file.
 func WriteStruct(filename string) error {
 	temp := config.mp
@@ -219,3 +219,33 @@ func TestWriteStruct(t *testing.T) {
 
 	assert.Equal(t, expected, got)
 }
+
+func TestPersist(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "kure.yaml")
+	assert.NoError(t, os.WriteFile(filename, []byte("editor: vim\n"), 0o600))
+
+	config = New()
+	SetFilename(filename)
+	// Runtime values must not be written
+	Set("database.path", "runtime.db")
+
+	err := Persist(map[string]interface{}{"vaults.work.path": "work.db"})
+	assert.NoError(t, err)
+	assert.Equal(t, "work.db", Get("vaults.work.path"))
+
+	c := New()
+	assert.NoError(t, c.Load(filename))
+	assert.Equal(t, "vim", c.Get("editor"))
+	assert.Equal(t, "work.db", c.Get("vaults.work.path"))
+	assert.Nil(t, c.Get("database.path"))
+}
+
+func TestPersistErrors(t *testing.T) {
+	config = New()
+	err := Persist(map[string]interface{}{"editor": "nano"})
+	assert.Error(t, err)
+
+	SetFilename(filepath.Join(t.TempDir(), "missing.yaml"))
+	err = Persist(map[string]interface{}{"editor": "nano"})
+	assert.Error(t, err)
+}
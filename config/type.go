@@ -49,14 +49,14 @@ func (c *Config) Load(filename string) error {
 	if filename == "" {
 		return errors.New("no configuration file was specified")
 	}
-	config.filename = filename
+	c.filename = filename
 
-	data, err := os.ReadFile(config.filename)
+	data, err := os.ReadFile(c.filename)
 	if err != nil {
 		return err
 	}
 
-	return config.populateMap(data, filepath.Ext(config.filename))
+	return c.populateMap(data, filepath.Ext(c.filename))
 }
 
 // Set sets a value for the key passed.
@@ -74,7 +74,7 @@ func (c *Config) Write(filename string, flags int) error {
 	// Avoid including the auth parameters in the configuration file
 	temp := c.Get("auth")
 	defer c.Set("auth", temp)
-	delete(config.mp, "auth")
+	delete(c.mp, "auth")
 
 	content, err := c.marshal(filepath.Ext(filename))
 	if err != nil {
@@ -0,0 +1,129 @@
+package crypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+
+	"github.com/pkg/errors"
+)
+
+// sealInfo binds the keys derived for sealing to this use.
+const sealInfo = "kure sealed data"
+
+// GenerateKeyPair returns a new X25519 private key and its public key.
+func GenerateKeyPair() (privateKey, publicKey []byte, err error) {
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "generating key pair")
+	}
+
+	return key.Bytes(), key.PublicKey().Bytes(), nil
+}
+
+// PublicKey returns the public key of an X25519 private key.
+func PublicKey(privateKey []byte) ([]byte, error) {
+	key, err := ecdh.X25519().NewPrivateKey(privateKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid private key")
+	}
+
+	return key.PublicKey().Bytes(), nil
+}
+
+// Seal ciphers data so only the owner of the private key matching the X25519 public key can
+// decipher it.
+//
+// The encryption key is derived from the key agreement between an ephemeral key pair and the
+// recipient's one, the ephemeral public key is prepended to the ciphertext.
+func Seal(publicKey, data []byte) ([]byte, error) {
+	recipient, err := ecdh.X25519().NewPublicKey(publicKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid public key")
+	}
+
+	ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, errEncrypt
+	}
+
+	secret, err := ephemeral.ECDH(recipient)
+	if err != nil {
+		return nil, errEncrypt
+	}
+
+	gcm, err := sealCipher(secret, ephemeral.PublicKey().Bytes(), publicKey)
+	if err != nil {
+		return nil, errEncrypt
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	_, _ = rand.Read(nonce)
+
+	dst := append(ephemeral.PublicKey().Bytes(), nonce...)
+	return gcm.Seal(dst, nonce, data, nil), nil
+}
+
+// Open deciphers data sealed with the public key of the X25519 private key passed.
+func Open(privateKey, data []byte) ([]byte, error) {
+	key, err := ecdh.X25519().NewPrivateKey(privateKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid private key")
+	}
+
+	pubSize := len(key.PublicKey().Bytes())
+	if len(data) < pubSize {
+		return nil, errDecrypt
+	}
+
+	ephemeralPub := data[:pubSize]
+	ephemeral, err := ecdh.X25519().NewPublicKey(ephemeralPub)
+	if err != nil {
+		return nil, errDecrypt
+	}
+
+	secret, err := key.ECDH(ephemeral)
+	if err != nil {
+		return nil, errDecrypt
+	}
+
+	gcm, err := sealCipher(secret, ephemeralPub, key.PublicKey().Bytes())
+	if err != nil {
+		return nil, errDecrypt
+	}
+
+	data = data[pubSize:]
+	nonceSize := gcm.NonceSize()
+	if len(data) < nonceSize {
+		return nil, errDecrypt
+	}
+
+	plaintext, err := gcm.Open(nil, data[:nonceSize], data[nonceSize:], nil)
+	if err != nil {
+		return nil, errDecrypt
+	}
+
+	return plaintext, nil
+}
+
+// sealCipher returns the AES-GCM cipher using the key derived from the shared secret and both public keys.
+func sealCipher(secret, ephemeralPub, recipientPub []byte) (cipher.AEAD, error) {
+	salt := make([]byte, 0, len(ephemeralPub)+len(recipientPub))
+	salt = append(salt, ephemeralPub...)
+	salt = append(salt, recipientPub...)
+
+	key, err := hkdf.Key(sha256.New, secret, salt, sealInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,65 @@
+package crypt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeal(t *testing.T) {
+	privateKey, publicKey, err := GenerateKeyPair()
+	assert.NoError(t, err)
+
+	gotPublicKey, err := PublicKey(privateKey)
+	assert.NoError(t, err)
+	assert.Equal(t, publicKey, gotPublicKey)
+
+	data := []byte("vault key")
+	sealed, err := Seal(publicKey, data)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(sealed), string(data))
+
+	got, err := Open(privateKey, sealed)
+	assert.NoError(t, err)
+	assert.Equal(t, data, got)
+
+	// Other key
+	otherKey, _, err := GenerateKeyPair()
+	assert.NoError(t, err)
+	_, err = Open(otherKey, sealed)
+	assert.Error(t, err)
+
+	// Tampered data
+	sealed[len(sealed)-1] ^= 1
+	_, err = Open(privateKey, sealed)
+	assert.Error(t, err)
+}
+
+func TestSealErrors(t *testing.T) {
+	_, err := Seal([]byte("invalid"), []byte("data"))
+	assert.Error(t, err)
+
+	_, err = PublicKey([]byte("invalid"))
+	assert.Error(t, err)
+
+	privateKey, _, err := GenerateKeyPair()
+	assert.NoError(t, err)
+
+	cases := []struct {
+		desc string
+		data []byte
+	}{
+		{desc: "Empty", data: nil},
+		{desc: "Short", data: make([]byte, 40)},
+		{desc: "Invalid", data: make([]byte, 80)},
+	}
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			_, err := Open(privateKey, tc.data)
+			assert.Error(t, err)
+		})
+	}
+
+	_, err = Open([]byte("invalid"), make([]byte, 80))
+	assert.Error(t, err)
+}
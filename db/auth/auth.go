@@ -15,9 +15,13 @@ var (
 	authKey = []byte("key")
 	// keyfileKey will exist only if the user uses a keyfile
 	keyfileKey = []byte("keyfile")
-	iterKey    = []byte("iterations")
-	memKey     = []byte("memory")
-	thKey      = []byte("threads")
+	// memberKey is the private key used to unlock team vaults
+	memberKey = []byte("member_key")
+	// teamKey will exist only in team vaults
+	teamKey = []byte("team")
+	iterKey = []byte("iterations")
+	memKey  = []byte("memory")
+	thKey   = []byte("threads")
 )
 
 // Params contains all the information needed for logging in.
@@ -25,6 +29,8 @@ type Params struct {
 	AuthKey    []byte
 	Argon2     Argon2
 	UseKeyfile bool
+	// Team is true if the database is a team vault, its master password is the vault key
+	Team bool
 }
 
 // Argon2 execution parameters.
@@ -53,6 +59,7 @@ func GetParams(db *bolt.DB) (Params, error) {
 		return nil
 	})
 	_, useKeyfile := params[string(keyfileKey)]
+	_, team := params[string(teamKey)]
 
 	return Params{
 		AuthKey: params[string(authKey)],
@@ -62,24 +69,98 @@ func GetParams(db *bolt.DB) (Params, error) {
 			Threads:    binary.BigEndian.Uint32(params[string(thKey)]),
 		},
 		UseKeyfile: useKeyfile,
+		Team:       team,
 	}, nil
 }
 
+// GetMemberKey returns the decrypted private key used to unlock team vaults, it's nil if it wasn't generated.
+func GetMemberKey(db *bolt.DB) ([]byte, error) {
+	var encKey []byte
+	_ = db.View(func(tx *bolt.Tx) error {
+		if b := tx.Bucket(bucket.Auth.GetName()); b != nil {
+			encKey = b.Get(memberKey)
+		}
+		return nil
+	})
+	if encKey == nil {
+		return nil, nil
+	}
+
+	key, err := crypt.Decrypt(encKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "decrypting member key")
+	}
+	return key, nil
+}
+
 // Register creates all the buckets, saves the authentication key and the argon2 parameters used.
 func Register(db *bolt.DB, key []byte, params Params) error {
 	return db.Update(func(tx *bolt.Tx) error {
 		// Create all the buckets except auth, it will be created in setParameters()
-		buckets := bucket.GetNames()
-		for _, bucket := range buckets {
-			if _, err := tx.CreateBucketIfNotExists([]byte(bucket)); err != nil {
-				return errors.Wrapf(err, "creating %q bucket", bucket)
-			}
+		if err := createBuckets(tx); err != nil {
+			return err
 		}
 
 		return storeParams(tx, key, params)
 	})
 }
 
+// SetMemberKey encrypts and saves the private key used to unlock team vaults.
+func SetMemberKey(db *bolt.DB, key []byte) error {
+	encKey, err := crypt.Encrypt(key)
+	if err != nil {
+		return errors.Wrap(err, "encrypting member key")
+	}
+
+	return db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(bucket.Auth.GetName())
+		if err != nil {
+			return errors.Wrap(err, "creating auth bucket")
+		}
+		return errors.Wrap(b.Put(memberKey, encKey), "saving member key")
+	})
+}
+
+// UpdateKey encrypts the authentication key with the credentials in the configuration and saves it.
+//
+// The transaction shouldn't be closed as it's handled by the caller.
+func UpdateKey(tx *bolt.Tx, key []byte) error {
+	b := tx.Bucket(bucket.Auth.GetName())
+	if b == nil {
+		return errors.New("auth bucket does not exist")
+	}
+	return storeAuthKey(b, key)
+}
+
+// CreateBuckets creates the record buckets missing in the database, those added
+// in versions newer than the one used to register.
+func CreateBuckets(db *bolt.DB) error {
+	missing := false
+	_ = db.View(func(tx *bolt.Tx) error {
+		for _, name := range bucket.GetNames() {
+			if tx.Bucket(name) == nil {
+				missing = true
+				break
+			}
+		}
+		return nil
+	})
+	if !missing {
+		return nil
+	}
+
+	return db.Update(createBuckets)
+}
+
+func createBuckets(tx *bolt.Tx) error {
+	for _, bucket := range bucket.GetNames() {
+		if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+			return errors.Wrapf(err, "creating %q bucket", bucket)
+		}
+	}
+	return nil
+}
+
 // storeParams creates the auth bucket and sets the authentication parameters.
 //
 // The transaction shouldn't be closed as it's already handled by Register().
@@ -97,6 +178,12 @@ func storeParams(tx *bolt.Tx, key []byte, params Params) error {
 		return err
 	}
 
+	if params.Team {
+		if err := b.Put(teamKey, []byte("1")); err != nil {
+			return errors.Wrap(err, "saving team value")
+		}
+	}
+
 	return storeAuthKey(b, key)
 }
 
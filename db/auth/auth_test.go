@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/GGP1/kure/crypt"
 	dbutil "github.com/GGP1/kure/db"
 	"github.com/GGP1/kure/db/bucket"
 
@@ -106,6 +107,79 @@ func TestSetParametersInvalidKeys(t *testing.T) {
 	}
 }
 
+func TestCreateBuckets(t *testing.T) {
+	db := setContext(t)
+
+	err := CreateBuckets(db)
+	assert.NoError(t, err)
+
+	err = db.View(func(tx *bolt.Tx) error {
+		for _, name := range bucket.GetNames() {
+			assert.NotNil(t, tx.Bucket(name), "Bucket %q not created", name)
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+
+	// Must not fail if the buckets already exist
+	assert.NoError(t, CreateBuckets(db))
+}
+
+func TestTeamParameter(t *testing.T) {
+	db := setContext(t)
+
+	params := Params{
+		Argon2: Argon2{Iterations: 1, Memory: 1, Threads: 1},
+		Team:   true,
+	}
+	assert.NoError(t, Register(db, []byte("test"), params))
+
+	got, err := GetParams(db)
+	assert.NoError(t, err)
+	assert.True(t, got.Team)
+}
+
+func TestMemberKey(t *testing.T) {
+	db := setContext(t)
+
+	got, err := GetMemberKey(db)
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+
+	expected := []byte("private key")
+	assert.NoError(t, SetMemberKey(db, expected))
+
+	got, err = GetMemberKey(db)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, got)
+}
+
+func TestUpdateKey(t *testing.T) {
+	db := setContext(t)
+
+	params := Params{Argon2: Argon2{Iterations: 1, Memory: 1, Threads: 1}}
+	assert.NoError(t, Register(db, []byte("old"), params))
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		return UpdateKey(tx, []byte("new"))
+	})
+	assert.NoError(t, err)
+
+	got, err := GetParams(db)
+	assert.NoError(t, err)
+	key, err := crypt.Decrypt(got.AuthKey)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("new"), key)
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket(bucket.Auth.GetName()); err != nil {
+			return err
+		}
+		return UpdateKey(tx, []byte("new"))
+	})
+	assert.Error(t, err)
+}
+
 func setContext(t testing.TB) *bolt.DB {
 	return dbutil.SetContext(t, bucket.Auth.GetName())
 }
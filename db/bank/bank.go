@@ -0,0 +1,63 @@
+package bank
+
+import (
+	"strings"
+
+	dbutil "github.com/GGP1/kure/db"
+	"github.com/GGP1/kure/db/bucket"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Create a new bank account.
+func Create(db *bolt.DB, bank *pb.BankAccount) error {
+	return db.Batch(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket.Bank.GetName())
+		return dbutil.Put(b, bank)
+	})
+}
+
+// Get retrieves the bank account with the specified name.
+func Get(db *bolt.DB, name string) (*pb.BankAccount, error) {
+	bank := &pb.BankAccount{}
+	if err := dbutil.Get(db, name, bank); err != nil {
+		return nil, err
+	}
+
+	return bank, nil
+}
+
+// List returns a list with all the bank accounts.
+func List(db *bolt.DB) ([]*pb.BankAccount, error) {
+	return dbutil.List(db, &pb.BankAccount{})
+}
+
+// ListNames returns a list with all the bank accounts names.
+func ListNames(db *bolt.DB) ([]string, error) {
+	return dbutil.ListNames(db, bucket.Bank.GetName())
+}
+
+// Remove removes one or more bank accounts from the database.
+func Remove(db *bolt.DB, names ...string) error {
+	return dbutil.Remove(db, bucket.Bank.GetName(), names...)
+}
+
+// Update updates a bank account, it removes the old one if the name differs.
+func Update(db *bolt.DB, oldName string, bank *pb.BankAccount) error {
+	if strings.ContainsRune(bank.Name, '\x00') {
+		return errors.New("bank account name contains null characters")
+	}
+
+	return db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket.Bank.GetName())
+		if oldName != bank.Name {
+			xorName := dbutil.XorName([]byte(oldName))
+			if err := b.Delete(xorName); err != nil {
+				return errors.Wrap(err, "remove old bank account")
+			}
+		}
+		return dbutil.Put(b, bank)
+	})
+}
@@ -0,0 +1,210 @@
+package bank
+
+import (
+	"testing"
+
+	"github.com/GGP1/kure/config"
+	"github.com/GGP1/kure/crypt"
+	dbutil "github.com/GGP1/kure/db"
+	"github.com/GGP1/kure/db/bucket"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/awnumar/memguard"
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestBankAccount(t *testing.T) {
+	db := setContext(t)
+
+	acc := &pb.BankAccount{
+		Name:          "test",
+		Bank:          "Bank",
+		Holder:        "John Doe",
+		AccountNumber: "12345678",
+		Iban:          "GB82WEST12345698765432",
+		Pin:           "1234",
+	}
+
+	t.Run("Create", create(db, acc))
+	t.Run("Get", get(db, acc))
+	t.Run("List", list(db, acc))
+	t.Run("List names", listNames(db, acc))
+	t.Run("Remove", remove(db, acc.Name))
+	t.Run("Update", update(db))
+	t.Run("Update name", updateName(db))
+}
+
+func create(db *bolt.DB, acc *pb.BankAccount) func(*testing.T) {
+	return func(t *testing.T) {
+		err := Create(db, acc)
+		assert.NoError(t, err)
+	}
+}
+
+func get(db *bolt.DB, expected *pb.BankAccount) func(*testing.T) {
+	return func(t *testing.T) {
+		got, err := Get(db, expected.Name)
+		assert.NoError(t, err)
+
+		if !proto.Equal(expected, got) {
+			t.Errorf("Expected %v, got %v", expected, got)
+		}
+	}
+}
+
+func list(db *bolt.DB, expected *pb.BankAccount) func(*testing.T) {
+	return func(t *testing.T) {
+		records, err := List(db)
+		assert.NoError(t, err)
+
+		assert.NotZero(t, len(records), "Expected one or more records")
+
+		got := records[0]
+		if !proto.Equal(expected, got) {
+			t.Errorf("Expected %v, got %v", expected, got)
+		}
+	}
+}
+
+func listNames(db *bolt.DB, expected *pb.BankAccount) func(*testing.T) {
+	return func(t *testing.T) {
+		records, err := ListNames(db)
+		assert.NoError(t, err)
+
+		if len(records) == 0 {
+			t.Fatal("Expected one or more records, got 0")
+		}
+
+		got := records[0]
+		if got != expected.Name {
+			t.Errorf("Expected %s, got %s", expected.Name, got)
+		}
+	}
+}
+
+func remove(db *bolt.DB, name string) func(*testing.T) {
+	return func(t *testing.T) {
+		err := Remove(db, name)
+		assert.NoError(t, err)
+	}
+}
+
+func update(db *bolt.DB) func(*testing.T) {
+	return func(t *testing.T) {
+		oldBankAccount := &pb.BankAccount{Name: "test"}
+		err := Create(db, oldBankAccount)
+		assert.NoError(t, err)
+
+		newBankAccount := &pb.BankAccount{Name: "test", Bank: "Bank"}
+		err = Update(db, oldBankAccount.Name, newBankAccount)
+		assert.NoError(t, err)
+
+		_, err = Get(db, newBankAccount.Name)
+		assert.NoError(t, err)
+	}
+}
+
+func updateName(db *bolt.DB) func(*testing.T) {
+	return func(t *testing.T) {
+		oldBankAccount := &pb.BankAccount{Name: "old"}
+		err := Create(db, oldBankAccount)
+		assert.NoError(t, err)
+
+		newBankAccount := &pb.BankAccount{Name: "new"}
+		err = Update(db, oldBankAccount.Name, newBankAccount)
+		assert.NoError(t, err)
+
+		_, err = Get(db, newBankAccount.Name)
+		assert.NoError(t, err)
+
+		_, err = Get(db, oldBankAccount.Name)
+		assert.Error(t, err)
+	}
+}
+
+func TestCreateErrors(t *testing.T) {
+	db := setContext(t)
+
+	cases := []struct {
+		desc string
+		name string
+	}{
+		{
+			desc: "Invalid name",
+			name: "",
+		},
+		{
+			desc: "Null characters",
+			name: string([]rune{'\x00'}),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			err := Create(db, &pb.BankAccount{Name: tc.name})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestGetError(t *testing.T) {
+	db := setContext(t)
+
+	_, err := Get(db, "non-existent")
+	assert.Error(t, err)
+}
+
+func TestUpdateError(t *testing.T) {
+	db := setContext(t)
+
+	name := string([]rune{'\x00'})
+	err := Update(db, "old", &pb.BankAccount{Name: name})
+	assert.Error(t, err)
+}
+
+func TestCryptErrors(t *testing.T) {
+	db := setContext(t)
+
+	name := "crypt-errors"
+	err := Create(db, &pb.BankAccount{Name: name})
+	assert.NoError(t, err)
+
+	// Try to get the bank account with another password
+	config.Set("auth.password", memguard.NewEnclave([]byte("invalid")))
+
+	_, err = Get(db, name)
+	assert.Error(t, err)
+	_, err = List(db)
+	assert.Error(t, err)
+}
+
+func TestProtoErrors(t *testing.T) {
+	db := setContext(t)
+
+	name := "unformatted"
+	err := db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket.Bank.GetName())
+		buf := make([]byte, 64)
+		encBuf, _ := crypt.Encrypt(buf)
+		return b.Put([]byte(name), encBuf)
+	})
+	assert.NoError(t, err, "Failed writing invalid type")
+
+	_, err = Get(db, name)
+	assert.Error(t, err)
+	_, err = List(db)
+	assert.Error(t, err)
+}
+
+func TestKeyError(t *testing.T) {
+	db := setContext(t)
+
+	err := Create(db, &pb.BankAccount{Name: ""})
+	assert.Error(t, err)
+}
+
+func setContext(t testing.TB) *bolt.DB {
+	return dbutil.SetContext(t, bucket.Bank.GetName())
+}
@@ -6,11 +6,17 @@ package bucket
 
 // Database bucket
 var (
-	Auth  = bucket{[]byte("kure_auth")}
-	Card  = bucket{[]byte("kure_card")}
-	Entry = bucket{[]byte("kure_entry")}
-	File  = bucket{[]byte("kure_file")}
-	TOTP  = bucket{[]byte("kure_totp")}
+	Auth     = bucket{[]byte("kure_auth")}
+	Bank     = bucket{[]byte("kure_bank")}
+	Card     = bucket{[]byte("kure_card")}
+	Entry    = bucket{[]byte("kure_entry")}
+	File     = bucket{[]byte("kure_file")}
+	Identity = bucket{[]byte("kure_identity")}
+	Note     = bucket{[]byte("kure_note")}
+	SSH      = bucket{[]byte("kure_ssh")}
+	Sync     = bucket{[]byte("kure_sync")}
+	Team     = bucket{[]byte("kure_team")}
+	TOTP     = bucket{[]byte("kure_totp")}
 )
 
 type bucket struct {
@@ -22,12 +28,16 @@ func (b *bucket) GetName() []byte {
 }
 
 // GetNames returns a slice with the names of the buckets where records are stored.
-// The auth bucket is not included.
+// The auth, sync and team buckets are not included.
 func GetNames() [][]byte {
 	return [][]byte{
+		Bank.GetName(),
 		Card.GetName(),
 		Entry.GetName(),
 		File.GetName(),
+		Identity.GetName(),
+		Note.GetName(),
+		SSH.GetName(),
 		TOTP.GetName(),
 	}
 }
@@ -14,6 +14,7 @@ import (
 
 	"github.com/awnumar/memguard"
 	"github.com/pkg/errors"
+	"github.com/spf13/cast"
 	"github.com/stretchr/testify/assert"
 	bolt "go.etcd.io/bbolt"
 	"google.golang.org/protobuf/proto"
@@ -27,8 +28,28 @@ type Record interface {
 	proto.Message
 }
 
+// Allowed returns whether the record name can be accessed. Members of team vaults can only access the
+// records whose names start with one of the prefixes they were granted, admins can access all of them.
+func Allowed(name string) bool {
+	prefixes := config.Get("auth.prefixes")
+	if prefixes == nil {
+		return true
+	}
+
+	for _, prefix := range cast.ToStringSlice(prefixes) {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
 // Get retrieves a record from the database, decrypts it and loads it into record.
 func Get(db *bolt.DB, name string, record Record) error {
+	if !Allowed(name) {
+		return errors.Errorf("access to %q denied", name)
+	}
+
 	return db.View(func(tx *bolt.Tx) error {
 		b := tx.Bucket(GetBucketName(record))
 
@@ -54,12 +75,20 @@ func Get(db *bolt.DB, name string, record Record) error {
 // GetBucketName returns the bucket name depending on the type of the record passed.
 func GetBucketName(r Record) []byte {
 	switch r.(type) {
+	case *pb.BankAccount:
+		return bucket.Bank.GetName()
 	case *pb.Card:
 		return bucket.Card.GetName()
 	case *pb.Entry:
 		return bucket.Entry.GetName()
 	case *pb.File, *pb.FileCheap:
 		return bucket.File.GetName()
+	case *pb.Identity:
+		return bucket.Identity.GetName()
+	case *pb.Note:
+		return bucket.Note.GetName()
+	case *pb.SSHKey:
+		return bucket.SSH.GetName()
 	case *pb.TOTP:
 		return bucket.TOTP.GetName()
 	default:
@@ -79,7 +108,11 @@ func List[R Record](db *bolt.DB, record R) ([]R, error) {
 	b := tx.Bucket(GetBucketName(record))
 	records := make([]R, 0, b.Stats().KeyN)
 
-	err = b.ForEach(func(_, v []byte) error {
+	err = b.ForEach(func(k, v []byte) error {
+		if !Allowed(string(XorName(k))) {
+			return nil
+		}
+
 		decRecord, err := crypt.Decrypt(v)
 		if err != nil {
 			return errors.Wrap(err, "decrypt record")
@@ -121,7 +154,9 @@ func ListNames(db *bolt.DB, bucketName []byte) ([]string, error) {
 	_ = b.ForEach(func(k, _ []byte) error {
 		// Xor record name to get the original one
 		name := XorName(k)
-		names = append(names, string(name))
+		if Allowed(string(name)) {
+			names = append(names, string(name))
+		}
 		return nil
 	})
 
@@ -136,6 +171,9 @@ func Put(b *bolt.Bucket, record Record) error {
 	if name == "" {
 		return errors.New("record name is empty")
 	}
+	if !Allowed(name) {
+		return errors.Errorf("access to %q denied", name)
+	}
 
 	buf, err := proto.Marshal(record)
 	if err != nil {
@@ -161,6 +199,12 @@ func Remove(db *bolt.DB, bucketName []byte, names ...string) error {
 		return nil
 	}
 
+	for _, name := range names {
+		if !Allowed(name) {
+			return errors.Errorf("access to %q denied", name)
+		}
+	}
+
 	return db.Update(func(tx *bolt.Tx) error {
 		b := tx.Bucket(bucketName)
 		for _, name := range names {
@@ -67,6 +67,26 @@ func TestGetBucketName(t *testing.T) {
 			record:   &pb.TOTP{},
 			expected: bucket.TOTP.GetName(),
 		},
+		{
+			desc:     "Identity",
+			record:   &pb.Identity{},
+			expected: bucket.Identity.GetName(),
+		},
+		{
+			desc:     "Bank account",
+			record:   &pb.BankAccount{},
+			expected: bucket.Bank.GetName(),
+		},
+		{
+			desc:     "Note",
+			record:   &pb.Note{},
+			expected: bucket.Note.GetName(),
+		},
+		{
+			desc:     "SSH key",
+			record:   &pb.SSHKey{},
+			expected: bucket.SSH.GetName(),
+		},
 	}
 
 	for _, tc := range cases {
@@ -122,6 +142,36 @@ func TestListNames(t *testing.T) {
 	assert.Equal(t, expected, got)
 }
 
+func TestAllowed(t *testing.T) {
+	db := dbutil.SetContext(t, bucketName)
+
+	createRecord(t, db, &pb.Card{Name: "team/visa"})
+	createRecord(t, db, &pb.Card{Name: "ops/amex"})
+	config.Set("auth.prefixes", []string{"team/"})
+
+	names, err := dbutil.ListNames(db, bucketName)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"team/visa"}, names)
+
+	cards, err := dbutil.List(db, &pb.Card{})
+	assert.NoError(t, err)
+	assert.Len(t, cards, 1)
+	assert.Equal(t, "team/visa", cards[0].Name)
+
+	assert.NoError(t, dbutil.Get(db, "team/visa", &pb.Card{}))
+	assert.Error(t, dbutil.Get(db, "ops/amex", &pb.Card{}))
+	assert.Error(t, dbutil.Remove(db, bucketName, "ops/amex"))
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		return dbutil.Put(tx.Bucket(bucketName), &pb.Card{Name: "ops/mastercard"})
+	})
+	assert.Error(t, err)
+
+	// Members without prefixes can't access any record
+	config.Set("auth.prefixes", []string{})
+	assert.False(t, dbutil.Allowed("team/visa"))
+}
+
 func TestListNamesNil(t *testing.T) {
 	db := dbutil.SetContext(t, bucketName)
 
@@ -67,6 +67,10 @@ func List(db *bolt.DB) ([]*pb.File, error) {
 	files := make([]*pb.File, 0, b.Stats().KeyN)
 
 	err = b.ForEach(func(k, v []byte) error {
+		if !dbutil.Allowed(string(dbutil.XorName(k))) {
+			return nil
+		}
+
 		file := &pb.File{}
 
 		decFile, err := crypt.Decrypt(v)
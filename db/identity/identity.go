@@ -0,0 +1,63 @@
+package identity
+
+import (
+	"strings"
+
+	dbutil "github.com/GGP1/kure/db"
+	"github.com/GGP1/kure/db/bucket"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Create a new identity.
+func Create(db *bolt.DB, identity *pb.Identity) error {
+	return db.Batch(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket.Identity.GetName())
+		return dbutil.Put(b, identity)
+	})
+}
+
+// Get retrieves the identity with the specified name.
+func Get(db *bolt.DB, name string) (*pb.Identity, error) {
+	identity := &pb.Identity{}
+	if err := dbutil.Get(db, name, identity); err != nil {
+		return nil, err
+	}
+
+	return identity, nil
+}
+
+// List returns a list with all the identities.
+func List(db *bolt.DB) ([]*pb.Identity, error) {
+	return dbutil.List(db, &pb.Identity{})
+}
+
+// ListNames returns a list with all the identities names.
+func ListNames(db *bolt.DB) ([]string, error) {
+	return dbutil.ListNames(db, bucket.Identity.GetName())
+}
+
+// Remove removes one or more identities from the database.
+func Remove(db *bolt.DB, names ...string) error {
+	return dbutil.Remove(db, bucket.Identity.GetName(), names...)
+}
+
+// Update updates a identity, it removes the old one if the name differs.
+func Update(db *bolt.DB, oldName string, identity *pb.Identity) error {
+	if strings.ContainsRune(identity.Name, '\x00') {
+		return errors.New("identity name contains null characters")
+	}
+
+	return db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket.Identity.GetName())
+		if oldName != identity.Name {
+			xorName := dbutil.XorName([]byte(oldName))
+			if err := b.Delete(xorName); err != nil {
+				return errors.Wrap(err, "remove old identity")
+			}
+		}
+		return dbutil.Put(b, identity)
+	})
+}
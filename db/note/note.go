@@ -0,0 +1,63 @@
+package note
+
+import (
+	"strings"
+
+	dbutil "github.com/GGP1/kure/db"
+	"github.com/GGP1/kure/db/bucket"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Create a new note.
+func Create(db *bolt.DB, note *pb.Note) error {
+	return db.Batch(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket.Note.GetName())
+		return dbutil.Put(b, note)
+	})
+}
+
+// Get retrieves the note with the specified name.
+func Get(db *bolt.DB, name string) (*pb.Note, error) {
+	note := &pb.Note{}
+	if err := dbutil.Get(db, name, note); err != nil {
+		return nil, err
+	}
+
+	return note, nil
+}
+
+// List returns a list with all the notes.
+func List(db *bolt.DB) ([]*pb.Note, error) {
+	return dbutil.List(db, &pb.Note{})
+}
+
+// ListNames returns a list with all the notes names.
+func ListNames(db *bolt.DB) ([]string, error) {
+	return dbutil.ListNames(db, bucket.Note.GetName())
+}
+
+// Remove removes one or more notes from the database.
+func Remove(db *bolt.DB, names ...string) error {
+	return dbutil.Remove(db, bucket.Note.GetName(), names...)
+}
+
+// Update updates a note, it removes the old one if the name differs.
+func Update(db *bolt.DB, oldName string, note *pb.Note) error {
+	if strings.ContainsRune(note.Name, '\x00') {
+		return errors.New("note name contains null characters")
+	}
+
+	return db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket.Note.GetName())
+		if oldName != note.Name {
+			xorName := dbutil.XorName([]byte(oldName))
+			if err := b.Delete(xorName); err != nil {
+				return errors.Wrap(err, "remove old note")
+			}
+		}
+		return dbutil.Put(b, note)
+	})
+}
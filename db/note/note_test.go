@@ -0,0 +1,206 @@
+package note
+
+import (
+	"testing"
+
+	"github.com/GGP1/kure/config"
+	"github.com/GGP1/kure/crypt"
+	dbutil "github.com/GGP1/kure/db"
+	"github.com/GGP1/kure/db/bucket"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/awnumar/memguard"
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestNote(t *testing.T) {
+	db := setContext(t)
+
+	n := &pb.Note{
+		Name: "test",
+		Text: "SSID: home\nPassword: 1234",
+	}
+
+	t.Run("Create", create(db, n))
+	t.Run("Get", get(db, n))
+	t.Run("List", list(db, n))
+	t.Run("List names", listNames(db, n))
+	t.Run("Remove", remove(db, n.Name))
+	t.Run("Update", update(db))
+	t.Run("Update name", updateName(db))
+}
+
+func create(db *bolt.DB, n *pb.Note) func(*testing.T) {
+	return func(t *testing.T) {
+		err := Create(db, n)
+		assert.NoError(t, err)
+	}
+}
+
+func get(db *bolt.DB, expected *pb.Note) func(*testing.T) {
+	return func(t *testing.T) {
+		got, err := Get(db, expected.Name)
+		assert.NoError(t, err)
+
+		if !proto.Equal(expected, got) {
+			t.Errorf("Expected %v, got %v", expected, got)
+		}
+	}
+}
+
+func list(db *bolt.DB, expected *pb.Note) func(*testing.T) {
+	return func(t *testing.T) {
+		records, err := List(db)
+		assert.NoError(t, err)
+
+		assert.NotZero(t, len(records), "Expected one or more records")
+
+		got := records[0]
+		if !proto.Equal(expected, got) {
+			t.Errorf("Expected %v, got %v", expected, got)
+		}
+	}
+}
+
+func listNames(db *bolt.DB, expected *pb.Note) func(*testing.T) {
+	return func(t *testing.T) {
+		records, err := ListNames(db)
+		assert.NoError(t, err)
+
+		if len(records) == 0 {
+			t.Fatal("Expected one or more records, got 0")
+		}
+
+		got := records[0]
+		if got != expected.Name {
+			t.Errorf("Expected %s, got %s", expected.Name, got)
+		}
+	}
+}
+
+func remove(db *bolt.DB, name string) func(*testing.T) {
+	return func(t *testing.T) {
+		err := Remove(db, name)
+		assert.NoError(t, err)
+	}
+}
+
+func update(db *bolt.DB) func(*testing.T) {
+	return func(t *testing.T) {
+		oldNote := &pb.Note{Name: "test"}
+		err := Create(db, oldNote)
+		assert.NoError(t, err)
+
+		newNote := &pb.Note{Name: "test", Text: "text"}
+		err = Update(db, oldNote.Name, newNote)
+		assert.NoError(t, err)
+
+		_, err = Get(db, newNote.Name)
+		assert.NoError(t, err)
+	}
+}
+
+func updateName(db *bolt.DB) func(*testing.T) {
+	return func(t *testing.T) {
+		oldNote := &pb.Note{Name: "old"}
+		err := Create(db, oldNote)
+		assert.NoError(t, err)
+
+		newNote := &pb.Note{Name: "new"}
+		err = Update(db, oldNote.Name, newNote)
+		assert.NoError(t, err)
+
+		_, err = Get(db, newNote.Name)
+		assert.NoError(t, err)
+
+		_, err = Get(db, oldNote.Name)
+		assert.Error(t, err)
+	}
+}
+
+func TestCreateErrors(t *testing.T) {
+	db := setContext(t)
+
+	cases := []struct {
+		desc string
+		name string
+	}{
+		{
+			desc: "Invalid name",
+			name: "",
+		},
+		{
+			desc: "Null characters",
+			name: string([]rune{'\x00'}),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			err := Create(db, &pb.Note{Name: tc.name})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestGetError(t *testing.T) {
+	db := setContext(t)
+
+	_, err := Get(db, "non-existent")
+	assert.Error(t, err)
+}
+
+func TestUpdateError(t *testing.T) {
+	db := setContext(t)
+
+	name := string([]rune{'\x00'})
+	err := Update(db, "old", &pb.Note{Name: name})
+	assert.Error(t, err)
+}
+
+func TestCryptErrors(t *testing.T) {
+	db := setContext(t)
+
+	name := "crypt-errors"
+	err := Create(db, &pb.Note{Name: name})
+	assert.NoError(t, err)
+
+	// Try to get the note with another password
+	config.Set("auth.password", memguard.NewEnclave([]byte("invalid")))
+
+	_, err = Get(db, name)
+	assert.Error(t, err)
+	_, err = List(db)
+	assert.Error(t, err)
+}
+
+func TestProtoErrors(t *testing.T) {
+	db := setContext(t)
+
+	name := "unformatted"
+	err := db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket.Note.GetName())
+		buf := make([]byte, 64)
+		encBuf, _ := crypt.Encrypt(buf)
+		return b.Put([]byte(name), encBuf)
+	})
+	assert.NoError(t, err, "Failed writing invalid type")
+
+	_, err = Get(db, name)
+	assert.Error(t, err)
+	_, err = List(db)
+	assert.Error(t, err)
+}
+
+func TestKeyError(t *testing.T) {
+	db := setContext(t)
+
+	err := Create(db, &pb.Note{Name: ""})
+	assert.Error(t, err)
+}
+
+func setContext(t testing.TB) *bolt.DB {
+	return dbutil.SetContext(t, bucket.Note.GetName())
+}
@@ -0,0 +1,63 @@
+package sshkey
+
+import (
+	"strings"
+
+	dbutil "github.com/GGP1/kure/db"
+	"github.com/GGP1/kure/db/bucket"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Create a new SSH key.
+func Create(db *bolt.DB, key *pb.SSHKey) error {
+	return db.Batch(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket.SSH.GetName())
+		return dbutil.Put(b, key)
+	})
+}
+
+// Get retrieves the SSH key with the specified name.
+func Get(db *bolt.DB, name string) (*pb.SSHKey, error) {
+	key := &pb.SSHKey{}
+	if err := dbutil.Get(db, name, key); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// List returns a list with all the SSH keys.
+func List(db *bolt.DB) ([]*pb.SSHKey, error) {
+	return dbutil.List(db, &pb.SSHKey{})
+}
+
+// ListNames returns a list with all the SSH keys names.
+func ListNames(db *bolt.DB) ([]string, error) {
+	return dbutil.ListNames(db, bucket.SSH.GetName())
+}
+
+// Remove removes one or more SSH keys from the database.
+func Remove(db *bolt.DB, names ...string) error {
+	return dbutil.Remove(db, bucket.SSH.GetName(), names...)
+}
+
+// Update updates an SSH key, it removes the old one if the name differs.
+func Update(db *bolt.DB, oldName string, key *pb.SSHKey) error {
+	if strings.ContainsRune(key.Name, '\x00') {
+		return errors.New("SSH key name contains null characters")
+	}
+
+	return db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket.SSH.GetName())
+		if oldName != key.Name {
+			xorName := dbutil.XorName([]byte(oldName))
+			if err := b.Delete(xorName); err != nil {
+				return errors.Wrap(err, "remove old SSH key")
+			}
+		}
+		return dbutil.Put(b, key)
+	})
+}
@@ -0,0 +1,209 @@
+package sshkey
+
+import (
+	"testing"
+
+	"github.com/GGP1/kure/config"
+	"github.com/GGP1/kure/crypt"
+	dbutil "github.com/GGP1/kure/db"
+	"github.com/GGP1/kure/db/bucket"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/awnumar/memguard"
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestSSHKey(t *testing.T) {
+	db := setContext(t)
+
+	key := &pb.SSHKey{
+		Name:        "test",
+		PublicKey:   "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIBq2tUZmg0PnfSwSqLLKVBEKrIVNjMLHnKlNqtAGuQI7 test@kure",
+		Comment:     "test@kure",
+		Fingerprint: "SHA256:2xVJgpXBVOWdvFVXHzeFuqCuDe2jRNCsmHr+kjRx2YE",
+		Type:        "ssh-ed25519",
+	}
+
+	t.Run("Create", create(db, key))
+	t.Run("Get", get(db, key))
+	t.Run("List", list(db, key))
+	t.Run("List names", listNames(db, key))
+	t.Run("Remove", remove(db, key.Name))
+	t.Run("Update", update(db))
+	t.Run("Update name", updateName(db))
+}
+
+func create(db *bolt.DB, key *pb.SSHKey) func(*testing.T) {
+	return func(t *testing.T) {
+		err := Create(db, key)
+		assert.NoError(t, err)
+	}
+}
+
+func get(db *bolt.DB, expected *pb.SSHKey) func(*testing.T) {
+	return func(t *testing.T) {
+		got, err := Get(db, expected.Name)
+		assert.NoError(t, err)
+
+		if !proto.Equal(expected, got) {
+			t.Errorf("Expected %v, got %v", expected, got)
+		}
+	}
+}
+
+func list(db *bolt.DB, expected *pb.SSHKey) func(*testing.T) {
+	return func(t *testing.T) {
+		records, err := List(db)
+		assert.NoError(t, err)
+
+		assert.NotZero(t, len(records), "Expected one or more records")
+
+		got := records[0]
+		if !proto.Equal(expected, got) {
+			t.Errorf("Expected %v, got %v", expected, got)
+		}
+	}
+}
+
+func listNames(db *bolt.DB, expected *pb.SSHKey) func(*testing.T) {
+	return func(t *testing.T) {
+		records, err := ListNames(db)
+		assert.NoError(t, err)
+
+		if len(records) == 0 {
+			t.Fatal("Expected one or more records, got 0")
+		}
+
+		got := records[0]
+		if got != expected.Name {
+			t.Errorf("Expected %s, got %s", expected.Name, got)
+		}
+	}
+}
+
+func remove(db *bolt.DB, name string) func(*testing.T) {
+	return func(t *testing.T) {
+		err := Remove(db, name)
+		assert.NoError(t, err)
+	}
+}
+
+func update(db *bolt.DB) func(*testing.T) {
+	return func(t *testing.T) {
+		oldKey := &pb.SSHKey{Name: "test"}
+		err := Create(db, oldKey)
+		assert.NoError(t, err)
+
+		newKey := &pb.SSHKey{Name: "test", Comment: "comment"}
+		err = Update(db, oldKey.Name, newKey)
+		assert.NoError(t, err)
+
+		_, err = Get(db, newKey.Name)
+		assert.NoError(t, err)
+	}
+}
+
+func updateName(db *bolt.DB) func(*testing.T) {
+	return func(t *testing.T) {
+		oldKey := &pb.SSHKey{Name: "old"}
+		err := Create(db, oldKey)
+		assert.NoError(t, err)
+
+		newKey := &pb.SSHKey{Name: "new"}
+		err = Update(db, oldKey.Name, newKey)
+		assert.NoError(t, err)
+
+		_, err = Get(db, newKey.Name)
+		assert.NoError(t, err)
+
+		_, err = Get(db, oldKey.Name)
+		assert.Error(t, err)
+	}
+}
+
+func TestCreateErrors(t *testing.T) {
+	db := setContext(t)
+
+	cases := []struct {
+		desc string
+		name string
+	}{
+		{
+			desc: "Invalid name",
+			name: "",
+		},
+		{
+			desc: "Null characters",
+			name: string([]rune{'\x00'}),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			err := Create(db, &pb.SSHKey{Name: tc.name})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestGetError(t *testing.T) {
+	db := setContext(t)
+
+	_, err := Get(db, "non-existent")
+	assert.Error(t, err)
+}
+
+func TestUpdateError(t *testing.T) {
+	db := setContext(t)
+
+	name := string([]rune{'\x00'})
+	err := Update(db, "old", &pb.SSHKey{Name: name})
+	assert.Error(t, err)
+}
+
+func TestCryptErrors(t *testing.T) {
+	db := setContext(t)
+
+	name := "crypt-errors"
+	err := Create(db, &pb.SSHKey{Name: name})
+	assert.NoError(t, err)
+
+	// Try to get the SSH key with another password
+	config.Set("auth.password", memguard.NewEnclave([]byte("invalid")))
+
+	_, err = Get(db, name)
+	assert.Error(t, err)
+	_, err = List(db)
+	assert.Error(t, err)
+}
+
+func TestProtoErrors(t *testing.T) {
+	db := setContext(t)
+
+	name := "unformatted"
+	err := db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket.SSH.GetName())
+		buf := make([]byte, 64)
+		encBuf, _ := crypt.Encrypt(buf)
+		return b.Put([]byte(name), encBuf)
+	})
+	assert.NoError(t, err, "Failed writing invalid type")
+
+	_, err = Get(db, name)
+	assert.Error(t, err)
+	_, err = List(db)
+	assert.Error(t, err)
+}
+
+func TestKeyError(t *testing.T) {
+	db := setContext(t)
+
+	err := Create(db, &pb.SSHKey{Name: ""})
+	assert.Error(t, err)
+}
+
+func setContext(t testing.TB) *bolt.DB {
+	return dbutil.SetContext(t, bucket.SSH.GetName())
+}
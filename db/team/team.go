@@ -0,0 +1,478 @@
+// Package team manages the members of team vaults.
+//
+// Team vaults are databases whose master password is a random vault key. The vault key is sealed with
+// the X25519 public key of every member, so each of them can unlock the vault with their private key.
+// The access list, encrypted with the vault key, records the prefixes of the record names each member
+// can access.
+package team
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"maps"
+	"regexp"
+	"sort"
+
+	"github.com/GGP1/kure/config"
+	"github.com/GGP1/kure/crypt"
+	dbutil "github.com/GGP1/kure/db"
+	authDB "github.com/GGP1/kure/db/auth"
+	"github.com/GGP1/kure/db/bucket"
+
+	"github.com/awnumar/memguard"
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Argon2 contains the parameters used to derive the encryption keys from the vault key. It's random so
+// it doesn't need to be hardened against brute force attacks.
+var Argon2 = authDB.Argon2{
+	Iterations: 1,
+	Memory:     1024,
+	Threads:    1,
+}
+
+var (
+	aclKey       = []byte("acl")
+	memberPrefix = []byte("member/")
+	validName    = regexp.MustCompile(`^[a-zA-Z0-9_.@-]+$`)
+	errNotTeam   = errors.New("the vault is not a team vault")
+)
+
+// Member of a team vault.
+type Member struct {
+	Name      string
+	PublicKey []byte
+	// Prefixes of the record names the member can access, admins can access all of them
+	Prefixes []string
+	Admin    bool
+}
+
+// access is a member entry in the access list.
+type access struct {
+	Prefixes []string `json:"prefixes,omitempty"`
+	Admin    bool     `json:"admin,omitempty"`
+}
+
+// keyEntry contains the member public key and the vault key sealed with it. It's stored in plain text
+// so members can find their entry before unlocking the vault.
+type keyEntry struct {
+	PublicKey []byte `json:"public_key"`
+	VaultKey  []byte `json:"vault_key"`
+}
+
+// Init adds the first member to a team vault, it's always an admin.
+//
+// The database credentials must be set to the configuration.
+func Init(db *bolt.DB, vaultKey []byte, admin Member) error {
+	if err := validateName(admin.Name); err != nil {
+		return err
+	}
+
+	return db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucket(bucket.Team.GetName())
+		if err != nil {
+			return errors.Wrap(err, "creating team bucket")
+		}
+
+		if err := putKeyEntry(b, admin.Name, admin.PublicKey, vaultKey); err != nil {
+			return err
+		}
+
+		return writeACL(b, map[string]access{admin.Name: {Admin: true}})
+	})
+}
+
+// Add adds a member to the team vault, the vault key is sealed with its public key.
+func Add(db *bolt.DB, member Member) error {
+	if err := validateName(member.Name); err != nil {
+		return err
+	}
+
+	vaultKey, err := currentVaultKey()
+	if err != nil {
+		return err
+	}
+
+	return db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket.Team.GetName())
+		if b == nil {
+			return errNotTeam
+		}
+
+		acl, err := readACL(b)
+		if err != nil {
+			return err
+		}
+		if _, ok := acl[member.Name]; ok {
+			return errors.Errorf("member %q already exists", member.Name)
+		}
+		if name, ok := findPublicKey(b, member.PublicKey); ok {
+			return errors.Errorf("the public key belongs to %q", name)
+		}
+
+		if err := putKeyEntry(b, member.Name, member.PublicKey, vaultKey); err != nil {
+			return err
+		}
+
+		acl[member.Name] = access{Prefixes: member.Prefixes, Admin: member.Admin}
+		return writeACL(b, acl)
+	})
+}
+
+// Current returns the member whose credentials are in use.
+func Current(db *bolt.DB) (*Member, error) {
+	name := config.GetString("auth.member")
+	if name == "" {
+		return nil, errNotTeam
+	}
+	return Get(db, name)
+}
+
+// Get returns the member with the name specified.
+func Get(db *bolt.DB, name string) (*Member, error) {
+	members, err := List(db)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, m := range members {
+		if m.Name == name {
+			return m, nil
+		}
+	}
+	return nil, errors.Errorf("member %q does not exist", name)
+}
+
+// List returns the members of the team vault sorted by name.
+func List(db *bolt.DB) ([]*Member, error) {
+	var members []*Member
+	err := db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket.Team.GetName())
+		if b == nil {
+			return errNotTeam
+		}
+
+		acl, err := readACL(b)
+		if err != nil {
+			return err
+		}
+
+		members = make([]*Member, 0, len(acl))
+		for name, a := range acl {
+			entry, err := getKeyEntry(b, name)
+			if err != nil {
+				return err
+			}
+			members = append(members, &Member{
+				Name:      name,
+				PublicKey: entry.PublicKey,
+				Prefixes:  a.Prefixes,
+				Admin:     a.Admin,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].Name < members[j].Name
+	})
+	return members, nil
+}
+
+// Remove removes a member from the team vault and rotates the vault key, the records are encrypted
+// with the new one so the member can no longer decrypt them.
+//
+// The new credentials are set to the configuration.
+func Remove(db *bolt.DB, name string) error {
+	prev, ok := config.Get("auth").(map[string]interface{})
+	if !ok {
+		return errors.New("no credentials found")
+	}
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket.Team.GetName())
+		if b == nil {
+			return errNotTeam
+		}
+
+		acl, err := readACL(b)
+		if err != nil {
+			return err
+		}
+		if _, ok := acl[name]; !ok {
+			return errors.Errorf("member %q does not exist", name)
+		}
+		delete(acl, name)
+		if !hasAdmin(acl) {
+			return errors.New("the team vault must have at least one admin")
+		}
+
+		if err := b.Delete(memberKey(name)); err != nil {
+			return errors.Wrap(err, "deleting member")
+		}
+
+		return rotate(tx, b, acl, prev)
+	})
+	if err != nil {
+		config.Set("auth", prev)
+		return err
+	}
+
+	return nil
+}
+
+// SetAccess updates the access of a member.
+func SetAccess(db *bolt.DB, name string, admin bool, prefixes []string) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket.Team.GetName())
+		if b == nil {
+			return errNotTeam
+		}
+
+		acl, err := readACL(b)
+		if err != nil {
+			return err
+		}
+		if _, ok := acl[name]; !ok {
+			return errors.Errorf("member %q does not exist", name)
+		}
+
+		acl[name] = access{Prefixes: prefixes, Admin: admin}
+		if !hasAdmin(acl) {
+			return errors.New("the team vault must have at least one admin")
+		}
+		return writeACL(b, acl)
+	})
+}
+
+// Unlock returns the name of the member the private key belongs to and the vault key.
+func Unlock(db *bolt.DB, privateKey []byte) (string, []byte, error) {
+	publicKey, err := crypt.PublicKey(privateKey)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var (
+		name  string
+		entry keyEntry
+	)
+	err = db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket.Team.GetName())
+		if b == nil {
+			return errNotTeam
+		}
+
+		n, ok := findPublicKey(b, publicKey)
+		if !ok {
+			return errors.New("the member key does not belong to any member of the team vault")
+		}
+
+		name = n
+		e, err := getKeyEntry(b, name)
+		entry = e
+		return err
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	vaultKey, err := crypt.Open(privateKey, entry.VaultKey)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "unsealing vault key")
+	}
+	return name, vaultKey, nil
+}
+
+// rotate generates new vault and names keys, encrypts the records and the authentication key with
+// them and seals the vault key for every member in the access list.
+func rotate(tx *bolt.Tx, b *bolt.Bucket, acl map[string]access, prev map[string]interface{}) error {
+	type record struct {
+		name []byte
+		data []byte
+	}
+
+	records := make(map[string][]record)
+	for _, name := range bucket.GetNames() {
+		rb := tx.Bucket(name)
+		if rb == nil {
+			continue
+		}
+
+		err := rb.ForEach(func(k, v []byte) error {
+			data, err := crypt.Decrypt(v)
+			if err != nil {
+				return errors.Wrap(err, "decrypt record")
+			}
+			records[string(name)] = append(records[string(name)], record{name: dbutil.XorName(k), data: data})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	vaultKey := randomKey()
+	nameKey := randomKey()
+	next := maps.Clone(prev)
+	// The enclave wipes the buffer passed
+	next["password"] = memguard.NewEnclave(bytes.Clone(vaultKey))
+	next["key"] = nameKey
+	config.Set("auth", next)
+
+	for _, name := range bucket.GetNames() {
+		if err := tx.DeleteBucket(name); err != nil && !errors.Is(err, bolt.ErrBucketNotFound) {
+			return errors.Wrapf(err, "deleting %q bucket", name)
+		}
+		rb, err := tx.CreateBucket(name)
+		if err != nil {
+			return errors.Wrapf(err, "creating %q bucket", name)
+		}
+
+		for _, r := range records[string(name)] {
+			encRecord, err := crypt.Encrypt(r.data)
+			if err != nil {
+				return errors.Wrap(err, "encrypt record")
+			}
+			if err := rb.Put(dbutil.XorName(r.name), encRecord); err != nil {
+				return errors.Wrap(err, "store record")
+			}
+		}
+	}
+
+	if err := authDB.UpdateKey(tx, nameKey); err != nil {
+		return err
+	}
+
+	for name := range acl {
+		entry, err := getKeyEntry(b, name)
+		if err != nil {
+			return err
+		}
+		if err := putKeyEntry(b, name, entry.PublicKey, vaultKey); err != nil {
+			return err
+		}
+	}
+
+	// The synchronization state is encrypted with the previous key
+	if err := tx.DeleteBucket(bucket.Sync.GetName()); err != nil && !errors.Is(err, bolt.ErrBucketNotFound) {
+		return errors.Wrap(err, "deleting sync bucket")
+	}
+
+	return writeACL(b, acl)
+}
+
+func currentVaultKey() ([]byte, error) {
+	enclave := config.GetEnclave("auth.password")
+	if enclave == nil {
+		return nil, errors.New("no credentials found")
+	}
+
+	buf, err := enclave.Open()
+	if err != nil {
+		return nil, errors.Wrap(err, "opening vault key")
+	}
+	defer buf.Destroy()
+
+	return bytes.Clone(buf.Bytes()), nil
+}
+
+func findPublicKey(b *bolt.Bucket, publicKey []byte) (string, bool) {
+	c := b.Cursor()
+	for k, v := c.Seek(memberPrefix); k != nil && bytes.HasPrefix(k, memberPrefix); k, v = c.Next() {
+		var entry keyEntry
+		if err := json.Unmarshal(v, &entry); err != nil {
+			continue
+		}
+		if bytes.Equal(entry.PublicKey, publicKey) {
+			return string(k[len(memberPrefix):]), true
+		}
+	}
+	return "", false
+}
+
+func getKeyEntry(b *bolt.Bucket, name string) (keyEntry, error) {
+	var entry keyEntry
+	v := b.Get(memberKey(name))
+	if v == nil {
+		return entry, errors.Errorf("member %q has no vault key", name)
+	}
+	if err := json.Unmarshal(v, &entry); err != nil {
+		return entry, errors.Wrapf(err, "decoding %q vault key", name)
+	}
+	return entry, nil
+}
+
+func putKeyEntry(b *bolt.Bucket, name string, publicKey, vaultKey []byte) error {
+	sealed, err := crypt.Seal(publicKey, vaultKey)
+	if err != nil {
+		return errors.Wrapf(err, "sealing vault key for %q", name)
+	}
+
+	data, err := json.Marshal(keyEntry{PublicKey: publicKey, VaultKey: sealed})
+	if err != nil {
+		return errors.Wrap(err, "encoding vault key")
+	}
+	return errors.Wrap(b.Put(memberKey(name), data), "saving vault key")
+}
+
+func memberKey(name string) []byte {
+	return []byte(string(memberPrefix) + name)
+}
+
+func readACL(b *bolt.Bucket) (map[string]access, error) {
+	encACL := b.Get(aclKey)
+	if encACL == nil {
+		return nil, errors.New("access list not found")
+	}
+
+	data, err := crypt.Decrypt(encACL)
+	if err != nil {
+		return nil, errors.Wrap(err, "decrypting access list")
+	}
+
+	var acl map[string]access
+	if err := json.Unmarshal(data, &acl); err != nil {
+		return nil, errors.Wrap(err, "decoding access list")
+	}
+	return acl, nil
+}
+
+func writeACL(b *bolt.Bucket, acl map[string]access) error {
+	data, err := json.Marshal(acl)
+	if err != nil {
+		return errors.Wrap(err, "encoding access list")
+	}
+
+	encACL, err := crypt.Encrypt(data)
+	if err != nil {
+		return errors.Wrap(err, "encrypting access list")
+	}
+	return errors.Wrap(b.Put(aclKey, encACL), "saving access list")
+}
+
+func hasAdmin(acl map[string]access) bool {
+	for _, a := range acl {
+		if a.Admin {
+			return true
+		}
+	}
+	return false
+}
+
+func randomKey() []byte {
+	key := make([]byte, 32)
+	_, _ = rand.Read(key)
+	return key
+}
+
+func validateName(name string) error {
+	if !validName.MatchString(name) {
+		return errors.Errorf("invalid member name %q, use letters, numbers and the characters _.@-", name)
+	}
+	return nil
+}
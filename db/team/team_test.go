@@ -0,0 +1,192 @@
+package team
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/GGP1/kure/config"
+	"github.com/GGP1/kure/crypt"
+	authDB "github.com/GGP1/kure/db/auth"
+	"github.com/GGP1/kure/db/entry"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/awnumar/memguard"
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestTeam(t *testing.T) {
+	db, alice := setContext(t)
+
+	bobKey, bobPub, err := crypt.GenerateKeyPair()
+	assert.NoError(t, err)
+	bob := Member{Name: "bob", PublicKey: bobPub, Prefixes: []string{"shared/"}}
+	assert.NoError(t, Add(db, bob))
+
+	members, err := List(db)
+	assert.NoError(t, err)
+	assert.Len(t, members, 2)
+	assert.Equal(t, "alice", members[0].Name)
+	assert.True(t, members[0].Admin)
+	assert.Equal(t, &bob, members[1])
+
+	name, vaultKey, err := Unlock(db, bobKey)
+	assert.NoError(t, err)
+	assert.Equal(t, "bob", name)
+	assert.Equal(t, currentKey(t), vaultKey)
+
+	name, _, err = Unlock(db, alice)
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", name)
+
+	assert.NoError(t, SetAccess(db, "bob", true, nil))
+	got, err := Get(db, "bob")
+	assert.NoError(t, err)
+	assert.True(t, got.Admin)
+	assert.Empty(t, got.Prefixes)
+}
+
+func TestAddErrors(t *testing.T) {
+	db, alice := setContext(t)
+	alicePub, err := crypt.PublicKey(alice)
+	assert.NoError(t, err)
+	_, pub, err := crypt.GenerateKeyPair()
+	assert.NoError(t, err)
+
+	cases := []struct {
+		desc   string
+		member Member
+	}{
+		{desc: "Invalid name", member: Member{Name: "b o b", PublicKey: pub}},
+		{desc: "Name exists", member: Member{Name: "alice", PublicKey: pub}},
+		{desc: "Public key exists", member: Member{Name: "bob", PublicKey: alicePub}},
+		{desc: "Invalid public key", member: Member{Name: "bob", PublicKey: []byte("invalid")}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			assert.Error(t, Add(db, tc.member))
+		})
+	}
+}
+
+func TestRemove(t *testing.T) {
+	db, alice := setContext(t)
+	assert.NoError(t, entry.Create(db, &pb.Entry{Name: "shared/github", Password: "secret", Expires: "Never"}))
+
+	bobKey, bobPub, err := crypt.GenerateKeyPair()
+	assert.NoError(t, err)
+	assert.NoError(t, Add(db, Member{Name: "bob", PublicKey: bobPub, Prefixes: []string{"shared/"}}))
+
+	oldKey := currentKey(t)
+	assert.NoError(t, Remove(db, "bob"))
+
+	_, _, err = Unlock(db, bobKey)
+	assert.Error(t, err)
+
+	// The configuration holds the new credentials
+	newKey := currentKey(t)
+	assert.NotEqual(t, oldKey, newKey)
+	e, err := entry.Get(db, "shared/github")
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", e.Password)
+
+	_, vaultKey, err := Unlock(db, alice)
+	assert.NoError(t, err)
+	assert.Equal(t, newKey, vaultKey)
+
+	params, err := authDB.GetParams(db)
+	assert.NoError(t, err)
+	nameKey, err := crypt.Decrypt(params.AuthKey)
+	assert.NoError(t, err)
+	assert.Equal(t, config.Get("auth.key"), nameKey)
+
+	members, err := List(db)
+	assert.NoError(t, err)
+	assert.Len(t, members, 1)
+}
+
+func TestRemoveErrors(t *testing.T) {
+	db, _ := setContext(t)
+
+	assert.Error(t, Remove(db, "bob"), "Member does not exist")
+	assert.Error(t, Remove(db, "alice"), "Last admin")
+	assert.Error(t, SetAccess(db, "alice", false, []string{"shared/"}), "Last admin")
+	assert.Error(t, SetAccess(db, "bob", false, nil), "Member does not exist")
+
+	// Nothing changed
+	members, err := List(db)
+	assert.NoError(t, err)
+	assert.Len(t, members, 1)
+	assert.True(t, members[0].Admin)
+}
+
+func TestNotTeam(t *testing.T) {
+	db, _ := setContext(t)
+	err := db.Update(func(tx *bolt.Tx) error {
+		return tx.DeleteBucket([]byte("kure_team"))
+	})
+	assert.NoError(t, err)
+
+	_, err = List(db)
+	assert.Error(t, err)
+	assert.Error(t, Add(db, Member{Name: "bob"}))
+	assert.Error(t, Remove(db, "bob"))
+	assert.Error(t, SetAccess(db, "bob", true, nil))
+
+	key, _, err := crypt.GenerateKeyPair()
+	assert.NoError(t, err)
+	_, _, err = Unlock(db, key)
+	assert.Error(t, err)
+}
+
+// setContext creates a team vault with "alice" as admin and returns her private key.
+func setContext(t *testing.T) (*bolt.DB, []byte) {
+	t.Helper()
+
+	f, err := os.CreateTemp(t.TempDir(), "*.db")
+	assert.NoError(t, err)
+	f.Close()
+
+	db, err := bolt.Open(f.Name(), 0o600, &bolt.Options{Timeout: time.Second})
+	assert.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	vaultKey := randomKey()
+	nameKey := randomKey()
+	config.Reset()
+	config.Set("auth", map[string]interface{}{
+		"password":   memguard.NewEnclave(bytes.Clone(vaultKey)),
+		"iterations": Argon2.Iterations,
+		"memory":     Argon2.Memory,
+		"threads":    Argon2.Threads,
+		"key":        nameKey,
+	})
+	assert.NoError(t, authDB.Register(db, nameKey, authDB.Params{Argon2: Argon2, Team: true}))
+
+	privateKey, publicKey, err := crypt.GenerateKeyPair()
+	assert.NoError(t, err)
+	assert.NoError(t, Init(db, vaultKey, Member{Name: "alice", PublicKey: publicKey}))
+
+	return db, privateKey
+}
+
+func currentKey(t *testing.T) []byte {
+	t.Helper()
+	key, err := currentVaultKey()
+	assert.NoError(t, err)
+	return key
+}
+
+func TestCurrent(t *testing.T) {
+	db, _ := setContext(t)
+
+	_, err := Current(db)
+	assert.Error(t, err)
+
+	config.Set("auth.member", "alice")
+	member, err := Current(db)
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", member.Name)
+}
@@ -0,0 +1,56 @@
+package kdbx
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/argon2"
+)
+
+// Test vectors from RFC 9106 section 5.
+func TestArgon2Key(t *testing.T) {
+	password := bytes.Repeat([]byte{0x01}, 32)
+	salt := bytes.Repeat([]byte{0x02}, 16)
+	secret := bytes.Repeat([]byte{0x03}, 8)
+	data := bytes.Repeat([]byte{0x04}, 12)
+
+	cases := []struct {
+		desc     string
+		mode     int
+		expected string
+	}{
+		{
+			desc:     "Argon2d",
+			mode:     argon2d,
+			expected: "512b391b6f1162975371d30919734294f868e3be3984f3c1a13a4db9fabe4acb",
+		},
+		{
+			desc:     "Argon2i",
+			mode:     argon2i,
+			expected: "c814d9d1dc7f37aa13f0d77f2494bda1c8de6b016dd388d29952a4c4672b6ce8",
+		},
+		{
+			desc:     "Argon2id",
+			mode:     argon2id,
+			expected: "0d640df58d78766c08c037a34a8b53c9d01ef0452d75b65eb52520e96b01e659",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got := argon2Key(tc.mode, password, salt, secret, data, 3, 32, 4, 32)
+			assert.Equal(t, tc.expected, hex.EncodeToString(got))
+		})
+	}
+}
+
+func TestArgon2KeyMatchesXCrypto(t *testing.T) {
+	password := []byte("kure")
+	salt := []byte("0123456789abcdef")
+
+	expected := argon2.IDKey(password, salt, 2, 256, 2, 32)
+	got := argon2Key(argon2id, password, salt, nil, nil, 2, 256, 2, 32)
+	assert.Equal(t, expected, got)
+}
@@ -0,0 +1,495 @@
+package kdbx
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"io"
+	"math"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20"
+	"golang.org/x/crypto/twofish"
+)
+
+const (
+	signature1 uint32 = 0x9AA2D903
+	signature2 uint32 = 0xB54BFB67
+	// KDBX 4.0, the lower 16 bits are the minor version and the upper 16 the major one
+	version       uint32 = 0x00040000
+	versionMajor4 uint32 = 4
+)
+
+// Outer header fields.
+const (
+	endOfHeader      byte = 0
+	cipherIDField    byte = 2
+	compressionField byte = 3
+	masterSeedField  byte = 4
+	ivField          byte = 7
+	kdfField         byte = 11
+)
+
+// Inner header fields.
+const (
+	innerEndOfHeader byte = 0
+	innerStreamID    byte = 1
+	innerStreamKey   byte = 2
+	innerBinary      byte = 3
+)
+
+const (
+	noCompression   uint32 = 0
+	gzipCompression uint32 = 1
+	chaCha20Stream  uint32 = 3
+)
+
+// Variant dictionary value types.
+const (
+	typeUInt32    byte = 0x04
+	typeUInt64    byte = 0x05
+	typeBool      byte = 0x08
+	typeInt32     byte = 0x0C
+	typeInt64     byte = 0x0D
+	typeString    byte = 0x18
+	typeByteArray byte = 0x42
+)
+
+var (
+	cipherAES256   = []byte{0x31, 0xC1, 0xF2, 0xE6, 0xBF, 0x71, 0x43, 0x50, 0xBE, 0x58, 0x05, 0x21, 0x6A, 0xFC, 0x5A, 0xFF}
+	cipherChaCha20 = []byte{0xD6, 0x03, 0x8A, 0x2B, 0x8B, 0x6F, 0x4C, 0xB5, 0xA5, 0x24, 0x33, 0x9A, 0x31, 0xDB, 0xB5, 0x9A}
+	cipherTwofish  = []byte{0xAD, 0x68, 0xF2, 0x9F, 0x57, 0x6F, 0x4B, 0xB9, 0xA3, 0x6A, 0xD4, 0x7A, 0xF9, 0x65, 0x34, 0x6C}
+
+	kdfAES      = []byte{0xC9, 0xD9, 0xF3, 0x9A, 0x62, 0x8A, 0x44, 0x60, 0xBF, 0x74, 0x0D, 0x08, 0xC1, 0x8A, 0x4F, 0xEA}
+	kdfArgon2d  = []byte{0xEF, 0x63, 0x6D, 0xDF, 0x8C, 0x29, 0x44, 0x4B, 0x91, 0xF7, 0xA9, 0xA4, 0x03, 0xE3, 0x0A, 0x0C}
+	kdfArgon2id = []byte{0x9E, 0x29, 0x8B, 0x19, 0x56, 0xDB, 0x47, 0x73, 0xB2, 0x3D, 0xFC, 0x3E, 0xC6, 0xF0, 0xA1, 0xE6}
+)
+
+// header contains the outer header fields used to decrypt the database.
+type header struct {
+	cipherID    []byte
+	compression uint32
+	masterSeed  []byte
+	iv          []byte
+	kdf         map[string]interface{}
+}
+
+// variant is a variant dictionary item.
+type variant struct {
+	key   string
+	value interface{}
+}
+
+// readHeader reads the outer header and returns it along with its raw bytes.
+func readHeader(r io.Reader) (*header, []byte, error) {
+	var raw bytes.Buffer
+	tr := io.TeeReader(r, &raw)
+
+	var sig [3]uint32
+	if err := binary.Read(tr, binary.LittleEndian, &sig); err != nil {
+		return nil, nil, errors.Wrap(err, "reading signature")
+	}
+	if sig[0] != signature1 || sig[1] != signature2 {
+		return nil, nil, errors.New("invalid file signature, it's not a KeePass database")
+	}
+	if major := sig[2] >> 16; major != versionMajor4 {
+		return nil, nil, errors.Errorf("unsupported KDBX version %d.%d, only KDBX 4 is supported", major, sig[2]&0xFFFF)
+	}
+
+	h := &header{}
+	for {
+		id, data, err := readField(tr)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "reading header")
+		}
+
+		switch id {
+		case endOfHeader:
+			return h, raw.Bytes(), h.validate()
+		case cipherIDField:
+			h.cipherID = data
+		case compressionField:
+			if len(data) != 4 {
+				return nil, nil, errors.New("invalid compression flags")
+			}
+			h.compression = binary.LittleEndian.Uint32(data)
+		case masterSeedField:
+			h.masterSeed = data
+		case ivField:
+			h.iv = data
+		case kdfField:
+			h.kdf, err = readVariantDictionary(data)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+}
+
+func (h *header) validate() error {
+	if len(h.masterSeed) != 32 {
+		return errors.New("invalid master seed")
+	}
+	if h.kdf == nil {
+		return errors.New("missing key derivation parameters")
+	}
+	if h.compression != noCompression && h.compression != gzipCompression {
+		return errors.Errorf("unsupported compression algorithm [%d]", h.compression)
+	}
+	return nil
+}
+
+// encode returns the outer header encoded.
+func (h *header) encode() []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, [3]uint32{signature1, signature2, version})
+
+	compression := make([]byte, 4)
+	binary.LittleEndian.PutUint32(compression, h.compression)
+
+	writeField(&buf, cipherIDField, h.cipherID)
+	writeField(&buf, compressionField, compression)
+	writeField(&buf, masterSeedField, h.masterSeed)
+	writeField(&buf, ivField, h.iv)
+	writeField(&buf, kdfField, writeVariantDictionary(kdfVariants(h.kdf)))
+	writeField(&buf, endOfHeader, []byte("\r\n\r\n"))
+
+	return buf.Bytes()
+}
+
+// readField reads a type-length-value header field.
+func readField(r io.Reader) (byte, []byte, error) {
+	var (
+		id   [1]byte
+		size uint32
+	)
+	if _, err := io.ReadFull(r, id[:]); err != nil {
+		return 0, nil, err
+	}
+	if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+		return 0, nil, err
+	}
+	// Avoid allocating huge buffers if the file is corrupted
+	if size > math.MaxInt32 {
+		return 0, nil, errors.New("invalid field size")
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return 0, nil, err
+	}
+
+	return id[0], data, nil
+}
+
+func writeField(w *bytes.Buffer, id byte, data []byte) {
+	w.WriteByte(id)
+	binary.Write(w, binary.LittleEndian, uint32(len(data)))
+	w.Write(data)
+}
+
+func readVariantDictionary(data []byte) (map[string]interface{}, error) {
+	r := bytes.NewReader(data)
+
+	var version uint16
+	if err := binary.Read(r, binary.LittleEndian, &version); err != nil || version&0xFF00 != 0x0100 {
+		return nil, errors.New("unsupported variant dictionary version")
+	}
+
+	dict := make(map[string]interface{})
+	for {
+		typ, err := r.ReadByte()
+		if err != nil {
+			return nil, errors.Wrap(err, "reading variant dictionary")
+		}
+		if typ == 0 {
+			return dict, nil
+		}
+
+		key, err := readSized(r)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading variant dictionary key")
+		}
+		value, err := readSized(r)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading variant dictionary value")
+		}
+
+		switch typ {
+		case typeUInt32, typeInt32:
+			if len(value) != 4 {
+				return nil, errors.Errorf("invalid %q value", key)
+			}
+			if typ == typeInt32 {
+				dict[string(key)] = int32(binary.LittleEndian.Uint32(value))
+			} else {
+				dict[string(key)] = binary.LittleEndian.Uint32(value)
+			}
+		case typeUInt64, typeInt64:
+			if len(value) != 8 {
+				return nil, errors.Errorf("invalid %q value", key)
+			}
+			if typ == typeInt64 {
+				dict[string(key)] = int64(binary.LittleEndian.Uint64(value))
+			} else {
+				dict[string(key)] = binary.LittleEndian.Uint64(value)
+			}
+		case typeBool:
+			dict[string(key)] = len(value) == 1 && value[0] != 0
+		case typeString:
+			dict[string(key)] = string(value)
+		case typeByteArray:
+			dict[string(key)] = value
+		default:
+			return nil, errors.Errorf("invalid variant dictionary type [%#x]", typ)
+		}
+	}
+}
+
+func writeVariantDictionary(variants []variant) []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, uint16(0x0100))
+
+	for _, v := range variants {
+		var (
+			typ   byte
+			value []byte
+		)
+		switch val := v.value.(type) {
+		case uint32:
+			typ, value = typeUInt32, binary.LittleEndian.AppendUint32(nil, val)
+		case uint64:
+			typ, value = typeUInt64, binary.LittleEndian.AppendUint64(nil, val)
+		case []byte:
+			typ, value = typeByteArray, val
+		}
+
+		buf.WriteByte(typ)
+		binary.Write(&buf, binary.LittleEndian, uint32(len(v.key)))
+		buf.WriteString(v.key)
+		binary.Write(&buf, binary.LittleEndian, uint32(len(value)))
+		buf.Write(value)
+	}
+	buf.WriteByte(0)
+
+	return buf.Bytes()
+}
+
+// kdfVariants returns the key derivation parameters in the order they are written.
+func kdfVariants(kdf map[string]interface{}) []variant {
+	keys := []string{"$UUID", "S", "R", "P", "M", "I", "V"}
+	variants := make([]variant, 0, len(keys))
+	for _, k := range keys {
+		if v, ok := kdf[k]; ok {
+			variants = append(variants, variant{key: k, value: v})
+		}
+	}
+	return variants
+}
+
+func readSized(r io.Reader) ([]byte, error) {
+	var size uint32
+	if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+		return nil, err
+	}
+	if size > math.MaxInt32 {
+		return nil, errors.New("invalid size")
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// compositeKey returns the key composed by the user credentials.
+func compositeKey(password []byte) []byte {
+	pwdHash := sha256.Sum256(password)
+	key := sha256.Sum256(pwdHash[:])
+	return key[:]
+}
+
+// transformKey derives the composite key using the key derivation function specified in the parameters.
+func transformKey(params map[string]interface{}, key []byte) ([]byte, error) {
+	uuid, _ := params["$UUID"].([]byte)
+
+	switch {
+	case bytes.Equal(uuid, kdfAES):
+		seed, _ := params["S"].([]byte)
+		rounds, _ := params["R"].(uint64)
+		return aesKDF(key, seed, rounds)
+
+	case bytes.Equal(uuid, kdfArgon2d), bytes.Equal(uuid, kdfArgon2id):
+		salt, _ := params["S"].([]byte)
+		parallelism, _ := params["P"].(uint32)
+		memory, _ := params["M"].(uint64)
+		iterations, _ := params["I"].(uint64)
+		version, _ := params["V"].(uint32)
+		secret, _ := params["K"].([]byte)
+		data, _ := params["A"].([]byte)
+
+		if version != argon2Version {
+			return nil, errors.Errorf("unsupported argon2 version [%#x]", version)
+		}
+		if parallelism < 1 || parallelism > math.MaxUint8 || iterations < 1 || iterations > math.MaxUint32 ||
+			memory/1024 < 8 || memory/1024 > math.MaxUint32 {
+			return nil, errors.New("invalid argon2 parameters")
+		}
+
+		mode := argon2d
+		if bytes.Equal(uuid, kdfArgon2id) {
+			if len(secret) == 0 && len(data) == 0 {
+				return argon2.IDKey(key, salt, uint32(iterations), uint32(memory/1024), uint8(parallelism), 32), nil
+			}
+			mode = argon2id
+		}
+
+		return argon2Key(mode, key, salt, secret, data, uint32(iterations), uint32(memory/1024), uint8(parallelism), 32), nil
+
+	default:
+		return nil, errors.New("unsupported key derivation function")
+	}
+}
+
+func aesKDF(key, seed []byte, rounds uint64) ([]byte, error) {
+	block, err := aes.NewCipher(seed)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid AES-KDF seed")
+	}
+
+	transformed := make([]byte, len(key))
+	copy(transformed, key)
+	for i := uint64(0); i < rounds; i++ {
+		block.Encrypt(transformed[:16], transformed[:16])
+		block.Encrypt(transformed[16:], transformed[16:])
+	}
+
+	sum := sha256.Sum256(transformed)
+	return sum[:], nil
+}
+
+// keys returns the encryption key and the HMAC base key.
+func keys(masterSeed, transformedKey []byte) ([]byte, []byte) {
+	encKey := sha256.Sum256(append(append([]byte{}, masterSeed...), transformedKey...))
+
+	h := sha512.New()
+	h.Write(masterSeed)
+	h.Write(transformedKey)
+	h.Write([]byte{0x01})
+
+	return encKey[:], h.Sum(nil)
+}
+
+// blockHMAC returns the HMAC of the block with the index specified.
+func blockHMAC(hmacKey []byte, index uint64, data []byte) []byte {
+	var idx [8]byte
+	binary.LittleEndian.PutUint64(idx[:], index)
+
+	key := sha512.Sum512(append(idx[:], hmacKey...))
+	mac := hmac.New(sha256.New, key[:])
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// headerHMAC returns the HMAC of the outer header.
+func headerHMAC(hmacKey, header []byte) []byte {
+	return blockHMAC(hmacKey, math.MaxUint64, header)
+}
+
+// decrypt decrypts the payload using the cipher specified in the header.
+func (h *header) decrypt(key, ciphertext []byte) ([]byte, error) {
+	switch {
+	case bytes.Equal(h.cipherID, cipherChaCha20):
+		stream, err := chacha20.NewUnauthenticatedCipher(key, h.iv)
+		if err != nil {
+			return nil, errors.Wrap(err, "creating cipher")
+		}
+		plaintext := make([]byte, len(ciphertext))
+		stream.XORKeyStream(plaintext, ciphertext)
+		return plaintext, nil
+
+	case bytes.Equal(h.cipherID, cipherAES256), bytes.Equal(h.cipherID, cipherTwofish):
+		block, err := h.blockCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		if len(h.iv) != block.BlockSize() || len(ciphertext) == 0 || len(ciphertext)%block.BlockSize() != 0 {
+			return nil, errors.New("invalid ciphertext")
+		}
+
+		plaintext := make([]byte, len(ciphertext))
+		cipher.NewCBCDecrypter(block, h.iv).CryptBlocks(plaintext, ciphertext)
+
+		padding := int(plaintext[len(plaintext)-1])
+		if padding == 0 || padding > block.BlockSize() {
+			return nil, errors.New("invalid padding")
+		}
+		return plaintext[:len(plaintext)-padding], nil
+
+	default:
+		return nil, errors.New("unsupported cipher")
+	}
+}
+
+// encrypt encrypts the payload using the cipher specified in the header.
+func (h *header) encrypt(key, plaintext []byte) ([]byte, error) {
+	if bytes.Equal(h.cipherID, cipherChaCha20) {
+		stream, err := chacha20.NewUnauthenticatedCipher(key, h.iv)
+		if err != nil {
+			return nil, errors.Wrap(err, "creating cipher")
+		}
+		ciphertext := make([]byte, len(plaintext))
+		stream.XORKeyStream(ciphertext, plaintext)
+		return ciphertext, nil
+	}
+
+	block, err := h.blockCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	padding := block.BlockSize() - len(plaintext)%block.BlockSize()
+	padded := append(plaintext, bytes.Repeat([]byte{byte(padding)}, padding)...)
+
+	ciphertext := make([]byte, len(padded))
+	cipher.NewCBCEncrypter(block, h.iv).CryptBlocks(ciphertext, padded)
+	return ciphertext, nil
+}
+
+func (h *header) blockCipher(key []byte) (cipher.Block, error) {
+	var (
+		block cipher.Block
+		err   error
+	)
+	if bytes.Equal(h.cipherID, cipherTwofish) {
+		block, err = twofish.NewCipher(key)
+	} else {
+		block, err = aes.NewCipher(key)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "creating cipher")
+	}
+
+	return block, nil
+}
+
+// innerStream returns the cipher used to protect values inside the XML document.
+func innerStream(id uint32, key []byte) (cipher.Stream, error) {
+	if id != chaCha20Stream {
+		return nil, errors.Errorf("unsupported inner random stream [%d]", id)
+	}
+
+	hash := sha512.Sum512(key)
+	stream, err := chacha20.NewUnauthenticatedCipher(hash[:32], hash[32:44])
+	if err != nil {
+		return nil, errors.Wrap(err, "creating inner stream cipher")
+	}
+
+	return stream, nil
+}
@@ -0,0 +1,531 @@
+// Package kdbx implements reading and writing of KeePass databases (KDBX 4).
+package kdbx
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const blockSize = 1024 * 1024
+
+// Standard entry fields, every other key is considered a custom field.
+const (
+	titleKey    = "Title"
+	usernameKey = "UserName"
+	passwordKey = "Password"
+	urlKey      = "URL"
+	notesKey    = "Notes"
+)
+
+// Key derivation parameters used when encoding databases (argon2id).
+var (
+	kdfIterations  uint64 = 10
+	kdfMemory      uint64 = 64 * 1024 * 1024
+	kdfParallelism uint32 = 2
+)
+
+// ErrInvalidCredentials is returned when the database can't be decrypted with the password provided.
+var ErrInvalidCredentials = errors.New("invalid credentials or corrupted database")
+
+// Database represents a KeePass database.
+type Database struct {
+	Name string
+	Root *Group
+}
+
+// Group contains entries and other groups.
+type Group struct {
+	Name    string
+	Groups  []*Group
+	Entries []*Entry
+}
+
+// Entry represents a KeePass entry.
+type Entry struct {
+	Title    string
+	Username string
+	Password string
+	URL      string
+	Notes    string
+	// Zero means the entry never expires
+	Expires time.Time
+	// Zero means the modification time is unknown
+	Modified    time.Time
+	Fields      []Field
+	Attachments []Attachment
+}
+
+// Field is a custom entry field.
+type Field struct {
+	Key       string
+	Value     string
+	Protected bool
+}
+
+// Attachment is a file attached to an entry.
+type Attachment struct {
+	Name string
+	Data []byte
+}
+
+// Field returns the value of the custom field with the key specified or an empty string if it doesn't exist.
+func (e *Entry) Field(key string) string {
+	for _, f := range e.Fields {
+		if f.Key == key {
+			return f.Value
+		}
+	}
+	return ""
+}
+
+// Decode reads and decrypts a KDBX 4 database.
+func Decode(r io.Reader, password []byte) (*Database, error) {
+	br := bufio.NewReader(r)
+	h, rawHeader, err := readHeader(br)
+	if err != nil {
+		return nil, err
+	}
+
+	var checksum, mac [32]byte
+	if _, err := io.ReadFull(br, checksum[:]); err != nil {
+		return nil, errors.Wrap(err, "reading header checksum")
+	}
+	if _, err := io.ReadFull(br, mac[:]); err != nil {
+		return nil, errors.Wrap(err, "reading header HMAC")
+	}
+	if sum := sha256.Sum256(rawHeader); !bytes.Equal(sum[:], checksum[:]) {
+		return nil, errors.New("header checksum mismatch, the database is corrupted")
+	}
+
+	transformedKey, err := transformKey(h.kdf, compositeKey(password))
+	if err != nil {
+		return nil, err
+	}
+	encKey, hmacKey := keys(h.masterSeed, transformedKey)
+
+	if !hmac.Equal(mac[:], headerHMAC(hmacKey, rawHeader)) {
+		return nil, ErrInvalidCredentials
+	}
+
+	ciphertext, err := readBlocks(br, hmacKey)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := h.decrypt(encKey, ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	if h.compression == gzipCompression {
+		payload, err = gunzip(payload)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return decodePayload(payload)
+}
+
+// Encode encrypts and writes the database in the KDBX 4 format, using AES-256 and argon2id.
+func Encode(w io.Writer, db *Database, password []byte) error {
+	h := &header{
+		cipherID:    cipherAES256,
+		compression: gzipCompression,
+		masterSeed:  randomBytes(32),
+		iv:          randomBytes(16),
+		kdf: map[string]interface{}{
+			"$UUID": kdfArgon2id,
+			"S":     randomBytes(32),
+			"P":     kdfParallelism,
+			"M":     kdfMemory,
+			"I":     kdfIterations,
+			"V":     uint32(argon2Version),
+		},
+	}
+
+	return encode(w, h, db, password)
+}
+
+func encode(w io.Writer, h *header, db *Database, password []byte) error {
+	transformedKey, err := transformKey(h.kdf, compositeKey(password))
+	if err != nil {
+		return err
+	}
+	encKey, hmacKey := keys(h.masterSeed, transformedKey)
+
+	payload, err := encodePayload(db)
+	if err != nil {
+		return err
+	}
+
+	payload, err = gzipBytes(payload)
+	if err != nil {
+		return err
+	}
+
+	ciphertext, err := h.encrypt(encKey, payload)
+	if err != nil {
+		return err
+	}
+
+	rawHeader := h.encode()
+	checksum := sha256.Sum256(rawHeader)
+
+	bw := bufio.NewWriter(w)
+	bw.Write(rawHeader)
+	bw.Write(checksum[:])
+	bw.Write(headerHMAC(hmacKey, rawHeader))
+	writeBlocks(bw, hmacKey, ciphertext)
+
+	if err := bw.Flush(); err != nil {
+		return errors.Wrap(err, "writing database")
+	}
+	return nil
+}
+
+// readBlocks reads and verifies the HMAC block stream and returns its content.
+func readBlocks(r io.Reader, hmacKey []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	for index := uint64(0); ; index++ {
+		var (
+			mac  [32]byte
+			size uint32
+		)
+		if _, err := io.ReadFull(r, mac[:]); err != nil {
+			return nil, errors.Wrap(err, "reading block HMAC")
+		}
+		if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+			return nil, errors.Wrap(err, "reading block size")
+		}
+
+		data := make([]byte, 12+int(size))
+		binary.LittleEndian.PutUint64(data[:8], index)
+		binary.LittleEndian.PutUint32(data[8:12], size)
+		if _, err := io.ReadFull(r, data[12:]); err != nil {
+			return nil, errors.Wrap(err, "reading block")
+		}
+
+		if !hmac.Equal(mac[:], blockHMAC(hmacKey, index, data)) {
+			return nil, errors.Errorf("block %d is corrupted", index)
+		}
+
+		if size == 0 {
+			return buf.Bytes(), nil
+		}
+		buf.Write(data[12:])
+	}
+}
+
+func writeBlocks(w io.Writer, hmacKey, data []byte) {
+	for index := uint64(0); ; index++ {
+		n := min(len(data), blockSize)
+
+		block := make([]byte, 12+n)
+		binary.LittleEndian.PutUint64(block[:8], index)
+		binary.LittleEndian.PutUint32(block[8:12], uint32(n))
+		copy(block[12:], data[:n])
+
+		w.Write(blockHMAC(hmacKey, index, block))
+		w.Write(block[8:])
+
+		// The last block is always empty
+		if n == 0 {
+			return
+		}
+		data = data[n:]
+	}
+}
+
+func decodePayload(payload []byte) (*Database, error) {
+	r := bytes.NewReader(payload)
+
+	var (
+		streamID  uint32
+		streamKey []byte
+		binaries  [][]byte
+	)
+readInnerHeader:
+	for {
+		id, data, err := readField(r)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading inner header")
+		}
+
+		switch id {
+		case innerEndOfHeader:
+			break readInnerHeader
+		case innerStreamID:
+			if len(data) != 4 {
+				return nil, errors.New("invalid inner random stream ID")
+			}
+			streamID = binary.LittleEndian.Uint32(data)
+		case innerStreamKey:
+			streamKey = data
+		case innerBinary:
+			if len(data) == 0 {
+				return nil, errors.New("invalid binary")
+			}
+			// Skip the flags byte
+			binaries = append(binaries, data[1:])
+		}
+	}
+
+	stream, err := innerStream(streamID, streamKey)
+	if err != nil {
+		return nil, err
+	}
+
+	document, err := io.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading XML document")
+	}
+
+	document, err = transformProtected(document, stream, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var file xmlFile
+	if err := xml.Unmarshal(document, &file); err != nil {
+		return nil, errors.Wrap(err, "decoding XML document")
+	}
+
+	recycleBin := ""
+	if !strings.EqualFold(file.Meta.RecycleBinEnabled, "false") {
+		recycleBin = file.Meta.RecycleBinUUID
+	}
+
+	root, err := decodeGroup(file.Root.Group, binaries, recycleBin)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Database{Name: file.Meta.DatabaseName, Root: root}, nil
+}
+
+func decodeGroup(g xmlGroup, binaries [][]byte, recycleBin string) (*Group, error) {
+	group := &Group{
+		Name:    g.Name,
+		Entries: make([]*Entry, 0, len(g.Entries)),
+		Groups:  make([]*Group, 0, len(g.Groups)),
+	}
+
+	for _, e := range g.Entries {
+		entry, err := decodeEntry(e, binaries)
+		if err != nil {
+			return nil, err
+		}
+		group.Entries = append(group.Entries, entry)
+	}
+
+	for _, subgroup := range g.Groups {
+		// Skip deleted entries
+		if recycleBin != "" && subgroup.UUID == recycleBin {
+			continue
+		}
+
+		sg, err := decodeGroup(subgroup, binaries, recycleBin)
+		if err != nil {
+			return nil, err
+		}
+		group.Groups = append(group.Groups, sg)
+	}
+
+	return group, nil
+}
+
+func decodeEntry(e xmlEntry, binaries [][]byte) (*Entry, error) {
+	entry := &Entry{}
+	for _, s := range e.Strings {
+		switch s.Key {
+		case titleKey:
+			entry.Title = s.Value.Content
+		case usernameKey:
+			entry.Username = s.Value.Content
+		case passwordKey:
+			entry.Password = s.Value.Content
+		case urlKey:
+			entry.URL = s.Value.Content
+		case notesKey:
+			entry.Notes = s.Value.Content
+		default:
+			entry.Fields = append(entry.Fields, Field{
+				Key:       s.Key,
+				Value:     s.Value.Content,
+				Protected: strings.EqualFold(s.Value.Protected, "true"),
+			})
+		}
+	}
+
+	if e.Times.LastModificationTime != "" {
+		// Not critical, ignore invalid times
+		entry.Modified, _ = parseTime(e.Times.LastModificationTime)
+	}
+
+	if strings.EqualFold(e.Times.Expires, "true") {
+		expires, err := parseTime(e.Times.ExpiryTime)
+		if err != nil {
+			return nil, errors.Wrapf(err, "entry %q", entry.Title)
+		}
+		entry.Expires = expires
+	}
+
+	for _, b := range e.Binaries {
+		if b.Value.Ref < 0 || b.Value.Ref >= len(binaries) {
+			return nil, errors.Errorf("entry %q: invalid attachment reference", entry.Title)
+		}
+		entry.Attachments = append(entry.Attachments, Attachment{Name: b.Key, Data: binaries[b.Value.Ref]})
+	}
+
+	return entry, nil
+}
+
+func encodePayload(db *Database) ([]byte, error) {
+	streamKey := randomBytes(64)
+	stream, err := innerStream(chaCha20Stream, streamKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var binaries [][]byte
+	root := db.Root
+	if root == nil {
+		root = &Group{}
+	}
+	if root.Name == "" {
+		root.Name = "Root"
+	}
+
+	file := xmlFile{
+		Meta: xmlMeta{Generator: "Kure", DatabaseName: db.Name},
+		Root: xmlRoot{Group: encodeGroup(root, &binaries)},
+	}
+
+	document, err := xml.Marshal(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "encoding XML document")
+	}
+
+	document, err = transformProtected(document, stream, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	writeField(&buf, innerStreamID, binary.LittleEndian.AppendUint32(nil, chaCha20Stream))
+	writeField(&buf, innerStreamKey, streamKey)
+	for _, b := range binaries {
+		// No flags (not protected in memory)
+		writeField(&buf, innerBinary, append([]byte{0}, b...))
+	}
+	writeField(&buf, innerEndOfHeader, nil)
+
+	buf.WriteString(xml.Header)
+	buf.Write(document)
+	return buf.Bytes(), nil
+}
+
+func encodeGroup(g *Group, binaries *[][]byte) xmlGroup {
+	group := xmlGroup{
+		UUID:    newUUID(),
+		Name:    g.Name,
+		Entries: make([]xmlEntry, 0, len(g.Entries)),
+		Groups:  make([]xmlGroup, 0, len(g.Groups)),
+	}
+
+	for _, e := range g.Entries {
+		group.Entries = append(group.Entries, encodeEntry(e, binaries))
+	}
+	for _, sg := range g.Groups {
+		group.Groups = append(group.Groups, encodeGroup(sg, binaries))
+	}
+
+	return group
+}
+
+func encodeEntry(e *Entry, binaries *[][]byte) xmlEntry {
+	entry := xmlEntry{
+		UUID: newUUID(),
+		Times: xmlTimes{
+			Expires: formatBool(!e.Expires.IsZero()),
+		},
+		Strings: []xmlString{
+			{Key: titleKey, Value: xmlValue{Content: e.Title}},
+			{Key: usernameKey, Value: xmlValue{Content: e.Username}},
+			{Key: passwordKey, Value: xmlValue{Content: e.Password, Protected: "True"}},
+			{Key: urlKey, Value: xmlValue{Content: e.URL}},
+			{Key: notesKey, Value: xmlValue{Content: e.Notes}},
+		},
+	}
+	if !e.Expires.IsZero() {
+		entry.Times.ExpiryTime = formatTime(e.Expires)
+	}
+	if !e.Modified.IsZero() {
+		entry.Times.LastModificationTime = formatTime(e.Modified)
+	}
+
+	for _, f := range e.Fields {
+		value := xmlValue{Content: f.Value}
+		if f.Protected {
+			value.Protected = "True"
+		}
+		entry.Strings = append(entry.Strings, xmlString{Key: f.Key, Value: value})
+	}
+
+	for _, a := range e.Attachments {
+		entry.Binaries = append(entry.Binaries, xmlBinary{Key: a.Name, Value: xmlBinaryValue{Ref: len(*binaries)}})
+		*binaries = append(*binaries, a.Data)
+	}
+
+	return entry
+}
+
+func gunzip(data []byte) ([]byte, error) {
+	gr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Wrap(err, "decompressing payload")
+	}
+	defer gr.Close()
+
+	payload, err := io.ReadAll(gr)
+	if err != nil {
+		return nil, errors.Wrap(err, "decompressing payload")
+	}
+	return payload, nil
+}
+
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	if _, err := gw.Write(data); err != nil {
+		return nil, errors.Wrap(err, "compressing payload")
+	}
+	if err := gw.Close(); err != nil {
+		return nil, errors.Wrap(err, "compressing payload")
+	}
+	return buf.Bytes(), nil
+}
+
+func newUUID() string {
+	return base64.StdEncoding.EncodeToString(randomBytes(16))
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	// crypto/rand Read never returns an error
+	rand.Read(b)
+	return b
+}
@@ -0,0 +1,611 @@
+package kdbx
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/chacha20"
+)
+
+var update = flag.Bool("update", false, "regenerate the KeePassXC layout fixture")
+
+const fixturePath = "testdata/keepassxc_layout.kdbx"
+
+func init() {
+	// Reduce argon2 parameters to speed up tests
+	kdfIterations = 1
+	kdfMemory = 64 * 1024
+	kdfParallelism = 1
+}
+
+func TestEncodeDecode(t *testing.T) {
+	password := []byte("kure")
+	db := testDatabase()
+
+	var buf bytes.Buffer
+	err := Encode(&buf, db, password)
+	assert.NoError(t, err)
+
+	got, err := Decode(&buf, password)
+	assert.NoError(t, err)
+	assert.Equal(t, db, got)
+}
+
+func TestEncodeDecodeParameters(t *testing.T) {
+	password := []byte("kure")
+	argon2Params := func(uuid []byte) map[string]interface{} {
+		return map[string]interface{}{
+			"$UUID": uuid,
+			"S":     randomBytes(32),
+			"P":     uint32(2),
+			"M":     uint64(32 * 1024),
+			"I":     uint64(2),
+			"V":     uint32(argon2Version),
+		}
+	}
+
+	cases := []struct {
+		desc     string
+		cipherID []byte
+		iv       []byte
+		kdf      map[string]interface{}
+	}{
+		{
+			desc:     "ChaCha20 and Argon2d",
+			cipherID: cipherChaCha20,
+			iv:       randomBytes(12),
+			kdf:      argon2Params(kdfArgon2d),
+		},
+		{
+			desc:     "Twofish and Argon2id",
+			cipherID: cipherTwofish,
+			iv:       randomBytes(16),
+			kdf:      argon2Params(kdfArgon2id),
+		},
+		{
+			desc:     "AES-256 and AES-KDF",
+			cipherID: cipherAES256,
+			iv:       randomBytes(16),
+			kdf: map[string]interface{}{
+				"$UUID": kdfAES,
+				"S":     randomBytes(32),
+				"R":     uint64(1000),
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			db := testDatabase()
+			h := &header{
+				cipherID:    tc.cipherID,
+				compression: gzipCompression,
+				masterSeed:  randomBytes(32),
+				iv:          tc.iv,
+				kdf:         tc.kdf,
+			}
+
+			var buf bytes.Buffer
+			err := encode(&buf, h, db, password)
+			assert.NoError(t, err)
+
+			got, err := Decode(&buf, password)
+			assert.NoError(t, err)
+			assert.Equal(t, db, got)
+		})
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	password := []byte("kure")
+	var buf bytes.Buffer
+	err := Encode(&buf, testDatabase(), password)
+	assert.NoError(t, err)
+	data := buf.Bytes()
+
+	kdbx3 := append([]byte{}, data...)
+	binary.LittleEndian.PutUint32(kdbx3[8:12], 0x00030001)
+
+	corrupted := append([]byte{}, data...)
+	corrupted[len(corrupted)-64] ^= 0xFF
+
+	cases := []struct {
+		desc     string
+		data     []byte
+		password []byte
+	}{
+		{
+			desc:     "Invalid password",
+			data:     data,
+			password: []byte("invalid"),
+		},
+		{
+			desc:     "Invalid signature",
+			data:     []byte("not a keepass database"),
+			password: password,
+		},
+		{
+			desc:     "Unsupported version",
+			data:     kdbx3,
+			password: password,
+		},
+		{
+			desc:     "Corrupted block",
+			data:     corrupted,
+			password: password,
+		},
+		{
+			desc:     "Truncated",
+			data:     data[:len(data)/2],
+			password: password,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			_, err := Decode(bytes.NewReader(tc.data), tc.password)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestDecodePayload(t *testing.T) {
+	streamKey := randomBytes(64)
+	hash := sha512.Sum512(streamKey)
+	stream, err := chacha20.NewUnauthenticatedCipher(hash[:32], hash[32:44])
+	assert.NoError(t, err)
+
+	protect := func(s string) string {
+		b := []byte(s)
+		stream.XORKeyStream(b, b)
+		return base64.StdEncoding.EncodeToString(b)
+	}
+
+	// Values must be protected in the same order they appear in the document,
+	// including the ones inside the history
+	oldPassword := protect("old")
+	password := protect("secret")
+	recycledPassword := protect("deleted")
+	document := `<?xml version="1.0" encoding="utf-8" standalone="yes"?>
+<KeePassFile>
+	<Meta>
+		<DatabaseName>Test</DatabaseName>
+		<RecycleBinEnabled>True</RecycleBinEnabled>
+		<RecycleBinUUID>cmVjeWNsZWJpbg==</RecycleBinUUID>
+	</Meta>
+	<Root>
+		<Group>
+			<UUID>cm9vdA==</UUID>
+			<Name>Root</Name>
+			<Entry>
+				<UUID>ZW50cnk=</UUID>
+				<Times>
+					<LastModificationTime>2024-05-03T12:30:00Z</LastModificationTime>
+					<Expires>True</Expires>
+					<ExpiryTime>2030-01-02T03:04:05Z</ExpiryTime>
+				</Times>
+				<History>
+					<Entry>
+						<String><Key>Password</Key><Value Protected="True">` + oldPassword + `</Value></String>
+					</Entry>
+				</History>
+				<String><Key>Title</Key><Value>GitHub</Value></String>
+				<String><Key>Password</Key><Value Protected="True">` + password + `</Value></String>
+				<String><Key>Empty</Key><Value Protected="True"/></String>
+			</Entry>
+			<Group>
+				<UUID>cmVjeWNsZWJpbg==</UUID>
+				<Name>Recycle Bin</Name>
+				<Entry>
+					<String><Key>Password</Key><Value Protected="True">` + recycledPassword + `</Value></String>
+				</Entry>
+			</Group>
+		</Group>
+	</Root>
+</KeePassFile>`
+
+	var buf bytes.Buffer
+	writeField(&buf, innerStreamID, binary.LittleEndian.AppendUint32(nil, chaCha20Stream))
+	writeField(&buf, innerStreamKey, streamKey)
+	writeField(&buf, innerEndOfHeader, nil)
+	buf.WriteString(document)
+
+	db, err := decodePayload(buf.Bytes())
+	assert.NoError(t, err)
+
+	expected := &Database{
+		Name: "Test",
+		Root: &Group{
+			Name: "Root",
+			Entries: []*Entry{
+				{
+					Title:    "GitHub",
+					Password: "secret",
+					Expires:  time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC),
+					Modified: time.Date(2024, 5, 3, 12, 30, 0, 0, time.UTC),
+					Fields:   []Field{{Key: "Empty", Protected: true}},
+				},
+			},
+			Groups: []*Group{},
+		},
+	}
+	assert.Equal(t, expected, db)
+}
+
+// TestDecodeKeePassXCLayout decodes a database using KeePassXC's defaults (AES-256, Argon2d and gzip) and
+// document layout, containing a TOTP, an attachment, a history and a recycle bin.
+//
+// The fixture was not saved by KeePassXC but written by writeKeePassXCLayout, which builds the file
+// following the format specification without using the package encoder. It should be replaced by a
+// database saved by KeePassXC when available, keeping the same content.
+func TestDecodeKeePassXCLayout(t *testing.T) {
+	if *update {
+		assert.NoError(t, writeKeePassXCLayout(fixturePath, []byte("kure")))
+	}
+
+	f, err := os.Open(fixturePath)
+	assert.NoError(t, err)
+	defer f.Close()
+
+	db, err := Decode(f, []byte("kure"))
+	assert.NoError(t, err)
+
+	expected := &Database{
+		Name: "Passwords",
+		Root: &Group{
+			Name: "Root",
+			Entries: []*Entry{
+				{
+					Title:    "GitHub",
+					Username: "kure",
+					Password: "p4$$w0rd <&>",
+					URL:      "https://github.com",
+					Notes:    "Multiline\nnotes",
+					Modified: time.Date(2024, 5, 3, 12, 30, 0, 0, time.UTC),
+					Fields: []Field{
+						{Key: "otp", Value: "otpauth://totp/GitHub:kure?secret=JBSWY3DPEHPK3PXP&period=30&digits=6&issuer=GitHub"},
+						{Key: "PIN", Value: "1234", Protected: true},
+					},
+					Attachments: []Attachment{{Name: "recovery.txt", Data: []byte("recovery codes")}},
+				},
+			},
+			Groups: []*Group{
+				{
+					Name: "Work",
+					Entries: []*Entry{
+						{
+							Title:    "Email",
+							Username: "user@example.com",
+							Password: "secret",
+							Expires:  time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC),
+							Modified: time.Date(2024, 5, 3, 12, 30, 0, 0, time.UTC),
+						},
+					},
+					Groups: []*Group{},
+				},
+			},
+		},
+	}
+	assert.Equal(t, expected, db)
+}
+
+func TestTime(t *testing.T) {
+	expected := time.Date(2024, 5, 3, 12, 30, 0, 0, time.UTC)
+
+	got, err := parseTime(formatTime(expected))
+	assert.NoError(t, err)
+	assert.Equal(t, expected, got)
+
+	_, err = parseTime("invalid")
+	assert.Error(t, err)
+}
+
+func testDatabase() *Database {
+	return &Database{
+		Name: "Test",
+		Root: &Group{
+			Name: "Root",
+			Entries: []*Entry{
+				{
+					Title:    "Email",
+					Username: "user@example.com",
+					Password: "p4$$w0rd <&>",
+					URL:      "https://mail.example.com",
+					Notes:    "Multiline\nnotes",
+					Expires:  time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+					Modified: time.Date(2024, 5, 3, 12, 30, 0, 0, time.UTC),
+					Fields: []Field{
+						{Key: "PIN", Value: "1234", Protected: true},
+						{Key: "Recovery email", Value: "recovery@example.com"},
+					},
+					Attachments: []Attachment{{Name: "key.txt", Data: []byte("recovery key")}},
+				},
+			},
+			Groups: []*Group{
+				{
+					Name: "Work",
+					Entries: []*Entry{
+						{Title: "GitHub", Username: "kure", Password: "secret"},
+					},
+					Groups: []*Group{
+						{Name: "Empty", Entries: []*Entry{}, Groups: []*Group{}},
+					},
+				},
+			},
+		},
+	}
+}
+
+// writeKeePassXCLayout writes the database decoded in TestDecodeKeePassXCLayout using only the standard
+// library and the argon2d implementation, so the test doesn't depend on the package encoder.
+func writeKeePassXCLayout(path string, password []byte) error {
+	seed := func(label string, n int) []byte {
+		sum := sha512.Sum512([]byte(label))
+		return sum[:n]
+	}
+	tlv := func(buf *bytes.Buffer, id byte, size func([]byte) []byte, data []byte) {
+		buf.WriteByte(id)
+		buf.Write(size(data))
+		buf.Write(data)
+	}
+	size32 := func(b []byte) []byte { return binary.LittleEndian.AppendUint32(nil, uint32(len(b))) }
+	u32 := func(v uint32) []byte { return binary.LittleEndian.AppendUint32(nil, v) }
+	u64 := func(v uint64) []byte { return binary.LittleEndian.AppendUint64(nil, v) }
+	kdbxTime := func(t time.Time) string {
+		return base64.StdEncoding.EncodeToString(u64(uint64(t.Unix() + 62135596800)))
+	}
+
+	masterSeed := seed("master seed", 32)
+	iv := seed("iv", 16)
+	salt := seed("salt", 32)
+	streamKey := seed("stream key", 64)
+	var (
+		iterations  uint64 = 2
+		memory      uint64 = 1024 * 1024
+		parallelism uint32 = 2
+	)
+
+	// KDF parameters, KeePassXC writes them sorted by key
+	var kdf bytes.Buffer
+	kdf.Write([]byte{0x00, 0x01})
+	variant := func(typ byte, key string, value []byte) {
+		kdf.WriteByte(typ)
+		kdf.Write(size32([]byte(key)))
+		kdf.WriteString(key)
+		kdf.Write(size32(value))
+		kdf.Write(value)
+	}
+	variant(0x42, "$UUID", []byte{0xEF, 0x63, 0x6D, 0xDF, 0x8C, 0x29, 0x44, 0x4B, 0x91, 0xF7, 0xA9, 0xA4, 0x03, 0xE3, 0x0A, 0x0C})
+	variant(0x05, "I", u64(iterations))
+	variant(0x05, "M", u64(memory))
+	variant(0x04, "P", u32(parallelism))
+	variant(0x42, "S", salt)
+	variant(0x04, "V", u32(0x13))
+	kdf.WriteByte(0x00)
+
+	var header bytes.Buffer
+	header.Write(u32(0x9AA2D903))
+	header.Write(u32(0xB54BFB67))
+	header.Write(u32(0x00040000))
+	tlv(&header, 2, size32, []byte{0x31, 0xC1, 0xF2, 0xE6, 0xBF, 0x71, 0x43, 0x50, 0xBE, 0x58, 0x05, 0x21, 0x6A, 0xFC, 0x5A, 0xFF})
+	tlv(&header, 3, size32, u32(1))
+	tlv(&header, 4, size32, masterSeed)
+	tlv(&header, 7, size32, iv)
+	tlv(&header, 11, size32, kdf.Bytes())
+	tlv(&header, 0, size32, []byte("\r\n\r\n"))
+
+	// Protected values are XORed with the inner stream in document order
+	streamHash := sha512.Sum512(streamKey)
+	stream, err := chacha20.NewUnauthenticatedCipher(streamHash[:32], streamHash[32:44])
+	if err != nil {
+		return err
+	}
+	protect := func(s string) string {
+		b := []byte(s)
+		stream.XORKeyStream(b, b)
+		return base64.StdEncoding.EncodeToString(b)
+	}
+	uuid := func(label string) string { return base64.StdEncoding.EncodeToString(seed(label, 16)) }
+	boolean := func(b bool) string {
+		if b {
+			return "True"
+		}
+		return "False"
+	}
+	modified := kdbxTime(time.Date(2024, 5, 3, 12, 30, 0, 0, time.UTC))
+	times := func(expires string) string {
+		expiry := modified
+		if expires != "" {
+			expiry = expires
+		}
+		return `<Times><LastModificationTime>` + modified + `</LastModificationTime><CreationTime>` + modified +
+			`</CreationTime><LastAccessTime>` + modified + `</LastAccessTime><ExpiryTime>` + expiry +
+			`</ExpiryTime><Expires>` + boolean(expires != "") + `</Expires><UsageCount>0</UsageCount>` +
+			`<LocationChanged>` + modified + `</LocationChanged></Times>`
+	}
+
+	document := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<KeePassFile>
+	<Meta>
+		<Generator>KeePassXC</Generator>
+		<DatabaseName>Passwords</DatabaseName>
+		<DatabaseNameChanged>` + modified + `</DatabaseNameChanged>
+		<DatabaseDescription/>
+		<DefaultUserName/>
+		<MaintenanceHistoryDays>365</MaintenanceHistoryDays>
+		<Color/>
+		<MasterKeyChanged>` + modified + `</MasterKeyChanged>
+		<MasterKeyChangeRec>-1</MasterKeyChangeRec>
+		<MasterKeyChangeForce>-1</MasterKeyChangeForce>
+		<MemoryProtection>
+			<ProtectTitle>False</ProtectTitle>
+			<ProtectUserName>False</ProtectUserName>
+			<ProtectPassword>True</ProtectPassword>
+			<ProtectURL>False</ProtectURL>
+			<ProtectNotes>False</ProtectNotes>
+		</MemoryProtection>
+		<CustomIcons/>
+		<RecycleBinEnabled>True</RecycleBinEnabled>
+		<RecycleBinUUID>` + uuid("recycle bin") + `</RecycleBinUUID>
+		<RecycleBinChanged>` + modified + `</RecycleBinChanged>
+		<EntryTemplatesGroup>AAAAAAAAAAAAAAAAAAAAAA==</EntryTemplatesGroup>
+		<HistoryMaxItems>10</HistoryMaxItems>
+		<HistoryMaxSize>6291456</HistoryMaxSize>
+		<CustomData>
+			<Item><Key>KPXC_DECRYPTION_TIME_PREFERENCE</Key><Value>1000</Value></Item>
+		</CustomData>
+	</Meta>
+	<Root>
+		<Group>
+			<UUID>` + uuid("root") + `</UUID>
+			<Name>Root</Name>
+			<Notes/>
+			<IconID>48</IconID>
+			` + times("") + `
+			<IsExpanded>True</IsExpanded>
+			<DefaultAutoTypeSequence/>
+			<EnableAutoType>null</EnableAutoType>
+			<EnableSearching>null</EnableSearching>
+			<LastTopVisibleEntry>AAAAAAAAAAAAAAAAAAAAAA==</LastTopVisibleEntry>
+			<Entry>
+				<UUID>` + uuid("github") + `</UUID>
+				<IconID>0</IconID>
+				<ForegroundColor/>
+				<BackgroundColor/>
+				<OverrideURL/>
+				<Tags/>
+				` + times("") + `
+				<String><Key>Notes</Key><Value>Multiline
+notes</Value></String>
+				<String><Key>Password</Key><Value Protected="True">` + protect("p4$$w0rd <&>") + `</Value></String>
+				<String><Key>Title</Key><Value>GitHub</Value></String>
+				<String><Key>URL</Key><Value>https://github.com</Value></String>
+				<String><Key>UserName</Key><Value>kure</Value></String>
+				<String><Key>otp</Key><Value>otpauth://totp/GitHub:kure?secret=JBSWY3DPEHPK3PXP&amp;period=30&amp;digits=6&amp;issuer=GitHub</Value></String>
+				<String><Key>PIN</Key><Value Protected="True">` + protect("1234") + `</Value></String>
+				<Binary><Key>recovery.txt</Key><Value Ref="0"/></Binary>
+				<AutoType>
+					<Enabled>True</Enabled>
+					<DataTransferObfuscation>0</DataTransferObfuscation>
+				</AutoType>
+				<History>
+					<Entry>
+						<UUID>` + uuid("github") + `</UUID>
+						<IconID>0</IconID>
+						` + times("") + `
+						<String><Key>Password</Key><Value Protected="True">` + protect("old") + `</Value></String>
+						<String><Key>Title</Key><Value>GitHub</Value></String>
+					</Entry>
+				</History>
+			</Entry>
+			<Group>
+				<UUID>` + uuid("work") + `</UUID>
+				<Name>Work</Name>
+				<Notes/>
+				<IconID>48</IconID>
+				` + times("") + `
+				<IsExpanded>True</IsExpanded>
+				<Entry>
+					<UUID>` + uuid("email") + `</UUID>
+					<IconID>0</IconID>
+					` + times(kdbxTime(time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC))) + `
+					<String><Key>Notes</Key><Value/></String>
+					<String><Key>Password</Key><Value Protected="True">` + protect("secret") + `</Value></String>
+					<String><Key>Title</Key><Value>Email</Value></String>
+					<String><Key>URL</Key><Value/></String>
+					<String><Key>UserName</Key><Value>user@example.com</Value></String>
+				</Entry>
+			</Group>
+			<Group>
+				<UUID>` + uuid("recycle bin") + `</UUID>
+				<Name>Recycle Bin</Name>
+				<Notes/>
+				<IconID>43</IconID>
+				` + times("") + `
+				<IsExpanded>False</IsExpanded>
+				<EnableAutoType>false</EnableAutoType>
+				<EnableSearching>false</EnableSearching>
+				<Entry>
+					<UUID>` + uuid("deleted") + `</UUID>
+					<IconID>0</IconID>
+					` + times("") + `
+					<String><Key>Password</Key><Value Protected="True">` + protect("deleted") + `</Value></String>
+					<String><Key>Title</Key><Value>Deleted</Value></String>
+				</Entry>
+			</Group>
+		</Group>
+		<DeletedObjects/>
+	</Root>
+</KeePassFile>
+`
+
+	var inner bytes.Buffer
+	tlv(&inner, 1, size32, u32(3))
+	tlv(&inner, 2, size32, streamKey)
+	// The first byte holds the flags, 0x01 means the binary is protected in memory
+	tlv(&inner, 3, size32, append([]byte{0x01}, "recovery codes"...))
+	tlv(&inner, 0, size32, nil)
+	inner.WriteString(document)
+
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	if _, err := zw.Write(inner.Bytes()); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	pwdHash := sha256.Sum256(password)
+	composite := sha256.Sum256(pwdHash[:])
+	transformed := argon2Key(argon2d, composite[:], salt, nil, nil, uint32(iterations), uint32(memory/1024), uint8(parallelism), 32)
+	encKey := sha256.Sum256(append(append([]byte{}, masterSeed...), transformed...))
+	hmacBase := sha512.Sum512(append(append(append([]byte{}, masterSeed...), transformed...), 0x01))
+	mac := func(index uint64, data []byte) []byte {
+		key := sha512.Sum512(append(u64(index), hmacBase[:]...))
+		h := hmac.New(sha256.New, key[:])
+		h.Write(data)
+		return h.Sum(nil)
+	}
+
+	// AES-256-CBC with PKCS#7 padding
+	plaintext := compressed.Bytes()
+	padding := aes.BlockSize - len(plaintext)%aes.BlockSize
+	plaintext = append(plaintext, bytes.Repeat([]byte{byte(padding)}, padding)...)
+	block, err := aes.NewCipher(encKey[:])
+	if err != nil {
+		return err
+	}
+	ciphertext := make([]byte, len(plaintext))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, plaintext)
+
+	var out bytes.Buffer
+	out.Write(header.Bytes())
+	headerHash := sha256.Sum256(header.Bytes())
+	out.Write(headerHash[:])
+	out.Write(mac(^uint64(0), header.Bytes()))
+	for index, data := range [][]byte{ciphertext, nil} {
+		blockData := append(u64(uint64(index)), size32(data)...)
+		blockData = append(blockData, data...)
+		out.Write(mac(uint64(index), blockData))
+		out.Write(blockData[8:])
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, out.Bytes(), 0o644)
+}
@@ -0,0 +1,185 @@
+package kdbx
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Seconds between 0001-01-01 and the Unix epoch, KDBX 4 times are stored relative to the former.
+const epochOffset = 62135596800
+
+type xmlFile struct {
+	XMLName xml.Name `xml:"KeePassFile"`
+	Meta    xmlMeta  `xml:"Meta"`
+	Root    xmlRoot  `xml:"Root"`
+}
+
+type xmlMeta struct {
+	Generator         string `xml:"Generator"`
+	DatabaseName      string `xml:"DatabaseName"`
+	RecycleBinEnabled string `xml:"RecycleBinEnabled,omitempty"`
+	RecycleBinUUID    string `xml:"RecycleBinUUID,omitempty"`
+}
+
+type xmlRoot struct {
+	Group xmlGroup `xml:"Group"`
+}
+
+type xmlGroup struct {
+	UUID    string     `xml:"UUID"`
+	Name    string     `xml:"Name"`
+	Entries []xmlEntry `xml:"Entry"`
+	Groups  []xmlGroup `xml:"Group"`
+}
+
+type xmlEntry struct {
+	UUID     string      `xml:"UUID"`
+	Times    xmlTimes    `xml:"Times"`
+	Strings  []xmlString `xml:"String"`
+	Binaries []xmlBinary `xml:"Binary"`
+}
+
+type xmlTimes struct {
+	LastModificationTime string `xml:"LastModificationTime,omitempty"`
+	Expires              string `xml:"Expires"`
+	ExpiryTime           string `xml:"ExpiryTime,omitempty"`
+}
+
+type xmlString struct {
+	Key   string   `xml:"Key"`
+	Value xmlValue `xml:"Value"`
+}
+
+type xmlValue struct {
+	Protected string `xml:"Protected,attr,omitempty"`
+	Content   string `xml:",chardata"`
+}
+
+type xmlBinary struct {
+	Key   string         `xml:"Key"`
+	Value xmlBinaryValue `xml:"Value"`
+}
+
+type xmlBinaryValue struct {
+	Ref int `xml:"Ref,attr"`
+}
+
+// transformProtected walks the XML document XORing the content of protected values with the
+// inner random stream, in the same order as they appear.
+//
+// If unprotect is true the values are decoded from base64, otherwise they are encoded to base64.
+func transformProtected(data []byte, stream cipher.Stream, unprotect bool) ([]byte, error) {
+	var buf bytes.Buffer
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	enc := xml.NewEncoder(&buf)
+
+	var (
+		protected bool
+		content   []byte
+	)
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, errors.Wrap(err, "parsing XML document")
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "Value" && isProtected(t.Attr) {
+				protected = true
+				content = content[:0]
+			}
+
+		case xml.CharData:
+			if protected {
+				content = append(content, t...)
+				continue
+			}
+
+		case xml.EndElement:
+			if protected {
+				value, err := xorValue(content, stream, unprotect)
+				if err != nil {
+					return nil, err
+				}
+				if err := enc.EncodeToken(xml.CharData(value)); err != nil {
+					return nil, errors.Wrap(err, "encoding XML document")
+				}
+				protected = false
+			}
+		}
+
+		if err := enc.EncodeToken(xml.CopyToken(tok)); err != nil {
+			return nil, errors.Wrap(err, "encoding XML document")
+		}
+	}
+
+	if err := enc.Flush(); err != nil {
+		return nil, errors.Wrap(err, "encoding XML document")
+	}
+
+	return buf.Bytes(), nil
+}
+
+func isProtected(attrs []xml.Attr) bool {
+	for _, attr := range attrs {
+		if attr.Name.Local == "Protected" && strings.EqualFold(attr.Value, "true") {
+			return true
+		}
+	}
+	return false
+}
+
+func xorValue(content []byte, stream cipher.Stream, unprotect bool) ([]byte, error) {
+	if !unprotect {
+		value := make([]byte, len(content))
+		stream.XORKeyStream(value, content)
+		return []byte(base64.StdEncoding.EncodeToString(value)), nil
+	}
+
+	value, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(content)))
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding protected value")
+	}
+	stream.XORKeyStream(value, value)
+	return value, nil
+}
+
+// parseTime parses both KDBX 4 (base64 encoded seconds) and KDBX 3 (ISO 8601) times.
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil || len(b) != 8 {
+		return time.Time{}, errors.Errorf("invalid time %q", s)
+	}
+
+	seconds := int64(binary.LittleEndian.Uint64(b))
+	return time.Unix(seconds-epochOffset, 0).UTC(), nil
+}
+
+func formatTime(t time.Time) string {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], uint64(t.Unix()+epochOffset))
+	return base64.StdEncoding.EncodeToString(b[:])
+}
+
+func formatBool(b bool) string {
+	if b {
+		return "True"
+	}
+	return "False"
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/GGP1/kure/commands/root"
 	"github.com/GGP1/kure/config"
 	"github.com/GGP1/kure/sig"
+	"github.com/GGP1/kure/vault"
 
 	"github.com/awnumar/memguard"
 	"github.com/spf13/pflag"
@@ -18,7 +19,8 @@ import (
 )
 
 func main() {
-	if err := validateFlags(); err != nil {
+	vaultName, err := validateFlags()
+	if err != nil {
 		if errors.Is(err, pflag.ErrHelp) {
 			os.Exit(0)
 		}
@@ -31,8 +33,13 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err := vault.Select(vaultName); err != nil {
+		fmt.Fprintln(os.Stderr, "couldn't select the vault:", err)
+		os.Exit(1)
+	}
+
 	// Check for and run stateless commands
-	if len(os.Args) < 2 || root.IsStatelessCommand(os.Args[1]) {
+	if len(os.Args) < 2 || root.IsStatelessCommand(os.Args[1:]...) {
 		if err := root.NewCmd(nil).Execute(); err != nil {
 			fmt.Fprintln(os.Stderr, "error:", err)
 			os.Exit(1)
@@ -68,26 +75,29 @@ func main() {
 
 // validateFlags looks for the command called and parses its flags. If the flag is `--help`,
 // it will print the command's help message and return the error pflag.ErrHelp.
-func validateFlags() error {
+//
+// It returns the name of the vault specified with the --vault flag, if any.
+func validateFlags() (string, error) {
 	// The help command is built-in so it won't be found below, plus it has no flags
 	if len(os.Args) > 1 && os.Args[1] == "help" {
-		return nil
+		return "", nil
 	}
 
 	cmd, args, err := root.NewCmd(nil).Find(os.Args[1:])
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	if err := cmd.ParseFlags(args); err != nil {
 		if errors.Is(err, pflag.ErrHelp) {
 			if err := cmd.Help(); err != nil {
-				return err
+				return "", err
 			}
-			return pflag.ErrHelp
+			return "", pflag.ErrHelp
 		}
-		return err
+		return "", err
 	}
 
-	return nil
+	vaultName, _ := cmd.Flags().GetString("vault")
+	return vaultName, nil
 }
@@ -25,12 +25,15 @@ type Entry struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Name     string `protobuf:"bytes,1,opt,name=name,proto3" json:"name"`
-	Username string `protobuf:"bytes,2,opt,name=username,proto3" json:"username"`
-	Password string `protobuf:"bytes,3,opt,name=password,proto3" json:"password"`
-	URL      string `protobuf:"bytes,4,opt,name=URL,proto3" json:"URL"`
-	Notes    string `protobuf:"bytes,5,opt,name=notes,proto3" json:"notes"`
-	Expires  string `protobuf:"bytes,6,opt,name=expires,proto3" json:"expires"`
+	Name              string `protobuf:"bytes,1,opt,name=name,proto3" json:"name"`
+	Username          string `protobuf:"bytes,2,opt,name=username,proto3" json:"username"`
+	Password          string `protobuf:"bytes,3,opt,name=password,proto3" json:"password"`
+	URL               string `protobuf:"bytes,4,opt,name=URL,proto3" json:"URL"`
+	Notes             string `protobuf:"bytes,5,opt,name=notes,proto3" json:"notes"`
+	Expires           string `protobuf:"bytes,6,opt,name=expires,proto3" json:"expires"`
+	PasswordUpdatedAt int64  `protobuf:"varint,7,opt,name=password_updated_at,json=passwordUpdatedAt,proto3" json:"password_updated_at"`
+	Profile           string `protobuf:"bytes,8,opt,name=profile,proto3" json:"profile"`
+	PreviousPassword  string `protobuf:"bytes,9,opt,name=previous_password,json=previousPassword,proto3" json:"previous_password"`
 }
 
 func (x *Entry) Reset() {
@@ -107,11 +110,32 @@ func (x *Entry) GetExpires() string {
 	return ""
 }
 
+func (x *Entry) GetPasswordUpdatedAt() int64 {
+	if x != nil {
+		return x.PasswordUpdatedAt
+	}
+	return 0
+}
+
+func (x *Entry) GetProfile() string {
+	if x != nil {
+		return x.Profile
+	}
+	return ""
+}
+
+func (x *Entry) GetPreviousPassword() string {
+	if x != nil {
+		return x.PreviousPassword
+	}
+	return ""
+}
+
 var File_entry_proto protoreflect.FileDescriptor
 
 var file_entry_proto_rawDesc = []byte{
 	0x0a, 0x0b, 0x65, 0x6e, 0x74, 0x72, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x02, 0x70,
-	0x62, 0x22, 0x95, 0x01, 0x0a, 0x05, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x12, 0x0a, 0x04, 0x6e,
+	0x62, 0x22, 0x8c, 0x02, 0x0a, 0x05, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x12, 0x0a, 0x04, 0x6e,
 	0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12,
 	0x1a, 0x0a, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
 	0x09, 0x52, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x70,
@@ -120,9 +144,17 @@ var file_entry_proto_rawDesc = []byte{
 	0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x55, 0x52, 0x4c, 0x12, 0x14, 0x0a, 0x05, 0x6e, 0x6f, 0x74,
 	0x65, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6e, 0x6f, 0x74, 0x65, 0x73, 0x12,
 	0x18, 0x0a, 0x07, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09,
-	0x52, 0x07, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x42, 0x19, 0x5a, 0x17, 0x67, 0x69, 0x74,
-	0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x47, 0x47, 0x50, 0x31, 0x2f, 0x6b, 0x75, 0x72,
-	0x65, 0x2f, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x52, 0x07, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x12, 0x2e, 0x0a, 0x13, 0x70, 0x61, 0x73,
+	0x73, 0x77, 0x6f, 0x72, 0x64, 0x5f, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x61, 0x74,
+	0x18, 0x07, 0x20, 0x01, 0x28, 0x03, 0x52, 0x11, 0x70, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64,
+	0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x41, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x72, 0x6f,
+	0x66, 0x69, 0x6c, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x70, 0x72, 0x6f, 0x66,
+	0x69, 0x6c, 0x65, 0x12, 0x2b, 0x0a, 0x11, 0x70, 0x72, 0x65, 0x76, 0x69, 0x6f, 0x75, 0x73, 0x5f,
+	0x70, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09, 0x52, 0x10,
+	0x70, 0x72, 0x65, 0x76, 0x69, 0x6f, 0x75, 0x73, 0x50, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64,
+	0x42, 0x19, 0x5a, 0x17, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x47,
+	0x47, 0x50, 0x31, 0x2f, 0x6b, 0x75, 0x72, 0x65, 0x2f, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x33,
 }
 
 var (
@@ -0,0 +1,31 @@
+// Package remote implements the destinations where database backups can be uploaded: S3-compatible
+// object storage, WebDAV and SFTP servers.
+//
+// Every upload is verified, an error is returned if the content stored in the remote doesn't match
+// the one sent.
+package remote
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/pkg/errors"
+)
+
+// Target is a remote location where backups are stored.
+type Target interface {
+	// Upload stores content under the name specified, replacing any previous object with the same name.
+	Upload(ctx context.Context, name string, content []byte) error
+}
+
+// errChecksum is returned when the content stored in the remote doesn't match the one uploaded.
+func errChecksum(expected, got string) error {
+	return errors.Errorf("integrity check failed: expected sha256 %s, got %s", expected, got)
+}
+
+// sha256Hex returns the hex-encoded SHA-256 hash of data.
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,153 @@
+package remote
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net"
+	"net/url"
+	"path"
+
+	"github.com/pkg/errors"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// SFTP uploads files to an SSH server using the SFTP subsystem.
+type SFTP struct {
+	addr   string
+	dir    string
+	config *ssh.ClientConfig
+}
+
+// NewSFTP returns an SFTP target, the URL has the format "sftp://[user@]host[:port]/<directory>".
+//
+// If the URL doesn't include the user, username is used.
+func NewSFTP(rawURL, username string, auth []ssh.AuthMethod, hostKeyCallback ssh.HostKeyCallback) (*SFTP, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing url")
+	}
+	if u.Scheme != "sftp" {
+		return nil, errors.Errorf("invalid url scheme %q, use sftp", u.Scheme)
+	}
+	if u.User != nil && u.User.Username() != "" {
+		username = u.User.Username()
+	}
+	if username == "" {
+		return nil, errors.New("missing username")
+	}
+	if len(auth) == 0 {
+		return nil, errors.New("missing authentication method, use a password or an SSH key")
+	}
+	if hostKeyCallback == nil {
+		return nil, errors.New("missing host key verification")
+	}
+
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "22")
+	}
+
+	dir := u.Path
+	if dir == "" {
+		dir = "."
+	}
+
+	return &SFTP{
+		addr: addr,
+		dir:  dir,
+		config: &ssh.ClientConfig{
+			User:            username,
+			Auth:            auth,
+			HostKeyCallback: hostKeyCallback,
+		},
+	}, nil
+}
+
+// Upload writes the content to a temporary file, reads it back to compare their checksums and
+// renames it, so the previous file with the same name is never left half written.
+func (s *SFTP) Upload(ctx context.Context, name string, content []byte) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return errors.Wrap(err, "connecting to server")
+	}
+
+	c, chans, reqs, err := ssh.NewClientConn(conn, s.addr, s.config)
+	if err != nil {
+		conn.Close()
+		return errors.Wrap(err, "ssh handshake")
+	}
+	sshClient := ssh.NewClient(c, chans, reqs)
+	defer sshClient.Close()
+
+	client, err := sftp.NewClient(sshClient)
+	if err != nil {
+		return errors.Wrap(err, "starting sftp session")
+	}
+	defer client.Close()
+
+	if err := client.MkdirAll(s.dir); err != nil {
+		return errors.Wrap(err, "creating directory")
+	}
+
+	dst := path.Join(s.dir, name)
+	tmp := dst + ".tmp"
+	if err := writeFile(client, tmp, content); err != nil {
+		client.Remove(tmp)
+		return err
+	}
+
+	if err := verifyFile(client, tmp, content); err != nil {
+		client.Remove(tmp)
+		return err
+	}
+
+	if err := client.PosixRename(tmp, dst); err != nil {
+		// The server doesn't support the posix-rename extension
+		client.Remove(dst)
+		if err := client.Rename(tmp, dst); err != nil {
+			client.Remove(tmp)
+			return errors.Wrap(err, "renaming file")
+		}
+	}
+
+	return nil
+}
+
+func writeFile(client *sftp.Client, name string, content []byte) error {
+	f, err := client.Create(name)
+	if err != nil {
+		return errors.Wrap(err, "creating file")
+	}
+
+	if _, err := f.Write(content); err != nil {
+		f.Close()
+		return errors.Wrap(err, "writing file")
+	}
+
+	if err := f.Close(); err != nil {
+		return errors.Wrap(err, "closing file")
+	}
+	return nil
+}
+
+func verifyFile(client *sftp.Client, name string, content []byte) error {
+	f, err := client.Open(name)
+	if err != nil {
+		return errors.Wrap(err, "verifying upload")
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return errors.Wrap(err, "verifying upload")
+	}
+
+	if expected, got := sha256Hex(content), hex.EncodeToString(h.Sum(nil)); expected != got {
+		return errChecksum(expected, got)
+	}
+	return nil
+}
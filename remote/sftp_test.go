@@ -0,0 +1,164 @@
+package remote
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/sftp"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestSFTPUpload(t *testing.T) {
+	addr, hostKey := newSFTPServer(t)
+	dir := filepath.Join(t.TempDir(), "backups")
+
+	s, err := NewSFTP("sftp://user@"+addr+dir, "", []ssh.AuthMethod{ssh.Password("pass")}, ssh.FixedHostKey(hostKey))
+	assert.NoError(t, err)
+
+	content := []byte("snapshot")
+	err = s.Upload(context.Background(), "kure.db.age", content)
+	assert.NoError(t, err)
+
+	// Replace the existing file
+	content = []byte("new snapshot")
+	err = s.Upload(context.Background(), "kure.db.age", content)
+	assert.NoError(t, err)
+
+	got, err := os.ReadFile(filepath.Join(dir, "kure.db.age"))
+	assert.NoError(t, err)
+	assert.Equal(t, content, got)
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1, "The temporary file wasn't removed")
+}
+
+func TestSFTPUploadErrors(t *testing.T) {
+	addr, hostKey := newSFTPServer(t)
+	dir := t.TempDir()
+
+	t.Run("Invalid password", func(t *testing.T) {
+		s, err := NewSFTP("sftp://"+addr+dir, "user", []ssh.AuthMethod{ssh.Password("invalid")}, ssh.FixedHostKey(hostKey))
+		assert.NoError(t, err)
+
+		err = s.Upload(context.Background(), "kure.db.age", []byte("snapshot"))
+		assert.ErrorContains(t, err, "ssh handshake")
+	})
+
+	t.Run("Unknown host key", func(t *testing.T) {
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		assert.NoError(t, err)
+		signer, err := ssh.NewSignerFromKey(priv)
+		assert.NoError(t, err)
+
+		s, err := NewSFTP("sftp://"+addr+dir, "user", []ssh.AuthMethod{ssh.Password("pass")}, ssh.FixedHostKey(signer.PublicKey()))
+		assert.NoError(t, err)
+
+		err = s.Upload(context.Background(), "kure.db.age", []byte("snapshot"))
+		assert.ErrorContains(t, err, "ssh handshake")
+	})
+}
+
+func TestNewSFTPErrors(t *testing.T) {
+	auth := []ssh.AuthMethod{ssh.Password("pass")}
+	cases := []struct {
+		desc     string
+		url      string
+		auth     []ssh.AuthMethod
+		hostKey  ssh.HostKeyCallback
+		username string
+	}{
+		{desc: "Invalid scheme", url: "ssh://user@localhost", auth: auth, hostKey: ssh.InsecureIgnoreHostKey()},
+		{desc: "Missing username", url: "sftp://localhost", auth: auth, hostKey: ssh.InsecureIgnoreHostKey()},
+		{desc: "Missing auth", url: "sftp://user@localhost", hostKey: ssh.InsecureIgnoreHostKey()},
+		{desc: "Missing host key", url: "sftp://user@localhost", auth: auth},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			_, err := NewSFTP(tc.url, tc.username, tc.auth, tc.hostKey)
+			assert.Error(t, err)
+		})
+	}
+}
+
+// newSFTPServer starts an in-process SSH server accepting the password "pass" that serves the
+// SFTP subsystem on the local filesystem.
+func newSFTPServer(t *testing.T) (string, ssh.PublicKey) {
+	t.Helper()
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	hostKey, err := ssh.NewSignerFromKey(priv)
+	assert.NoError(t, err)
+
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(c ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
+			if c.User() == "user" && string(pass) == "pass" {
+				return nil, nil
+			}
+			return nil, os.ErrPermission
+		},
+	}
+	config.AddHostKey(hostKey)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go serveSFTP(conn, config)
+		}
+	}()
+
+	return l.Addr().String(), hostKey.PublicKey()
+}
+
+func serveSFTP(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	for newChan := range chans {
+		if newChan.ChannelType() != "session" {
+			newChan.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+
+		channel, requests, err := newChan.Accept()
+		if err != nil {
+			continue
+		}
+
+		go func(in <-chan *ssh.Request) {
+			for req := range in {
+				ok := req.Type == "subsystem" && string(req.Payload[4:]) == "sftp"
+				req.Reply(ok, nil)
+			}
+		}(requests)
+
+		server, err := sftp.NewServer(channel)
+		if err != nil {
+			channel.Close()
+			continue
+		}
+		go func() {
+			server.Serve()
+			server.Close()
+		}()
+	}
+}
@@ -0,0 +1,88 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/pkg/errors"
+)
+
+// WebDAV uploads files to a WebDAV server (Nextcloud, ownCloud, Apache mod_dav, etc.).
+type WebDAV struct {
+	client   *http.Client
+	url      *url.URL
+	username string
+	password string
+}
+
+// NewWebDAV returns a WebDAV target, the URL is the collection (directory) where files are stored.
+func NewWebDAV(client *http.Client, rawURL, username, password string) (*WebDAV, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing url")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, errors.Errorf("invalid url scheme %q, use http or https", u.Scheme)
+	}
+
+	return &WebDAV{
+		client:   client,
+		url:      u,
+		username: username,
+		password: password,
+	}, nil
+}
+
+// Upload puts the file in the collection and downloads it back to compare their checksums.
+func (w *WebDAV) Upload(ctx context.Context, name string, content []byte) error {
+	u := *w.url
+	u.Path = path.Join("/", u.Path, name)
+	u.RawPath = ""
+
+	res, err := w.do(ctx, http.MethodPut, u.String(), content)
+	if err != nil {
+		return errors.Wrap(err, "uploading file")
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusCreated && res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK {
+		return errors.Errorf("uploading file: %s", res.Status)
+	}
+
+	res, err = w.do(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return errors.Wrap(err, "verifying upload")
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return errors.Errorf("verifying upload: %s", res.Status)
+	}
+
+	stored, err := io.ReadAll(res.Body)
+	if err != nil {
+		return errors.Wrap(err, "verifying upload")
+	}
+	if expected, got := sha256Hex(content), sha256Hex(stored); expected != got {
+		return errChecksum(expected, got)
+	}
+
+	return nil
+}
+
+func (w *WebDAV) do(ctx context.Context, method, url string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/octet-stream")
+	}
+	if w.username != "" || w.password != "" {
+		req.SetBasicAuth(w.username, w.password)
+	}
+
+	return w.client.Do(req)
+}
@@ -0,0 +1,102 @@
+package remote
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebDAVUpload(t *testing.T) {
+	server := newFakeWebDAV(nil)
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	w, err := NewWebDAV(ts.Client(), ts.URL+"/dav/backups/", "user", "pass")
+	assert.NoError(t, err)
+
+	content := []byte("snapshot")
+	err = w.Upload(context.Background(), "kure.db.age", content)
+	assert.NoError(t, err)
+	assert.Equal(t, content, server.files["/dav/backups/kure.db.age"])
+}
+
+func TestWebDAVUploadErrors(t *testing.T) {
+	t.Run("Unauthorized", func(t *testing.T) {
+		ts := httptest.NewServer(newFakeWebDAV(nil))
+		defer ts.Close()
+
+		w, err := NewWebDAV(ts.Client(), ts.URL, "user", "invalid")
+		assert.NoError(t, err)
+
+		err = w.Upload(context.Background(), "kure.db.age", []byte("snapshot"))
+		assert.ErrorContains(t, err, "401")
+	})
+
+	t.Run("Corrupted", func(t *testing.T) {
+		ts := httptest.NewServer(newFakeWebDAV(func(b []byte) []byte { return b[1:] }))
+		defer ts.Close()
+
+		w, err := NewWebDAV(ts.Client(), ts.URL, "user", "pass")
+		assert.NoError(t, err)
+
+		err = w.Upload(context.Background(), "kure.db.age", []byte("snapshot"))
+		assert.ErrorContains(t, err, "integrity check failed")
+	})
+
+	t.Run("Invalid scheme", func(t *testing.T) {
+		_, err := NewWebDAV(http.DefaultClient, "ftp://localhost", "", "")
+		assert.Error(t, err)
+	})
+}
+
+// fakeWebDAV is a minimal WebDAV server supporting PUT and GET requests with basic authentication.
+type fakeWebDAV struct {
+	files map[string][]byte
+	// corrupt modifies the content stored
+	corrupt func([]byte) []byte
+	mu      sync.Mutex
+}
+
+func newFakeWebDAV(corrupt func([]byte) []byte) *fakeWebDAV {
+	return &fakeWebDAV{files: make(map[string][]byte), corrupt: corrupt}
+}
+
+func (f *fakeWebDAV) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if user, pass, ok := r.BasicAuth(); !ok || user != "user" || pass != "pass" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if f.corrupt != nil {
+			body = f.corrupt(body)
+		}
+		f.files[r.URL.Path] = body
+		w.WriteHeader(http.StatusCreated)
+
+	case http.MethodGet:
+		body, ok := f.files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(body)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
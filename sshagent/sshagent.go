@@ -0,0 +1,237 @@
+// Package sshagent implements the ssh-agent protocol on top of the SSH keys stored in the vault.
+//
+// Keys are read and decrypted on every request and are never written to disk nor kept in memory
+// between requests. Operations that modify the agent's keyring (add, remove, lock, unlock) are not
+// supported, keys are managed with "kure ssh" instead.
+package sshagent
+
+import (
+	"bytes"
+	"net"
+	"sync"
+
+	"github.com/GGP1/kure/db/sshkey"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// ErrNotSupported is returned by the operations that would modify the keys served by the agent.
+var ErrNotSupported = errors.New("operation not supported, use kure ssh to manage keys")
+
+// ConfirmFunc is called before using a key to sign, the request is rejected if it returns false.
+type ConfirmFunc func(key *pb.SSHKey) bool
+
+// Agent serves the SSH keys stored in the database.
+type Agent struct {
+	db      *bolt.DB
+	confirm ConfirmFunc
+	// mu serializes requests so confirmations are prompted one at a time
+	mu sync.Mutex
+}
+
+// New returns an agent that serves the keys stored in db. If confirm is not nil,
+// it's called every time a key is used.
+func New(db *bolt.DB, confirm ConfirmFunc) *Agent {
+	return &Agent{db: db, confirm: confirm}
+}
+
+// Serve accepts connections on the listener and serves the agent protocol on each of them.
+// It returns nil once the listener is closed.
+func (a *Agent) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return errors.Wrap(err, "accepting connection")
+		}
+
+		go func(c net.Conn) {
+			defer c.Close()
+			// ServeAgent returns when the client closes the connection
+			_ = agent.ServeAgent(a, c)
+		}(conn)
+	}
+}
+
+// List returns the public keys stored in the database.
+func (a *Agent) List() ([]*agent.Key, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	keys, err := sshkey.List(a.db)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]*agent.Key, 0, len(keys))
+	for _, k := range keys {
+		pub, err := PublicKey(k)
+		if err != nil {
+			return nil, err
+		}
+
+		comment := k.Comment
+		if comment == "" {
+			comment = k.Name
+		}
+		list = append(list, &agent.Key{
+			Format:  pub.Type(),
+			Blob:    pub.Marshal(),
+			Comment: comment,
+		})
+	}
+
+	return list, nil
+}
+
+// Sign has the agent sign the data using a protocol 2 key as defined in [PROTOCOL.agent] section 2.6.2.
+func (a *Agent) Sign(key ssh.PublicKey, data []byte) (*ssh.Signature, error) {
+	return a.SignWithFlags(key, data, 0)
+}
+
+// SignWithFlags signs like Sign, but allows for additional flags to be sent/received.
+func (a *Agent) SignWithFlags(key ssh.PublicKey, data []byte, flags agent.SignatureFlags) (*ssh.Signature, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	k, err := a.lookup(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.confirm != nil && !a.confirm(k) {
+		return nil, errors.Errorf("use of %q rejected", k.Name)
+	}
+
+	signer, err := Signer(k)
+	if err != nil {
+		return nil, err
+	}
+
+	if flags == 0 {
+		return signer.Sign(nil, data)
+	}
+
+	algSigner, ok := signer.(ssh.AlgorithmSigner)
+	if !ok {
+		return nil, errors.Errorf("%q does not support signature flags", k.Name)
+	}
+
+	var algorithm string
+	switch {
+	case flags&agent.SignatureFlagRsaSha256 != 0:
+		algorithm = ssh.KeyAlgoRSASHA256
+	case flags&agent.SignatureFlagRsaSha512 != 0:
+		algorithm = ssh.KeyAlgoRSASHA512
+	default:
+		return nil, errors.Errorf("unsupported signature flags: %d", flags)
+	}
+
+	return algSigner.SignWithAlgorithm(nil, data, algorithm)
+}
+
+// Signers is not supported as it would bypass the confirmation.
+func (a *Agent) Signers() ([]ssh.Signer, error) {
+	return nil, ErrNotSupported
+}
+
+// Extension is not supported.
+func (a *Agent) Extension(extensionType string, contents []byte) ([]byte, error) {
+	return nil, agent.ErrExtensionUnsupported
+}
+
+// Add is not supported.
+func (a *Agent) Add(key agent.AddedKey) error {
+	return ErrNotSupported
+}
+
+// Remove is not supported.
+func (a *Agent) Remove(key ssh.PublicKey) error {
+	return ErrNotSupported
+}
+
+// RemoveAll is not supported.
+func (a *Agent) RemoveAll() error {
+	return ErrNotSupported
+}
+
+// Lock is not supported.
+func (a *Agent) Lock(passphrase []byte) error {
+	return ErrNotSupported
+}
+
+// Unlock is not supported.
+func (a *Agent) Unlock(passphrase []byte) error {
+	return ErrNotSupported
+}
+
+// lookup returns the record whose public key matches the one specified.
+func (a *Agent) lookup(key ssh.PublicKey) (*pb.SSHKey, error) {
+	keys, err := sshkey.List(a.db)
+	if err != nil {
+		return nil, err
+	}
+
+	blob := key.Marshal()
+	for _, k := range keys {
+		pub, err := PublicKey(k)
+		if err != nil {
+			return nil, err
+		}
+		if bytes.Equal(pub.Marshal(), blob) {
+			return k, nil
+		}
+	}
+
+	return nil, errors.New("key not found")
+}
+
+// ParseKey parses the private key, decrypting it with the passphrase if it's not empty. If the key
+// is encrypted and no passphrase was given, the error returned wraps a *ssh.PassphraseMissingError.
+//
+// OpenSSH and PEM (PKCS#1, PKCS#8 and SEC 1) encoded RSA, ECDSA and Ed25519 keys are supported.
+func ParseKey(privateKey, passphrase string) (any, error) {
+	var (
+		key any
+		err error
+	)
+	if passphrase == "" {
+		key, err = ssh.ParseRawPrivateKey([]byte(privateKey))
+	} else {
+		key, err = ssh.ParseRawPrivateKeyWithPassphrase([]byte(privateKey), []byte(passphrase))
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing private key")
+	}
+
+	return key, nil
+}
+
+// PublicKey parses the public key of the record.
+func PublicKey(key *pb.SSHKey) (ssh.PublicKey, error) {
+	pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(key.PublicKey))
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing %q public key", key.Name)
+	}
+	return pub, nil
+}
+
+// Signer returns a signer that uses the record's private key.
+func Signer(key *pb.SSHKey) (ssh.Signer, error) {
+	raw, err := ParseKey(key.PrivateKey, key.Passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, err := ssh.NewSignerFromKey(raw)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating signer")
+	}
+	return signer, nil
+}
@@ -0,0 +1,238 @@
+package sshagent
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net"
+	"strings"
+	"testing"
+
+	dbutil "github.com/GGP1/kure/db"
+	"github.com/GGP1/kure/db/bucket"
+	"github.com/GGP1/kure/db/sshkey"
+	"github.com/GGP1/kure/pb"
+
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func TestAgent(t *testing.T) {
+	db := setContext(t)
+	key := createKey(t, db, "github")
+
+	var confirmed []string
+	client := newClient(t, New(db, func(k *pb.SSHKey) bool {
+		confirmed = append(confirmed, k.Name)
+		return true
+	}))
+
+	keys, err := client.List()
+	assert.NoError(t, err)
+	assert.Len(t, keys, 1)
+	assert.Equal(t, "github", keys[0].Comment)
+	assert.Equal(t, ssh.KeyAlgoED25519, keys[0].Format)
+
+	pub, err := PublicKey(key)
+	assert.NoError(t, err)
+
+	data := []byte("challenge")
+	sig, err := client.Sign(pub, data)
+	assert.NoError(t, err)
+	assert.NoError(t, pub.Verify(data, sig))
+	assert.Equal(t, []string{"github"}, confirmed)
+}
+
+func TestAgentRSAFlags(t *testing.T) {
+	db := setContext(t)
+
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	block := &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(priv)}
+	key := newRecord(t, "rsa", string(pem.EncodeToMemory(block)))
+	assert.NoError(t, sshkey.Create(db, key))
+
+	client := newClient(t, New(db, nil))
+	pub, err := PublicKey(key)
+	assert.NoError(t, err)
+
+	data := []byte("challenge")
+	sig, err := client.SignWithFlags(pub, data, agent.SignatureFlagRsaSha512)
+	assert.NoError(t, err)
+	assert.Equal(t, ssh.KeyAlgoRSASHA512, sig.Format)
+	assert.NoError(t, pub.Verify(data, sig))
+}
+
+func TestAgentRejected(t *testing.T) {
+	db := setContext(t)
+	key := createKey(t, db, "rejected")
+
+	client := newClient(t, New(db, func(*pb.SSHKey) bool { return false }))
+	pub, err := PublicKey(key)
+	assert.NoError(t, err)
+
+	_, err = client.Sign(pub, []byte("challenge"))
+	assert.Error(t, err)
+}
+
+func TestAgentErrors(t *testing.T) {
+	db := setContext(t)
+	client := newClient(t, New(db, nil))
+
+	signer, err := ssh.NewSignerFromKey(ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize)))
+	assert.NoError(t, err)
+
+	t.Run("Key not found", func(t *testing.T) {
+		_, err := client.Sign(signer.PublicKey(), []byte("challenge"))
+		assert.Error(t, err)
+	})
+
+	t.Run("Add", func(t *testing.T) {
+		err := client.Add(agent.AddedKey{PrivateKey: ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))})
+		assert.Error(t, err)
+	})
+
+	t.Run("Remove all", func(t *testing.T) {
+		assert.Error(t, client.RemoveAll())
+	})
+
+	t.Run("Lock", func(t *testing.T) {
+		assert.Error(t, client.Lock([]byte("passphrase")))
+	})
+}
+
+func TestServe(t *testing.T) {
+	db := setContext(t)
+	createKey(t, db, "serve")
+
+	l, err := net.Listen("unix", t.TempDir()+"/agent.sock")
+	assert.NoError(t, err)
+
+	done := make(chan error)
+	go func() {
+		done <- New(db, nil).Serve(l)
+	}()
+
+	conn, err := net.Dial("unix", l.Addr().String())
+	assert.NoError(t, err)
+
+	keys, err := agent.NewClient(conn).List()
+	assert.NoError(t, err)
+	assert.Len(t, keys, 1)
+
+	conn.Close()
+	l.Close()
+	assert.NoError(t, <-done)
+}
+
+func TestParseKey(t *testing.T) {
+	_, ed, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	ec, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	openSSH, err := ssh.MarshalPrivateKey(ed, "")
+	assert.NoError(t, err)
+	encrypted, err := ssh.MarshalPrivateKeyWithPassphrase(ed, "", []byte("passphrase"))
+	assert.NoError(t, err)
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(ec)
+	assert.NoError(t, err)
+
+	cases := []struct {
+		desc       string
+		key        *pem.Block
+		passphrase string
+	}{
+		{
+			desc: "OpenSSH",
+			key:  openSSH,
+		},
+		{
+			desc:       "OpenSSH encrypted",
+			key:        encrypted,
+			passphrase: "passphrase",
+		},
+		{
+			desc: "PKCS#8",
+			key:  &pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			_, err := ParseKey(string(pem.EncodeToMemory(tc.key)), tc.passphrase)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestParseKeyErrors(t *testing.T) {
+	_, ed, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	encrypted, err := ssh.MarshalPrivateKeyWithPassphrase(ed, "", []byte("passphrase"))
+	assert.NoError(t, err)
+	key := string(pem.EncodeToMemory(encrypted))
+
+	t.Run("Passphrase missing", func(t *testing.T) {
+		_, err := ParseKey(key, "")
+		var missing *ssh.PassphraseMissingError
+		assert.ErrorAs(t, err, &missing)
+	})
+
+	t.Run("Invalid passphrase", func(t *testing.T) {
+		_, err := ParseKey(key, "invalid")
+		assert.Error(t, err)
+	})
+
+	t.Run("Invalid key", func(t *testing.T) {
+		_, err := ParseKey("not a key", "")
+		assert.Error(t, err)
+	})
+}
+
+func createKey(t *testing.T, db *bolt.DB, name string) *pb.SSHKey {
+	t.Helper()
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	assert.NoError(t, err)
+
+	key := newRecord(t, name, string(pem.EncodeToMemory(block)))
+	assert.NoError(t, sshkey.Create(db, key))
+	return key
+}
+
+func newRecord(t *testing.T, name, privateKey string) *pb.SSHKey {
+	t.Helper()
+
+	key := &pb.SSHKey{Name: name, PrivateKey: privateKey}
+	signer, err := Signer(key)
+	assert.NoError(t, err)
+
+	key.PublicKey = strings.TrimSpace(string(ssh.MarshalAuthorizedKey(signer.PublicKey())))
+	return key
+}
+
+func newClient(t *testing.T, a *Agent) agent.ExtendedAgent {
+	t.Helper()
+
+	c1, c2 := net.Pipe()
+	t.Cleanup(func() {
+		c1.Close()
+		c2.Close()
+	})
+	go agent.ServeAgent(a, c2)
+
+	return agent.NewClient(c1)
+}
+
+func setContext(t testing.TB) *bolt.DB {
+	return dbutil.SetContext(t, bucket.SSH.GetName())
+}
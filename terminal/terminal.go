@@ -111,8 +111,18 @@ func Scanlns(r *bufio.Reader, field string) string {
 }
 
 // ScanPassword returns the input password encrypted inside an Enclave.
+//
+// If the standard input is not a terminal (e.g. kure is used as the git credential helper), the
+// password is read from the controlling terminal instead.
 func ScanPassword(message string, verify bool) (*memguard.Enclave, error) {
 	fd := int(syscall.Stdin)
+	if !term.IsTerminal(fd) {
+		if tty, err := os.Open("/dev/tty"); err == nil {
+			defer tty.Close()
+			fd = int(tty.Fd())
+		}
+	}
+
 	oldState, err := term.GetState(fd)
 	if err != nil {
 		return nil, errors.Wrap(err, "terminal state")
@@ -0,0 +1,180 @@
+// Package vault manages the databases defined in the configuration file.
+//
+// The database under the "database" key is the default vault, the others are declared under
+// "vaults.<name>" with their own database and key file paths.
+package vault
+
+import (
+	"os"
+	"regexp"
+	"sort"
+
+	"github.com/GGP1/kure/config"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cast"
+)
+
+// DefaultName is the name of the vault defined under the "database" key.
+const DefaultName = "default"
+
+// EnvVar is the environment variable used to select a vault when the flag isn't specified.
+const EnvVar = "KURE_VAULT"
+
+const (
+	dbPathKey    = "database.path"
+	keyfileKey   = "keyfile.path"
+	vaultKey     = "vault"
+	vaultsKey    = "vaults"
+	pathField    = "path"
+	keyfileField = "keyfile"
+)
+
+var validName = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+var (
+	// active is the name of the vault in use
+	active = DefaultName
+	// defaultVault holds the default vault values while other vault is in use,
+	// as its keys are replaced in the configuration
+	defaultVault *Vault
+)
+
+// Vault represents a database and its key file.
+type Vault struct {
+	Name    string
+	Path    string
+	Keyfile string
+}
+
+// Active returns the name of the vault in use.
+func Active() string {
+	return active
+}
+
+// Create adds a new vault to the configuration file.
+func Create(name, path, keyfile string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	if _, err := Get(name); err == nil {
+		return errors.Errorf("vault %q already exists", name)
+	}
+	if path == "" {
+		return errors.New("vault path is empty")
+	}
+
+	values := map[string]interface{}{
+		key(name, pathField): path,
+	}
+	if keyfile != "" {
+		values[key(name, keyfileField)] = keyfile
+	}
+
+	return errors.Wrap(config.Persist(values), "saving vault")
+}
+
+// Get returns the vault with the name specified.
+func Get(name string) (Vault, error) {
+	if name == DefaultName {
+		if defaultVault != nil {
+			return *defaultVault, nil
+		}
+		return Vault{
+			Name:    DefaultName,
+			Path:    config.GetString(dbPathKey),
+			Keyfile: config.GetString(keyfileKey),
+		}, nil
+	}
+
+	if !config.IsSet(key(name, pathField)) {
+		return Vault{}, errors.Errorf("vault %q does not exist", name)
+	}
+
+	return Vault{
+		Name:    name,
+		Path:    config.GetString(key(name, pathField)),
+		Keyfile: config.GetString(key(name, keyfileField)),
+	}, nil
+}
+
+// List returns the default vault followed by the others sorted by name.
+func List() ([]Vault, error) {
+	mp := cast.ToStringMap(config.Get(vaultsKey))
+	names := make([]string, 0, len(mp))
+	for name := range mp {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	vaults := make([]Vault, 0, len(names)+1)
+	def, _ := Get(DefaultName)
+	vaults = append(vaults, def)
+	for _, name := range names {
+		v, err := Get(name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid vault %q", name)
+		}
+		vaults = append(vaults, v)
+	}
+
+	return vaults, nil
+}
+
+// Select sets the vault database and key file paths to the configuration.
+//
+// If name is empty, the vault is taken from the KURE_VAULT environment variable or the
+// "vault" configuration key, in that order.
+func Select(name string) error {
+	if name == "" {
+		name = os.Getenv(EnvVar)
+	}
+	if name == "" {
+		name = config.GetString(vaultKey)
+	}
+	if name == "" {
+		name = DefaultName
+	}
+
+	v, err := Get(name)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case name == DefaultName:
+		defaultVault = nil
+	case defaultVault == nil:
+		def, _ := Get(DefaultName)
+		defaultVault = &def
+	}
+
+	config.Set(dbPathKey, v.Path)
+	config.Set(keyfileKey, v.Keyfile)
+	active = name
+	return nil
+}
+
+// SetDefault saves the vault as the one used when none is specified.
+func SetDefault(name string) error {
+	if _, err := Get(name); err != nil {
+		return err
+	}
+
+	return errors.Wrap(config.Persist(map[string]interface{}{vaultKey: name}), "saving default vault")
+}
+
+// ValidateName returns an error if the name cannot be used for a new vault.
+func ValidateName(name string) error {
+	if name == DefaultName {
+		return errors.Errorf("%q is reserved for the database under the \"database\" key", DefaultName)
+	}
+	if !validName.MatchString(name) {
+		return errors.Errorf("invalid vault name %q, use letters, numbers, dashes and underscores only", name)
+	}
+	return nil
+}
+
+func key(name, field string) string {
+	return vaultsKey + "." + name + "." + field
+}
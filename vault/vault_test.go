@@ -0,0 +1,115 @@
+package vault
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/GGP1/kure/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelect(t *testing.T) {
+	setConfig(t)
+
+	assert.NoError(t, Select("work"))
+	assert.Equal(t, "work", Active())
+	assert.Equal(t, "work.db", config.GetString(dbPathKey))
+	assert.Equal(t, "work.key", config.GetString(keyfileKey))
+
+	// The default vault is still available
+	def, err := Get(DefaultName)
+	assert.NoError(t, err)
+	assert.Equal(t, Vault{Name: DefaultName, Path: "kure.db"}, def)
+
+	assert.NoError(t, Select(DefaultName))
+	assert.Equal(t, DefaultName, Active())
+	assert.Equal(t, "kure.db", config.GetString(dbPathKey))
+	assert.Equal(t, "", config.GetString(keyfileKey))
+}
+
+func TestSelectPrecedence(t *testing.T) {
+	setConfig(t)
+
+	config.Set(vaultKey, "work")
+	assert.NoError(t, Select(""))
+	assert.Equal(t, "work", Active())
+
+	t.Setenv(EnvVar, DefaultName)
+	assert.NoError(t, Select(""))
+	assert.Equal(t, DefaultName, Active())
+
+	assert.Error(t, Select("missing"))
+	assert.Equal(t, DefaultName, Active())
+}
+
+func TestList(t *testing.T) {
+	setConfig(t)
+	config.Set("vaults.archive.path", "archive.db")
+
+	vaults, err := List()
+	assert.NoError(t, err)
+
+	expected := []Vault{
+		{Name: DefaultName, Path: "kure.db"},
+		{Name: "archive", Path: "archive.db"},
+		{Name: "work", Path: "work.db", Keyfile: "work.key"},
+	}
+	assert.Equal(t, expected, vaults)
+}
+
+func TestCreate(t *testing.T) {
+	filename := setConfig(t)
+
+	assert.NoError(t, Create("personal", "personal.db", ""))
+	assert.NoError(t, SetDefault("personal"))
+
+	c := config.New()
+	assert.NoError(t, c.Load(filename))
+	assert.Equal(t, "personal.db", c.Get("vaults.personal.path"))
+	assert.Nil(t, c.Get("vaults.personal.keyfile"))
+	assert.Equal(t, "personal", c.Get(vaultKey))
+
+	v, err := Get("personal")
+	assert.NoError(t, err)
+	assert.Equal(t, Vault{Name: "personal", Path: "personal.db"}, v)
+}
+
+func TestCreateErrors(t *testing.T) {
+	setConfig(t)
+
+	cases := []struct {
+		desc string
+		name string
+		path string
+	}{
+		{desc: "Default", name: DefaultName, path: "kure.db"},
+		{desc: "Invalid name", name: "my.vault", path: "my.db"},
+		{desc: "Empty name", name: "", path: "my.db"},
+		{desc: "Already exists", name: "work", path: "work.db"},
+		{desc: "Empty path", name: "personal", path: ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			assert.Error(t, Create(tc.name, tc.path, ""))
+		})
+	}
+
+	assert.Error(t, SetDefault("missing"))
+}
+
+func setConfig(t *testing.T) string {
+	t.Helper()
+
+	filename := filepath.Join(t.TempDir(), "kure.yaml")
+	content := "database:\n  path: kure.db\nvaults:\n  work:\n    path: work.db\n    keyfile: work.key\n"
+	assert.NoError(t, os.WriteFile(filename, []byte(content), 0o600))
+
+	config.Reset()
+	assert.NoError(t, config.Load(filename))
+	active = DefaultName
+	defaultVault = nil
+
+	return filename
+}